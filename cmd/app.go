@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/repo"
+	"hodlbook/pkg/database"
+	"hodlbook/pkg/integrations/prices"
+	"hodlbook/pkg/integrations/prices/mockprices"
+
+	"github.com/pkg/errors"
+)
+
+// newLogger logs to stderr so command output on stdout stays parseable.
+func newLogger(cfg *config.Config) *slog.Logger {
+	return cfg.Log.NewLogger(os.Stderr)
+}
+
+// openDatabase opens the configured database without touching its schema.
+func openDatabase(cfg *config.Config) (*database.Database, *repo.Repository, error) {
+	open := database.WithPath(cfg.Database.Path)
+	if cfg.Database.Type == config.DatabasePostgres {
+		open = database.WithPostgres(cfg.Database.PostgresDSN())
+	}
+
+	db, err := database.New(open)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize database")
+	}
+
+	repository, err := repo.New(db.Get())
+	if err != nil {
+		db.Close()
+		return nil, nil, errors.Wrap(err, "failed to create repository")
+	}
+
+	return db, repository, nil
+}
+
+// openRepository opens the configured database and brings its schema up to
+// date, as every command that reads or writes data expects.
+func openRepository(cfg *config.Config) (*database.Database, *repo.Repository, error) {
+	db, repository, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := repository.Migrate(); err != nil {
+		db.Close()
+		return nil, nil, errors.Wrap(err, "failed to run migrations")
+	}
+
+	return db, repository, nil
+}
+
+// newPriceService builds the price service with the configured provider,
+// cache TTL, provider timeout and offline mode.
+func newPriceService(cfg *config.Config) (*prices.PriceService, error) {
+	opts := []prices.Option{
+		prices.WithCacheTTL(cfg.Prices.CacheTTL.Std()),
+		prices.WithProviderTimeout(cfg.Prices.ProviderTimeout.Std()),
+		prices.WithOffline(cfg.Prices.Offline),
+	}
+	if cfg.Prices.Provider == config.PriceProviderMock {
+		var mockOpts []mockprices.Option
+		if cfg.Prices.MockFixture != "" {
+			fixture, err := mockprices.LoadFixture(cfg.Prices.MockFixture)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to load mock price fixture")
+			}
+			mockOpts = append(mockOpts, mockprices.WithFixture(fixture))
+		}
+		opts = append(opts, prices.WithMockPrices(mockprices.NewPriceFetcher(mockOpts...)))
+	}
+	return prices.NewPriceService(opts...), nil
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: hodlbook %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and treats -h as a successful run.
+func parseFlags(fs *flag.FlagSet, args []string) (bool, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// subcommand splits "summary --json" style arguments so flags may follow the
+// subcommand name.
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+	return args[0], args[1:]
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"hodlbook/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+func runBackup(cfg *config.Config, args []string) error {
+	fs := newFlagSet("backup", "[out.db]")
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("at most one output file is allowed")
+	}
+
+	target := fs.Arg(0)
+	if target == "" {
+		name := fmt.Sprintf("hodlbook-%s.db", time.Now().Format("20060102-150405"))
+		target = filepath.Join(filepath.Dir(cfg.Database.Path), "backups", name)
+	}
+
+	db, _, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Backup(target); err != nil {
+		return err
+	}
+
+	fmt.Printf("backup written to %s\n", target)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/importexport"
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/pkg/errors"
+)
+
+func runExport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("export", "<out.zip>")
+	all := fs.Bool("all", false, "export every portfolio")
+	portfolioID := fs.Int64("portfolio", models.DefaultPortfolioID, "portfolio ID to export, ignored with -all")
+	format := fs.String("format", importexport.FormatCSV, "file format inside the archive: csv or json")
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one output file is required")
+	}
+	*format = strings.ToLower(*format)
+	if *format != importexport.FormatCSV && *format != importexport.FormatJSON {
+		return errors.New("format must be csv or json")
+	}
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var portfolios []models.Portfolio
+	if *all {
+		if portfolios, err = repository.ListPortfolios(); err != nil {
+			return errors.Wrap(err, "failed to list portfolios")
+		}
+	} else {
+		portfolio, err := repository.GetPortfolioByID(*portfolioID)
+		if err != nil {
+			return errors.Wrapf(err, "portfolio %d", *portfolioID)
+		}
+		portfolios = []models.Portfolio{*portfolio}
+	}
+
+	out, err := os.OpenFile(fs.Arg(0), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	if err := writeExport(zw, repository, portfolios, *format); err != nil {
+		zw.Close()
+		os.Remove(fs.Arg(0))
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported %d portfolio(s) to %s\n", len(portfolios), fs.Arg(0))
+	return nil
+}
+
+// writeExport stores portfolios.json at the root of the archive and one
+// directory of assets and exchanges per portfolio.
+func writeExport(zw *zip.Writer, repository *repo.Repository, portfolios []models.Portfolio, format string) error {
+	index, err := json.MarshalIndent(portfolios, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, "portfolios.json", index); err != nil {
+		return err
+	}
+
+	for _, portfolio := range portfolios {
+		assets, err := repository.GetAssetsByPortfolio(portfolio.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch assets")
+		}
+		exchanges, err := repository.GetExchangesByPortfolio(portfolio.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch exchanges")
+		}
+
+		var assetData, exchangeData []byte
+		if format == importexport.FormatJSON {
+			if assetData, err = json.MarshalIndent(assets, "", "  "); err != nil {
+				return err
+			}
+			if exchangeData, err = json.MarshalIndent(exchanges, "", "  "); err != nil {
+				return err
+			}
+		} else {
+			assetData = importexport.AssetsToCSV(assets)
+			exchangeData = importexport.ExchangesToCSV(exchanges)
+		}
+
+		dir := fmt.Sprintf("portfolio-%d/", portfolio.ID)
+		if err := writeZipFile(zw, dir+"assets."+format, assetData); err != nil {
+			return err
+		}
+		if err := writeZipFile(zw, dir+"exchanges."+format, exchangeData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/service"
+
+	"github.com/pkg/errors"
+)
+
+func runRecomputeHistory(cfg *config.Config, args []string) error {
+	fs := newFlagSet("recompute-history", "")
+	missingOnly := fs.Bool("missing-only", false, "only add a first value for symbols without any history")
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	priceFetcher, err := newPriceService(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	historicPriceSvc, err := service.NewHistoricPriceService(
+		service.WithHistoricPriceContext(ctx),
+		service.WithHistoricPriceLogger(newLogger(cfg)),
+		service.WithHistoricPriceFetcher(priceFetcher),
+		service.WithHistoricPriceTargetHour(cfg.Prices.HistoricHour),
+		service.WithHistoricPriceRepo(repository),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create historic price service")
+	}
+
+	if *missingOnly {
+		if err := historicPriceSvc.FillMissing(ctx); err != nil {
+			return err
+		}
+		fmt.Println("first historic values recorded")
+		return nil
+	}
+
+	rebuilt, err := historicPriceSvc.RebuildHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("recomputed %d daily values from stored prices\n", rebuilt)
+	return nil
+}
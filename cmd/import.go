@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/importexport"
+	"hodlbook/internal/models"
+
+	"github.com/pkg/errors"
+)
+
+func runImport(cfg *config.Config, args []string) error {
+	fs := newFlagSet("import", "<file>")
+	format := fs.String("format", "", "file format: csv, json or kraken (default from the file extension)")
+	portfolioID := fs.Int64("portfolio", models.DefaultPortfolioID, "portfolio ID to import into")
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one file is required")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := repository.GetPortfolioByID(*portfolioID); err != nil {
+		return errors.Wrapf(err, "portfolio %d", *portfolioID)
+	}
+
+	fetcher, err := newPriceService(cfg)
+	if err != nil {
+		return err
+	}
+	result, err := importexport.NewImporter(repository, nil, fetcher).Import(context.Background(), *portfolioID, filepath.Base(path), strings.ToLower(*format), data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("import #%d %s: %d of %d rows imported\n", result.Log.ID, result.Log.Status, result.Imported, result.Total)
+	for _, rowErr := range result.Errors {
+		fmt.Printf("  row %d: %s\n", rowErr.Row, rowErr.Message)
+	}
+	return nil
+}
@@ -1,27 +1,14 @@
 package main
 
 import (
-	"context"
-	"log"
-	"log/slog"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"syscall"
+	"sort"
 
 	_ "hodlbook/docs"
-	"hodlbook/internal/handler"
-	"hodlbook/internal/repo"
-	"hodlbook/internal/service"
-	uihandler "hodlbook/internal/ui/handler"
-	"hodlbook/pkg/database"
-	"hodlbook/pkg/integrations/memcache"
-	"hodlbook/pkg/integrations/prices"
-	"hodlbook/pkg/integrations/wmPubsub"
-	"hodlbook/pkg/utils"
-
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"hodlbook/internal/config"
 )
 
 // @title HodlBook API
@@ -31,149 +18,86 @@ import (
 // @host localhost:2008
 // @BasePath /
 
-func main() {
-	utils.LoadEnv()
-
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	}))
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	dbPath := utils.GetEnv("DB_PATH", "./data/hodlbook.db")
-	db, err := database.New(database.WithPath(dbPath))
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
-	}
-	defer db.Close()
-
-	repository, err := repo.New(db.Get())
-	if err != nil {
-		log.Fatal("Failed to create repository:", err)
-	}
-
-	if err := repository.Migrate(); err != nil {
-		log.Fatal("Failed to run migrations:", err)
-	}
+type command struct {
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
 
-	priceFetcher := prices.NewPriceService()
-	priceCache := memcache.New[string, float64]()
-	priceCh := make(chan []byte, 10)
-	sseCh := make(chan []byte, 10)
-	pricePublisher := wmPubsub.New(
-		wmPubsub.WithChannel(priceCh),
-		wmPubsub.WithContext(ctx),
-		wmPubsub.WithTopic("prices"),
-		wmPubsub.WithLogger(logger),
-		wmPubsub.WithHandler(func(data []byte) error {
-			select {
-			case sseCh <- data:
-			default:
-				logger.Warn("sseCh full, dropping message")
-			}
-			return nil
-		}),
-	)
-	if err := pricePublisher.Subscribe(); err != nil {
-		log.Fatal("Failed to start price subscriber:", err)
-	}
+var commands = map[string]command{
+	"serve":             {"run the web UI and API server (default)", runServe},
+	"import":            {"import transactions from a CSV, JSON or Kraken ledger file", runImport},
+	"export":            {"export transactions to a zip archive", runExport},
+	"backup":            {"write a consistent copy of the database", runBackup},
+	"migrate":           {"show or apply database migrations", runMigrate},
+	"prices":            {"fetch current prices for held assets", runPrices},
+	"portfolio":         {"print portfolio holdings and value", runPortfolio},
+	"recompute-history": {"rebuild daily price history from stored prices", runRecomputeHistory},
+}
 
-	livePriceSvc, err := service.NewLivePriceService(
-		service.WithLivePriceContext(ctx),
-		service.WithLivePriceLogger(logger),
-		service.WithLivePriceCache(priceCache),
-		service.WithLivePriceFetcher(priceFetcher),
-		service.WithLivePricePublisher(pricePublisher),
-		service.WithLivePriceRepo(repository),
-	)
+func main() {
+	fs := flag.NewFlagSet("hodlbook", flag.ContinueOnError)
+	fs.Usage = func() { usage(fs.Output()) }
+	configFile := fs.String("config", "", "YAML or TOML config file (default $CONFIG_FILE)")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(config.Options{File: *configFile})
 	if err != nil {
-		log.Fatal("Failed to create live price service:", err)
+		fmt.Fprintf(os.Stderr, "hodlbook: %v\n", err)
+		os.Exit(1)
 	}
 
-	historicPriceSvc, err := service.NewHistoricPriceService(
-		service.WithHistoricPriceContext(ctx),
-		service.WithHistoricPriceLogger(logger),
-		service.WithHistoricPriceFetcher(priceFetcher),
-		service.WithHistoricPriceRepo(repository),
-	)
-	if err != nil {
-		log.Fatal("Failed to create historic price service:", err)
+	if *printConfig {
+		data, err := cfg.Redacted().YAML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hodlbook: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
 	}
 
-	assetCreatedCh := make(chan []byte, 10)
-	assetHistoricSvc, err := service.NewAssetHistoricService(
-		service.WithAssetHistoricContext(ctx),
-		service.WithAssetHistoricLogger(logger),
-		service.WithAssetHistoricFetcher(priceFetcher),
-		service.WithAssetHistoricRepo(repository),
-		service.WithAssetHistoricChannel(assetCreatedCh),
-	)
-	if err != nil {
-		log.Fatal("Failed to create asset historic service:", err)
+	name, args := "serve", fs.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
 
-	if err := livePriceSvc.Start(); err != nil {
-		log.Fatal("Failed to start live price service:", err)
-	}
-	if err := historicPriceSvc.Start(); err != nil {
-		log.Fatal("Failed to start historic price service:", err)
+	if name == "help" {
+		usage(os.Stdout)
+		return
 	}
-	if err := assetHistoricSvc.Start(); err != nil {
-		log.Fatal("Failed to start asset historic service:", err)
-	}
-
-	r := gin.Default()
-
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	webHandler, err := uihandler.New(
-		uihandler.WithEngine(r),
-		uihandler.WithRepository(repository),
-		uihandler.WithPriceCache(priceCache),
-		uihandler.WithPriceFetcher(priceFetcher),
-	)
-	if err != nil {
-		log.Fatal("Failed to create web handler:", err)
-	}
-	if err := webHandler.Setup(); err != nil {
-		log.Fatal("Failed to setup web routes:", err)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "hodlbook: unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
 	}
 
-	h, err := handler.New(
-		handler.WithEngine(r),
-		handler.WithRepository(repository),
-		handler.WithPriceChannel(sseCh),
-		handler.WithPriceCache(priceCache),
-		handler.WithAssetCreatedPublisher(assetHistoricSvc.Publisher()),
-		handler.WithLivePriceService(livePriceSvc),
-	)
-	if err != nil {
-		log.Fatal("Failed to create handler:", err)
-	}
-	if err := h.Setup(); err != nil {
-		log.Fatal("Failed to setup routes:", err)
+	if err := cmd.run(cfg, args); err != nil {
+		fmt.Fprintf(os.Stderr, "hodlbook %s: %v\n", name, err)
+		os.Exit(1)
 	}
+}
 
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "2008"
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		<-sigCh
-		logger.Info("shutting down...")
-		cancel()
-		livePriceSvc.Stop()
-		historicPriceSvc.Stop()
-		os.Exit(0)
-	}()
-
-	logger.Info("starting HodlBook", "port", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	fmt.Fprintln(w, "Usage: hodlbook [--config file] [--print-config] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-18s %s\n", name, commands[name].summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'hodlbook <command> -h' for the flags of a command.")
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"hodlbook/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := newFlagSet("migrate", "status|up")
+	sub, args := subcommand(args)
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if sub != "status" && sub != "up" {
+		fs.Usage()
+		return errors.New("subcommand must be status or up")
+	}
+
+	db, repository, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch sub {
+	case "status":
+		statuses, err := repository.MigrationStatus()
+		if err != nil {
+			return errors.Wrap(err, "failed to inspect schema")
+		}
+
+		pending := 0
+		for _, status := range statuses {
+			switch {
+			case !status.Exists:
+				pending++
+				fmt.Printf("%-24s missing\n", status.Table)
+			case len(status.MissingColumns) > 0:
+				pending++
+				fmt.Printf("%-24s missing columns: %s\n", status.Table, strings.Join(status.MissingColumns, ", "))
+			default:
+				fmt.Printf("%-24s up to date\n", status.Table)
+			}
+		}
+		if pending > 0 {
+			fmt.Printf("\n%d table(s) need migrating, run 'hodlbook migrate up'\n", pending)
+		}
+	case "up":
+		if err := repository.Migrate(); err != nil {
+			return errors.Wrap(err, "failed to run migrations")
+		}
+		fmt.Println("schema is up to date")
+	}
+	return nil
+}
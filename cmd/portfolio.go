@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/repo"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+type portfolioHolding struct {
+	Symbol string          `json:"symbol"`
+	Amount decimal.Decimal `json:"amount"`
+	Price  float64         `json:"price"`
+	Value  float64         `json:"value"`
+}
+
+type portfolioSummary struct {
+	PortfolioID int64              `json:"portfolio_id"`
+	TotalValue  float64            `json:"total_value"`
+	Currency    string             `json:"currency"`
+	Holdings    []portfolioHolding `json:"holdings"`
+}
+
+func runPortfolio(cfg *config.Config, args []string) error {
+	fs := newFlagSet("portfolio", "summary")
+	portfolioID := fs.Int64("portfolio", 0, "portfolio ID (0 for all portfolios)")
+	asJSON := fs.Bool("json", false, "print the summary as JSON")
+	sub, args := subcommand(args)
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if sub != "summary" {
+		fs.Usage()
+		return errors.New("subcommand must be summary")
+	}
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *portfolioID > 0 {
+		if _, err := repository.GetPortfolioByID(*portfolioID); err != nil {
+			return errors.Wrapf(err, "portfolio %d", *portfolioID)
+		}
+	}
+
+	holdings, err := calculateHoldings(repository, *portfolioID)
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate holdings")
+	}
+
+	priceMap, err := syncPrices(cfg, repository)
+	if err != nil {
+		return err
+	}
+
+	summary := portfolioSummary{
+		PortfolioID: *portfolioID,
+		Currency:    "USD",
+		Holdings:    make([]portfolioHolding, 0),
+	}
+	for symbol, amount := range holdings {
+		if !amount.IsPositive() {
+			continue
+		}
+		price := priceMap[symbol]
+		value := amount.InexactFloat64() * price
+		summary.TotalValue += value
+		summary.Holdings = append(summary.Holdings, portfolioHolding{
+			Symbol: symbol,
+			Amount: amount,
+			Price:  price,
+			Value:  value,
+		})
+	}
+	sort.Slice(summary.Holdings, func(i, j int) bool {
+		return summary.Holdings[i].Value > summary.Holdings[j].Value
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+
+	fmt.Printf("%-10s %18s %16s %16s\n", "SYMBOL", "AMOUNT", "PRICE", "VALUE")
+	for _, h := range summary.Holdings {
+		fmt.Printf("%-10s %18s %16.2f %16.2f\n", h.Symbol, h.Amount.String(), h.Price, h.Value)
+	}
+	fmt.Printf("\nTotal: %.2f %s\n", summary.TotalValue, summary.Currency)
+	return nil
+}
+
+func calculateHoldings(repository *repo.Repository, portfolioID int64) (map[string]decimal.Decimal, error) {
+	holdings := make(map[string]decimal.Decimal)
+
+	assets, err := repository.GetAssetsByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, asset := range assets {
+		switch asset.TransactionType {
+		case "deposit":
+			holdings[asset.Symbol] = holdings[asset.Symbol].Add(asset.Amount)
+		case "withdraw":
+			holdings[asset.Symbol] = holdings[asset.Symbol].Sub(asset.Amount)
+		}
+	}
+
+	exchanges, err := repository.GetExchangesByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ex := range exchanges {
+		holdings[ex.FromSymbol] = holdings[ex.FromSymbol].Sub(ex.FromAmount)
+		holdings[ex.ToSymbol] = holdings[ex.ToSymbol].Add(ex.ToAmount)
+	}
+
+	return holdings, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+	"hodlbook/internal/service"
+	"hodlbook/pkg/integrations/eventbus"
+	"hodlbook/pkg/integrations/memcache"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func runPrices(cfg *config.Config, args []string) error {
+	fs := newFlagSet("prices", "sync")
+	asJSON := fs.Bool("json", false, "print prices as JSON")
+	store := fs.Bool("store", true, "record the fetched prices in the database")
+	sub, args := subcommand(args)
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+	if sub != "sync" {
+		fs.Usage()
+		return errors.New("subcommand must be sync")
+	}
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	priceMap, err := syncPrices(cfg, repository)
+	if err != nil {
+		return err
+	}
+
+	if *store {
+		now := time.Now()
+		for symbol, value := range priceMap {
+			if value <= 0 {
+				continue
+			}
+			if err := repository.CreatePrice(&models.Price{
+				Symbol:    symbol,
+				Currency:  "USD",
+				Price:     decimal.NewFromFloat(value),
+				Timestamp: now,
+			}); err != nil {
+				return errors.Wrapf(err, "failed to store price for %s", symbol)
+			}
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(priceMap)
+	}
+
+	symbols := make([]string, 0, len(priceMap))
+	for symbol := range priceMap {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		fmt.Printf("%-10s %16.8f USD\n", symbol, priceMap[symbol])
+	}
+	return nil
+}
+
+// syncPrices runs one live price sync for every held symbol and returns the
+// resulting prices in USD. Symbols no provider knows are reported as zero.
+func syncPrices(cfg *config.Config, repository *repo.Repository) (map[string]float64, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	logger := newLogger(cfg)
+	bus, err := eventbus.New(
+		eventbus.WithContext(ctx),
+		eventbus.WithLogger(logger),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create event bus")
+	}
+	defer bus.Close(ctx)
+
+	priceFetcher, err := newPriceService(cfg)
+	if err != nil {
+		return nil, err
+	}
+	priceCache := memcache.New[string, float64]()
+	livePriceSvc, err := service.NewLivePriceService(
+		service.WithLivePriceContext(ctx),
+		service.WithLivePriceLogger(logger),
+		service.WithLivePriceCache(priceCache),
+		service.WithLivePriceFetcher(priceFetcher),
+		service.WithLivePricePublisher(bus),
+		service.WithLivePriceRepo(repository),
+		service.WithLivePriceOffline(cfg.Prices.Offline),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create live price service")
+	}
+
+	if err := livePriceSvc.ForceSync(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to sync prices")
+	}
+
+	priceMap := make(map[string]float64)
+	for _, symbol := range priceCache.Keys() {
+		priceMap[symbol], _ = priceCache.Get(symbol)
+	}
+	return priceMap, nil
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"hodlbook/internal/config"
+	"hodlbook/internal/handler"
+	"hodlbook/internal/service"
+	uihandler "hodlbook/internal/ui/handler"
+	"hodlbook/pkg/integrations/broadcast"
+	"hodlbook/pkg/integrations/eventbus"
+	"hodlbook/pkg/integrations/lifecycle"
+	"hodlbook/pkg/integrations/memcache"
+	"hodlbook/pkg/integrations/notify"
+	"hodlbook/pkg/integrations/wallets/esplora"
+	"hodlbook/pkg/integrations/wallets/evm"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// stopTimeout bounds each component; the whole shutdown is bounded by
+// the configured shutdown timeout.
+const stopTimeout = 10 * time.Second
+
+func runServe(cfg *config.Config, args []string) error {
+	fs := newFlagSet("serve", "")
+	fs.IntVar(&cfg.HTTP.Port, "port", cfg.HTTP.Port, "HTTP listen port")
+	if ok, err := parseFlags(fs, args); !ok {
+		return err
+	}
+
+	logger := cfg.Log.NewLogger(os.Stdout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lc, err := lifecycle.New(
+		lifecycle.WithLogger(logger),
+		lifecycle.WithStopTimeout(stopTimeout),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create lifecycle manager")
+	}
+	// Releases whatever was started if setup fails; a no-op after shutdown.
+	defer lc.Stop(context.Background())
+
+	db, repository, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	lc.Add(lifecycle.Component{Name: "database", Stop: lifecycle.CloseFunc(db.Close)})
+
+	priceFetcher, err := newPriceService(cfg)
+	if err != nil {
+		return err
+	}
+	priceCache := memcache.New[string, float64]()
+	bus, err := eventbus.New(
+		eventbus.WithContext(ctx),
+		eventbus.WithLogger(logger),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create event bus")
+	}
+	// Closing the bus flushes queued events, so subscribers such as the alert
+	// and asset history services finish before the database closes.
+	lc.Add(lifecycle.Component{Name: "event bus", Stop: bus.Close})
+	if err := lc.Start(); err != nil {
+		return err
+	}
+
+	priceHub := broadcast.New()
+	liveHub := broadcast.New(broadcast.WithoutSnapshots())
+	err = bus.Subscribe(events.TopicPricesUpdated, eventbus.Handle(func(_ context.Context, p events.PricesUpdated) error {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		priceHub.Publish("prices", data)
+		return nil
+	}), events.SubscribeOptions{Name: "sse", Policy: events.DropOldest})
+	if err != nil {
+		return errors.Wrap(err, "failed to subscribe price stream")
+	}
+
+	livePriceSvc, err := service.NewLivePriceService(
+		service.WithLivePriceContext(ctx),
+		service.WithLivePriceLogger(logger),
+		service.WithLivePriceCache(priceCache),
+		service.WithLivePriceFetcher(priceFetcher),
+		service.WithLivePricePublisher(bus),
+		service.WithLivePriceRepo(repository),
+		service.WithLivePriceInterval(cfg.Prices.UpdateInterval.Std()),
+		service.WithLivePriceSyncInterval(cfg.Prices.SymbolSyncInterval.Std()),
+		service.WithLivePriceOffline(cfg.Prices.Offline),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create live price service")
+	}
+
+	historicPriceSvc, err := service.NewHistoricPriceService(
+		service.WithHistoricPriceContext(ctx),
+		service.WithHistoricPriceLogger(logger),
+		service.WithHistoricPriceFetcher(priceFetcher),
+		service.WithHistoricPriceRepo(repository),
+		service.WithHistoricPriceTargetHour(cfg.Prices.HistoricHour),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create historic price service")
+	}
+
+	assetHistoricSvc, err := service.NewAssetHistoricService(
+		service.WithAssetHistoricContext(ctx),
+		service.WithAssetHistoricLogger(logger),
+		service.WithAssetHistoricFetcher(priceFetcher),
+		service.WithAssetHistoricRepo(repository),
+		service.WithAssetHistoricBus(bus),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create asset historic service")
+	}
+
+	alertSvc, err := service.NewAlertService(
+		service.WithAlertContext(ctx),
+		service.WithAlertLogger(logger),
+		service.WithAlertRepo(repository),
+		service.WithAlertBus(bus),
+		service.WithAlertNotifiers(notify.NewLogNotifier(logger)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create alert service")
+	}
+
+	bitcoinFetcher := esplora.NewBalanceFetcher()
+	bitcoinFetcher.BaseURL = strings.TrimSuffix(cfg.Wallets.EsploraURL, "/")
+	walletOpts := []service.WalletOption{
+		service.WithWalletContext(ctx),
+		service.WithWalletLogger(logger),
+		service.WithWalletRepo(repository),
+		service.WithWalletInterval(cfg.Wallets.SyncInterval.Std()),
+		service.WithWalletFetcher(wallets.ChainBitcoin, bitcoinFetcher),
+	}
+	for chain, endpoint := range cfg.Wallets.RPCEndpoints() {
+		walletOpts = append(walletOpts,
+			service.WithWalletFetcher(chain, evm.NewBalanceFetcher(endpoint, wallets.NativeSymbols[chain])))
+	}
+	walletSvc, err := service.NewWalletService(walletOpts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to create wallet service")
+	}
+
+	recurringSvc, err := service.NewRecurringService(
+		service.WithRecurringContext(ctx),
+		service.WithRecurringLogger(logger),
+		service.WithRecurringRepo(repository),
+		service.WithRecurringPriceCache(priceCache),
+		service.WithRecurringPublisher(bus),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create recurring service")
+	}
+
+	lc.Add(lifecycle.Component{Name: "alert service", Start: alertSvc.Start})
+	lc.Add(lifecycle.Component{Name: "asset historic service", Start: assetHistoricSvc.Start})
+	lc.Add(lifecycle.Component{Name: "live price service", Start: livePriceSvc.Start, Stop: lifecycle.StopFunc(livePriceSvc.Stop)})
+	lc.Add(lifecycle.Component{Name: "historic price service", Start: historicPriceSvc.Start, Stop: lifecycle.StopFunc(historicPriceSvc.Stop)})
+	lc.Add(lifecycle.Component{Name: "wallet service", Start: walletSvc.Start, Stop: lifecycle.StopFunc(walletSvc.Stop)})
+	lc.Add(lifecycle.Component{Name: "recurring service", Start: recurringSvc.Start, Stop: lifecycle.StopFunc(recurringSvc.Stop)})
+
+	if cfg.App.Env == config.EnvProduction {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		return errors.Wrap(err, "failed to set trusted proxies")
+	}
+	r.Use(handler.MetricsMiddleware())
+
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	uiOpts := []uihandler.Option{
+		uihandler.WithEngine(r),
+		uihandler.WithRepository(repository),
+		uihandler.WithPriceCache(priceCache),
+		uihandler.WithPriceFetcher(priceFetcher),
+		uihandler.WithEventPublisher(bus),
+		uihandler.WithLiveUpdates(bus, liveHub),
+		uihandler.WithPriceStaleAfter(cfg.Prices.StaleAfter.Std()),
+		uihandler.WithRequireAPIKey(cfg.HTTP.RequireAPIKey),
+	}
+	if cfg.UI.Dev {
+		uiOpts = append(uiOpts,
+			uihandler.WithFS(os.DirFS("./internal/ui")),
+			uihandler.WithDevMode(true),
+		)
+	}
+	webHandler, err := uihandler.New(uiOpts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to create web handler")
+	}
+	if err := webHandler.Setup(); err != nil {
+		return errors.Wrap(err, "failed to setup web routes")
+	}
+
+	h, err := handler.New(
+		handler.WithEngine(r),
+		handler.WithRepository(repository),
+		handler.WithPriceHub(priceHub),
+		handler.WithPriceCache(priceCache),
+		handler.WithEventPublisher(bus),
+		handler.WithLivePriceService(livePriceSvc),
+		handler.WithWalletService(walletSvc),
+		handler.WithRecurringService(recurringSvc),
+		handler.WithPriceFetcher(priceFetcher),
+		handler.WithRequireAPIKey(cfg.HTTP.RequireAPIKey),
+		handler.WithPriceStaleAfter(cfg.Prices.StaleAfter.Std()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create handler")
+	}
+	if err := h.Setup(); err != nil {
+		return errors.Wrap(err, "failed to setup routes")
+	}
+
+	srv := &http.Server{
+		Addr:    cfg.HTTP.Addr(),
+		Handler: r,
+	}
+	// SSE streams never go idle, so end them as soon as shutdown begins.
+	srv.RegisterOnShutdown(priceHub.Close)
+	srv.RegisterOnShutdown(liveHub.Close)
+
+	serverErrs := make(chan error, 1)
+	lc.Add(lifecycle.HTTPServer("http server", srv, serverErrs))
+
+	logger.Info("starting HodlBook", "addr", srv.Addr, "env", cfg.App.Env)
+	if err := lc.Start(); err != nil {
+		return err
+	}
+
+	sigCtx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	var runErr error
+	select {
+	case <-sigCtx.Done():
+		logger.Info("shutting down...")
+	case runErr = <-serverErrs:
+		logger.Error("server failed, shutting down", "error", runErr)
+		runErr = errors.Wrap(runErr, "server failed")
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout.Std())
+	defer shutdownCancel()
+	if err := lc.Stop(shutdownCtx); err != nil {
+		return errors.Wrap(err, "shutdown incomplete")
+	}
+
+	logger.Info("shutdown complete")
+	return runErr
+}
@@ -0,0 +1,576 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidConfig     = errors.New("invalid config")
+	ErrUnknownFileFormat = errors.New("config file must end in .yaml, .yml or .toml")
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+
+	DatabaseSQLite   = "sqlite"
+	DatabasePostgres = "postgres"
+
+	PriceProviderLive = "live"
+	PriceProviderMock = "mock"
+)
+
+// Config is the application configuration. Every key can be set in an
+// optional YAML or TOML file, in .env or in the environment; the env tag
+// names the variable. Later sources win: defaults, file, .env, environment.
+type Config struct {
+	App      AppConfig      `yaml:"app" toml:"app"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	HTTP     HTTPConfig     `yaml:"http" toml:"http"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Prices   PricesConfig   `yaml:"prices" toml:"prices"`
+	Wallets  WalletsConfig  `yaml:"wallets" toml:"wallets"`
+	UI       UIConfig       `yaml:"ui" toml:"ui"`
+}
+
+type AppConfig struct {
+	// Env is development or production. Production runs gin in release mode.
+	Env string `yaml:"env" toml:"env" env:"APP_ENV"`
+	// DefaultCurrency is the reference currency for values. Only USD is
+	// supported.
+	DefaultCurrency string `yaml:"default_currency" toml:"default_currency" env:"DEFAULT_CURRENCY"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// Format is text or json.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+type HTTPConfig struct {
+	BindAddress string `yaml:"bind_address" toml:"bind_address" env:"APP_BIND_ADDRESS"`
+	Port        int    `yaml:"port" toml:"port" env:"APP_PORT"`
+	// TrustedProxies lists the IPs or CIDRs whose forwarding headers are
+	// believed when resolving client IPs. Empty trusts none.
+	TrustedProxies  []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"APP_TRUSTED_PROXIES"`
+	RequireAPIKey   bool     `yaml:"require_api_key" toml:"require_api_key" env:"API_KEY_REQUIRED"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	// Type is sqlite or postgres.
+	Type string `yaml:"type" toml:"type" env:"DB_TYPE"`
+	// Path is the SQLite database file.
+	Path string `yaml:"path" toml:"path" env:"DB_PATH"`
+	// DSN is a full PostgreSQL connection string. When set, the individual
+	// connection fields below are ignored.
+	DSN      string `yaml:"dsn" toml:"dsn" env:"DB_DSN"`
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	// SSLMode is passed to PostgreSQL as sslmode, e.g. disable or require.
+	SSLMode string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+}
+
+type PricesConfig struct {
+	// Offline disables every price provider. Prices then come only from
+	// those entered by hand.
+	Offline bool `yaml:"offline" toml:"offline" env:"PRICE_OFFLINE"`
+	// Provider is live, to fetch prices from the exchanges, or mock, to make
+	// them up locally with a deterministic random walk.
+	Provider string `yaml:"provider" toml:"provider" env:"PRICE_PROVIDER"`
+	// MockFixture is a JSON file listing the assets the mock provider prices.
+	// When empty it prices every symbol.
+	MockFixture string `yaml:"mock_fixture" toml:"mock_fixture" env:"PRICE_MOCK_FIXTURE"`
+	// UpdateInterval is how often live prices are fetched.
+	UpdateInterval Duration `yaml:"update_interval" toml:"update_interval" env:"PRICE_UPDATE_INTERVAL"`
+	// SymbolSyncInterval is how often the set of held symbols is reloaded.
+	SymbolSyncInterval Duration `yaml:"symbol_sync_interval" toml:"symbol_sync_interval" env:"PRICE_SYMBOL_SYNC_INTERVAL"`
+	// CacheTTL is how long fetched provider prices are reused.
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"PRICE_CACHE_TTL"`
+	// ProviderTimeout bounds each provider's part of a full price fetch.
+	ProviderTimeout Duration `yaml:"provider_timeout" toml:"provider_timeout" env:"PRICE_PROVIDER_TIMEOUT"`
+	// StaleAfter is the age past which a last known price is shown as stale.
+	StaleAfter Duration `yaml:"stale_after" toml:"stale_after" env:"PRICE_STALE_AFTER"`
+	// HistoricHour is the UTC hour at which daily historic prices are stored.
+	HistoricHour int `yaml:"historic_hour" toml:"historic_hour" env:"HISTORIC_PRICE_HOUR"`
+}
+
+type WalletsConfig struct {
+	// SyncInterval is how often watch-only wallet balances are read and
+	// reconciled with recorded holdings.
+	SyncInterval Duration `yaml:"sync_interval" toml:"sync_interval" env:"WALLET_SYNC_INTERVAL"`
+	// EsploraURL is the Esplora API bitcoin balances are read from, such as
+	// blockstream.info or a self-hosted electrs.
+	EsploraURL string `yaml:"esplora_url" toml:"esplora_url" env:"WALLET_ESPLORA_URL"`
+	// RPCURLs lists chain=url pairs naming the JSON-RPC endpoint of each EVM
+	// chain. Wallets on a chain without one fail to sync.
+	RPCURLs []string `yaml:"rpc_urls" toml:"rpc_urls" env:"WALLET_RPC_URLS"`
+}
+
+// RPCEndpoints maps each chain in RPCURLs to its endpoint.
+func (c *WalletsConfig) RPCEndpoints() map[string]string {
+	endpoints := make(map[string]string, len(c.RPCURLs))
+	for _, entry := range c.RPCURLs {
+		chain, endpoint, ok := strings.Cut(entry, "=")
+		if ok {
+			endpoints[strings.TrimSpace(chain)] = strings.TrimSpace(endpoint)
+		}
+	}
+	return endpoints
+}
+
+type UIConfig struct {
+	// Dev serves templates and static files from ./internal/ui and reloads
+	// templates on every request.
+	Dev bool `yaml:"dev" toml:"dev" env:"UI_DEV"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		App: AppConfig{
+			Env:             EnvDevelopment,
+			DefaultCurrency: "USD",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		HTTP: HTTPConfig{
+			BindAddress:     "0.0.0.0",
+			Port:            2008,
+			TrustedProxies:  []string{},
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Type:    DatabaseSQLite,
+			Path:    "./data/hodlbook.db",
+			Host:    "localhost",
+			Port:    5432,
+			User:    "hodlbook",
+			Name:    "hodlbook",
+			SSLMode: "disable",
+		},
+		Prices: PricesConfig{
+			Provider:           PriceProviderLive,
+			UpdateInterval:     Duration(time.Minute),
+			SymbolSyncInterval: Duration(time.Hour),
+			CacheTTL:           Duration(time.Minute),
+			ProviderTimeout:    Duration(20 * time.Second),
+			StaleAfter:         Duration(5 * time.Minute),
+			HistoricHour:       0,
+		},
+		Wallets: WalletsConfig{
+			SyncInterval: Duration(time.Hour),
+			EsploraURL:   "https://blockstream.info/api",
+			RPCURLs:      []string{"ethereum=https://ethereum-rpc.publicnode.com"},
+		},
+	}
+}
+
+// Options selects where Load reads from. Empty fields fall back to the
+// defaults noted on each.
+type Options struct {
+	// File is a YAML or TOML config file. Defaults to $CONFIG_FILE; no file
+	// is read when both are empty.
+	File string
+	// EnvFile is the dotenv file. Defaults to .env; a missing file is fine.
+	EnvFile string
+	// Environ is the process environment. Defaults to os.Environ().
+	Environ []string
+}
+
+// Load builds and validates the configuration.
+func Load(opts Options) (*Config, error) {
+	if opts.EnvFile == "" {
+		opts.EnvFile = ".env"
+	}
+	if opts.Environ == nil {
+		opts.Environ = os.Environ()
+	}
+
+	env, err := readEnvFile(opts.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range opts.Environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+
+	cfg := Default()
+
+	file := opts.File
+	if file == "" {
+		file = env["CONFIG_FILE"]
+	}
+	if file != "" {
+		if err := cfg.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), env); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		return ErrUnknownFileFormat
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.App.Env == EnvDevelopment || c.App.Env == EnvProduction,
+		"APP_ENV must be development or production, got %q", c.App.Env)
+	check(c.App.DefaultCurrency == "USD",
+		"DEFAULT_CURRENCY must be USD, got %q", c.App.DefaultCurrency)
+
+	_, levelErr := c.Log.SlogLevel()
+	check(levelErr == nil, "LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json",
+		"LOG_FORMAT must be text or json, got %q", c.Log.Format)
+
+	check(c.HTTP.Port > 0 && c.HTTP.Port < 65536, "APP_PORT must be between 1 and 65535, got %d", c.HTTP.Port)
+	for _, proxy := range c.HTTP.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"APP_TRUSTED_PROXIES entry %q is not an IP or CIDR", proxy)
+	}
+	check(c.HTTP.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+
+	switch c.Database.Type {
+	case DatabaseSQLite:
+		check(c.Database.Path != "", "DB_PATH cannot be empty")
+	case DatabasePostgres:
+		if c.Database.DSN == "" {
+			check(c.Database.Host != "", "DB_HOST cannot be empty")
+			check(c.Database.Port > 0 && c.Database.Port < 65536,
+				"DB_PORT must be between 1 and 65535, got %d", c.Database.Port)
+			check(c.Database.Name != "", "DB_NAME cannot be empty")
+		}
+	default:
+		check(false, "DB_TYPE must be sqlite or postgres, got %q", c.Database.Type)
+	}
+
+	check(c.Prices.Provider == PriceProviderLive || c.Prices.Provider == PriceProviderMock,
+		"PRICE_PROVIDER must be live or mock, got %q", c.Prices.Provider)
+	check(c.Prices.UpdateInterval > 0, "PRICE_UPDATE_INTERVAL must be positive")
+	check(c.Prices.SymbolSyncInterval > 0, "PRICE_SYMBOL_SYNC_INTERVAL must be positive")
+	check(c.Prices.CacheTTL > 0, "PRICE_CACHE_TTL must be positive")
+	check(c.Prices.ProviderTimeout > 0, "PRICE_PROVIDER_TIMEOUT must be positive")
+	check(c.Prices.StaleAfter > 0, "PRICE_STALE_AFTER must be positive")
+	check(c.Prices.HistoricHour >= 0 && c.Prices.HistoricHour < 24,
+		"HISTORIC_PRICE_HOUR must be between 0 and 23, got %d", c.Prices.HistoricHour)
+
+	check(c.Wallets.SyncInterval > 0, "WALLET_SYNC_INTERVAL must be positive")
+	check(isHTTPURL(c.Wallets.EsploraURL), "WALLET_ESPLORA_URL must be an http or https URL, got %q", c.Wallets.EsploraURL)
+	for _, entry := range c.Wallets.RPCURLs {
+		chain, endpoint, _ := strings.Cut(entry, "=")
+		check(wallets.IsEVMChain(strings.TrimSpace(chain)) && isHTTPURL(strings.TrimSpace(endpoint)),
+			"WALLET_RPC_URLS entries must be chain=url with a supported EVM chain, got %q", entry)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Addr is the HTTP listen address.
+func (c *HTTPConfig) Addr() string {
+	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.Port))
+}
+
+func (c *LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Level))
+	return level, err
+}
+
+// NewLogger builds a logger writing to w in the configured level and format.
+func (c *LogConfig) NewLogger(w io.Writer) *slog.Logger {
+	level, _ := c.SlogLevel()
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// PostgresDSN is the PostgreSQL connection string, either DSN as given or
+// one built from the individual connection fields.
+func (c *DatabaseConfig) PostgresDSN() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Name,
+	}
+	if c.User != "" {
+		u.User = url.UserPassword(c.User, c.Password)
+		if c.Password == "" {
+			u.User = url.User(c.User)
+		}
+	}
+	if c.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {c.SSLMode}}.Encode()
+	}
+	return u.String()
+}
+
+// Redacted returns a copy with database credentials masked, for printing.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.HTTP.TrustedProxies = append([]string{}, c.HTTP.TrustedProxies...)
+	redacted.Wallets.RPCURLs = make([]string, len(c.Wallets.RPCURLs))
+	for i, entry := range c.Wallets.RPCURLs {
+		redacted.Wallets.RPCURLs[i] = redactRPCURL(entry)
+	}
+	if redacted.Database.Password != "" {
+		redacted.Database.Password = redactedValue
+	}
+	if redacted.Database.DSN != "" {
+		redacted.Database.DSN = redactDSN(redacted.Database.DSN)
+	}
+	return &redacted
+}
+
+const redactedValue = "REDACTED"
+
+// redactDSN masks the password of a URL style DSN. Keyword/value strings are
+// masked entirely since the password can appear anywhere in them.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return redactedValue
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+	return u.String()
+}
+
+// redactRPCURL masks the path and query of an RPC endpoint, where hosted
+// providers put their API keys.
+func redactRPCURL(entry string) string {
+	chain, endpoint, _ := strings.Cut(entry, "=")
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return chain + "=" + redactedValue
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		u.Path, u.RawQuery = "/"+redactedValue, ""
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	return chain + "=" + u.String()
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// YAML renders the configuration in config file form.
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Duration accepts Go durations such as "90s" or "5m" and, for compatibility
+// with older settings, a bare number of seconds.
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if seconds, err := strconv.Atoi(s); err == nil {
+		*d = Duration(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// applyEnv sets every field with an env tag whose variable is present.
+func applyEnv(v reflect.Value, env map[string]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, env); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := t.Field(i).Tag.Get("env")
+		raw, ok := env[key]
+		if key == "" || !ok {
+			continue
+		}
+		if err := setField(field, strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if u, ok := field.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// readEnvFile parses a dotenv file. Lines may start with "export", values
+// may be single or double quoted, and unquoted values end at " #".
+func readEnvFile(path string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return env, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, lineNum)
+		}
+
+		value = strings.TrimSpace(value)
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') {
+			end := strings.IndexByte(value[1:], value[0])
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated quote", path, lineNum)
+			}
+			value = value[1 : end+1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return env, nil
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func load(t *testing.T, opts Options) (*Config, error) {
+	if opts.EnvFile == "" {
+		opts.EnvFile = filepath.Join(t.TempDir(), "missing.env")
+	}
+	if opts.Environ == nil {
+		opts.Environ = []string{}
+	}
+	return Load(opts)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(t, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+	assert.Equal(t, "0.0.0.0:2008", cfg.HTTP.Addr())
+}
+
+func TestLoad_YAMLFile(t *testing.T) {
+	path := writeFile(t, "hodlbook.yaml", `
+app:
+  env: production
+http:
+  port: 9000
+  trusted_proxies: [10.0.0.0/8, 192.168.1.1]
+log:
+  format: json
+prices:
+  update_interval: 5m
+  historic_hour: 3
+`)
+
+	cfg, err := load(t, Options{File: path})
+	require.NoError(t, err)
+	assert.Equal(t, EnvProduction, cfg.App.Env)
+	assert.Equal(t, 9000, cfg.HTTP.Port)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, cfg.HTTP.TrustedProxies)
+	assert.Equal(t, "json", cfg.Log.Format)
+	assert.Equal(t, 5*time.Minute, cfg.Prices.UpdateInterval.Std())
+	assert.Equal(t, 3, cfg.Prices.HistoricHour)
+	assert.Equal(t, "./data/hodlbook.db", cfg.Database.Path, "unset keys keep defaults")
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	path := writeFile(t, "hodlbook.toml", `
+[database]
+path = "/var/lib/hodlbook.db"
+
+[ui]
+dev = true
+`)
+
+	cfg, err := load(t, Options{File: path})
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/hodlbook.db", cfg.Database.Path)
+	assert.True(t, cfg.UI.Dev)
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeFile(t, "hodlbook.yaml", "http:\n  prot: 9000\n")
+	_, err := load(t, Options{File: path})
+	assert.ErrorContains(t, err, "prot")
+
+	_, err = load(t, Options{File: writeFile(t, "hodlbook.ini", "")})
+	assert.ErrorIs(t, err, ErrUnknownFileFormat)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "hodlbook.yaml", "http:\n  port: 9000\nlog:\n  level: warn\ndatabase:\n  path: /file.db\n")
+	envFile := writeFile(t, ".env", "CONFIG_FILE="+file+"\nAPP_PORT=9100\nLOG_LEVEL=error\n")
+
+	cfg, err := load(t, Options{
+		EnvFile: envFile,
+		Environ: []string{"APP_PORT=9200"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 9200, cfg.HTTP.Port, "environment beats .env")
+	assert.Equal(t, "error", cfg.Log.Level, ".env beats the config file")
+	assert.Equal(t, "/file.db", cfg.Database.Path, "config file beats defaults")
+}
+
+func TestLoad_EnvTypes(t *testing.T) {
+	cfg, err := load(t, Options{Environ: []string{
+		"API_KEY_REQUIRED=true",
+		"APP_TRUSTED_PROXIES=10.0.0.1, 10.0.0.2",
+		"PRICE_UPDATE_INTERVAL=300",
+		"SHUTDOWN_TIMEOUT=45s",
+	}})
+	require.NoError(t, err)
+	assert.True(t, cfg.HTTP.RequireAPIKey)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cfg.HTTP.TrustedProxies)
+	assert.Equal(t, 5*time.Minute, cfg.Prices.UpdateInterval.Std(), "bare numbers are seconds")
+	assert.Equal(t, 45*time.Second, cfg.HTTP.ShutdownTimeout.Std())
+
+	_, err = load(t, Options{Environ: []string{"APP_PORT=abc"}})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "APP_PORT")
+}
+
+func TestLoad_Validation(t *testing.T) {
+	_, err := load(t, Options{Environ: []string{
+		"APP_ENV=staging",
+		"LOG_LEVEL=verbose",
+		"DB_TYPE=mysql",
+		"HISTORIC_PRICE_HOUR=24",
+		"APP_TRUSTED_PROXIES=not-an-ip",
+		"PRICE_PROVIDER=random",
+		"WALLET_RPC_URLS=dogecoin=https://rpc.example",
+	}})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	for _, key := range []string{"APP_ENV", "LOG_LEVEL", "DB_TYPE", "HISTORIC_PRICE_HOUR", "APP_TRUSTED_PROXIES", "PRICE_PROVIDER", "WALLET_RPC_URLS"} {
+		assert.ErrorContains(t, err, key)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := writeFile(t, ".env", `# comment
+export APP_ENV=production
+DB_PATH="/data/my db.db"
+LOG_LEVEL='warn'
+APP_PORT=2009 # inline comment
+EMPTY=
+`)
+
+	env, err := readEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"APP_ENV":   "production",
+		"DB_PATH":   "/data/my db.db",
+		"LOG_LEVEL": "warn",
+		"APP_PORT":  "2009",
+		"EMPTY":     "",
+	}, env)
+
+	_, err = readEnvFile(writeFile(t, ".env", "NOT A VAR\n"))
+	assert.ErrorContains(t, err, ":1:")
+}
+
+func TestConfig_YAMLRoundTrip(t *testing.T) {
+	data, err := Default().YAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "update_interval: 1m0s")
+
+	cfg, err := load(t, Options{File: writeFile(t, "out.yaml", string(data))})
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestDatabaseConfig_Postgres(t *testing.T) {
+	cfg, err := load(t, Options{Environ: []string{
+		"DB_TYPE=postgres",
+		"DB_HOST=db",
+		"DB_USER=hodl",
+		"DB_PASSWORD=p@ss",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://hodl:p%40ss@db:5432/hodlbook?sslmode=disable", cfg.Database.PostgresDSN())
+
+	cfg.Database.DSN = "postgres://hodl:secret@db/hodlbook"
+	assert.Equal(t, cfg.Database.DSN, cfg.Database.PostgresDSN(), "an explicit DSN wins")
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "REDACTED", redacted.Database.Password)
+	assert.Equal(t, "postgres://hodl:REDACTED@db/hodlbook", redacted.Database.DSN)
+	assert.Equal(t, "p@ss", cfg.Database.Password, "the original is untouched")
+
+	cfg.Database.DSN = "host=db password=secret"
+	assert.Equal(t, "REDACTED", cfg.Redacted().Database.DSN)
+
+	_, err = load(t, Options{Environ: []string{"DB_TYPE=postgres", "DB_HOST=", "DB_PORT=0"}})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "DB_HOST")
+	assert.ErrorContains(t, err, "DB_PORT")
+}
+
+func TestWalletsConfig_RPCURLs(t *testing.T) {
+	cfg, err := load(t, Options{Environ: []string{
+		"WALLET_RPC_URLS=ethereum=https://mainnet.infura.io/v3/secret-key, base=https://mainnet.base.org",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ethereum": "https://mainnet.infura.io/v3/secret-key",
+		"base":     "https://mainnet.base.org",
+	}, cfg.Wallets.RPCEndpoints())
+
+	assert.Equal(t, []string{
+		"ethereum=https://mainnet.infura.io/REDACTED",
+		"base=https://mainnet.base.org",
+	}, cfg.Redacted().Wallets.RPCURLs)
+}
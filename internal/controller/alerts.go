@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hodlbook/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertRuleRequest struct {
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	Symbol          string  `json:"symbol"`
+	PortfolioID     int64   `json:"portfolio_id"`
+	Threshold       float64 `json:"threshold"`
+	WindowMinutes   int     `json:"window_minutes"`
+	TargetPercent   float64 `json:"target_percent"`
+	CooldownMinutes int     `json:"cooldown_minutes"`
+	Channels        string  `json:"channels"`
+	Enabled         *bool   `json:"enabled"`
+}
+
+func (r *AlertRuleRequest) apply(rule *models.AlertRule) {
+	rule.Name = strings.TrimSpace(r.Name)
+	rule.Type = r.Type
+	rule.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	rule.PortfolioID = r.PortfolioID
+	rule.Threshold = r.Threshold
+	rule.WindowMinutes = r.WindowMinutes
+	rule.TargetPercent = r.TargetPercent
+	rule.CooldownMinutes = r.CooldownMinutes
+	rule.Channels = r.Channels
+	if rule.CooldownMinutes <= 0 {
+		rule.CooldownMinutes = models.DefaultAlertCooldownMinutes
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+}
+
+// ListAlerts godoc
+// @Summary List alert rules
+// @Description Get all alert rules
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} models.AlertRule
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts [get]
+func (c *Controller) ListAlerts(ctx *gin.Context) {
+	rules, err := c.repo.ListAlertRules()
+	if err != nil {
+		internalError(ctx, "failed to fetch alerts")
+		return
+	}
+	ctx.JSON(http.StatusOK, rules)
+}
+
+// GetAlert godoc
+// @Summary Get an alert rule by ID
+// @Description Get a single alert rule by its ID
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Alert rule ID"
+// @Success 200 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/alerts/{id} [get]
+func (c *Controller) GetAlert(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid alert id")
+		return
+	}
+
+	rule, err := c.repo.GetAlertRuleByID(id)
+	if err != nil {
+		notFound(ctx, "alert not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// CreateAlert godoc
+// @Summary Create an alert rule
+// @Description Create a price, price change, portfolio value or allocation drift alert
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param alert body AlertRuleRequest true "Alert rule"
+// @Success 201 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts [post]
+func (c *Controller) CreateAlert(ctx *gin.Context) {
+	var req AlertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	rule := models.AlertRule{Enabled: true}
+	req.apply(&rule)
+	if !c.validAlertRule(ctx, &rule) {
+		return
+	}
+
+	if err := c.repo.CreateAlertRule(&rule); err != nil {
+		internalError(ctx, "failed to create alert")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAlert godoc
+// @Summary Update an alert rule
+// @Description Update an existing alert rule by its ID
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path int true "Alert rule ID"
+// @Param alert body AlertRuleRequest true "Alert rule"
+// @Success 200 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/{id} [put]
+func (c *Controller) UpdateAlert(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid alert id")
+		return
+	}
+
+	rule, err := c.repo.GetAlertRuleByID(id)
+	if err != nil {
+		notFound(ctx, "alert not found")
+		return
+	}
+
+	var req AlertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	req.apply(rule)
+	if !c.validAlertRule(ctx, rule) {
+		return
+	}
+
+	if err := c.repo.UpdateAlertRule(rule); err != nil {
+		internalError(ctx, "failed to update alert")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// DeleteAlert godoc
+// @Summary Delete an alert rule
+// @Description Delete an alert rule by its ID
+// @Tags alerts
+// @Param id path int true "Alert rule ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/{id} [delete]
+func (c *Controller) DeleteAlert(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid alert id")
+		return
+	}
+
+	if err := c.repo.DeleteAlertRule(id); err != nil {
+		internalError(ctx, "failed to delete alert")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListAlertHistory godoc
+// @Summary List triggered alerts
+// @Description Get the most recently triggered alerts
+// @Tags alerts
+// @Produce json
+// @Param limit query int false "Number of events to return (default 50, max 500)"
+// @Success 200 {array} models.AlertEvent
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/history [get]
+func (c *Controller) ListAlertHistory(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	events, err := c.repo.ListAlertEvents(limit)
+	if err != nil {
+		internalError(ctx, "failed to fetch alert history")
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+func (c *Controller) validAlertRule(ctx *gin.Context, rule *models.AlertRule) bool {
+	if err := rule.Validate(); err != nil {
+		badRequest(ctx, err.Error())
+		return false
+	}
+	if rule.PortfolioID != 0 && !c.portfolioExists(rule.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return false
+	}
+	return true
+}
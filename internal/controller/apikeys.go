@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description Get all API keys. Secrets are never returned.
+// @Tags keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 500 {object} map[string]string
+// @Router /api/keys [get]
+func (c *Controller) ListAPIKeys(ctx *gin.Context) {
+	keys, err := c.repo.ListAPIKeys()
+	if err != nil {
+		internalError(ctx, "failed to fetch api keys")
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a scoped API key. The key is only returned in this response.
+// @Tags keys
+// @Accept json
+// @Produce json
+// @Param key body CreateAPIKeyRequest true "Key data"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/keys [post]
+func (c *Controller) CreateAPIKey(ctx *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		badRequest(ctx, "name is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !models.IsValidScope(scope) {
+			badRequest(ctx, "invalid scope: "+scope)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		badRequest(ctx, "expires_at must be in the future")
+		return
+	}
+
+	key, raw, err := c.repo.CreateAPIKey(req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, repo.ErrAPIKeyNoScopes) {
+			badRequest(ctx, err.Error())
+			return
+		}
+		internalError(ctx, "failed to create api key")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: *key, Key: raw})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key so it can no longer be used
+// @Tags keys
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/keys/{id} [delete]
+func (c *Controller) RevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid api key id")
+		return
+	}
+
+	if _, err := c.repo.GetAPIKeyByID(id); err != nil {
+		notFound(ctx, "api key not found")
+		return
+	}
+
+	if err := c.repo.RevokeAPIKey(id); err != nil {
+		internalError(ctx, "failed to revoke api key")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
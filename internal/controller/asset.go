@@ -1,17 +1,20 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
-	"hodlbook/pkg/integrations/prices"
+	"hodlbook/pkg/types/events"
 	priceTypes "hodlbook/pkg/types/prices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -20,17 +23,28 @@ import (
 // @Description Get a list of assets with optional filters
 // @Tags assets
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Param limit query int false "Limit"
 // @Param offset query int false "Offset"
-// @Param symbol query string false "Symbol"
+// @Param symbol query string false "Symbols, comma-separated"
 // @Param transaction_type query string false "Transaction type (deposit, withdraw)"
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param q query string false "Case-insensitive search in notes"
+// @Param sort query string false "Sort field (date, type, symbol, amount)"
+// @Param dir query string false "Sort direction (asc, desc)"
+// @Param after query string false "Cursor from next_cursor; overrides offset"
+// @Param before query string false "Cursor from prev_cursor; overrides offset"
 // @Success 200 {object} repo.AssetListResult
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/assets [get]
 func (c *Controller) ListAssets(ctx *gin.Context) {
-	filter := repo.AssetFilter{}
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+	filter := repo.AssetFilter{PortfolioID: portfolioID}
 
 	if limitStr := ctx.Query("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil {
@@ -43,7 +57,7 @@ func (c *Controller) ListAssets(ctx *gin.Context) {
 		}
 	}
 	if symbol := ctx.Query("symbol"); symbol != "" {
-		filter.Symbol = symbol
+		filter.Symbols = strings.Split(symbol, ",")
 	}
 	if txType := ctx.Query("transaction_type"); txType != "" {
 		filter.TransactionType = txType
@@ -60,7 +74,17 @@ func (c *Controller) ListAssets(ctx *gin.Context) {
 		}
 	}
 
+	filter.Search = ctx.Query("q")
+	filter.SortBy = ctx.Query("sort")
+	filter.SortDir = ctx.Query("dir")
+	filter.After = ctx.Query("after")
+	filter.Before = ctx.Query("before")
+
 	result, err := c.repo.ListAssets(filter)
+	if errors.Is(err, repo.ErrInvalidSort) || errors.Is(err, repo.ErrInvalidCursor) {
+		badRequest(ctx, err.Error())
+		return
+	}
 	if err != nil {
 		internalError(ctx, "failed to fetch assets")
 		return
@@ -116,11 +140,22 @@ func (c *Controller) CreateAsset(ctx *gin.Context) {
 		asset.Timestamp = time.Now()
 	}
 
+	if asset.PortfolioID != 0 && !c.portfolioExists(asset.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if !c.walletInPortfolio(asset.WalletID, asset.PortfolioID) {
+		badRequest(ctx, "wallet not found in portfolio")
+		return
+	}
+
 	if err := c.repo.CreateAsset(&asset); err != nil {
 		internalError(ctx, "failed to create asset")
 		return
 	}
 
+	c.publish(ctx.Request.Context(), events.TopicAssetCreated, asset)
 	ctx.JSON(http.StatusCreated, asset)
 }
 
@@ -144,7 +179,8 @@ func (c *Controller) UpdateAsset(ctx *gin.Context) {
 		return
 	}
 
-	if _, err = c.repo.GetAssetByID(id); err != nil {
+	existing, err := c.repo.GetAssetByID(id)
+	if err != nil {
 		notFound(ctx, "asset not found")
 		return
 	}
@@ -155,14 +191,27 @@ func (c *Controller) UpdateAsset(ctx *gin.Context) {
 		return
 	}
 
+	if asset.PortfolioID == 0 {
+		asset.PortfolioID = existing.PortfolioID
+	} else if !c.portfolioExists(asset.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if !c.walletInPortfolio(asset.WalletID, asset.PortfolioID) {
+		badRequest(ctx, "wallet not found in portfolio")
+		return
+	}
+
 	asset.ID = id
 	if err := c.repo.UpdateAsset(&asset); err != nil {
 		internalError(ctx, "failed to update asset")
 		return
 	}
 
-	c.ensurePriceAtTimestamp(asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
+	c.ensurePriceAtTimestamp(ctx.Request.Context(), asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
 
+	c.publish(ctx.Request.Context(), events.TopicAssetUpdated, asset)
 	ctx.JSON(http.StatusOK, asset)
 }
 
@@ -182,11 +231,16 @@ func (c *Controller) DeleteAsset(ctx *gin.Context) {
 		return
 	}
 
+	existing, _ := c.repo.GetAssetByID(id)
 	if err := c.repo.DeleteAsset(id); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		internalError(ctx, "failed to delete asset")
 		return
 	}
 
+	if existing != nil {
+		c.publish(ctx.Request.Context(), events.TopicAssetDeleted, *existing)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -207,28 +261,28 @@ func (c *Controller) GetUniqueSymbols(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, symbols)
 }
 
-func (c *Controller) ensurePriceAtTimestamp(symbol, name string, timestamp time.Time, priceSource *string) {
+func (c *Controller) ensurePriceAtTimestamp(ctx context.Context, symbol, name string, timestamp time.Time, priceSource *string) {
 	if c.priceFetcher == nil {
 		return
 	}
 
 	var priceValue float64
 
-	if priceSource != nil && *priceSource != "" {
+	sourceFetcher, ok := c.priceFetcher.(priceTypes.SourceFetcher)
+	if ok && priceSource != nil && *priceSource != "" {
 		price := &priceTypes.Price{
 			Asset: priceTypes.Asset{
 				Symbol: symbol,
 				Name:   name,
 			},
 		}
-		fetcher := prices.NewPriceService()
-		if err := fetcher.FetchBySource(*priceSource, price); err == nil && price.Value > 0 {
+		if err := sourceFetcher.FetchBySource(ctx, *priceSource, price); err == nil && price.Value > 0 {
 			priceValue = price.Value
 		}
 	}
 
 	if priceValue == 0 {
-		allPrices, err := c.priceFetcher.FetchAll()
+		allPrices, err := c.priceFetcher.FetchAll(ctx)
 		if err != nil {
 			return
 		}
@@ -247,7 +301,7 @@ func (c *Controller) ensurePriceAtTimestamp(symbol, name string, timestamp time.
 	c.repo.CreatePrice(&models.Price{
 		Symbol:    symbol,
 		Currency:  "USD",
-		Price:     priceValue,
+		Price:     decimal.NewFromFloat(priceValue),
 		Timestamp: timestamp,
 	})
 }
@@ -1,19 +1,25 @@
 package controller
 
 import (
+	"context"
 	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
-	"hodlbook/pkg/types/pubsub"
 	"hodlbook/pkg/types/repo"
 	"log/slog"
+	"time"
 )
 
+// defaultPriceStaleAfter is used when WithPriceStaleAfter is not given.
+const defaultPriceStaleAfter = 5 * time.Minute
+
 type Controller struct {
-	logger          slog.Logger
-	repo            repo.Repository
-	priceCache      cache.Cache[string, float64]
-	priceFetcher    prices.PriceFetcher
-	assetCreatedPub pubsub.Publisher
+	logger       slog.Logger
+	repo         repo.Repository
+	priceCache   cache.Cache[string, float64]
+	priceFetcher prices.PriceFetcher
+	events       events.Publisher
+	staleAfter   time.Duration
 }
 
 type Option func(*Controller)
@@ -36,9 +42,9 @@ func WithPriceCache(pc cache.Cache[string, float64]) Option {
 	}
 }
 
-func WithAssetCreatedPublisher(p pubsub.Publisher) Option {
+func WithEventPublisher(p events.Publisher) Option {
 	return func(c *Controller) {
-		c.assetCreatedPub = p
+		c.events = p
 	}
 }
 
@@ -48,8 +54,18 @@ func WithPriceFetcher(pf prices.PriceFetcher) Option {
 	}
 }
 
+// WithPriceStaleAfter sets the age past which a price is reported as stale.
+// Non-positive durations keep the default.
+func WithPriceStaleAfter(d time.Duration) Option {
+	return func(c *Controller) {
+		if d > 0 {
+			c.staleAfter = d
+		}
+	}
+}
+
 func New(opts ...Option) (*Controller, error) {
-	c := &Controller{}
+	c := &Controller{staleAfter: defaultPriceStaleAfter}
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -58,3 +74,9 @@ func New(opts ...Option) (*Controller, error) {
 	}
 	return c, nil
 }
+
+func (c *Controller) publish(ctx context.Context, topic string, payload any) {
+	if c.events != nil {
+		c.events.Publish(ctx, topic, payload)
+	}
+}
@@ -1,20 +1,27 @@
 package controller
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
+	"hodlbook/pkg/integrations/broadcast"
+	"hodlbook/pkg/integrations/memcache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
 
 	"github.com/glebarez/sqlite"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 )
@@ -27,7 +34,27 @@ type ControllerTestSuite struct {
 
 	createdAsset    *models.Asset
 	createdAsset2   *models.Asset
-	createdExchange *models.Exchange
+	createdExchange  *models.Exchange
+	createdPortfolio *models.Portfolio
+}
+
+// newTestRepository returns a repository on its own migrated in-memory
+// database, for tests that need a data set the suite does not share.
+func newTestRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository, err := repo.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return repository
 }
 
 func (s *ControllerTestSuite) SetupSuite() {
@@ -35,11 +62,11 @@ func (s *ControllerTestSuite) SetupSuite() {
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	s.Require().NoError(err)
-	s.Require().NoError(db.AutoMigrate(&models.Asset{}, &models.Exchange{}, &models.AssetHistoricValue{}))
 	s.db = db
 
 	repository, err := repo.New(db)
 	s.Require().NoError(err)
+	s.Require().NoError(repository.Migrate())
 
 	ctrl, err := New(WithRepository(repository))
 	s.Require().NoError(err)
@@ -62,11 +89,68 @@ func (s *ControllerTestSuite) SetupSuite() {
 	exchanges.PUT("/:id", ctrl.UpdateExchange)
 	exchanges.DELETE("/:id", ctrl.DeleteExchange)
 
+	portfolios := api.Group("/portfolios")
+	portfolios.GET("", ctrl.ListPortfolios)
+	portfolios.POST("", ctrl.CreatePortfolio)
+	portfolios.GET("/:id", ctrl.GetPortfolio)
+	portfolios.PUT("/:id", ctrl.UpdatePortfolio)
+	portfolios.DELETE("/:id", ctrl.DeletePortfolio)
+
+	alerts := api.Group("/alerts")
+	alerts.GET("", ctrl.ListAlerts)
+	alerts.POST("", ctrl.CreateAlert)
+	alerts.GET("/history", ctrl.ListAlertHistory)
+	alerts.GET("/:id", ctrl.GetAlert)
+	alerts.PUT("/:id", ctrl.UpdateAlert)
+	alerts.DELETE("/:id", ctrl.DeleteAlert)
+
+	notifications := api.Group("/notifications")
+	notifications.GET("", ctrl.ListNotificationChannels)
+	notifications.POST("", ctrl.CreateNotificationChannel)
+	notifications.PUT("/:id", ctrl.UpdateNotificationChannel)
+	notifications.DELETE("/:id", ctrl.DeleteNotificationChannel)
+	notifications.POST("/:id/test", ctrl.TestNotificationChannel)
+
+	keys := api.Group("/keys")
+	keys.GET("", ctrl.ListAPIKeys)
+	keys.POST("", ctrl.CreateAPIKey)
+	keys.DELETE("/:id", ctrl.RevokeAPIKey)
+
+	reconciliations := api.Group("/reconciliations")
+	reconciliations.GET("", ctrl.ListReconciliations)
+	reconciliations.POST("", ctrl.CreateReconciliation)
+	reconciliations.POST("/import", ctrl.ImportReconciliation)
+	reconciliations.GET("/:id", ctrl.GetReconciliation)
+	reconciliations.DELETE("/:id", ctrl.DeleteReconciliation)
+	reconciliations.POST("/:id/lines/:line_id/adjust", ctrl.AdjustReconciliationLine)
+
+	wallets := api.Group("/wallets")
+	wallets.GET("", ctrl.ListWallets)
+	wallets.POST("", ctrl.CreateWallet)
+	wallets.GET("/discrepancies", ctrl.ListWalletDiscrepancies)
+	wallets.POST("/discrepancies/:id/accept", ctrl.AcceptWalletDiscrepancy)
+	wallets.POST("/discrepancies/:id/dismiss", ctrl.DismissWalletDiscrepancy)
+	wallets.GET("/:id", ctrl.GetWallet)
+	wallets.PUT("/:id", ctrl.UpdateWallet)
+	wallets.DELETE("/:id", ctrl.DeleteWallet)
+
+	recurring := api.Group("/recurring")
+	recurring.GET("", ctrl.ListRecurring)
+	recurring.POST("", ctrl.CreateRecurring)
+	recurring.GET("/runs", ctrl.ListRecurringRuns)
+	recurring.POST("/runs/:run_id/confirm", ctrl.ConfirmRecurringRun)
+	recurring.POST("/runs/:run_id/skip", ctrl.SkipRecurringRun)
+	recurring.GET("/:id", ctrl.GetRecurring)
+	recurring.PUT("/:id", ctrl.UpdateRecurring)
+	recurring.DELETE("/:id", ctrl.DeleteRecurring)
+
 	portfolio := api.Group("/portfolio")
 	portfolio.GET("/summary", ctrl.PortfolioSummary)
 	portfolio.GET("/allocation", ctrl.PortfolioAllocation)
 	portfolio.GET("/performance", ctrl.PortfolioPerformance)
 	portfolio.GET("/history", ctrl.PortfolioHistory)
+
+	s.router.GET("/metrics", ctrl.Metrics)
 }
 
 // Asset Tests
@@ -87,7 +171,7 @@ func (s *ControllerTestSuite) Test02_Asset_Create() {
 	asset := models.Asset{
 		Symbol:          "BTC",
 		Name:            "Bitcoin",
-		Amount:          1.5,
+		Amount:          decimal.RequireFromString("1.5"),
 		TransactionType: "deposit",
 		Timestamp:       time.Now(),
 	}
@@ -105,7 +189,7 @@ func (s *ControllerTestSuite) Test02_Asset_Create() {
 	s.NotZero(created.ID)
 	s.Equal("BTC", created.Symbol)
 	s.Equal("Bitcoin", created.Name)
-	s.Equal(1.5, created.Amount)
+	s.Equal("1.5", created.Amount.String())
 	s.Equal("deposit", created.TransactionType)
 
 	s.createdAsset = &created
@@ -115,7 +199,7 @@ func (s *ControllerTestSuite) Test03_Asset_CreateSecond() {
 	asset := models.Asset{
 		Symbol:          "ETH",
 		Name:            "Ethereum",
-		Amount:          10.0,
+		Amount:          decimal.NewFromInt(10),
 		TransactionType: "deposit",
 		Timestamp:       time.Now(),
 	}
@@ -170,7 +254,7 @@ func (s *ControllerTestSuite) Test07_Asset_Update() {
 	updated := models.Asset{
 		Symbol:          "BTC",
 		Name:            "Bitcoin Updated",
-		Amount:          2.0,
+		Amount:          decimal.NewFromInt(2),
 		TransactionType: "deposit",
 		Timestamp:       time.Now(),
 	}
@@ -186,11 +270,11 @@ func (s *ControllerTestSuite) Test07_Asset_Update() {
 	var asset models.Asset
 	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &asset))
 	s.Equal("Bitcoin Updated", asset.Name)
-	s.Equal(2.0, asset.Amount)
+	s.Equal("2", asset.Amount.String())
 }
 
 func (s *ControllerTestSuite) Test08_Asset_UpdateNotFound() {
-	updated := models.Asset{Symbol: "XRP", Name: "Ripple", Amount: 1.0, TransactionType: "deposit"}
+	updated := models.Asset{Symbol: "XRP", Name: "Ripple", Amount: decimal.NewFromInt(1), TransactionType: "deposit"}
 	body, _ := json.Marshal(updated)
 
 	req := httptest.NewRequest(http.MethodPut, "/api/assets/999", bytes.NewReader(body))
@@ -240,9 +324,9 @@ func (s *ControllerTestSuite) Test41_Exchange_Create() {
 	exchange := models.Exchange{
 		FromSymbol:  "BTC",
 		ToSymbol:    "ETH",
-		FromAmount:  1.0,
-		ToAmount:    15.0,
-		Fee:         0.001,
+		FromAmount:  decimal.NewFromInt(1),
+		ToAmount:    decimal.NewFromInt(15),
+		Fee:         decimal.RequireFromString("0.001"),
 		FeeCurrency: "BTC",
 		Notes:       "BTC to ETH swap",
 		Timestamp:   time.Now(),
@@ -259,8 +343,8 @@ func (s *ControllerTestSuite) Test41_Exchange_Create() {
 	var created models.Exchange
 	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
 	s.NotZero(created.ID)
-	s.Equal(1.0, created.FromAmount)
-	s.Equal(15.0, created.ToAmount)
+	s.Equal("1", created.FromAmount.String())
+	s.Equal("15", created.ToAmount.String())
 
 	s.createdExchange = &created
 }
@@ -269,8 +353,8 @@ func (s *ControllerTestSuite) Test42_Exchange_CreateSameSymbol() {
 	exchange := models.Exchange{
 		FromSymbol: "BTC",
 		ToSymbol:   "BTC",
-		FromAmount: 1.0,
-		ToAmount:   1.0,
+		FromAmount: decimal.NewFromInt(1),
+		ToAmount:   decimal.NewFromInt(1),
 	}
 	body, _ := json.Marshal(exchange)
 
@@ -318,8 +402,8 @@ func (s *ControllerTestSuite) Test46_Exchange_Update() {
 	updated := models.Exchange{
 		FromSymbol: "BTC",
 		ToSymbol:   "ETH",
-		FromAmount: 2.0,
-		ToAmount:   30.0,
+		FromAmount: decimal.NewFromInt(2),
+		ToAmount:   decimal.NewFromInt(30),
 		Notes:      "Updated exchange",
 		Timestamp:  time.Now(),
 	}
@@ -334,12 +418,12 @@ func (s *ControllerTestSuite) Test46_Exchange_Update() {
 
 	var exchange models.Exchange
 	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &exchange))
-	s.Equal(2.0, exchange.FromAmount)
-	s.Equal(30.0, exchange.ToAmount)
+	s.Equal("2", exchange.FromAmount.String())
+	s.Equal("30", exchange.ToAmount.String())
 }
 
 func (s *ControllerTestSuite) Test47_Exchange_UpdateNotFound() {
-	updated := models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 1.0, ToAmount: 1.0}
+	updated := models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.NewFromInt(1)}
 	body, _ := json.Marshal(updated)
 
 	req := httptest.NewRequest(http.MethodPut, "/api/exchanges/999", bytes.NewReader(body))
@@ -432,6 +516,351 @@ func (s *ControllerTestSuite) Test63_Portfolio_History() {
 	s.Contains(result, "history")
 }
 
+func (s *ControllerTestSuite) Test64_Metrics() {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	s.Contains(body, "# TYPE hodlbook_portfolio_total_value_usd gauge")
+	s.Contains(body, `hodlbook_asset_holdings{portfolio_id="1",portfolio="Default",symbol="ETH"}`)
+}
+
+// Portfolios Tests
+
+func (s *ControllerTestSuite) Test70_Portfolios_ListDefault() {
+	req := httptest.NewRequest(http.MethodGet, "/api/portfolios", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+
+	var result []models.Portfolio
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+	s.Require().Len(result, 1)
+	s.Equal(models.DefaultPortfolioID, result[0].ID)
+}
+
+func (s *ControllerTestSuite) Test71_Portfolios_Create() {
+	body, _ := json.Marshal(models.Portfolio{Name: "Trading"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/portfolios", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusCreated, w.Code)
+
+	var created models.Portfolio
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	s.NotZero(created.ID)
+	s.Equal("Trading", created.Name)
+
+	s.createdPortfolio = &created
+}
+
+func (s *ControllerTestSuite) Test72_Portfolios_CreateMissingName() {
+	req := httptest.NewRequest(http.MethodPost, "/api/portfolios", bytes.NewReader([]byte(`{"name": "  "}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *ControllerTestSuite) Test73_Portfolios_ScopedAssets() {
+	s.Require().NotNil(s.createdPortfolio)
+
+	asset := models.Asset{
+		PortfolioID:     s.createdPortfolio.ID,
+		Symbol:          "SOL",
+		Amount:          decimal.NewFromInt(5),
+		TransactionType: "deposit",
+		Timestamp:       time.Now(),
+	}
+	body, _ := json.Marshal(asset)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/assets", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/assets?portfolio_id=%d", s.createdPortfolio.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	var result map[string]interface{}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+	s.Equal(float64(1), result["total"])
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/portfolio/summary?portfolio_id=%d", s.createdPortfolio.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+	holdings := result["holdings"].([]interface{})
+	s.Require().Len(holdings, 1)
+	s.Equal("SOL", holdings[0].(map[string]interface{})["symbol"])
+}
+
+func (s *ControllerTestSuite) Test74_Portfolios_UnknownID() {
+	req := httptest.NewRequest(http.MethodGet, "/api/assets?portfolio_id=999", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/portfolio/summary?portfolio_id=abc", nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *ControllerTestSuite) Test75_Portfolios_DeleteDefault() {
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/portfolios/%d", models.DefaultPortfolioID), nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *ControllerTestSuite) Test76_Portfolios_Delete() {
+	s.Require().NotNil(s.createdPortfolio)
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/portfolios/%d", s.createdPortfolio.ID), nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/portfolios/%d", s.createdPortfolio.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNotFound, w.Code)
+}
+
+// API Key Tests
+
+func (s *ControllerTestSuite) Test80_APIKeys_Create() {
+	body := []byte(`{"name": "grafana", "scopes": ["read:portfolio", "read:prices"]}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusCreated, w.Code)
+
+	var created CreateAPIKeyResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	s.NotZero(created.ID)
+	s.NotEmpty(created.Key)
+	s.Equal("read:portfolio,read:prices", created.Scopes)
+	s.NotContains(w.Body.String(), "key_hash")
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/keys/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/keys", nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	var keys []models.APIKey
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &keys))
+	s.Require().Len(keys, 1)
+	s.NotNil(keys[0].RevokedAt)
+}
+
+func (s *ControllerTestSuite) Test81_APIKeys_CreateInvalidScope() {
+	body := []byte(`{"name": "bad", "scopes": ["write:everything"]}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *ControllerTestSuite) Test82_APIKeys_RevokeNotFound() {
+	req := httptest.NewRequest(http.MethodDelete, "/api/keys/99999", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusNotFound, w.Code)
+}
+
+// Alert Tests
+
+func (s *ControllerTestSuite) Test85_Alerts_CRUD() {
+	body := []byte(`{"name": "BTC moon", "type": "price_above", "symbol": "btc", "threshold": 150000}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alerts", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code)
+
+	var created models.AlertRule
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	s.Equal("BTC", created.Symbol)
+	s.True(created.Enabled)
+	s.Equal(models.DefaultAlertCooldownMinutes, created.CooldownMinutes)
+
+	body = []byte(`{"name": "BTC moon", "type": "price_above", "symbol": "BTC", "threshold": 200000, "enabled": false}`)
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/alerts/%d", created.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	var updated models.AlertRule
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &updated))
+	s.Equal(200000.0, updated.Threshold)
+	s.False(updated.Enabled)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/alerts/history", nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/alerts/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNoContent, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/alerts/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *ControllerTestSuite) Test86_Alerts_CreateInvalid() {
+	cases := []string{
+		`{"type": "price_above", "threshold": 10}`,
+		`{"type": "price_change", "symbol": "ETH", "threshold": 5}`,
+		`{"type": "unknown", "symbol": "ETH", "threshold": 5}`,
+		`{"type": "portfolio_below", "threshold": 0}`,
+	}
+	for _, body := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/alerts", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		s.Equal(http.StatusBadRequest, w.Code, body)
+	}
+}
+
+// Notification Channel Tests
+
+func (s *ControllerTestSuite) Test87_NotificationChannels_CreateAndTest() {
+	var signature string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-HodlBook-Signature")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hook.Close()
+
+	body := []byte(fmt.Sprintf(`{"name": "ops", "type": "webhook", "url": %q, "secret": "s3cret"}`, hook.URL))
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code)
+	s.NotContains(w.Body.String(), "s3cret")
+
+	var created models.NotificationChannel
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	s.True(created.Enabled)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/notifications/%d/test", created.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+	s.True(strings.HasPrefix(signature, "sha256="))
+
+	body = []byte(fmt.Sprintf(`{"name": "ops", "type": "webhook", "url": %q, "enabled": false}`, hook.URL))
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/notifications/%d", created.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	var stored models.NotificationChannel
+	s.Require().NoError(s.db.First(&stored, created.ID).Error)
+	s.Equal("s3cret", stored.Secret)
+	s.False(stored.Enabled)
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/notifications/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNoContent, w.Code)
+}
+
+func (s *ControllerTestSuite) Test88_NotificationChannels_Invalid() {
+	cases := []string{
+		`{"type": "webhook", "url": "http://localhost"}`,
+		`{"name": "tg", "type": "telegram", "chat_id": "1"}`,
+		`{"name": "mail", "type": "smtp", "smtp_host": "localhost"}`,
+		`{"name": "mail", "type": "smtp", "smtp_host": "localhost", "from": "me@example.com\r\nBcc: evil@example.com", "to": "you@example.com"}`,
+		`{"name": "pager", "type": "pager"}`,
+	}
+	for _, body := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		s.Equal(http.StatusBadRequest, w.Code, body)
+	}
+}
+
+// SSE Tests
+
+func (s *ControllerTestSuite) Test89_SSEPrices_SnapshotAndResume() {
+	hub := broadcast.New()
+	hub.Publish("prices", []byte(`{"BTC":1}`))
+	hub.Publish("prices", []byte(`{"BTC":2}`))
+
+	router := gin.New()
+	router.GET("/stream", SSEPrices(hub))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	readFirstEvent := func(header string) string {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+		s.Require().NoError(err)
+		if header != "" {
+			req.Header.Set("Last-Event-ID", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+
+		var event strings.Builder
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			s.Require().NoError(err)
+			if line == "\n" {
+				return event.String()
+			}
+			event.WriteString(line)
+		}
+	}
+
+	s.Equal("id: 2\nevent: prices\ndata: {\"BTC\":2}\n", readFirstEvent(""))
+
+	hub.Publish("prices", []byte(`{"BTC":3}`))
+	s.Equal("id: 2\nevent: prices\ndata: {\"BTC\":2}\n", readFirstEvent("1"))
+}
+
 // Delete Tests
 
 func (s *ControllerTestSuite) Test90_Exchange_Delete() {
@@ -482,6 +911,172 @@ func (s *ControllerTestSuite) Test93_Asset_DeleteNotFound() {
 	s.Equal(http.StatusNoContent, w.Code)
 }
 
+// Wallet Tests
+
+func (s *ControllerTestSuite) Test94_Wallets_CreateInvalid() {
+	cases := []string{
+		`{"name": "cold", "chain": "dogecoin", "address": "D"}`,
+		`{"name": "cold", "chain": "ethereum", "address": "0x1234"}`,
+		`{"name": "cold", "chain": "bitcoin", "address": "xpub123"}`,
+		`{"name": "cold", "chain": "bitcoin", "address": "bc1q", "tokens": "USDC=0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}`,
+		`{"name": "cold", "chain": "ethereum", "address": "0x00000000219ab540356cBB839Cbe05303d7705Fa", "tokens": "USDC"}`,
+		`{"name": "cold", "chain": "bitcoin", "address": "bc1q", "mode": "auto"}`,
+		`{"chain": "bitcoin", "address": "bc1q"}`,
+	}
+	for _, body := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/wallets", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		s.Equal(http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (s *ControllerTestSuite) Test95_Wallets_CreateAndAcceptDiscrepancy() {
+	body := `{"name": "vault", "chain": "ethereum", "address": "0x00000000219ab540356cBB839Cbe05303d7705Fa", "tokens": "usdc=0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/wallets", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var wallet models.Wallet
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &wallet))
+	s.Equal(models.WalletModePropose, wallet.Mode)
+	s.Equal(models.DefaultPortfolioID, wallet.PortfolioID)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/assets", bytes.NewReader([]byte(`{"symbol": "ETH", "amount": "1", "transaction_type": "deposit", "wallet_id": 999}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusBadRequest, w.Code, "entries can only be tagged with a wallet of their portfolio")
+
+	s.Require().NoError(s.db.Create(&models.WalletBalance{WalletID: wallet.ID, Symbol: "USDC", Amount: decimal.RequireFromString("0.5"), ObservedAt: time.Now()}).Error)
+	discrepancy := models.WalletDiscrepancy{
+		PortfolioID:  models.DefaultPortfolioID,
+		WalletID:     wallet.ID,
+		Symbol:       "USDC",
+		Difference:   decimal.RequireFromString("0.5"),
+		ProposedType: "deposit",
+		Status:       models.DiscrepancyOpen,
+		ObservedAt:   time.Now(),
+	}
+	s.Require().NoError(s.db.Create(&discrepancy).Error)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/wallets/discrepancies/%d/accept", discrepancy.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var asset models.Asset
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &asset))
+	s.Equal("deposit", asset.TransactionType)
+	s.Equal("0.5", asset.Amount.String())
+	s.Require().NotNil(asset.WalletID)
+	s.Equal(wallet.ID, *asset.WalletID)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/wallets/discrepancies/%d/dismiss", discrepancy.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusConflict, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/wallets/discrepancies/999/accept", nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNotFound, w.Code)
+
+	s.Require().NoError(s.db.Delete(&asset).Error)
+}
+
+// Recurring Transaction Tests
+
+func (s *ControllerTestSuite) Test96_Recurring_CreateInvalid() {
+	cases := []string{
+		`{"name": "dca", "type": "buy", "symbol": "BTC", "amount": 100, "cadence": "weekly", "start_at": "2024-01-01T00:00:00Z"}`,
+		`{"name": "dca", "type": "exchange", "symbol": "BTC", "amount": 100, "cadence": "weekly", "start_at": "2024-01-01T00:00:00Z"}`,
+		`{"name": "dca", "type": "deposit", "symbol": "BTC", "amount": 0, "cadence": "weekly", "start_at": "2024-01-01T00:00:00Z"}`,
+		`{"name": "dca", "type": "deposit", "symbol": "BTC", "amount": 1, "cadence": "hourly", "start_at": "2024-01-01T00:00:00Z"}`,
+		`{"name": "dca", "type": "deposit", "symbol": "BTC", "amount": 1, "amount_unit": "eur", "cadence": "weekly", "start_at": "2024-01-01T00:00:00Z"}`,
+		`{"name": "dca", "type": "deposit", "symbol": "BTC", "amount": 1, "cadence": "weekly"}`,
+		`{"name": "dca", "type": "deposit", "symbol": "BTC", "amount": 1, "cadence": "weekly", "start_at": "2024-01-01T00:00:00Z", "end_at": "2023-01-01T00:00:00Z"}`,
+	}
+	for _, body := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/recurring", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		s.Equal(http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (s *ControllerTestSuite) Test97_Recurring_CreateAndConfirmRun() {
+	body := `{"name": "Weekly BTC", "type": "exchange", "symbol": "btc", "from_symbol": "usdt", "amount": "100", "amount_unit": "fiat", "cadence": "weekly", "start_at": "2024-01-01T09:00:00Z", "require_confirmation": true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/recurring", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var recurring models.RecurringTransaction
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &recurring))
+	s.Equal("BTC", recurring.Symbol)
+	s.Equal("USDT", recurring.FromSymbol)
+	s.True(recurring.Enabled)
+	s.True(recurring.StartAt.Equal(recurring.NextRunAt), "past occurrences are caught up from the start")
+
+	run := models.RecurringRun{
+		RecurringID: recurring.ID,
+		PortfolioID: recurring.PortfolioID,
+		Name:        recurring.Name,
+		Type:        "exchange",
+		Symbol:      "BTC",
+		Amount:      decimal.RequireFromString("0.0025"),
+		FromSymbol:  "USDT",
+		FromAmount:  decimal.NewFromInt(100),
+		ScheduledAt: recurring.NextRunAt,
+		Status:      models.RecurringRunPending,
+	}
+	s.Require().NoError(s.ctrl.repo.SaveRecurringRun(&recurring, &run))
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/recurring/runs?recurring_id=%d&status=pending", recurring.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code)
+	var runs []models.RecurringRun
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &runs))
+	s.Require().Len(runs, 1)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/recurring/runs/%d/confirm", run.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &run))
+	s.Equal(models.RecurringRunExecuted, run.Status)
+	s.Require().NotNil(run.ExchangeID)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/recurring/runs/%d/skip", run.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusConflict, w.Code)
+
+	body = `{"name": "Weekly BTC", "type": "exchange", "symbol": "BTC", "from_symbol": "USDT", "amount": "100", "amount_unit": "fiat", "cadence": "monthly", "start_at": "2024-01-01T09:00:00Z", "enabled": false}`
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/recurring/%d", recurring.ID), bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &recurring))
+	s.False(recurring.Enabled)
+	s.True(recurring.NextRunAt.After(time.Now()), "a new cadence does not book past occurrences")
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/recurring/%d", recurring.ID), nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusNoContent, w.Code)
+
+	s.Require().NoError(s.db.Delete(&models.Exchange{}, *run.ExchangeID).Error)
+}
+
 func TestControllers(t *testing.T) {
 	suite.Run(t, new(ControllerTestSuite))
 }
@@ -490,14 +1085,14 @@ type mockPriceFetcher struct {
 	prices map[string]float64
 }
 
-func (m *mockPriceFetcher) Fetch(price *prices.Price) error {
+func (m *mockPriceFetcher) Fetch(_ context.Context, price *prices.Price) error {
 	if val, ok := m.prices[price.Asset.Symbol]; ok {
 		price.Value = val
 	}
 	return nil
 }
 
-func (m *mockPriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (m *mockPriceFetcher) FetchMany(_ context.Context, pairs ...*prices.Price) error {
 	for _, p := range pairs {
 		if val, ok := m.prices[p.Asset.Symbol]; ok {
 			p.Value = val
@@ -506,7 +1101,7 @@ func (m *mockPriceFetcher) FetchMany(pairs ...*prices.Price) error {
 	return nil
 }
 
-func (m *mockPriceFetcher) FetchAll() ([]prices.Price, error) {
+func (m *mockPriceFetcher) FetchAll(_ context.Context) ([]prices.Price, error) {
 	result := make([]prices.Price, 0, len(m.prices))
 	for symbol, value := range m.prices {
 		result = append(result, prices.Price{
@@ -542,7 +1137,7 @@ func TestUpdateAsset_CreatesPriceAtTimestamp(t *testing.T) {
 	asset := &models.Asset{
 		Symbol:          "BARD",
 		Name:            "lombard-protocol",
-		Amount:          100,
+		Amount:          decimal.NewFromInt(100),
 		TransactionType: "deposit",
 		Timestamp:       assetTime,
 	}
@@ -554,7 +1149,7 @@ func TestUpdateAsset_CreatesPriceAtTimestamp(t *testing.T) {
 	updated := models.Asset{
 		Symbol:          "BARD",
 		Name:            "lombard-protocol",
-		Amount:          150,
+		Amount:          decimal.NewFromInt(150),
 		TransactionType: "deposit",
 		Timestamp:       assetTime,
 	}
@@ -573,8 +1168,8 @@ func TestUpdateAsset_CreatesPriceAtTimestamp(t *testing.T) {
 	if len(prices) != 1 {
 		t.Fatalf("expected 1 price record, got %d", len(prices))
 	}
-	if prices[0].Price != 0.78 {
-		t.Errorf("expected price 0.78, got %f", prices[0].Price)
+	if !prices[0].Price.Equal(decimal.RequireFromString("0.78")) {
+		t.Errorf("expected price 0.78, got %s", prices[0].Price)
 	}
 }
 
@@ -603,7 +1198,7 @@ func TestUpdateAsset_CreatesPriceEvenIfExisting(t *testing.T) {
 	asset := &models.Asset{
 		Symbol:          "BTC",
 		Name:            "bitcoin",
-		Amount:          1,
+		Amount:          decimal.NewFromInt(1),
 		TransactionType: "deposit",
 		Timestamp:       assetTime,
 	}
@@ -612,7 +1207,7 @@ func TestUpdateAsset_CreatesPriceEvenIfExisting(t *testing.T) {
 	repository.CreatePrice(&models.Price{
 		Symbol:    "BTC",
 		Currency:  "USD",
-		Price:     87000,
+		Price:     decimal.NewFromInt(87000),
 		Timestamp: assetTime.Add(-48 * time.Hour),
 	})
 
@@ -622,7 +1217,7 @@ func TestUpdateAsset_CreatesPriceEvenIfExisting(t *testing.T) {
 	updated := models.Asset{
 		Symbol:          "BTC",
 		Name:            "bitcoin",
-		Amount:          2,
+		Amount:          decimal.NewFromInt(2),
 		TransactionType: "deposit",
 		Timestamp:       assetTime,
 	}
@@ -642,3 +1237,297 @@ func TestUpdateAsset_CreatesPriceEvenIfExisting(t *testing.T) {
 		t.Fatalf("expected 2 price records, got %d", len(prices))
 	}
 }
+
+type recordingPublisher struct {
+	topics []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, topic string, _ any) error {
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func TestMutations_PublishEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository, _ := repo.New(db)
+	if err := repository.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{}
+	ctrl, _ := New(
+		WithRepository(repository),
+		WithEventPublisher(publisher),
+	)
+
+	router := gin.New()
+	router.POST("/api/assets", ctrl.CreateAsset)
+	router.PUT("/api/assets/:id", ctrl.UpdateAsset)
+	router.DELETE("/api/assets/:id", ctrl.DeleteAsset)
+	router.POST("/api/exchanges", ctrl.CreateExchange)
+	router.DELETE("/api/exchanges/:id", ctrl.DeleteExchange)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/assets", `{"symbol": "BTC", "name": "Bitcoin", "amount": 1, "transaction_type": "deposit"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var asset models.Asset
+	json.Unmarshal(w.Body.Bytes(), &asset)
+
+	do(http.MethodPut, fmt.Sprintf("/api/assets/%d", asset.ID), `{"symbol": "BTC", "name": "Bitcoin", "amount": 2, "transaction_type": "deposit"}`)
+	do(http.MethodDelete, fmt.Sprintf("/api/assets/%d", asset.ID), "")
+
+	w = do(http.MethodPost, "/api/exchanges", `{"from_symbol": "BTC", "from_amount": 1, "to_symbol": "ETH", "to_amount": 30}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var exchange models.Exchange
+	json.Unmarshal(w.Body.Bytes(), &exchange)
+	do(http.MethodDelete, fmt.Sprintf("/api/exchanges/%d", exchange.ID), "")
+
+	expected := []string{
+		events.TopicAssetCreated,
+		events.TopicAssetUpdated,
+		events.TopicAssetDeleted,
+		events.TopicExchangeCreated,
+		events.TopicExchangeDeleted,
+	}
+	if strings.Join(publisher.topics, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected topics %v, got %v", expected, publisher.topics)
+	}
+}
+
+func TestSearchCurrencies_UsesPriceFetcher(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository, _ := repo.New(db)
+
+	router := gin.New()
+	withoutFetcher, _ := New(WithRepository(repository))
+	router.GET("/none", withoutFetcher.SearchCurrencies)
+	withFetcher, _ := New(
+		WithRepository(repository),
+		WithPriceFetcher(&mockPriceFetcher{prices: map[string]float64{"BTC": 95000, "ETH": 3000}}),
+	)
+	router.GET("/currencies", withFetcher.SearchCurrencies)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/none", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a price fetcher, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies?q=bt", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var results []struct {
+		Symbol string  `json:"symbol"`
+		Price  float64 `json:"price"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Symbol != "BTC" || results[0].Price != 95000 {
+		t.Errorf("expected only BTC at 95000, got %+v", results)
+	}
+}
+
+func TestListPrices_FlagsStalePrices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.LastKnownPrice{}); err != nil {
+		t.Fatal(err)
+	}
+
+	repository, _ := repo.New(db)
+	fetchedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	repository.SaveLastKnownPrices([]models.LastKnownPrice{
+		{Symbol: "BTC", Price: decimal.NewFromInt(95000), Source: "kraken", FetchedAt: time.Now()},
+		{Symbol: "ETH", Price: decimal.NewFromInt(3000), Source: "binance", FetchedAt: fetchedAt},
+	})
+
+	priceCache := memcache.New[string, float64]()
+	priceCache.Set("BTC", 96000)
+	priceCache.Set("ETH", 0)
+	priceCache.Set("SOL", 0)
+
+	ctrl, _ := New(
+		WithRepository(repository),
+		WithPriceCache(priceCache),
+		WithPriceStaleAfter(5*time.Minute),
+	)
+
+	router := gin.New()
+	router.GET("/api/prices", ctrl.ListPrices)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/prices", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var quotes map[string]PriceQuote
+	if err := json.Unmarshal(w.Body.Bytes(), &quotes); err != nil {
+		t.Fatal(err)
+	}
+
+	if btc := quotes["BTC"]; btc.Price != 96000 || btc.Stale || btc.Source != "kraken" {
+		t.Errorf("expected fresh BTC quote at 96000, got %+v", btc)
+	}
+	eth := quotes["ETH"]
+	if eth.Price != 3000 || !eth.Stale || eth.AsOf == nil || !eth.AsOf.Equal(fetchedAt) {
+		t.Errorf("expected stale ETH quote at 3000 as of %s, got %+v", fetchedAt, eth)
+	}
+	if sol := quotes["SOL"]; !sol.Stale || sol.AsOf != nil {
+		t.Errorf("expected SOL without a known price to be stale, got %+v", sol)
+	}
+}
+
+func TestManualPrices_SetAndDeleteCurrentPrice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Price{}, &models.LastKnownPrice{}); err != nil {
+		t.Fatal(err)
+	}
+
+	repository, _ := repo.New(db)
+	priceCache := memcache.New[string, float64]()
+	priceCache.Set("BTC", 0)
+
+	ctrl, _ := New(
+		WithRepository(repository),
+		WithPriceCache(priceCache),
+	)
+
+	router := gin.New()
+	router.GET("/api/prices/manual", ctrl.ListManualPrices)
+	router.POST("/api/prices/manual", ctrl.CreateManualPrice)
+	router.DELETE("/api/prices/manual/:id", ctrl.DeleteManualPrice)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/prices/manual", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, body := range []string{
+		`{"symbol": "", "price": 1}`,
+		`{"symbol": "BTC", "price": 0}`,
+		fmt.Sprintf(`{"symbol": "BTC", "price": 1, "timestamp": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339)),
+		`{"symbol": "BTC", "price": 1, "timestamp": "2021-01-02T00:00:00Z", "expires_at": "2021-01-01T00:00:00Z"}`,
+	} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w := post(`{"symbol": "btc", "price": "42000.5"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Price
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Symbol != "BTC" || created.Source != models.PriceSourceManual {
+		t.Errorf("expected manual BTC price, got %+v", created)
+	}
+	if price, _ := priceCache.Get("BTC"); price != 42000.5 {
+		t.Errorf("expected current price 42000.5, got %v", price)
+	}
+
+	if w := post(`{"symbol": "BTC", "price": 30000, "timestamp": "2021-01-01T00:00:00Z"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	if price, _ := priceCache.Get("BTC"); price != 42000.5 {
+		t.Errorf("a historical price must not replace the current one, got %v", price)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/prices/manual?symbol=btc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed []models.Price
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed) != 2 || listed[0].ID != created.ID {
+		t.Fatalf("expected 2 manual prices, newest first, got %+v", listed)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/prices/manual/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if price, _ := priceCache.Get("BTC"); price != 30000 {
+		t.Errorf("expected the remaining manual price 30000, got %v", price)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/prices/manual/%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestAmounts_SentAsJSONNumbers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl, err := New(WithRepository(newTestRepository(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.POST("/api/assets", ctrl.CreateAsset)
+	router.POST("/api/exchanges", ctrl.CreateExchange)
+
+	post := func(path, body string) string {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201 from %s, got %d: %s", path, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	body := post("/api/assets", `{"symbol": "ETH", "amount": "0.000000000000000001", "transaction_type": "deposit", "timestamp": "2024-01-01T00:00:00Z"}`)
+	if !strings.Contains(body, `"amount":0.000000000000000001,`) {
+		t.Errorf("expected amount as an exact JSON number, got %s", body)
+	}
+
+	body = post("/api/exchanges", `{"from_symbol": "ETH", "to_symbol": "BTC", "from_amount": 1.5, "to_amount": "0.05", "fee": 0, "timestamp": "2024-01-02T00:00:00Z"}`)
+	for _, field := range []string{`"from_amount":1.5,`, `"to_amount":0.05,`, `"fee":0,`} {
+		if !strings.Contains(body, field) {
+			t.Errorf("expected %s in %s", field, body)
+		}
+	}
+}
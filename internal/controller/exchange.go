@@ -4,10 +4,12 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
+	"hodlbook/pkg/types/events"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,18 +20,29 @@ import (
 // @Description Get a list of exchanges with optional filters
 // @Tags exchanges
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Param limit query int false "Limit"
 // @Param offset query int false "Offset"
-// @Param symbol query string false "Symbol (matches from or to)"
+// @Param symbol query string false "Symbols, comma-separated (matches from or to)"
 // @Param from_symbol query string false "From Symbol"
 // @Param to_symbol query string false "To Symbol"
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param q query string false "Case-insensitive search in notes"
+// @Param sort query string false "Sort field (date, from_symbol, to_symbol, from_amount, to_amount)"
+// @Param dir query string false "Sort direction (asc, desc)"
+// @Param after query string false "Cursor from next_cursor; overrides offset"
+// @Param before query string false "Cursor from prev_cursor; overrides offset"
 // @Success 200 {object} repo.ExchangeListResult
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/exchanges [get]
 func (c *Controller) ListExchanges(ctx *gin.Context) {
-	filter := repo.ExchangeFilter{}
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+	filter := repo.ExchangeFilter{PortfolioID: portfolioID}
 
 	if limitStr := ctx.Query("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil {
@@ -42,7 +55,7 @@ func (c *Controller) ListExchanges(ctx *gin.Context) {
 		}
 	}
 	if symbol := ctx.Query("symbol"); symbol != "" {
-		filter.Symbol = &symbol
+		filter.Symbols = strings.Split(symbol, ",")
 	}
 	if fromSymbol := ctx.Query("from_symbol"); fromSymbol != "" {
 		filter.FromSymbol = &fromSymbol
@@ -62,7 +75,17 @@ func (c *Controller) ListExchanges(ctx *gin.Context) {
 		}
 	}
 
+	filter.Search = ctx.Query("q")
+	filter.SortBy = ctx.Query("sort")
+	filter.SortDir = ctx.Query("dir")
+	filter.After = ctx.Query("after")
+	filter.Before = ctx.Query("before")
+
 	result, err := c.repo.ListExchanges(filter)
+	if errors.Is(err, repo.ErrInvalidSort) || errors.Is(err, repo.ErrInvalidCursor) {
+		badRequest(ctx, err.Error())
+		return
+	}
 	if err != nil {
 		internalError(ctx, "failed to fetch exchanges")
 		return
@@ -123,11 +146,22 @@ func (c *Controller) CreateExchange(ctx *gin.Context) {
 		exchange.Timestamp = time.Now()
 	}
 
+	if exchange.PortfolioID != 0 && !c.portfolioExists(exchange.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if !c.walletInPortfolio(exchange.WalletID, exchange.PortfolioID) {
+		badRequest(ctx, "wallet not found in portfolio")
+		return
+	}
+
 	if err := c.repo.CreateExchange(&exchange); err != nil {
 		internalError(ctx, "failed to create exchange")
 		return
 	}
 
+	c.publish(ctx.Request.Context(), events.TopicExchangeCreated, exchange)
 	ctx.JSON(http.StatusCreated, exchange)
 }
 
@@ -151,7 +185,8 @@ func (c *Controller) UpdateExchange(ctx *gin.Context) {
 		return
 	}
 
-	if _, err = c.repo.GetExchangeByID(id); err != nil {
+	existing, err := c.repo.GetExchangeByID(id)
+	if err != nil {
 		notFound(ctx, "exchange not found")
 		return
 	}
@@ -167,12 +202,25 @@ func (c *Controller) UpdateExchange(ctx *gin.Context) {
 		return
 	}
 
+	if exchange.PortfolioID == 0 {
+		exchange.PortfolioID = existing.PortfolioID
+	} else if !c.portfolioExists(exchange.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if !c.walletInPortfolio(exchange.WalletID, exchange.PortfolioID) {
+		badRequest(ctx, "wallet not found in portfolio")
+		return
+	}
+
 	exchange.ID = id
 	if err := c.repo.UpdateExchange(&exchange); err != nil {
 		internalError(ctx, "failed to update exchange")
 		return
 	}
 
+	c.publish(ctx.Request.Context(), events.TopicExchangeUpdated, exchange)
 	ctx.JSON(http.StatusOK, exchange)
 }
 
@@ -192,10 +240,15 @@ func (c *Controller) DeleteExchange(ctx *gin.Context) {
 		return
 	}
 
+	existing, _ := c.repo.GetExchangeByID(id)
 	if err := c.repo.DeleteExchange(id); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		internalError(ctx, "failed to delete exchange")
 		return
 	}
 
+	if existing != nil {
+		c.publish(ctx.Request.Context(), events.TopicExchangeDeleted, *existing)
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
@@ -7,7 +7,6 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 )
@@ -33,14 +33,17 @@ func (s *ImportExportTestSuite) SetupSuite() {
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	s.Require().NoError(err)
-	s.Require().NoError(db.AutoMigrate(&models.Asset{}, &models.Exchange{}, &models.AssetHistoricValue{}, &models.ImportLog{}))
+	s.Require().NoError(db.AutoMigrate(&models.Portfolio{}, &models.Asset{}, &models.Exchange{}, &models.AssetHistoricValue{}, &models.ImportLog{}))
 	s.db = db
 
 	repository, err := repo.New(db)
 	s.Require().NoError(err)
 	s.repo = repository
 
-	ctrl, err := New(WithRepository(repository))
+	ctrl, err := New(
+		WithRepository(repository),
+		WithPriceFetcher(&mockPriceFetcher{prices: map[string]float64{"BTC": 50000, "ETH": 3000}}),
+	)
 	s.Require().NoError(err)
 	s.ctrl = ctrl
 
@@ -69,9 +72,9 @@ func (s *ImportExportTestSuite) SetupTest() {
 
 func (s *ImportExportTestSuite) seedAssets() {
 	assets := []models.Asset{
-		{Symbol: "BTC", Name: "Bitcoin", Amount: 1.5, TransactionType: "deposit", Timestamp: time.Now()},
-		{Symbol: "ETH", Name: "Ethereum", Amount: 10.0, TransactionType: "deposit", Timestamp: time.Now()},
-		{Symbol: "BTC", Name: "Bitcoin", Amount: 0.5, TransactionType: "withdrawal", Timestamp: time.Now()},
+		{Symbol: "BTC", Name: "Bitcoin", Amount: decimal.RequireFromString("1.5"), TransactionType: "deposit", Timestamp: time.Now()},
+		{Symbol: "ETH", Name: "Ethereum", Amount: decimal.NewFromInt(10), TransactionType: "deposit", Timestamp: time.Now()},
+		{Symbol: "BTC", Name: "Bitcoin", Amount: decimal.RequireFromString("0.5"), TransactionType: "withdrawal", Timestamp: time.Now()},
 	}
 	for _, a := range assets {
 		s.db.Create(&a)
@@ -80,8 +83,8 @@ func (s *ImportExportTestSuite) seedAssets() {
 
 func (s *ImportExportTestSuite) seedExchanges() {
 	exchanges := []models.Exchange{
-		{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 0.1, ToAmount: 1.5, Timestamp: time.Now()},
-		{FromSymbol: "ETH", ToSymbol: "USDT", FromAmount: 5.0, ToAmount: 5000.0, Timestamp: time.Now()},
+		{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.RequireFromString("0.1"), ToAmount: decimal.RequireFromString("1.5"), Timestamp: time.Now()},
+		{FromSymbol: "ETH", ToSymbol: "USDT", FromAmount: decimal.NewFromInt(5), ToAmount: decimal.NewFromInt(5000), Timestamp: time.Now()},
 	}
 	for _, e := range exchanges {
 		s.db.Create(&e)
@@ -373,7 +376,7 @@ func (s *ImportExportTestSuite) TestRetryImport() {
 	s.db.Create(log)
 
 	correctedAssets := []models.Asset{
-		{Symbol: "BTC", Name: "Bitcoin", Amount: 1.0, TransactionType: "deposit"},
+		{Symbol: "BTC", Name: "Bitcoin", Amount: decimal.NewFromInt(1), TransactionType: "deposit"},
 	}
 	body, _ := json.Marshal(correctedAssets)
 
@@ -396,7 +399,7 @@ func (s *ImportExportTestSuite) TestRetryImport() {
 }
 
 func (s *ImportExportTestSuite) TestRetryImport_NotFound() {
-	correctedAssets := []models.Asset{{Symbol: "BTC", Amount: 1.0, TransactionType: "deposit"}}
+	correctedAssets := []models.Asset{{Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "deposit"}}
 	body, _ := json.Marshal(correctedAssets)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/imports/999/retry", bytes.NewReader(body))
@@ -407,104 +410,6 @@ func (s *ImportExportTestSuite) TestRetryImport_NotFound() {
 	s.Equal(http.StatusNotFound, w.Code)
 }
 
-// Helper function tests
-
-func (s *ImportExportTestSuite) TestParseAssetsFromCSV() {
-	csvData := `symbol;name;amount;transaction_type;timestamp;notes
-BTC;Bitcoin;1.5;deposit;2024-01-15T10:30:00Z;Test note
-ETH;Ethereum;10.0;withdrawal;;No timestamp`
-
-	assets, errors := parseAssetsFromCSV([]byte(csvData))
-	s.Len(assets, 2)
-	s.Empty(errors)
-
-	s.Equal("BTC", assets[0].Symbol)
-	s.Equal("Bitcoin", assets[0].Name)
-	s.Equal(1.5, assets[0].Amount)
-	s.Equal("deposit", assets[0].TransactionType)
-	s.Equal("Test note", assets[0].Notes)
-
-	s.Equal("ETH", assets[1].Symbol)
-	s.Equal("withdrawal", assets[1].TransactionType)
-}
-
-func (s *ImportExportTestSuite) TestParseAssetsFromCSV_InvalidFormat() {
-	csvData := `not a valid csv with proper structure`
-
-	assets, errors := parseAssetsFromCSV([]byte(csvData))
-	s.Empty(assets)
-	s.Len(errors, 1)
-	s.Contains(errors[0].Message, "at least one data row")
-}
-
-func (s *ImportExportTestSuite) TestParseAssetsFromJSON() {
-	jsonData := `[
-		{"symbol": "BTC", "name": "Bitcoin", "amount": 1.5, "transaction_type": "deposit"},
-		{"symbol": "ETH", "amount": 10.0, "transaction_type": "withdrawal"}
-	]`
-
-	assets, errors := parseAssetsFromJSON([]byte(jsonData))
-	s.Len(assets, 2)
-	s.Empty(errors)
-
-	s.Equal("BTC", assets[0].Symbol)
-	s.Equal(1.5, assets[0].Amount)
-}
-
-func (s *ImportExportTestSuite) TestParseAssetsFromJSON_InvalidJSON() {
-	jsonData := `not valid json`
-
-	assets, errors := parseAssetsFromJSON([]byte(jsonData))
-	s.Empty(assets)
-	s.Len(errors, 1)
-	s.Contains(errors[0].Message, "invalid JSON")
-}
-
-func (s *ImportExportTestSuite) TestValidateAssetFields() {
-	validAsset := &models.Asset{Symbol: "BTC", Amount: 1.0, TransactionType: "deposit"}
-	s.NoError(validateAssetFields(validAsset))
-
-	missingSymbol := &models.Asset{Amount: 1.0, TransactionType: "deposit"}
-	s.Error(validateAssetFields(missingSymbol))
-	s.Contains(validateAssetFields(missingSymbol).Error(), "symbol")
-
-	negativeAmount := &models.Asset{Symbol: "BTC", Amount: -1.0, TransactionType: "deposit"}
-	s.Error(validateAssetFields(negativeAmount))
-	s.Contains(validateAssetFields(negativeAmount).Error(), "amount")
-
-	invalidType := &models.Asset{Symbol: "BTC", Amount: 1.0, TransactionType: "invalid"}
-	s.Error(validateAssetFields(invalidType))
-	s.Contains(validateAssetFields(invalidType).Error(), "transaction_type")
-
-	withdrawAlias := &models.Asset{Symbol: "BTC", Amount: 1.0, TransactionType: "withdraw"}
-	s.NoError(validateAssetFields(withdrawAlias))
-	s.Equal("withdrawal", withdrawAlias.TransactionType)
-}
-
-func (s *ImportExportTestSuite) TestAssetsToCSV() {
-	assets := []models.Asset{
-		{Symbol: "BTC", Name: "Bitcoin", Amount: 1.5, TransactionType: "deposit", Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), Notes: "Test"},
-	}
-
-	csv := string(assetsToCSV(assets))
-	lines := strings.Split(strings.TrimSpace(csv), "\n")
-	s.Len(lines, 2)
-	s.Equal("symbol;name;amount;transaction_type;timestamp;notes", lines[0])
-	s.Contains(lines[1], "BTC;Bitcoin;1.5;deposit;")
-}
-
-func (s *ImportExportTestSuite) TestExchangesToCSV() {
-	exchanges := []models.Exchange{
-		{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 1.0, ToAmount: 15.0, Fee: 0.001, FeeCurrency: "BTC", Timestamp: time.Now()},
-	}
-
-	csv := string(exchangesToCSV(exchanges))
-	lines := strings.Split(strings.TrimSpace(csv), "\n")
-	s.Len(lines, 2)
-	s.Equal("from_symbol;to_symbol;from_amount;to_amount;fee;fee_currency;timestamp;notes", lines[0])
-	s.Contains(lines[1], "BTC;ETH;1;15;0.001;BTC;")
-}
-
 func TestImportExport(t *testing.T) {
 	suite.Run(t, new(ImportExportTestSuite))
 }
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/types/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ManualPriceRequest sets a symbol's price by hand. Timestamp defaults to
+// now; an earlier one records a historical price. A price set for now pins
+// the symbol until it is deleted or ExpiresAt passes; a historical price
+// expires at its own timestamp unless ExpiresAt says otherwise.
+type ManualPriceRequest struct {
+	Symbol    string          `json:"symbol"`
+	Price     decimal.Decimal `json:"price"`
+	Timestamp *time.Time      `json:"timestamp"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+}
+
+// ListManualPrices godoc
+// @Summary List manual prices
+// @Description Get prices entered by hand, newest first, optionally for one symbol
+// @Tags prices
+// @Produce json
+// @Param symbol query string false "Asset symbol"
+// @Success 200 {array} models.Price
+// @Failure 500 {object} map[string]string
+// @Router /api/prices/manual [get]
+func (c *Controller) ListManualPrices(ctx *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(ctx.Query("symbol")))
+
+	prices, err := c.repo.ListManualPrices(symbol)
+	if err != nil {
+		internalError(ctx, "failed to fetch manual prices")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, prices)
+}
+
+// CreateManualPrice godoc
+// @Summary Set a price by hand
+// @Description Store a current or historical price for a symbol with source "manual". A price set for now pins the symbol: it stays the current price, and the symbol is not fetched, until it is deleted or expires_at passes. A historical price only feeds history unless expires_at is in the future.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param price body ManualPriceRequest true "Manual price"
+// @Success 201 {object} models.Price
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/prices/manual [post]
+func (c *Controller) CreateManualPrice(ctx *gin.Context) {
+	var req ManualPriceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	price := models.Price{
+		Symbol:    strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		Currency:  "USD",
+		Price:     req.Price,
+		Timestamp: time.Now(),
+	}
+	if req.Timestamp != nil {
+		price.Timestamp = *req.Timestamp
+		price.ExpiresAt = req.Timestamp
+	}
+	if req.ExpiresAt != nil {
+		price.ExpiresAt = req.ExpiresAt
+	}
+
+	switch {
+	case price.Symbol == "":
+		badRequest(ctx, "symbol is required")
+		return
+	case !price.Price.IsPositive():
+		badRequest(ctx, "price must be positive")
+		return
+	case price.Timestamp.After(time.Now()):
+		badRequest(ctx, "timestamp cannot be in the future")
+		return
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(price.Timestamp):
+		badRequest(ctx, "expires_at must be after timestamp")
+		return
+	}
+
+	current, err := c.repo.CreateManualPrice(&price)
+	if err != nil {
+		internalError(ctx, "failed to save manual price")
+		return
+	}
+	if current != nil {
+		c.setCurrentPrice(ctx, price.Symbol, current)
+	}
+
+	ctx.JSON(http.StatusCreated, price)
+}
+
+// DeleteManualPrice godoc
+// @Summary Delete a manual price
+// @Description Delete a manual price by its ID. If it was the current price, the newest remaining manual price that still pins the symbol replaces it, then the newest remaining manual price.
+// @Tags prices
+// @Param id path int true "Price ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/prices/manual/{id} [delete]
+func (c *Controller) DeleteManualPrice(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid price id")
+		return
+	}
+
+	symbol, current, err := c.repo.DeleteManualPrice(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		notFound(ctx, "manual price not found")
+		return
+	}
+	if err != nil {
+		internalError(ctx, "failed to delete manual price")
+		return
+	}
+	c.setCurrentPrice(ctx, symbol, current)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// setCurrentPrice updates the live price after a manual price changed it. A
+// nil current leaves the symbol without a price until the next fetch.
+func (c *Controller) setCurrentPrice(ctx *gin.Context, symbol string, current *models.LastKnownPrice) {
+	if c.priceCache == nil {
+		return
+	}
+	if current == nil {
+		if _, ok := c.priceCache.Get(symbol); !ok {
+			return
+		}
+		c.priceCache.Set(symbol, 0)
+		return
+	}
+
+	value := current.Price.InexactFloat64()
+	c.priceCache.Set(symbol, value)
+	c.publish(ctx.Request.Context(), events.TopicPricesUpdated, events.PricesUpdated{symbol: value})
+}
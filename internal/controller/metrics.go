@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"hodlbook/pkg/integrations/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	portfolioTotalValue = metrics.NewGaugeVec(
+		"hodlbook_portfolio_total_value_usd",
+		"Total value of all portfolios in USD.",
+	)
+	portfolioValue = metrics.NewGaugeVec(
+		"hodlbook_portfolio_value_usd",
+		"Value of a portfolio in USD.",
+		"portfolio_id", "portfolio",
+	)
+	assetHoldings = metrics.NewGaugeVec(
+		"hodlbook_asset_holdings",
+		"Amount held of an asset.",
+		"portfolio_id", "portfolio", "symbol",
+	)
+	assetValue = metrics.NewGaugeVec(
+		"hodlbook_asset_value_usd",
+		"Value of an asset holding in USD.",
+		"portfolio_id", "portfolio", "symbol",
+	)
+)
+
+// Metrics godoc
+// @Summary Prometheus metrics
+// @Description Portfolio values, price provider, scheduler, SSE and HTTP metrics in the Prometheus text format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string "metrics"
+// @Router /metrics [get]
+func (c *Controller) Metrics(ctx *gin.Context) {
+	if err := c.collectPortfolioMetrics(); err != nil {
+		internalError(ctx, "failed to collect portfolio metrics")
+		return
+	}
+
+	ctx.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	_ = metrics.Default.WriteText(ctx.Writer)
+}
+
+type gaugeSample struct {
+	value  float64
+	labels []string
+}
+
+// collectPortfolioMetrics computes every portfolio gauge first and then swaps
+// the new values in under the registry lock, so a concurrent scrape never sees
+// the gauges half reset.
+func (c *Controller) collectPortfolioMetrics() error {
+	portfolios, err := c.repo.ListPortfolios()
+	if err != nil {
+		return err
+	}
+
+	var total float64
+	var portfolioSamples, holdingSamples, valueSamples []gaugeSample
+	for _, portfolio := range portfolios {
+		holdings, err := c.calculateHoldings(portfolio.ID)
+		if err != nil {
+			return err
+		}
+
+		id := strconv.FormatInt(portfolio.ID, 10)
+		var value float64
+		for symbol, holding := range holdings {
+			if !holding.IsPositive() {
+				continue
+			}
+			amount := holding.InexactFloat64()
+
+			var price float64
+			if c.priceCache != nil {
+				price, _ = c.priceCache.Get(symbol)
+			}
+
+			labels := []string{id, portfolio.Name, symbol}
+			holdingSamples = append(holdingSamples, gaugeSample{value: amount, labels: labels})
+			valueSamples = append(valueSamples, gaugeSample{value: amount * price, labels: labels})
+			value += amount * price
+		}
+
+		portfolioSamples = append(portfolioSamples, gaugeSample{value: value, labels: []string{id, portfolio.Name}})
+		total += value
+	}
+
+	metrics.Default.Update(func() {
+		replaceGauge(portfolioValue, portfolioSamples)
+		replaceGauge(assetHoldings, holdingSamples)
+		replaceGauge(assetValue, valueSamples)
+		portfolioTotalValue.Set(total)
+	})
+	return nil
+}
+
+func replaceGauge(gauge *metrics.GaugeVec, samples []gaugeSample) {
+	gauge.Reset()
+	for _, sample := range samples {
+		gauge.Set(sample.value, sample.labels...)
+	}
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationChannelRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Topic    string `json:"topic"`
+	ChatID   string `json:"chat_id"`
+	SMTPHost string `json:"smtp_host"`
+	SMTPPort int    `json:"smtp_port"`
+	Username string `json:"username"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Secret   string `json:"secret"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+// apply copies the request onto channel. An empty secret keeps the stored one
+// so clients can update a channel without resending credentials.
+func (r *NotificationChannelRequest) apply(channel *models.NotificationChannel) {
+	channel.Name = strings.TrimSpace(r.Name)
+	channel.Type = r.Type
+	channel.URL = strings.TrimSpace(r.URL)
+	channel.Topic = strings.TrimSpace(r.Topic)
+	channel.ChatID = strings.TrimSpace(r.ChatID)
+	channel.SMTPHost = strings.TrimSpace(r.SMTPHost)
+	channel.SMTPPort = r.SMTPPort
+	channel.Username = r.Username
+	channel.From = strings.TrimSpace(r.From)
+	channel.To = r.To
+	if r.Secret != "" {
+		channel.Secret = r.Secret
+	}
+	if r.Enabled != nil {
+		channel.Enabled = *r.Enabled
+	}
+}
+
+// ListNotificationChannels godoc
+// @Summary List notification channels
+// @Description Get all configured notification channels. Secrets are never returned.
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} models.NotificationChannel
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications [get]
+func (c *Controller) ListNotificationChannels(ctx *gin.Context) {
+	channels, err := c.repo.ListNotificationChannels()
+	if err != nil {
+		internalError(ctx, "failed to fetch notification channels")
+		return
+	}
+	ctx.JSON(http.StatusOK, channels)
+}
+
+// CreateNotificationChannel godoc
+// @Summary Create a notification channel
+// @Description Create a webhook, smtp, ntfy, gotify or telegram channel
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param channel body NotificationChannelRequest true "Notification channel"
+// @Success 201 {object} models.NotificationChannel
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications [post]
+func (c *Controller) CreateNotificationChannel(ctx *gin.Context) {
+	var req NotificationChannelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	channel := models.NotificationChannel{Enabled: true}
+	req.apply(&channel)
+	if err := channel.Validate(); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+
+	if err := c.repo.CreateNotificationChannel(&channel); err != nil {
+		internalError(ctx, "failed to create notification channel")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, channel)
+}
+
+// UpdateNotificationChannel godoc
+// @Summary Update a notification channel
+// @Description Update a notification channel by its ID. Omit secret to keep the stored one.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path int true "Channel ID"
+// @Param channel body NotificationChannelRequest true "Notification channel"
+// @Success 200 {object} models.NotificationChannel
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/{id} [put]
+func (c *Controller) UpdateNotificationChannel(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid channel id")
+		return
+	}
+
+	channel, err := c.repo.GetNotificationChannelByID(id)
+	if err != nil {
+		notFound(ctx, "notification channel not found")
+		return
+	}
+
+	var req NotificationChannelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	req.apply(channel)
+	if err := channel.Validate(); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+
+	if err := c.repo.UpdateNotificationChannel(channel); err != nil {
+		internalError(ctx, "failed to update notification channel")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, channel)
+}
+
+// DeleteNotificationChannel godoc
+// @Summary Delete a notification channel
+// @Description Delete a notification channel by its ID
+// @Tags notifications
+// @Param id path int true "Channel ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/{id} [delete]
+func (c *Controller) DeleteNotificationChannel(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid channel id")
+		return
+	}
+
+	if err := c.repo.DeleteNotificationChannel(id); err != nil {
+		internalError(ctx, "failed to delete notification channel")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// TestNotificationChannel godoc
+// @Summary Send a test notification
+// @Description Send a test message through a notification channel
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Channel ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/notifications/{id}/test [post]
+func (c *Controller) TestNotificationChannel(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid channel id")
+		return
+	}
+
+	channel, err := c.repo.GetNotificationChannelByID(id)
+	if err != nil {
+		notFound(ctx, "notification channel not found")
+		return
+	}
+
+	if err := service.SendTestNotification(ctx.Request.Context(), channel); err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to send test notification", "details": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "sent"})
+}
@@ -6,21 +6,24 @@ import (
 	"strconv"
 	"time"
 
+	"hodlbook/internal/models"
+
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type AssetHolding struct {
-	Symbol string  `json:"symbol"`
-	Amount float64 `json:"amount"`
-	Price  float64 `json:"price"`
-	Value  float64 `json:"value"`
+	Symbol string          `json:"symbol"`
+	Amount decimal.Decimal `json:"amount"`
+	Price  float64         `json:"price"`
+	Value  float64         `json:"value"`
 }
 
 type AllocationEntry struct {
-	Symbol     string  `json:"symbol"`
-	Amount     float64 `json:"amount"`
-	Value      float64 `json:"value"`
-	Percentage float64 `json:"percentage"`
+	Symbol     string          `json:"symbol"`
+	Amount     decimal.Decimal `json:"amount"`
+	Value      float64         `json:"value"`
+	Percentage float64         `json:"percentage"`
 }
 
 type PerformanceEntry struct {
@@ -36,70 +39,24 @@ type HistoryPoint struct {
 	Value float64 `json:"value"`
 }
 
-func (c *Controller) calculateHoldings() (map[string]float64, error) {
-	holdings := make(map[string]float64)
-
-	assets, err := c.repo.GetAllAssets()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, err := c.repo.GetAllExchanges()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings, nil
+func (c *Controller) calculateHoldings(portfolioID int64) (map[string]decimal.Decimal, error) {
+	return c.calculateHoldingsAtDate(portfolioID, time.Time{})
 }
 
-func (c *Controller) calculateHoldingsAtDate(targetDate time.Time) (map[string]float64, error) {
-	holdings := make(map[string]float64)
-
-	assets, err := c.repo.GetAllAssets()
+// calculateHoldingsAtDate nets the entries dated up to targetDate, or all of
+// them when it is zero.
+func (c *Controller) calculateHoldingsAtDate(portfolioID int64, targetDate time.Time) (map[string]decimal.Decimal, error) {
+	assets, err := c.repo.GetAssetsByPortfolio(portfolioID)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, asset := range assets {
-		if asset.Timestamp.After(targetDate) {
-			continue
-		}
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, err := c.repo.GetAllExchanges()
+	exchanges, err := c.repo.GetExchangesByPortfolio(portfolioID)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, ex := range exchanges {
-		if ex.Timestamp.After(targetDate) {
-			continue
-		}
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings, nil
+	return models.NetHoldings(assets, exchanges, targetDate), nil
 }
 
 // PortfolioSummary godoc
@@ -107,11 +64,17 @@ func (c *Controller) calculateHoldingsAtDate(targetDate time.Time) (map[string]f
 // @Description Get the total portfolio value with holdings breakdown
 // @Tags portfolio
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /api/portfolio/summary [get]
 func (c *Controller) PortfolioSummary(ctx *gin.Context) {
-	holdings, err := c.calculateHoldings()
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	holdings, err := c.calculateHoldings(portfolioID)
 	if err != nil {
 		internalError(ctx, "failed to calculate holdings")
 		return
@@ -121,7 +84,7 @@ func (c *Controller) PortfolioSummary(ctx *gin.Context) {
 	assetHoldings := make([]AssetHolding, 0)
 
 	for symbol, amount := range holdings {
-		if amount <= 0 {
+		if !amount.IsPositive() {
 			continue
 		}
 
@@ -130,7 +93,7 @@ func (c *Controller) PortfolioSummary(ctx *gin.Context) {
 			price, _ = c.priceCache.Get(symbol)
 		}
 
-		value := amount * price
+		value := amount.InexactFloat64() * price
 		totalValue += value
 
 		assetHoldings = append(assetHoldings, AssetHolding{
@@ -153,11 +116,17 @@ func (c *Controller) PortfolioSummary(ctx *gin.Context) {
 // @Description Get the portfolio allocation by asset with percentages
 // @Tags portfolio
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /api/portfolio/allocation [get]
 func (c *Controller) PortfolioAllocation(ctx *gin.Context) {
-	holdings, err := c.calculateHoldings()
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	holdings, err := c.calculateHoldings(portfolioID)
 	if err != nil {
 		internalError(ctx, "failed to calculate holdings")
 		return
@@ -167,7 +136,7 @@ func (c *Controller) PortfolioAllocation(ctx *gin.Context) {
 	allocations := make([]AllocationEntry, 0)
 
 	for symbol, amount := range holdings {
-		if amount <= 0 {
+		if !amount.IsPositive() {
 			continue
 		}
 
@@ -176,7 +145,7 @@ func (c *Controller) PortfolioAllocation(ctx *gin.Context) {
 			price, _ = c.priceCache.Get(symbol)
 		}
 
-		value := amount * price
+		value := amount.InexactFloat64() * price
 		totalValue += value
 
 		allocations = append(allocations, AllocationEntry{
@@ -207,17 +176,23 @@ func (c *Controller) PortfolioAllocation(ctx *gin.Context) {
 // @Description Get profit/loss calculations per asset
 // @Tags portfolio
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /api/portfolio/performance [get]
 func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
-	holdings, err := c.calculateHoldings()
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	holdings, err := c.calculateHoldings(portfolioID)
 	if err != nil {
 		internalError(ctx, "failed to calculate holdings")
 		return
 	}
 
-	assets, err := c.repo.GetAllAssets()
+	assets, err := c.repo.GetAssetsByPortfolio(portfolioID)
 	if err != nil {
 		internalError(ctx, "failed to get assets")
 		return
@@ -246,7 +221,12 @@ func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
 		}
 	}
 
+	// Cost basis uses the price recorded at the transaction time, manual or
+	// fetched, and falls back to the closest daily snapshot.
 	findPriceAtTime := func(symbol string, t time.Time) float64 {
+		if recorded, err := c.repo.GetPriceAtTime(symbol, "USD", t); err == nil && recorded != nil {
+			return recorded.Price.InexactFloat64()
+		}
 		prices := historicPrices[symbol]
 		if len(prices) == 0 {
 			return 0
@@ -279,16 +259,17 @@ func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
 			timestamp: asset.Timestamp,
 			apply: func(costBasis, runningHoldings map[string]float64) {
 				price := findPriceAtTime(asset.Symbol, asset.Timestamp)
+				amount := asset.Amount.InexactFloat64()
 				switch asset.TransactionType {
 				case "deposit":
-					costBasis[asset.Symbol] += asset.Amount * price
-					runningHoldings[asset.Symbol] += asset.Amount
+					costBasis[asset.Symbol] += amount * price
+					runningHoldings[asset.Symbol] += amount
 				case "withdraw":
 					if runningHoldings[asset.Symbol] > 0 {
 						avgCost := costBasis[asset.Symbol] / runningHoldings[asset.Symbol]
-						costBasis[asset.Symbol] -= asset.Amount * avgCost
+						costBasis[asset.Symbol] -= amount * avgCost
 					}
-					runningHoldings[asset.Symbol] -= asset.Amount
+					runningHoldings[asset.Symbol] -= amount
 				}
 			},
 		})
@@ -309,7 +290,7 @@ func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
 	performance := make([]PerformanceEntry, 0)
 
 	for symbol, amount := range holdings {
-		if amount <= 0 {
+		if !amount.IsPositive() {
 			continue
 		}
 
@@ -318,7 +299,7 @@ func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
 			price, _ = c.priceCache.Get(symbol)
 		}
 
-		currentValue := amount * price
+		currentValue := amount.InexactFloat64() * price
 		cost := costBasis[symbol]
 		profitLoss := currentValue - cost
 
@@ -359,11 +340,17 @@ func (c *Controller) PortfolioPerformance(ctx *gin.Context) {
 // @Description Get portfolio value over time using historic prices
 // @Tags portfolio
 // @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
 // @Param days query int false "Number of days of history (default 30)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /api/portfolio/history [get]
 func (c *Controller) PortfolioHistory(ctx *gin.Context) {
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
 	days := 30
 	if d := ctx.Query("days"); d != "" {
 		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
@@ -400,14 +387,14 @@ func (c *Controller) PortfolioHistory(ctx *gin.Context) {
 		dateStr := date.Format("2006-01-02")
 
 		endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-		holdings, err := c.calculateHoldingsAtDate(endOfDay)
+		holdings, err := c.calculateHoldingsAtDate(portfolioID, endOfDay)
 		if err != nil {
 			continue
 		}
 
 		var dailyValue float64
 		for symbol, amount := range holdings {
-			if amount <= 0 {
+			if !amount.IsPositive() {
 				continue
 			}
 
@@ -420,7 +407,7 @@ func (c *Controller) PortfolioHistory(ctx *gin.Context) {
 			if price == 0 && c.priceCache != nil {
 				price, _ = c.priceCache.Get(symbol)
 			}
-			dailyValue += amount * price
+			dailyValue += amount.InexactFloat64() * price
 		}
 
 		historyPoints = append(historyPoints, HistoryPoint{
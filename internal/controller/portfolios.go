@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListPortfolios godoc
+// @Summary List portfolios
+// @Description Get all portfolios
+// @Tags portfolios
+// @Produce json
+// @Success 200 {array} models.Portfolio
+// @Failure 500 {object} map[string]string
+// @Router /api/portfolios [get]
+func (c *Controller) ListPortfolios(ctx *gin.Context) {
+	portfolios, err := c.repo.ListPortfolios()
+	if err != nil {
+		internalError(ctx, "failed to fetch portfolios")
+		return
+	}
+	ctx.JSON(http.StatusOK, portfolios)
+}
+
+// GetPortfolio godoc
+// @Summary Get a portfolio by ID
+// @Description Get a single portfolio by its ID
+// @Tags portfolios
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Success 200 {object} models.Portfolio
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/portfolios/{id} [get]
+func (c *Controller) GetPortfolio(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid portfolio id")
+		return
+	}
+
+	portfolio, err := c.repo.GetPortfolioByID(id)
+	if err != nil {
+		notFound(ctx, "portfolio not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, portfolio)
+}
+
+// CreatePortfolio godoc
+// @Summary Create a new portfolio
+// @Description Create a new portfolio with the provided name and description
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param portfolio body models.Portfolio true "Portfolio data"
+// @Success 201 {object} models.Portfolio
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/portfolios [post]
+func (c *Controller) CreatePortfolio(ctx *gin.Context) {
+	var portfolio models.Portfolio
+	if err := ctx.ShouldBindJSON(&portfolio); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	portfolio.ID = 0
+	portfolio.Name = strings.TrimSpace(portfolio.Name)
+	if portfolio.Name == "" {
+		badRequest(ctx, "name is required")
+		return
+	}
+
+	if err := c.repo.CreatePortfolio(&portfolio); err != nil {
+		internalError(ctx, "failed to create portfolio")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, portfolio)
+}
+
+// UpdatePortfolio godoc
+// @Summary Update a portfolio
+// @Description Rename or re-describe an existing portfolio
+// @Tags portfolios
+// @Accept json
+// @Produce json
+// @Param id path int true "Portfolio ID"
+// @Param portfolio body models.Portfolio true "Portfolio data"
+// @Success 200 {object} models.Portfolio
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/portfolios/{id} [put]
+func (c *Controller) UpdatePortfolio(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid portfolio id")
+		return
+	}
+
+	portfolio, err := c.repo.GetPortfolioByID(id)
+	if err != nil {
+		notFound(ctx, "portfolio not found")
+		return
+	}
+
+	var input models.Portfolio
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		badRequest(ctx, "name is required")
+		return
+	}
+
+	portfolio.Name = input.Name
+	portfolio.Description = input.Description
+	if err := c.repo.UpdatePortfolio(portfolio); err != nil {
+		internalError(ctx, "failed to update portfolio")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, portfolio)
+}
+
+// DeletePortfolio godoc
+// @Summary Delete a portfolio
+// @Description Delete a portfolio and everything scoped to it: assets, exchanges, import logs, alerts, wallets, reconciliations, recurring transactions and allocation targets
+// @Tags portfolios
+// @Param id path int true "Portfolio ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/portfolios/{id} [delete]
+func (c *Controller) DeletePortfolio(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid portfolio id")
+		return
+	}
+
+	if err := c.repo.DeletePortfolio(id); err != nil {
+		if errors.Is(err, repo.ErrDefaultPortfolio) {
+			badRequest(ctx, err.Error())
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			internalError(ctx, "failed to delete portfolio")
+			return
+		}
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// portfolioIDQuery reads the optional portfolio_id query parameter. Zero means
+// the consolidated view across all portfolios. On failure the error response
+// has already been written.
+func (c *Controller) portfolioIDQuery(ctx *gin.Context) (int64, bool) {
+	idStr := ctx.Query("portfolio_id")
+	if idStr == "" {
+		return 0, true
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id < 0 {
+		badRequest(ctx, "invalid portfolio id")
+		return 0, false
+	}
+	if id > 0 && !c.portfolioExists(id) {
+		notFound(ctx, "portfolio not found")
+		return 0, false
+	}
+	return id, true
+}
+
+func (c *Controller) portfolioExists(id int64) bool {
+	_, err := c.repo.GetPortfolioByID(id)
+	return err == nil
+}
+
+// walletInPortfolio reports whether an entry of portfolioID may be tagged
+// with walletID. Untagged entries always may.
+func (c *Controller) walletInPortfolio(walletID *int64, portfolioID int64) bool {
+	if walletID == nil {
+		return true
+	}
+	if portfolioID == 0 {
+		portfolioID = models.DefaultPortfolioID
+	}
+	wallet, err := c.repo.GetWalletByID(*walletID)
+	return err == nil && wallet.PortfolioID == portfolioID
+}
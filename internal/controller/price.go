@@ -1,33 +1,90 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"hodlbook/internal/models"
 	"hodlbook/pkg/integrations/prices"
 
 	"github.com/gin-gonic/gin"
 )
 
+// deepSearcher is implemented by price fetchers that can look a currency up
+// across several providers.
+type deepSearcher interface {
+	DeepSearch(ctx context.Context, query, name, network string, providers []string) ([]prices.DeepSearchResult, error)
+}
+
+var _ deepSearcher = (*prices.PriceService)(nil)
+
+// PriceQuote is a price along with where and when it was fetched. Stale is
+// set when the price could not be refreshed recently; AsOf is empty when no
+// fetch was recorded.
+type PriceQuote struct {
+	Price  float64    `json:"price"`
+	Source string     `json:"source,omitempty"`
+	AsOf   *time.Time `json:"as_of,omitempty"`
+	Stale  bool       `json:"stale"`
+}
+
+// lastKnownPrices returns the stored prices by symbol. Quotes are still
+// served from the cache when they cannot be read.
+func (c *Controller) lastKnownPrices() map[string]models.LastKnownPrice {
+	stored, err := c.repo.GetLastKnownPrices()
+	if err != nil {
+		return nil
+	}
+	bySymbol := make(map[string]models.LastKnownPrice, len(stored))
+	for _, p := range stored {
+		bySymbol[p.Symbol] = p
+	}
+	return bySymbol
+}
+
+// quote prefers the live price and falls back to the stored one. A symbol
+// with neither is reported as stale rather than worth nothing.
+func (c *Controller) quote(symbol string, lastKnown map[string]models.LastKnownPrice, now time.Time) PriceQuote {
+	var q PriceQuote
+	q.Price, _ = c.priceCache.Get(symbol)
+
+	stored, ok := lastKnown[symbol]
+	if !ok {
+		q.Stale = q.Price <= 0
+		return q
+	}
+	if q.Price <= 0 {
+		q.Price = stored.Price.InexactFloat64()
+	}
+	asOf := stored.FetchedAt
+	q.Source = stored.Source
+	q.AsOf = &asOf
+	q.Stale = stored.IsStale(now, c.staleAfter)
+	return q
+}
+
 // ListPrices godoc
 // @Summary List current prices
-// @Description Get current prices for all tracked assets
+// @Description Get current prices for all tracked assets by symbol. Prices that could not be refreshed recently are marked stale.
 // @Tags prices
 // @Produce json
-// @Success 200 {object} map[string]float64
+// @Success 200 {object} map[string]PriceQuote
 // @Router /api/prices [get]
 func (c *Controller) ListPrices(ctx *gin.Context) {
 	if c.priceCache == nil {
 		serviceUnavailable(ctx, "price service not available")
 		return
 	}
-	prices := make(map[string]float64)
+	lastKnown := c.lastKnownPrices()
+	now := time.Now()
+	quotes := make(map[string]PriceQuote)
 	for _, key := range c.priceCache.Keys() {
-		if val, ok := c.priceCache.Get(key); ok {
-			prices[key] = val
-		}
+		quotes[key] = c.quote(key, lastKnown, now)
 	}
-	ctx.JSON(http.StatusOK, prices)
+	ctx.JSON(http.StatusOK, quotes)
 }
 
 // GetPrice godoc
@@ -46,15 +103,18 @@ func (c *Controller) GetPrice(ctx *gin.Context) {
 	}
 	symbol := ctx.Param("symbol")
 
-	price, ok := c.priceCache.Get(symbol)
-	if !ok {
+	if _, ok := c.priceCache.Get(symbol); !ok {
 		notFound(ctx, "price not found for symbol")
 		return
 	}
+	q := c.quote(symbol, c.lastKnownPrices(), time.Now())
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"symbol": symbol,
-		"price":  price,
+		"price":  q.Price,
+		"source": q.Source,
+		"as_of":  q.AsOf,
+		"stale":  q.Stale,
 	})
 }
 
@@ -87,12 +147,21 @@ func (c *Controller) GetPriceHistory(ctx *gin.Context) {
 // @Param q query string false "Search query to filter currencies"
 // @Success 200 {array} object
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /api/prices/currencies [get]
 func (c *Controller) SearchCurrencies(ctx *gin.Context) {
 	query := strings.ToUpper(ctx.Query("q"))
 
-	fetcher := prices.NewPriceService()
-	allPrices, err := fetcher.FetchAll()
+	if c.priceFetcher == nil {
+		serviceUnavailable(ctx, "price fetcher not available")
+		return
+	}
+
+	allPrices, err := c.priceFetcher.FetchAll(ctx.Request.Context())
+	if errors.Is(err, prices.ErrOffline) {
+		serviceUnavailable(ctx, err.Error())
+		return
+	}
 	if err != nil {
 		internalError(ctx, "failed to fetch currencies")
 		return
@@ -134,6 +203,7 @@ func (c *Controller) SearchCurrencies(ctx *gin.Context) {
 // @Success 200 {array} prices.DeepSearchResult
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /api/prices/deep-search [get]
 func (c *Controller) DeepSearchCurrencies(ctx *gin.Context) {
 	query := ctx.Query("q")
@@ -146,8 +216,17 @@ func (c *Controller) DeepSearchCurrencies(ctx *gin.Context) {
 	network := ctx.Query("network")
 	providerParams := ctx.QueryArray("providers")
 
-	fetcher := prices.NewPriceService()
-	results, err := fetcher.DeepSearch(query, name, network, providerParams)
+	searcher, ok := c.priceFetcher.(deepSearcher)
+	if !ok {
+		serviceUnavailable(ctx, "deep search not available")
+		return
+	}
+
+	results, err := searcher.DeepSearch(ctx.Request.Context(), query, name, network, providerParams)
+	if errors.Is(err, prices.ErrOffline) {
+		serviceUnavailable(ctx, err.Error())
+		return
+	}
 	if err != nil {
 		internalError(ctx, "deep search failed")
 		return
@@ -0,0 +1,510 @@
+package controller
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hodlbook/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// defaultRebalanceMinTrade is the smallest exchange proposed, in the
+	// reference currency, when the request does not set one.
+	defaultRebalanceMinTrade = 10.0
+	// defaultRebalanceFeeRate is the exchange fee assumed when the request
+	// does not set one.
+	defaultRebalanceFeeRate = 0.001
+	// minTradeValue drops the rounding dust left when matching trades.
+	minTradeValue = 0.01
+)
+
+const (
+	DriftKindSymbol   = "symbol"
+	DriftKindCategory = "category"
+)
+
+type AllocationTargetInput struct {
+	Symbol   string  `json:"symbol"`
+	Category string  `json:"category"`
+	Weight   float64 `json:"weight"`
+}
+
+type AllocationTargetsRequest struct {
+	PortfolioID int64                   `json:"portfolio_id"`
+	Targets     []AllocationTargetInput `json:"targets"`
+}
+
+type AssetCategoryInput struct {
+	Symbol   string `json:"symbol"`
+	Category string `json:"category"`
+}
+
+type AssetCategoriesRequest struct {
+	Categories []AssetCategoryInput `json:"categories"`
+}
+
+// DriftEntry compares what a target covers with its target. DriftValue is
+// positive when it is over weight.
+type DriftEntry struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Symbols     []string `json:"symbols"`
+	Value       float64  `json:"value"`
+	TargetValue float64  `json:"target_value"`
+	CurrentPct  float64  `json:"current_percentage"`
+	TargetPct   float64  `json:"target_percentage"`
+	DriftPct    float64  `json:"drift_percentage"`
+	DriftValue  float64  `json:"drift_value"`
+}
+
+// RebalanceTrade is a proposed exchange. ToAmount is estimated at the
+// current prices after the fee.
+type RebalanceTrade struct {
+	FromSymbol string          `json:"from_symbol"`
+	FromAmount decimal.Decimal `json:"from_amount"`
+	ToSymbol   string          `json:"to_symbol"`
+	ToAmount   decimal.Decimal `json:"to_amount"`
+	Value      float64         `json:"value"`
+	Fee        float64         `json:"fee"`
+}
+
+// RebalancePlan is the drift of a portfolio from its targets and the
+// exchanges that bring it back. Holdings without a price cannot be valued
+// and are left out.
+type RebalancePlan struct {
+	PortfolioID   int64            `json:"portfolio_id"`
+	TotalValue    float64          `json:"total_value"`
+	MinTrade      float64          `json:"min_trade"`
+	FeeRate       float64          `json:"fee_rate"`
+	Targets       int              `json:"targets"`
+	Drift         []DriftEntry     `json:"drift"`
+	Trades        []RebalanceTrade `json:"trades"`
+	EstimatedFees float64          `json:"estimated_fees"`
+	Unpriced      []string         `json:"unpriced"`
+	Warnings      []string         `json:"warnings"`
+}
+
+// GetAllocationTargets godoc
+// @Summary Get allocation targets
+// @Description Get the target weights, in percent, of a portfolio by symbol or category. Without portfolio_id the targets of all portfolios together are returned.
+// @Tags portfolio
+// @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
+// @Success 200 {array} models.AllocationTarget
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /api/portfolio/targets [get]
+func (c *Controller) GetAllocationTargets(ctx *gin.Context) {
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	targets, err := c.repo.ListAllocationTargets(portfolioID)
+	if err != nil {
+		internalError(ctx, "failed to fetch allocation targets")
+		return
+	}
+	ctx.JSON(http.StatusOK, targets)
+}
+
+// UpdateAllocationTargets godoc
+// @Summary Set allocation targets
+// @Description Replace the target weights of a portfolio. Each target names a symbol or a category and the weights add up to 100. An empty list clears the targets.
+// @Tags portfolio
+// @Accept json
+// @Produce json
+// @Param targets body AllocationTargetsRequest true "Allocation targets"
+// @Success 200 {array} models.AllocationTarget
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /api/portfolio/targets [put]
+func (c *Controller) UpdateAllocationTargets(ctx *gin.Context) {
+	var req AllocationTargetsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+	if req.PortfolioID > 0 && !c.portfolioExists(req.PortfolioID) {
+		notFound(ctx, "portfolio not found")
+		return
+	}
+
+	targets := make([]models.AllocationTarget, 0, len(req.Targets))
+	for _, t := range req.Targets {
+		targets = append(targets, models.AllocationTarget{
+			Symbol:   strings.ToUpper(strings.TrimSpace(t.Symbol)),
+			Category: strings.TrimSpace(t.Category),
+			Weight:   t.Weight,
+		})
+	}
+	if err := models.ValidateTargets(targets); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+
+	if err := c.repo.ReplaceAllocationTargets(req.PortfolioID, targets); err != nil {
+		internalError(ctx, "failed to save allocation targets")
+		return
+	}
+	ctx.JSON(http.StatusOK, targets)
+}
+
+// ListAssetCategories godoc
+// @Summary List asset categories
+// @Description Get the category of every categorised symbol
+// @Tags portfolio
+// @Produce json
+// @Success 200 {array} models.AssetCategory
+// @Failure 500 {object} APIError
+// @Router /api/portfolio/categories [get]
+func (c *Controller) ListAssetCategories(ctx *gin.Context) {
+	categories, err := c.repo.ListAssetCategories()
+	if err != nil {
+		internalError(ctx, "failed to fetch asset categories")
+		return
+	}
+	ctx.JSON(http.StatusOK, categories)
+}
+
+// UpdateAssetCategories godoc
+// @Summary Set asset categories
+// @Description Replace the categories symbols belong to, shared by every portfolio. A symbol listed twice keeps its last category.
+// @Tags portfolio
+// @Accept json
+// @Produce json
+// @Param categories body AssetCategoriesRequest true "Asset categories"
+// @Success 200 {array} models.AssetCategory
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /api/portfolio/categories [put]
+func (c *Controller) UpdateAssetCategories(ctx *gin.Context) {
+	var req AssetCategoriesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	bySymbol := make(map[string]string, len(req.Categories))
+	for _, input := range req.Categories {
+		symbol := strings.ToUpper(strings.TrimSpace(input.Symbol))
+		category := strings.TrimSpace(input.Category)
+		if symbol == "" || category == "" {
+			badRequest(ctx, models.ErrCategoryRequired.Error())
+			return
+		}
+		bySymbol[symbol] = category
+	}
+
+	categories := make([]models.AssetCategory, 0, len(bySymbol))
+	for symbol, category := range bySymbol {
+		categories = append(categories, models.AssetCategory{Symbol: symbol, Category: category})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Symbol < categories[j].Symbol
+	})
+
+	if err := c.repo.ReplaceAssetCategories(categories); err != nil {
+		internalError(ctx, "failed to save asset categories")
+		return
+	}
+	ctx.JSON(http.StatusOK, categories)
+}
+
+// PortfolioRebalance godoc
+// @Summary Plan a rebalance
+// @Description Report how far the holdings of a portfolio drift from their target weights, in percent and in the reference currency, and propose the fewest exchanges that bring them back at the current prices. Drifts and trades smaller than min_trade are left alone, and fee_rate is deducted from what every exchange receives.
+// @Tags portfolio
+// @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
+// @Param min_trade query number false "Smallest trade in the reference currency (default 10)"
+// @Param fee_rate query number false "Estimated fee per trade as a fraction (default 0.001)"
+// @Success 200 {object} RebalancePlan
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /api/portfolio/rebalance [get]
+func (c *Controller) PortfolioRebalance(ctx *gin.Context) {
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	minTrade := defaultRebalanceMinTrade
+	if v := ctx.Query("min_trade"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 {
+			badRequest(ctx, "min_trade must be a non-negative number")
+			return
+		}
+		minTrade = parsed
+	}
+	feeRate := defaultRebalanceFeeRate
+	if v := ctx.Query("fee_rate"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed >= 1 {
+			badRequest(ctx, "fee_rate must be at least 0 and below 1")
+			return
+		}
+		feeRate = parsed
+	}
+
+	holdings, err := c.calculateHoldings(portfolioID)
+	if err != nil {
+		internalError(ctx, "failed to calculate holdings")
+		return
+	}
+	targets, err := c.repo.ListAllocationTargets(portfolioID)
+	if err != nil {
+		internalError(ctx, "failed to fetch allocation targets")
+		return
+	}
+	categories, err := c.repo.ListAssetCategories()
+	if err != nil {
+		internalError(ctx, "failed to fetch asset categories")
+		return
+	}
+
+	prices := make(map[string]float64)
+	var unpriced []string
+	for symbol, amount := range holdings {
+		if !amount.IsPositive() {
+			delete(holdings, symbol)
+			continue
+		}
+		var price float64
+		if c.priceCache != nil {
+			price, _ = c.priceCache.Get(symbol)
+		}
+		if price <= 0 {
+			unpriced = append(unpriced, symbol)
+			delete(holdings, symbol)
+			continue
+		}
+		prices[symbol] = price
+	}
+	// Symbols that are not held yet may have to be bought.
+	if c.priceCache != nil {
+		candidates := make([]string, 0, len(targets)+len(categories))
+		for _, target := range targets {
+			candidates = append(candidates, target.Symbol)
+		}
+		for _, category := range categories {
+			candidates = append(candidates, category.Symbol)
+		}
+		for _, symbol := range candidates {
+			if symbol == "" || prices[symbol] > 0 {
+				continue
+			}
+			if price, found := c.priceCache.Get(symbol); found && price > 0 {
+				prices[symbol] = price
+			}
+		}
+	}
+	sort.Strings(unpriced)
+
+	plan := planRebalance(holdings, prices, targets, categories, minTrade, feeRate)
+	plan.PortfolioID = portfolioID
+	plan.Unpriced = append(plan.Unpriced, unpriced...)
+	ctx.JSON(http.StatusOK, plan)
+}
+
+type rebalanceGroup struct {
+	entry   DriftEntry
+	members []string
+}
+
+// planRebalance groups the priced holdings under the targets covering them,
+// with holdings no target covers in groups of their own targeting zero, and
+// matches the largest surplus with the largest shortfall until every drift
+// above minTrade is closed.
+func planRebalance(holdings map[string]decimal.Decimal, prices map[string]float64, targets []models.AllocationTarget, categories []models.AssetCategory, minTrade, feeRate float64) *RebalancePlan {
+	plan := &RebalancePlan{
+		MinTrade: minTrade,
+		FeeRate:  feeRate,
+		Targets:  len(targets),
+		Drift:    make([]DriftEntry, 0),
+		Trades:   make([]RebalanceTrade, 0),
+		Unpriced: make([]string, 0),
+		Warnings: make([]string, 0),
+	}
+
+	values := make(map[string]float64, len(holdings))
+	for symbol, amount := range holdings {
+		values[symbol] = amount.InexactFloat64() * prices[symbol]
+		plan.TotalValue += values[symbol]
+	}
+	if len(targets) == 0 {
+		plan.Warnings = append(plan.Warnings, "no allocation targets are set")
+		return plan
+	}
+
+	categoryOf := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryOf[category.Symbol] = category.Category
+	}
+
+	groups := make(map[string]*rebalanceGroup)
+	order := make([]string, 0, len(targets))
+	for _, target := range targets {
+		entry := DriftEntry{Name: target.Symbol, Kind: DriftKindSymbol, TargetPct: target.Weight}
+		if target.Category != "" {
+			entry.Name, entry.Kind = target.Category, DriftKindCategory
+		}
+		groups[target.Key()] = &rebalanceGroup{entry: entry}
+		order = append(order, target.Key())
+	}
+
+	held := make([]string, 0, len(values))
+	for symbol := range values {
+		held = append(held, symbol)
+	}
+	sort.Slice(held, func(i, j int) bool {
+		return values[held[i]] > values[held[j]]
+	})
+	for _, symbol := range held {
+		key := symbol
+		if _, ok := groups[key]; !ok {
+			if category := categoryOf[symbol]; category != "" {
+				if _, ok := groups["category:"+category]; ok {
+					key = "category:" + category
+				}
+			}
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &rebalanceGroup{entry: DriftEntry{Name: symbol, Kind: DriftKindSymbol}}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.members = append(group.members, symbol)
+		group.entry.Value += values[symbol]
+	}
+
+	buys := make(map[string]float64)
+	sells := make(map[string]float64)
+	for _, key := range order {
+		group := groups[key]
+		entry := &group.entry
+		entry.Symbols = append(make([]string, 0, len(group.members)), group.members...)
+		if entry.Kind == DriftKindSymbol && len(entry.Symbols) == 0 {
+			entry.Symbols = append(entry.Symbols, entry.Name)
+		}
+		entry.TargetValue = plan.TotalValue * entry.TargetPct / 100
+		entry.DriftValue = entry.Value - entry.TargetValue
+		if plan.TotalValue > 0 {
+			entry.CurrentPct = entry.Value / plan.TotalValue * 100
+			entry.DriftPct = entry.CurrentPct - entry.TargetPct
+		}
+
+		if entry.DriftValue == 0 || math.Abs(entry.DriftValue) < minTrade {
+			continue
+		}
+		if entry.DriftValue > 0 {
+			// Sell the largest holdings first so that fewer symbols trade.
+			remaining := entry.DriftValue
+			for _, symbol := range group.members {
+				sell := math.Min(values[symbol], remaining)
+				sells[symbol] += sell
+				remaining -= sell
+				if remaining <= 0 {
+					break
+				}
+			}
+			continue
+		}
+
+		buy := rebalanceBuySymbol(group, categories)
+		if buy == "" || prices[buy] <= 0 {
+			plan.Warnings = append(plan.Warnings, "no priced symbol to buy for "+entry.Name)
+			continue
+		}
+		buys[buy] -= entry.DriftValue
+	}
+
+	for _, key := range order {
+		plan.Drift = append(plan.Drift, groups[key].entry)
+	}
+	sort.SliceStable(plan.Drift, func(i, j int) bool {
+		return math.Abs(plan.Drift[i].DriftValue) > math.Abs(plan.Drift[j].DriftValue)
+	})
+
+	plan.Trades = matchTrades(sells, buys, prices, minTrade, feeRate)
+	for _, trade := range plan.Trades {
+		plan.EstimatedFees += trade.Fee
+	}
+	return plan
+}
+
+// rebalanceBuySymbol picks what a group short of its target buys: its own
+// symbol, or for a category its largest holding, or the first symbol in the
+// category when none is held.
+func rebalanceBuySymbol(group *rebalanceGroup, categories []models.AssetCategory) string {
+	if group.entry.Kind == DriftKindSymbol {
+		return group.entry.Name
+	}
+	if len(group.members) > 0 {
+		return group.members[0]
+	}
+	for _, category := range categories {
+		if category.Category == group.entry.Name {
+			return category.Symbol
+		}
+	}
+	return ""
+}
+
+// matchTrades pairs the largest remaining sale with the largest remaining
+// purchase, which needs at most one exchange fewer than the symbols
+// involved. Pieces left below minTrade are not traded.
+func matchTrades(sells, buys map[string]float64, prices map[string]float64, minTrade, feeRate float64) []RebalanceTrade {
+	type leg struct {
+		symbol string
+		value  float64
+	}
+	sorted := func(values map[string]float64) []leg {
+		legs := make([]leg, 0, len(values))
+		for symbol, value := range values {
+			legs = append(legs, leg{symbol, value})
+		}
+		sort.Slice(legs, func(i, j int) bool {
+			if legs[i].value != legs[j].value {
+				return legs[i].value > legs[j].value
+			}
+			return legs[i].symbol < legs[j].symbol
+		})
+		return legs
+	}
+
+	from, to := sorted(sells), sorted(buys)
+	trades := make([]RebalanceTrade, 0)
+	for i, j := 0, 0; i < len(from) && j < len(to); {
+		value := math.Min(from[i].value, to[j].value)
+		if value >= minTrade && value >= minTradeValue {
+			fee := value * feeRate
+			trades = append(trades, RebalanceTrade{
+				FromSymbol: from[i].symbol,
+				FromAmount: decimal.NewFromFloat(value / prices[from[i].symbol]).Round(8),
+				ToSymbol:   to[j].symbol,
+				ToAmount:   decimal.NewFromFloat((value - fee) / prices[to[j].symbol]).Round(8),
+				Value:      value,
+				Fee:        fee,
+			})
+		}
+		from[i].value -= value
+		to[j].value -= value
+		if from[i].value <= 0 {
+			i++
+		}
+		if to[j].value <= 0 {
+			j++
+		}
+	}
+	return trades
+}
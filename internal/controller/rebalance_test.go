@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/integrations/memcache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rebalanceFixture() (map[string]decimal.Decimal, map[string]float64, []models.AssetCategory) {
+	holdings := map[string]decimal.Decimal{
+		"BTC":  decimal.NewFromInt(1),
+		"ETH":  decimal.NewFromInt(10),
+		"USDT": decimal.NewFromInt(15000),
+		"DOGE": decimal.NewFromInt(50000),
+	}
+	prices := map[string]float64{"BTC": 60000, "ETH": 2000, "USDT": 1, "DOGE": 0.1}
+	categories := []models.AssetCategory{
+		{Symbol: "USDC", Category: "Stablecoins"},
+		{Symbol: "USDT", Category: "Stablecoins"},
+	}
+	return holdings, prices, categories
+}
+
+var rebalanceTargets = []models.AllocationTarget{
+	{Symbol: "BTC", Weight: 50},
+	{Symbol: "ETH", Weight: 30},
+	{Category: "Stablecoins", Weight: 20},
+}
+
+func TestPlanRebalance_DriftAndTrades(t *testing.T) {
+	holdings, prices, categories := rebalanceFixture()
+
+	plan := planRebalance(holdings, prices, rebalanceTargets, categories, 10, 0.001)
+	assert.InDelta(t, 100000, plan.TotalValue, 1e-6)
+
+	require.Len(t, plan.Drift, 4)
+	btc, eth := plan.Drift[0], plan.Drift[1]
+	assert.Equal(t, "BTC", btc.Name)
+	assert.InDelta(t, 60, btc.CurrentPct, 1e-9)
+	assert.InDelta(t, 10, btc.DriftPct, 1e-9)
+	assert.InDelta(t, 10000, btc.DriftValue, 1e-6)
+	assert.Equal(t, "ETH", eth.Name)
+	assert.InDelta(t, -10000, eth.DriftValue, 1e-6)
+
+	byName := make(map[string]DriftEntry)
+	for _, entry := range plan.Drift {
+		byName[entry.Name] = entry
+	}
+	assert.Equal(t, DriftKindCategory, byName["Stablecoins"].Kind)
+	assert.Equal(t, []string{"USDT"}, byName["Stablecoins"].Symbols)
+	assert.InDelta(t, -5000, byName["Stablecoins"].DriftValue, 1e-6)
+	assert.Zero(t, byName["DOGE"].TargetPct, "untargeted holdings target zero")
+	assert.InDelta(t, 5000, byName["DOGE"].DriftValue, 1e-6)
+
+	require.Len(t, plan.Trades, 2)
+	first, second := plan.Trades[0], plan.Trades[1]
+	assert.Equal(t, "BTC", first.FromSymbol)
+	assert.Equal(t, "ETH", first.ToSymbol)
+	assert.Equal(t, "0.16666667", first.FromAmount.String())
+	assert.Equal(t, "4.995", first.ToAmount.String())
+	assert.Equal(t, "DOGE", second.FromSymbol)
+	assert.Equal(t, "USDT", second.ToSymbol)
+	assert.Equal(t, "50000", second.FromAmount.String())
+	assert.InDelta(t, 15, plan.EstimatedFees, 1e-6)
+}
+
+func TestPlanRebalance_MinTradeAndFewestTrades(t *testing.T) {
+	holdings, prices, categories := rebalanceFixture()
+
+	plan := planRebalance(holdings, prices, rebalanceTargets, categories, 6000, 0)
+	require.Len(t, plan.Trades, 1, "drifts below the minimum trade are left alone")
+	assert.Equal(t, "BTC", plan.Trades[0].FromSymbol)
+	assert.Zero(t, plan.EstimatedFees)
+
+	trades := matchTrades(
+		map[string]float64{"A": 70},
+		map[string]float64{"B": 40, "C": 30},
+		map[string]float64{"A": 1, "B": 1, "C": 1},
+		0, 0,
+	)
+	require.Len(t, trades, 2)
+	assert.InDelta(t, 40, trades[0].Value, 1e-9)
+	assert.Equal(t, "B", trades[0].ToSymbol)
+}
+
+func TestPlanRebalance_CategoryWithoutHoldings(t *testing.T) {
+	holdings := map[string]decimal.Decimal{"BTC": decimal.NewFromInt(1)}
+	prices := map[string]float64{"BTC": 100, "USDC": 1}
+	categories := []models.AssetCategory{{Symbol: "USDC", Category: "Stablecoins"}}
+	targets := []models.AllocationTarget{{Symbol: "BTC", Weight: 80}, {Category: "Stablecoins", Weight: 20}}
+
+	plan := planRebalance(holdings, prices, targets, categories, 1, 0)
+	require.Len(t, plan.Trades, 1)
+	assert.Equal(t, "USDC", plan.Trades[0].ToSymbol, "a category buys its first symbol when none is held")
+	assert.Equal(t, "20", plan.Trades[0].ToAmount.String())
+
+	plan = planRebalance(holdings, prices, targets, nil, 1, 0)
+	assert.Empty(t, plan.Trades)
+	assert.Equal(t, []string{"no priced symbol to buy for Stablecoins"}, plan.Warnings)
+
+	plan = planRebalance(holdings, prices, nil, nil, 1, 0)
+	assert.Empty(t, plan.Drift)
+	assert.Equal(t, []string{"no allocation targets are set"}, plan.Warnings)
+}
+
+func TestPortfolioRebalance_Endpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repository := newTestRepository(t)
+
+	cache := memcache.New[string, float64]()
+	cache.Set("BTC", 100)
+	cache.Set("SOL", 10)
+	ctrl, err := New(WithRepository(repository), WithPriceCache(cache))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/api/portfolio/targets", ctrl.GetAllocationTargets)
+	router.PUT("/api/portfolio/targets", ctrl.UpdateAllocationTargets)
+	router.PUT("/api/portfolio/categories", ctrl.UpdateAssetCategories)
+	router.GET("/api/portfolio/rebalance", ctrl.PortfolioRebalance)
+
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "BTC", Amount: decimal.NewFromInt(2), TransactionType: "deposit", Timestamp: time.Now()}))
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "XYZ", Amount: decimal.NewFromInt(5), TransactionType: "deposit", Timestamp: time.Now()}))
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPut, "/api/portfolio/targets", `{"portfolio_id": 1, "targets": [{"symbol": "BTC", "weight": 50}, {"symbol": "SOL", "weight": 40}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrTargetsTotal.Error())
+
+	w = send(http.MethodPut, "/api/portfolio/targets", `{"portfolio_id": 1, "targets": [{"symbol": "btc", "weight": 50}, {"symbol": "SOL", "weight": 50}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = send(http.MethodPut, "/api/portfolio/categories", `{"categories": [{"symbol": "sol", "category": "L1"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = send(http.MethodGet, "/api/portfolio/rebalance?portfolio_id=1&fee_rate=2", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = send(http.MethodGet, "/api/portfolio/rebalance?portfolio_id=1&min_trade=1&fee_rate=0", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var plan RebalancePlan
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	assert.Equal(t, int64(1), plan.PortfolioID)
+	assert.Equal(t, []string{"XYZ"}, plan.Unpriced)
+	assert.InDelta(t, 200, plan.TotalValue, 1e-9)
+	require.Len(t, plan.Trades, 1)
+	assert.Equal(t, "BTC", plan.Trades[0].FromSymbol)
+	assert.Equal(t, "1", plan.Trades[0].FromAmount.String())
+	assert.Equal(t, "SOL", plan.Trades[0].ToSymbol)
+	assert.Equal(t, "10", plan.Trades[0].ToAmount.String())
+}
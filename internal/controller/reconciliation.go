@@ -0,0 +1,529 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/importexport"
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+	"hodlbook/pkg/types/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	// reconciliationWindow is how close to the reconciliation date a
+	// transaction must be to be suggested as a timing difference.
+	reconciliationWindow = 72 * time.Hour
+	// maxCandidates bounds the transactions suggested for each line.
+	maxCandidates = 10
+)
+
+type ReportedBalance struct {
+	Symbol string          `json:"symbol"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+type ReconciliationRequest struct {
+	PortfolioID int64             `json:"portfolio_id"`
+	AsOf        *time.Time        `json:"as_of"`
+	Notes       string            `json:"notes"`
+	Balances    []ReportedBalance `json:"balances"`
+}
+
+// ReconciliationCandidate is a transaction that could explain a difference.
+// Effect is what it adds to the recorded holdings of the line's symbol.
+type ReconciliationCandidate struct {
+	Type        string          `json:"type"`
+	ID          int64           `json:"id"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Description string          `json:"description"`
+	Effect      decimal.Decimal `json:"effect"`
+	Reason      string          `json:"reason"`
+}
+
+type ReconciliationLineReport struct {
+	models.ReconciliationLine
+	Candidates []ReconciliationCandidate `json:"candidates"`
+}
+
+// ReconciliationReport is a reconciliation with, for every line that does
+// not match, the transactions that could explain the difference.
+type ReconciliationReport struct {
+	ID          int64                      `json:"id"`
+	PortfolioID int64                      `json:"portfolio_id"`
+	AsOf        time.Time                  `json:"as_of"`
+	Source      string                     `json:"source"`
+	Notes       string                     `json:"notes"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	Lines       []ReconciliationLineReport `json:"lines"`
+}
+
+// reconcile compares reported balances with the holdings recorded for a
+// portfolio at asOf and stores the result. Balances reported twice for a
+// symbol are added up.
+func (c *Controller) reconcile(portfolioID int64, asOf time.Time, source, notes string, balances []ReportedBalance) (*models.Reconciliation, error) {
+	holdings, err := c.calculateHoldingsAtDate(portfolioID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]decimal.Decimal)
+	for _, b := range balances {
+		symbol := strings.ToUpper(strings.TrimSpace(b.Symbol))
+		reported[symbol] = reported[symbol].Add(b.Amount)
+	}
+
+	reconciliation := &models.Reconciliation{
+		PortfolioID: portfolioID,
+		AsOf:        asOf.UTC(),
+		Source:      source,
+		Notes:       strings.TrimSpace(notes),
+	}
+	for symbol, amount := range reported {
+		reconciliation.Lines = append(reconciliation.Lines, models.ReconciliationLine{
+			Symbol:     symbol,
+			Reported:   amount,
+			Recorded:   holdings[symbol],
+			Difference: amount.Sub(holdings[symbol]),
+		})
+	}
+	sort.Slice(reconciliation.Lines, func(i, j int) bool {
+		return reconciliation.Lines[i].Symbol < reconciliation.Lines[j].Symbol
+	})
+
+	if err := c.repo.CreateReconciliation(reconciliation); err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+// report suggests explanations for every unadjusted difference of a
+// reconciliation.
+func (c *Controller) report(reconciliation *models.Reconciliation) (*ReconciliationReport, error) {
+	assets, err := c.repo.GetAssetsByPortfolio(reconciliation.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := c.repo.GetExchangesByPortfolio(reconciliation.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconciliationReport{
+		ID:          reconciliation.ID,
+		PortfolioID: reconciliation.PortfolioID,
+		AsOf:        reconciliation.AsOf,
+		Source:      reconciliation.Source,
+		Notes:       reconciliation.Notes,
+		CreatedAt:   reconciliation.CreatedAt,
+		Lines:       make([]ReconciliationLineReport, 0, len(reconciliation.Lines)),
+	}
+	for _, line := range reconciliation.Lines {
+		item := ReconciliationLineReport{ReconciliationLine: line, Candidates: []ReconciliationCandidate{}}
+		if !line.Difference.IsZero() && line.AdjustmentAssetID == nil {
+			item.Candidates = explainDifference(line, reconciliation.AsOf, assets, exchanges)
+		}
+		report.Lines = append(report.Lines, item)
+	}
+	return report, nil
+}
+
+// explainDifference lists the transactions of a line's symbol that could
+// account for its difference: those whose effect matches it exactly, those
+// dated just after the reconciliation that would close it had they been
+// booked earlier, those dated just before it that the reporter may not have
+// settled yet, and exchange fees, which holdings do not deduct. Exact
+// matches come first, then the rest by distance from the reconciliation.
+func explainDifference(line models.ReconciliationLine, asOf time.Time, assets []models.Asset, exchanges []models.Exchange) []ReconciliationCandidate {
+	var candidates []ReconciliationCandidate
+	consider := func(candidate ReconciliationCandidate) {
+		switch {
+		case candidate.Effect.Abs().Equal(line.Difference.Abs()):
+			candidate.Reason = "amount matches the difference"
+		case candidate.Timestamp.After(asOf) && candidate.Timestamp.Sub(asOf) <= reconciliationWindow &&
+			candidate.Effect.Sign() == line.Difference.Sign():
+			candidate.Reason = "recorded shortly after the reconciliation date"
+		case !candidate.Timestamp.After(asOf) && asOf.Sub(candidate.Timestamp) <= reconciliationWindow &&
+			candidate.Effect.Sign() == -line.Difference.Sign():
+			candidate.Reason = "recorded shortly before the reconciliation date"
+		default:
+			return
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	for _, asset := range assets {
+		if asset.Symbol != line.Symbol {
+			continue
+		}
+		var effect decimal.Decimal
+		switch asset.TransactionType {
+		case "deposit":
+			effect = asset.Amount
+		case "withdraw":
+			effect = asset.Amount.Neg()
+		default:
+			continue
+		}
+		consider(ReconciliationCandidate{
+			Type:        "asset",
+			ID:          asset.ID,
+			Timestamp:   asset.Timestamp,
+			Description: asset.TransactionType + " " + asset.Amount.String() + " " + asset.Symbol,
+			Effect:      effect,
+		})
+	}
+
+	for _, ex := range exchanges {
+		description := ex.FromAmount.String() + " " + ex.FromSymbol + " to " + ex.ToAmount.String() + " " + ex.ToSymbol
+		if ex.FromSymbol == line.Symbol {
+			consider(ReconciliationCandidate{Type: "exchange", ID: ex.ID, Timestamp: ex.Timestamp, Description: description, Effect: ex.FromAmount.Neg()})
+		}
+		if ex.ToSymbol == line.Symbol {
+			consider(ReconciliationCandidate{Type: "exchange", ID: ex.ID, Timestamp: ex.Timestamp, Description: description, Effect: ex.ToAmount})
+		}
+		if strings.EqualFold(ex.FeeCurrency, line.Symbol) && ex.Fee.IsPositive() && line.Difference.IsNegative() && !ex.Timestamp.After(asOf) {
+			candidates = append(candidates, ReconciliationCandidate{
+				Type:        "exchange",
+				ID:          ex.ID,
+				Timestamp:   ex.Timestamp,
+				Description: description + ", fee " + ex.Fee.String() + " " + line.Symbol,
+				Effect:      ex.Fee.Neg(),
+				Reason:      "fee not deducted from holdings",
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iExact := candidates[i].Effect.Abs().Equal(line.Difference.Abs())
+		jExact := candidates[j].Effect.Abs().Equal(line.Difference.Abs())
+		if iExact != jExact {
+			return iExact
+		}
+		return absDuration(candidates[i].Timestamp.Sub(asOf)) < absDuration(candidates[j].Timestamp.Sub(asOf))
+	})
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	return candidates
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// ListReconciliations godoc
+// @Summary List reconciliations
+// @Description Get past reconciliations with their lines, newest first
+// @Tags reconciliations
+// @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
+// @Success 200 {array} models.Reconciliation
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations [get]
+func (c *Controller) ListReconciliations(ctx *gin.Context) {
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	reconciliations, err := c.repo.ListReconciliations(portfolioID)
+	if err != nil {
+		internalError(ctx, "failed to fetch reconciliations")
+		return
+	}
+	ctx.JSON(http.StatusOK, reconciliations)
+}
+
+// GetReconciliation godoc
+// @Summary Get a reconciliation report
+// @Description Get a reconciliation with the transactions that could explain each open difference
+// @Tags reconciliations
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Success 200 {object} ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id} [get]
+func (c *Controller) GetReconciliation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid reconciliation id")
+		return
+	}
+
+	reconciliation, err := c.repo.GetReconciliationByID(id)
+	if err != nil {
+		notFound(ctx, "reconciliation not found")
+		return
+	}
+
+	report, err := c.report(reconciliation)
+	if err != nil {
+		internalError(ctx, "failed to build reconciliation report")
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// CreateReconciliation godoc
+// @Summary Reconcile reported balances
+// @Description Compare balances reported by an exchange or wallet with the holdings recorded at as_of (default now) and store the result. Only reported symbols are compared.
+// @Tags reconciliations
+// @Accept json
+// @Produce json
+// @Param reconciliation body ReconciliationRequest true "Reported balances"
+// @Success 201 {object} ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations [post]
+func (c *Controller) CreateReconciliation(ctx *gin.Context) {
+	var req ReconciliationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	if len(req.Balances) == 0 {
+		badRequest(ctx, "at least one balance is required")
+		return
+	}
+	for _, b := range req.Balances {
+		if strings.TrimSpace(b.Symbol) == "" {
+			badRequest(ctx, "symbol is required")
+			return
+		}
+		if b.Amount.IsNegative() {
+			badRequest(ctx, "amount cannot be negative")
+			return
+		}
+	}
+
+	if req.PortfolioID == 0 {
+		req.PortfolioID = models.DefaultPortfolioID
+	}
+	if !c.portfolioExists(req.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	asOf := time.Now()
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+
+	reconciliation, err := c.reconcile(req.PortfolioID, asOf, "", req.Notes, req.Balances)
+	if err != nil {
+		internalError(ctx, "failed to reconcile balances")
+		return
+	}
+
+	report, err := c.report(reconciliation)
+	if err != nil {
+		internalError(ctx, "failed to build reconciliation report")
+		return
+	}
+	ctx.JSON(http.StatusCreated, report)
+}
+
+// ImportReconciliation godoc
+// @Summary Reconcile balances from a CSV file
+// @Description Upload reported balances as CSV with symbol and amount (or balance) columns, separated by semicolons or commas. An optional portfolio column holds a portfolio ID or name; rows without one use portfolio_id. One reconciliation is stored per portfolio.
+// @Tags reconciliations
+// @Accept multipart/form-data
+// @Produce json
+// @Param portfolio_id query int false "Portfolio of rows without a portfolio column (defaults to the default portfolio)"
+// @Param as_of query string false "Date of the balances, RFC 3339 or YYYY-MM-DD (defaults to now)"
+// @Param file formData file true "CSV file"
+// @Success 201 {array} ReconciliationReport
+// @Failure 400 {object} APIError
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/import [post]
+func (c *Controller) ImportReconciliation(ctx *gin.Context) {
+	defaultPortfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+	if defaultPortfolioID == 0 {
+		defaultPortfolioID = models.DefaultPortfolioID
+	}
+
+	asOf, ok := parseAsOf(ctx)
+	if !ok {
+		return
+	}
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		badRequest(ctx, "file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		badRequest(ctx, "failed to read file")
+		return
+	}
+
+	rows, rowErrors := importexport.ParseBalancesCSV(data)
+	if len(rowErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid balances", "errors": rowErrors})
+		return
+	}
+
+	portfolios, err := c.repo.ListPortfolios()
+	if err != nil {
+		internalError(ctx, "failed to fetch portfolios")
+		return
+	}
+
+	var order []int64
+	byPortfolio := make(map[int64][]ReportedBalance)
+	for _, row := range rows {
+		portfolioID := defaultPortfolioID
+		if row.Portfolio != "" {
+			portfolioID = findPortfolio(portfolios, row.Portfolio)
+			if portfolioID == 0 {
+				badRequestWithDetails(ctx, "portfolio not found", "row "+strconv.Itoa(row.Row)+": "+row.Portfolio)
+				return
+			}
+		}
+		if _, seen := byPortfolio[portfolioID]; !seen {
+			order = append(order, portfolioID)
+		}
+		byPortfolio[portfolioID] = append(byPortfolio[portfolioID], ReportedBalance{Symbol: row.Symbol, Amount: row.Amount})
+	}
+
+	reports := make([]*ReconciliationReport, 0, len(order))
+	for _, portfolioID := range order {
+		reconciliation, err := c.reconcile(portfolioID, asOf, header.Filename, "", byPortfolio[portfolioID])
+		if err != nil {
+			internalError(ctx, "failed to reconcile balances")
+			return
+		}
+		report, err := c.report(reconciliation)
+		if err != nil {
+			internalError(ctx, "failed to build reconciliation report")
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	ctx.JSON(http.StatusCreated, reports)
+}
+
+// parseAsOf reads the as_of query parameter. A bare date means the end of
+// that day in UTC.
+func parseAsOf(ctx *gin.Context) (time.Time, bool) {
+	raw := ctx.Query("as_of")
+	if raw == "" {
+		return time.Now(), true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t.Add(24*time.Hour - time.Second), true
+	}
+	badRequest(ctx, "as_of must be RFC 3339 or YYYY-MM-DD")
+	return time.Time{}, false
+}
+
+// findPortfolio resolves a portfolio ID or case-insensitive name, returning
+// zero when none matches.
+func findPortfolio(portfolios []models.Portfolio, ref string) int64 {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		for _, p := range portfolios {
+			if p.ID == id {
+				return id
+			}
+		}
+	}
+	for _, p := range portfolios {
+		if strings.EqualFold(p.Name, ref) {
+			return p.ID
+		}
+	}
+	return 0
+}
+
+// DeleteReconciliation godoc
+// @Summary Delete a reconciliation
+// @Description Delete a reconciliation from the history. Adjustments booked from it are kept.
+// @Tags reconciliations
+// @Param id path int true "Reconciliation ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id} [delete]
+func (c *Controller) DeleteReconciliation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid reconciliation id")
+		return
+	}
+
+	if err := c.repo.DeleteReconciliation(id); err != nil {
+		internalError(ctx, "failed to delete reconciliation")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AdjustReconciliationLine godoc
+// @Summary Book an adjusting entry
+// @Description Record the deposit or withdrawal that closes the difference of a reconciliation line, dated at the reconciliation
+// @Tags reconciliations
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Param line_id path int true "Line ID"
+// @Success 201 {object} models.Asset
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reconciliations/{id}/lines/{line_id}/adjust [post]
+func (c *Controller) AdjustReconciliationLine(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid reconciliation id")
+		return
+	}
+	lineID, err := strconv.ParseInt(ctx.Param("line_id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid line id")
+		return
+	}
+
+	asset, err := c.repo.AdjustReconciliationLine(id, lineID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		notFound(ctx, "reconciliation line not found")
+		return
+	case errors.Is(err, repo.ErrLineAdjusted), errors.Is(err, repo.ErrLineReconciled):
+		errorResponse(ctx, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		internalError(ctx, "failed to book adjustment")
+		return
+	}
+
+	c.publish(ctx.Request.Context(), events.TopicAssetCreated, *asset)
+	ctx.JSON(http.StatusCreated, asset)
+}
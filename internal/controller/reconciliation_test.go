@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReconciliationRouter(t *testing.T) (*gin.Engine, *repo.Repository) {
+	gin.SetMode(gin.TestMode)
+
+	repository := newTestRepository(t)
+
+	ctrl, err := New(WithRepository(repository))
+	require.NoError(t, err)
+
+	router := gin.New()
+	reconciliations := router.Group("/api/reconciliations")
+	reconciliations.GET("", ctrl.ListReconciliations)
+	reconciliations.POST("", ctrl.CreateReconciliation)
+	reconciliations.POST("/import", ctrl.ImportReconciliation)
+	reconciliations.GET("/:id", ctrl.GetReconciliation)
+	reconciliations.POST("/:id/lines/:line_id/adjust", ctrl.AdjustReconciliationLine)
+	return router, repository
+}
+
+func TestReconciliation_CreateExplainAndAdjust(t *testing.T) {
+	router, repository := newReconciliationRouter(t)
+
+	asOf := time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC)
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "BTC", Amount: decimal.RequireFromString("1"), TransactionType: "deposit", Timestamp: asOf.AddDate(0, -1, 0)}))
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "BTC", Amount: decimal.RequireFromString("0.2"), TransactionType: "deposit", Timestamp: asOf.Add(-time.Hour)}))
+	// Booked a day late: the exchange already counts it.
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "ETH", Amount: decimal.RequireFromString("3"), TransactionType: "deposit", Timestamp: asOf.Add(24 * time.Hour)}))
+
+	body := fmt.Sprintf(`{"as_of": %q, "balances": [{"symbol": "btc", "amount": "1"}, {"symbol": "ETH", "amount": 3}]}`, asOf.Format(time.RFC3339))
+	req := httptest.NewRequest(http.MethodPost, "/api/reconciliations", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var report ReconciliationReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Lines, 2)
+
+	btc, eth := report.Lines[0], report.Lines[1]
+	assert.Equal(t, "BTC", btc.Symbol)
+	assert.Equal(t, "1.2", btc.Recorded.String())
+	assert.Equal(t, "-0.2", btc.Difference.String())
+	require.NotEmpty(t, btc.Candidates)
+	assert.Equal(t, "amount matches the difference", btc.Candidates[0].Reason)
+
+	assert.Equal(t, "3", eth.Difference.String())
+	require.Len(t, eth.Candidates, 1)
+	assert.Equal(t, "amount matches the difference", eth.Candidates[0].Reason)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/reconciliations/%d/lines/%d/adjust", report.ID, btc.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var asset models.Asset
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &asset))
+	assert.Equal(t, "withdraw", asset.TransactionType)
+	assert.Equal(t, "0.2", asset.Amount.String())
+	assert.True(t, asOf.Equal(asset.Timestamp))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/reconciliations/%d/lines/%d/adjust", report.ID, btc.ID), nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reconciliations/%d", report.ID), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.NotNil(t, report.Lines[0].AdjustmentAssetID)
+	assert.Empty(t, report.Lines[0].Candidates, "adjusted lines need no explanation")
+}
+
+func TestReconciliation_ImportCSV(t *testing.T) {
+	router, repository := newReconciliationRouter(t)
+	require.NoError(t, repository.CreatePortfolio(&models.Portfolio{Name: "Kraken"}))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "balances.csv")
+	require.NoError(t, err)
+	part.Write([]byte("symbol,amount,portfolio\nBTC,0.5,kraken\nETH,2,\nETH,1,\n"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reconciliations/import?as_of=2024-06-30", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var reports []ReconciliationReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reports))
+	require.Len(t, reports, 2)
+	assert.Equal(t, int64(2), reports[0].PortfolioID)
+	assert.Equal(t, "balances.csv", reports[0].Source)
+	assert.Equal(t, time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC), reports[0].AsOf.UTC())
+	assert.Equal(t, models.DefaultPortfolioID, reports[1].PortfolioID)
+	require.Len(t, reports[1].Lines, 1)
+	assert.Equal(t, "3", reports[1].Lines[0].Reported.String(), "balances of a symbol are added up")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/reconciliations?portfolio_id=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var history []models.Reconciliation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history, 1)
+}
+
+func TestExplainDifference_FeesAndTiming(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	line := models.ReconciliationLine{Symbol: "ETH", Difference: decimal.RequireFromString("-0.01")}
+	exchanges := []models.Exchange{
+		{ID: 1, FromSymbol: "USDT", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(3000), ToAmount: decimal.NewFromInt(1), Fee: decimal.RequireFromString("0.004"), FeeCurrency: "ETH", Timestamp: asOf.AddDate(0, -1, 0)},
+	}
+	assets := []models.Asset{
+		{ID: 1, Symbol: "ETH", Amount: decimal.NewFromInt(5), TransactionType: "deposit", Timestamp: asOf.Add(-time.Hour)},
+		{ID: 2, Symbol: "ETH", Amount: decimal.NewFromInt(5), TransactionType: "deposit", Timestamp: asOf.AddDate(0, 0, -30)},
+		{ID: 3, Symbol: "ETH", Amount: decimal.NewFromInt(5), TransactionType: "withdraw", Timestamp: asOf.Add(-time.Hour)},
+	}
+
+	candidates := explainDifference(line, asOf, assets, exchanges)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, int64(1), candidates[0].ID)
+	assert.Equal(t, "recorded shortly before the reconciliation date", candidates[0].Reason)
+	assert.Equal(t, "fee not deducted from holdings", candidates[1].Reason)
+	assert.Equal(t, "-0.004", candidates[1].Effect.String())
+}
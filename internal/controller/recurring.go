@@ -0,0 +1,333 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+	"hodlbook/pkg/types/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type RecurringRequest struct {
+	PortfolioID         int64           `json:"portfolio_id"`
+	Name                string          `json:"name"`
+	Type                string          `json:"type"`
+	Symbol              string          `json:"symbol"`
+	FromSymbol          string          `json:"from_symbol"`
+	Amount              decimal.Decimal `json:"amount"`
+	AmountUnit          string          `json:"amount_unit"`
+	Cadence             string          `json:"cadence"`
+	StartAt             time.Time       `json:"start_at"`
+	EndAt               *time.Time      `json:"end_at"`
+	RequireConfirmation bool            `json:"require_confirmation"`
+	Enabled             *bool           `json:"enabled"`
+}
+
+func (r *RecurringRequest) apply(recurring *models.RecurringTransaction) {
+	recurring.PortfolioID = r.PortfolioID
+	if recurring.PortfolioID == 0 {
+		recurring.PortfolioID = models.DefaultPortfolioID
+	}
+	recurring.Name = strings.TrimSpace(r.Name)
+	recurring.Type = r.Type
+	recurring.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	recurring.FromSymbol = strings.ToUpper(strings.TrimSpace(r.FromSymbol))
+	if recurring.Type != "exchange" {
+		recurring.FromSymbol = ""
+	}
+	recurring.Amount = r.Amount
+	recurring.AmountUnit = r.AmountUnit
+	if recurring.AmountUnit == "" {
+		recurring.AmountUnit = models.RecurringAmountCoin
+	}
+	recurring.Cadence = r.Cadence
+	recurring.StartAt = r.StartAt.UTC()
+	recurring.EndAt = nil
+	if r.EndAt != nil {
+		end := r.EndAt.UTC()
+		recurring.EndAt = &end
+	}
+	recurring.RequireConfirmation = r.RequireConfirmation
+	if r.Enabled != nil {
+		recurring.Enabled = *r.Enabled
+	}
+}
+
+// ListRecurring godoc
+// @Summary List recurring transactions
+// @Description Get recurring transactions such as DCA schedules
+// @Tags recurring
+// @Produce json
+// @Param portfolio_id query int false "Portfolio ID (omit for all portfolios)"
+// @Success 200 {array} models.RecurringTransaction
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring [get]
+func (c *Controller) ListRecurring(ctx *gin.Context) {
+	portfolioID, ok := c.portfolioIDQuery(ctx)
+	if !ok {
+		return
+	}
+
+	list, err := c.repo.ListRecurring(portfolioID)
+	if err != nil {
+		internalError(ctx, "failed to fetch recurring transactions")
+		return
+	}
+	ctx.JSON(http.StatusOK, list)
+}
+
+// GetRecurring godoc
+// @Summary Get a recurring transaction by ID
+// @Description Get a single recurring transaction by its ID
+// @Tags recurring
+// @Produce json
+// @Param id path int true "Recurring transaction ID"
+// @Success 200 {object} models.RecurringTransaction
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/recurring/{id} [get]
+func (c *Controller) GetRecurring(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid recurring transaction id")
+		return
+	}
+
+	recurring, err := c.repo.GetRecurringByID(id)
+	if err != nil {
+		notFound(ctx, "recurring transaction not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, recurring)
+}
+
+// CreateRecurring godoc
+// @Summary Create a recurring transaction
+// @Description Schedule a deposit, withdrawal or exchange (from_symbol into symbol) every day, week, two weeks or month from start_at until end_at. The amount is a quantity of symbol (amount_unit coin, default) or a value in the reference currency (fiat). Occurrences before now are booked on the next run at their historic price. With require_confirmation occurrences wait as pending runs.
+// @Tags recurring
+// @Accept json
+// @Produce json
+// @Param recurring body RecurringRequest true "Recurring transaction"
+// @Success 201 {object} models.RecurringTransaction
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring [post]
+func (c *Controller) CreateRecurring(ctx *gin.Context) {
+	var req RecurringRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	recurring := models.RecurringTransaction{Enabled: true}
+	req.apply(&recurring)
+	if err := recurring.Validate(); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+	if !c.portfolioExists(recurring.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	recurring.Reschedule(recurring.StartAt)
+	if err := c.repo.CreateRecurring(&recurring); err != nil {
+		internalError(ctx, "failed to create recurring transaction")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, recurring)
+}
+
+// UpdateRecurring godoc
+// @Summary Update a recurring transaction
+// @Description Update a recurring transaction by its ID. Changing its start or cadence moves it to the next occurrence from now; past occurrences are not booked again.
+// @Tags recurring
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring transaction ID"
+// @Param recurring body RecurringRequest true "Recurring transaction"
+// @Success 200 {object} models.RecurringTransaction
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring/{id} [put]
+func (c *Controller) UpdateRecurring(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid recurring transaction id")
+		return
+	}
+
+	recurring, err := c.repo.GetRecurringByID(id)
+	if err != nil {
+		notFound(ctx, "recurring transaction not found")
+		return
+	}
+
+	var req RecurringRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	start, cadence := recurring.StartAt, recurring.Cadence
+	req.apply(recurring)
+	if err := recurring.Validate(); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+	if !c.portfolioExists(recurring.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if !recurring.StartAt.Equal(start) || recurring.Cadence != cadence {
+		recurring.Reschedule(time.Now())
+	}
+	if err := c.repo.UpdateRecurring(recurring); err != nil {
+		internalError(ctx, "failed to update recurring transaction")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recurring)
+}
+
+// DeleteRecurring godoc
+// @Summary Delete a recurring transaction
+// @Description Stop a recurring transaction and drop its pending runs. Entries it already booked are kept.
+// @Tags recurring
+// @Param id path int true "Recurring transaction ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring/{id} [delete]
+func (c *Controller) DeleteRecurring(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid recurring transaction id")
+		return
+	}
+
+	if err := c.repo.DeleteRecurring(id); err != nil {
+		internalError(ctx, "failed to delete recurring transaction")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListRecurringRuns godoc
+// @Summary List recurring runs
+// @Description Get the occurrences of every recurring transaction, or of one when recurring_id is given, newest first
+// @Tags recurring
+// @Produce json
+// @Param recurring_id query int false "Recurring transaction ID"
+// @Param status query string false "Filter by status (pending, executed, skipped)"
+// @Success 200 {array} models.RecurringRun
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring/runs [get]
+func (c *Controller) ListRecurringRuns(ctx *gin.Context) {
+	var recurringID int64
+	if idStr := ctx.Query("recurring_id"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			badRequest(ctx, "invalid recurring transaction id")
+			return
+		}
+		recurringID = id
+	}
+
+	runs, err := c.repo.ListRecurringRuns(recurringID, ctx.Query("status"))
+	if err != nil {
+		internalError(ctx, "failed to fetch recurring runs")
+		return
+	}
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// ConfirmRecurringRun godoc
+// @Summary Confirm a pending recurring run
+// @Description Book the entry of a pending run at its scheduled time
+// @Tags recurring
+// @Produce json
+// @Param run_id path int true "Run ID"
+// @Success 200 {object} models.RecurringRun
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring/runs/{run_id}/confirm [post]
+func (c *Controller) ConfirmRecurringRun(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("run_id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid run id")
+		return
+	}
+
+	run, err := c.repo.ConfirmRecurringRun(id, time.Now())
+	if err != nil {
+		recurringRunError(ctx, err, "failed to confirm run")
+		return
+	}
+
+	switch {
+	case run.AssetID != nil:
+		if asset, err := c.repo.GetAssetByID(*run.AssetID); err == nil {
+			c.publish(ctx.Request.Context(), events.TopicAssetCreated, *asset)
+		}
+	case run.ExchangeID != nil:
+		if exchange, err := c.repo.GetExchangeByID(*run.ExchangeID); err == nil {
+			c.publish(ctx.Request.Context(), events.TopicExchangeCreated, *exchange)
+		}
+	}
+	ctx.JSON(http.StatusOK, run)
+}
+
+// SkipRecurringRun godoc
+// @Summary Skip a pending recurring run
+// @Description Close a pending run without booking anything
+// @Tags recurring
+// @Produce json
+// @Param run_id path int true "Run ID"
+// @Success 200 {object} models.RecurringRun
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recurring/runs/{run_id}/skip [post]
+func (c *Controller) SkipRecurringRun(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("run_id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid run id")
+		return
+	}
+
+	run, err := c.repo.SkipRecurringRun(id, time.Now())
+	if err != nil {
+		recurringRunError(ctx, err, "failed to skip run")
+		return
+	}
+	ctx.JSON(http.StatusOK, run)
+}
+
+func recurringRunError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		notFound(ctx, "run not found")
+	case errors.Is(err, repo.ErrRunNotPending):
+		errorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		internalError(ctx, message)
+	}
+}
@@ -0,0 +1,553 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StrategyDCA            = "dca"
+	StrategyLumpSum        = "lump_sum"
+	StrategyValueAveraging = "value_averaging"
+	StrategyRebalance      = "rebalance"
+)
+
+const (
+	// defaultSimulationDays is the range simulated when no start is given.
+	defaultSimulationDays = 365
+	// maxSimulationDays bounds the range of a simulation.
+	maxSimulationDays = 3650
+	// maxSimulationStrategies bounds the strategies compared at once.
+	maxSimulationStrategies = 10
+)
+
+var (
+	ErrSimulationNoStrategies      = errors.New("at least one strategy is required")
+	ErrSimulationTooManyStrategies = fmt.Errorf("at most %d strategies can be compared", maxSimulationStrategies)
+	ErrSimulationInvalidType       = errors.New("strategy type must be dca, lump_sum, value_averaging or rebalance")
+	ErrSimulationInvalidAmount     = errors.New("strategy amount must be positive")
+	ErrSimulationInvalidCadence    = errors.New("strategy cadence must be daily, weekly, biweekly or monthly")
+	ErrSimulationNoTargets         = errors.New("strategy targets are required")
+	ErrSimulationInvalidWeight     = errors.New("target weights must be positive")
+	ErrSimulationInvalidRange      = errors.New("end must not be before start")
+	ErrSimulationRangeTooLong      = fmt.Errorf("a simulation covers at most %d days", maxSimulationDays)
+)
+
+// StrategyRequest describes a hypothetical strategy. Amount is in the
+// reference currency: the sum invested once for lump_sum and rebalance, the
+// contribution of every period for dca, and the growth of the target value
+// every period for value_averaging. Targets are weights by symbol and are
+// normalised to add up to one.
+type StrategyRequest struct {
+	Name    string             `json:"name"`
+	Type    string             `json:"type"`
+	Amount  float64            `json:"amount"`
+	Cadence string             `json:"cadence"`
+	Targets map[string]float64 `json:"targets"`
+}
+
+type SimulationRequest struct {
+	PortfolioID int64             `json:"portfolio_id"`
+	Start       *time.Time        `json:"start"`
+	End         *time.Time        `json:"end"`
+	Strategies  []StrategyRequest `json:"strategies"`
+}
+
+type SimulationPoint struct {
+	Date     string  `json:"date"`
+	Invested float64 `json:"invested"`
+	Value    float64 `json:"value"`
+}
+
+// StrategyResult is the value curve of a strategy. Invested is the net
+// amount put in, which value averaging lowers when it sells.
+type StrategyResult struct {
+	Name       string             `json:"name"`
+	Type       string             `json:"type"`
+	Cadence    string             `json:"cadence,omitempty"`
+	Invested   float64            `json:"invested"`
+	FinalValue float64            `json:"final_value"`
+	ProfitLoss float64            `json:"profit_loss"`
+	ReturnPct  float64            `json:"return_percent"`
+	Trades     int                `json:"trades"`
+	Holdings   map[string]float64 `json:"holdings"`
+	Points     []SimulationPoint  `json:"points"`
+}
+
+// SimulationResult compares the strategies with the value of the actual
+// portfolio over the same days. Start is moved forward to the first day
+// every strategy symbol has a price.
+type SimulationResult struct {
+	PortfolioID int64            `json:"portfolio_id"`
+	Start       string           `json:"start"`
+	End         string           `json:"end"`
+	Strategies  []StrategyResult `json:"strategies"`
+	Actual      []HistoryPoint   `json:"actual"`
+}
+
+// normalize validates the request and fills in its defaults: the last year
+// up to today, a monthly cadence and upper case target symbols.
+func (r *SimulationRequest) normalize(now time.Time) error {
+	if len(r.Strategies) == 0 {
+		return ErrSimulationNoStrategies
+	}
+	if len(r.Strategies) > maxSimulationStrategies {
+		return ErrSimulationTooManyStrategies
+	}
+
+	end := simulationDay(now)
+	if r.End != nil {
+		end = simulationDay(*r.End)
+	}
+	start := end.AddDate(0, 0, -(defaultSimulationDays - 1))
+	if r.Start != nil {
+		start = simulationDay(*r.Start)
+	}
+	if end.Before(start) {
+		return ErrSimulationInvalidRange
+	}
+	if int(end.Sub(start).Hours()/24) >= maxSimulationDays {
+		return ErrSimulationRangeTooLong
+	}
+	r.Start, r.End = &start, &end
+
+	for i := range r.Strategies {
+		strategy := &r.Strategies[i]
+		switch strategy.Type {
+		case StrategyDCA, StrategyLumpSum, StrategyValueAveraging, StrategyRebalance:
+		default:
+			return ErrSimulationInvalidType
+		}
+		if strategy.Amount <= 0 {
+			return ErrSimulationInvalidAmount
+		}
+
+		if strategy.Type == StrategyLumpSum {
+			strategy.Cadence = ""
+		} else if strategy.Cadence == "" {
+			strategy.Cadence = models.CadenceMonthly
+		}
+		switch strategy.Cadence {
+		case "", models.CadenceDaily, models.CadenceWeekly, models.CadenceBiweekly, models.CadenceMonthly:
+		default:
+			return ErrSimulationInvalidCadence
+		}
+
+		if len(strategy.Targets) == 0 {
+			return ErrSimulationNoTargets
+		}
+		var total float64
+		targets := make(map[string]float64, len(strategy.Targets))
+		for symbol, weight := range strategy.Targets {
+			if weight <= 0 {
+				return ErrSimulationInvalidWeight
+			}
+			targets[strings.ToUpper(strings.TrimSpace(symbol))] += weight
+			total += weight
+		}
+		for symbol := range targets {
+			targets[symbol] /= total
+		}
+		strategy.Targets = targets
+
+		strategy.Name = strings.TrimSpace(strategy.Name)
+		if strategy.Name == "" {
+			strategy.Name = fmt.Sprintf("Strategy %d", i+1)
+		}
+	}
+	return nil
+}
+
+// RunSimulation godoc
+// @Summary Simulate investment strategies
+// @Description Replay hypothetical strategies over the stored daily prices between start and end (default: the last 365 days) and compare their value with the actual portfolio. Strategies are dca (amount every period), lump_sum (amount once), value_averaging (target value growing by amount every period, buying or selling the difference) and rebalance (amount once, brought back to the target weights every period). Use format=csv to download the value curves.
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "Response format (json or csv)"
+// @Param simulation body SimulationRequest true "Simulation"
+// @Success 200 {object} SimulationResult
+// @Failure 400 {object} APIError
+// @Failure 500 {object} APIError
+// @Router /api/simulations [post]
+func (c *Controller) RunSimulation(ctx *gin.Context) {
+	format := strings.ToLower(ctx.DefaultQuery("format", "json"))
+	if format != "csv" && format != "json" {
+		badRequest(ctx, "format must be csv or json")
+		return
+	}
+
+	var req SimulationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+	if err := req.normalize(time.Now().UTC()); err != nil {
+		badRequest(ctx, err.Error())
+		return
+	}
+	if req.PortfolioID > 0 && !c.portfolioExists(req.PortfolioID) {
+		notFound(ctx, "portfolio not found")
+		return
+	}
+
+	result, err := c.simulate(&req)
+	if err != nil {
+		var missing *missingPriceError
+		if errors.As(err, &missing) {
+			badRequest(ctx, err.Error())
+			return
+		}
+		internalError(ctx, "failed to run simulation")
+		return
+	}
+
+	if format == "json" {
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+
+	filename := fmt.Sprintf("simulation_%s_%s.csv", result.Start, result.End)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Data(http.StatusOK, "text/csv", simulationToCSV(result))
+}
+
+type missingPriceError struct {
+	symbol string
+}
+
+func (e *missingPriceError) Error() string {
+	return "no price history for " + e.symbol + " in the simulated range"
+}
+
+// simulate prices every strategy symbol, and every symbol the portfolio
+// held, on each day of the range and replays the strategies.
+func (c *Controller) simulate(req *SimulationRequest) (*SimulationResult, error) {
+	days := simulationDays(*req.Start, *req.End)
+
+	changes, err := c.holdingChanges(req.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string][]float64)
+	load := func(symbol string) error {
+		if _, ok := prices[symbol]; ok {
+			return nil
+		}
+		history, err := c.repo.SelectAllBySymbol(symbol)
+		if err != nil {
+			return err
+		}
+		prices[symbol] = dailyPrices(symbol, history, days)
+		return nil
+	}
+	for _, strategy := range req.Strategies {
+		for symbol := range strategy.Targets {
+			if err := load(symbol); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	first := 0
+	for _, strategy := range req.Strategies {
+		for symbol := range strategy.Targets {
+			known := firstPricedDay(prices[symbol])
+			if known < 0 {
+				return nil, &missingPriceError{symbol: symbol}
+			}
+			if known > first {
+				first = known
+			}
+		}
+	}
+	for symbol := range prices {
+		prices[symbol] = prices[symbol][first:]
+	}
+	days = days[first:]
+
+	for _, change := range changes {
+		if err := load(change.symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SimulationResult{
+		PortfolioID: req.PortfolioID,
+		Start:       days[0].Format("2006-01-02"),
+		End:         days[len(days)-1].Format("2006-01-02"),
+		Strategies:  make([]StrategyResult, 0, len(req.Strategies)),
+		Actual:      actualHistory(changes, prices, days),
+	}
+	for _, strategy := range req.Strategies {
+		result.Strategies = append(result.Strategies, simulateStrategy(strategy, prices, days))
+	}
+	return result, nil
+}
+
+// simulateStrategy replays a strategy day by day. Periodic contributions and
+// rebalances happen on the days of its cadence counted from the first day,
+// at the price of that day.
+func simulateStrategy(strategy StrategyRequest, prices map[string][]float64, days []time.Time) StrategyResult {
+	result := StrategyResult{
+		Name:     strategy.Name,
+		Type:     strategy.Type,
+		Cadence:  strategy.Cadence,
+		Holdings: make(map[string]float64, len(strategy.Targets)),
+		Points:   make([]SimulationPoint, 0, len(days)),
+	}
+
+	valueAt := func(day int) float64 {
+		var value float64
+		for symbol, amount := range result.Holdings {
+			value += amount * prices[symbol][day]
+		}
+		return value
+	}
+	buy := func(day int, value float64) {
+		for symbol, weight := range strategy.Targets {
+			result.Holdings[symbol] += value * weight / prices[symbol][day]
+		}
+		result.Invested += value
+		result.Trades++
+	}
+
+	periods := periodDays(days, strategy.Cadence)
+	period := 0
+	for day, date := range days {
+		isPeriod := periods[day]
+		if isPeriod {
+			period++
+		}
+
+		switch strategy.Type {
+		case StrategyLumpSum:
+			if day == 0 {
+				buy(day, strategy.Amount)
+			}
+		case StrategyDCA:
+			if isPeriod {
+				buy(day, strategy.Amount)
+			}
+		case StrategyValueAveraging:
+			if isPeriod {
+				current := valueAt(day)
+				diff := strategy.Amount*float64(period) - current
+				switch {
+				case diff > 0:
+					buy(day, diff)
+				case diff < 0 && current > 0:
+					// Sell across the holdings as they are, so that no
+					// symbol goes short when the weights have drifted.
+					for symbol := range result.Holdings {
+						result.Holdings[symbol] *= 1 + diff/current
+					}
+					result.Invested += diff
+					result.Trades++
+				}
+			}
+		case StrategyRebalance:
+			if day == 0 {
+				buy(day, strategy.Amount)
+			} else if isPeriod {
+				total := valueAt(day)
+				for symbol, weight := range strategy.Targets {
+					result.Holdings[symbol] = total * weight / prices[symbol][day]
+				}
+				result.Trades++
+			}
+		}
+
+		result.Points = append(result.Points, SimulationPoint{
+			Date:     date.Format("2006-01-02"),
+			Invested: result.Invested,
+			Value:    valueAt(day),
+		})
+	}
+
+	result.FinalValue = result.Points[len(result.Points)-1].Value
+	result.ProfitLoss = result.FinalValue - result.Invested
+	if result.Invested > 0 {
+		result.ReturnPct = result.ProfitLoss / result.Invested * 100
+	}
+	return result
+}
+
+type holdingChange struct {
+	timestamp time.Time
+	symbol    string
+	amount    float64
+}
+
+// holdingChanges lists what every deposit, withdrawal and exchange of a
+// portfolio did to its holdings, oldest first.
+func (c *Controller) holdingChanges(portfolioID int64) ([]holdingChange, error) {
+	assets, err := c.repo.GetAssetsByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := c.repo.GetExchangesByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]holdingChange, 0, len(assets)+2*len(exchanges))
+	for _, asset := range assets {
+		switch asset.TransactionType {
+		case "deposit":
+			changes = append(changes, holdingChange{asset.Timestamp, asset.Symbol, asset.Amount.InexactFloat64()})
+		case "withdraw":
+			changes = append(changes, holdingChange{asset.Timestamp, asset.Symbol, -asset.Amount.InexactFloat64()})
+		}
+	}
+	for _, ex := range exchanges {
+		changes = append(changes,
+			holdingChange{ex.Timestamp, ex.FromSymbol, -ex.FromAmount.InexactFloat64()},
+			holdingChange{ex.Timestamp, ex.ToSymbol, ex.ToAmount.InexactFloat64()},
+		)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].timestamp.Before(changes[j].timestamp)
+	})
+	return changes, nil
+}
+
+// actualHistory values the holdings of the portfolio at the end of each day.
+func actualHistory(changes []holdingChange, prices map[string][]float64, days []time.Time) []HistoryPoint {
+	holdings := make(map[string]float64)
+	points := make([]HistoryPoint, 0, len(days))
+	next := 0
+	for day, date := range days {
+		endOfDay := date.AddDate(0, 0, 1)
+		for next < len(changes) && changes[next].timestamp.Before(endOfDay) {
+			holdings[changes[next].symbol] += changes[next].amount
+			next++
+		}
+
+		var value float64
+		for symbol, amount := range holdings {
+			if amount > 0 {
+				value += amount * prices[symbol][day]
+			}
+		}
+		points = append(points, HistoryPoint{Date: date.Format("2006-01-02"), Value: value})
+	}
+	return points
+}
+
+// dailyPrices returns the price of a symbol on each day, carrying the last
+// known value forward over days without a snapshot. Days before the first
+// snapshot are priced zero.
+func dailyPrices(symbol string, history []models.AssetHistoricValue, days []time.Time) []float64 {
+	prices := make([]float64, len(days))
+	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
+		for i := range prices {
+			prices[i] = 1
+		}
+		return prices
+	}
+
+	sorted := make([]models.AssetHistoricValue, 0, len(history))
+	for _, h := range history {
+		if h.Value > 0 {
+			sorted = append(sorted, h)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var last float64
+	next := 0
+	for i, date := range days {
+		endOfDay := date.AddDate(0, 0, 1)
+		for next < len(sorted) && sorted[next].Timestamp.Before(endOfDay) {
+			last = sorted[next].Value
+			next++
+		}
+		prices[i] = last
+	}
+	return prices
+}
+
+func firstPricedDay(prices []float64) int {
+	for i, price := range prices {
+		if price > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// periodDays flags the days a periodic strategy acts on: the first day and
+// every occurrence of its cadence after it.
+func periodDays(days []time.Time, cadence string) []bool {
+	periods := make([]bool, len(days))
+	if cadence == "" {
+		return periods
+	}
+	for n := 0; ; n++ {
+		at := models.CadenceOccurrence(days[0], cadence, n)
+		day := int(at.Sub(days[0]).Hours() / 24)
+		if day >= len(days) {
+			return periods
+		}
+		periods[day] = true
+	}
+}
+
+func simulationDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func simulationDays(start, end time.Time) []time.Time {
+	days := make([]time.Time, 0, int(end.Sub(start).Hours()/24)+1)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// simulationToCSV writes one row per day with the invested amount and value
+// of every strategy followed by the value of the actual portfolio.
+func simulationToCSV(result *SimulationResult) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"date"}
+	for _, strategy := range result.Strategies {
+		header = append(header, strategy.Name+" invested", strategy.Name+" value")
+	}
+	header = append(header, "actual value")
+	w.Write(header)
+
+	for i, point := range result.Actual {
+		row := []string{point.Date}
+		for _, strategy := range result.Strategies {
+			row = append(row,
+				strconv.FormatFloat(strategy.Points[i].Invested, 'f', 2, 64),
+				strconv.FormatFloat(strategy.Points[i].Value, 'f', 2, 64),
+			)
+		}
+		row = append(row, strconv.FormatFloat(point.Value, 'f', 2, 64))
+		w.Write(row)
+	}
+
+	w.Flush()
+	return buf.Bytes()
+}
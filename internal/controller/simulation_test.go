@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulationRouter(t *testing.T) (*gin.Engine, *repo.Repository) {
+	gin.SetMode(gin.TestMode)
+
+	repository := newTestRepository(t)
+
+	ctrl, err := New(WithRepository(repository))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/api/simulations", ctrl.RunSimulation)
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	for _, v := range []models.AssetHistoricValue{
+		{Symbol: "BTC", Value: 100, Timestamp: day(time.January, 1)},
+		{Symbol: "BTC", Value: 200, Timestamp: day(time.February, 1)},
+		{Symbol: "BTC", Value: 100, Timestamp: day(time.March, 1)},
+		{Symbol: "ETH", Value: 10, Timestamp: day(time.January, 1)},
+	} {
+		require.NoError(t, repository.Insert(&v))
+	}
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "deposit", Timestamp: day(time.January, 15).Add(12 * time.Hour)}))
+	return router, repository
+}
+
+func postSimulation(router *gin.Engine, query, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/simulations"+query, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+const simulationBody = `{
+	"start": "2023-12-25T00:00:00Z",
+	"end": "2024-03-01T00:00:00Z",
+	"strategies": [
+		{"name": "Lump sum", "type": "lump_sum", "amount": 1000, "targets": {"btc": 1}},
+		{"name": "DCA", "type": "dca", "amount": 100, "targets": {"BTC": 1}},
+		{"name": "Value averaging", "type": "value_averaging", "amount": 100, "targets": {"BTC": 1}},
+		{"name": "Rebalance", "type": "rebalance", "amount": 1000, "cadence": "monthly", "targets": {"BTC": 50, "ETH": 50}}
+	]
+}`
+
+func TestSimulation_Strategies(t *testing.T) {
+	router, _ := newSimulationRouter(t)
+
+	w := postSimulation(router, "", simulationBody)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var result SimulationResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "2024-01-01", result.Start, "the simulation starts when every symbol has a price")
+	assert.Equal(t, "2024-03-01", result.End)
+	require.Len(t, result.Strategies, 4)
+
+	lump, dca, va, rebalance := result.Strategies[0], result.Strategies[1], result.Strategies[2], result.Strategies[3]
+	assert.InDelta(t, 1000, lump.Invested, 1e-9)
+	assert.InDelta(t, 1000, lump.FinalValue, 1e-9)
+	assert.InDelta(t, 2000, lump.Points[31].Value, 1e-9)
+
+	assert.Equal(t, models.CadenceMonthly, dca.Cadence)
+	assert.Equal(t, 3, dca.Trades)
+	assert.InDelta(t, 2.5, dca.Holdings["BTC"], 1e-9)
+	assert.InDelta(t, 300, dca.Invested, 1e-9)
+	assert.InDelta(t, -50, dca.ProfitLoss, 1e-9)
+	assert.InDelta(t, -100.0/6, dca.ReturnPct, 1e-9)
+
+	assert.Equal(t, 2, va.Trades, "no trade when the value is on target")
+	assert.InDelta(t, 3, va.Holdings["BTC"], 1e-9)
+	assert.InDelta(t, 300, va.FinalValue, 1e-9)
+
+	assert.InDelta(t, 5.625, rebalance.Holdings["BTC"], 1e-9)
+	assert.InDelta(t, 56.25, rebalance.Holdings["ETH"], 1e-9)
+	assert.InDelta(t, 1125, rebalance.FinalValue, 1e-9)
+
+	require.Len(t, result.Actual, 61)
+	assert.Zero(t, result.Actual[13].Value)
+	assert.InDelta(t, 100, result.Actual[14].Value, 1e-9)
+	assert.InDelta(t, 200, result.Actual[31].Value, 1e-9)
+}
+
+func TestSimulation_ValueAveragingSells(t *testing.T) {
+	router, _ := newSimulationRouter(t)
+
+	body := `{"start": "2024-01-18T00:00:00Z", "end": "2024-02-01T00:00:00Z", "strategies": [
+		{"type": "value_averaging", "amount": 100, "cadence": "weekly", "targets": {"BTC": 1}}
+	]}`
+	w := postSimulation(router, "", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var result SimulationResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	va := result.Strategies[0]
+	assert.Equal(t, "Strategy 1", va.Name)
+	assert.Equal(t, 3, va.Trades)
+	assert.InDelta(t, 1.5, va.Holdings["BTC"], 1e-9, "the doubled price overshoots the 300 target")
+	assert.InDelta(t, 100, va.Invested, 1e-9)
+	assert.InDelta(t, 300, va.FinalValue, 1e-9)
+}
+
+func TestSimulation_CSV(t *testing.T) {
+	router, _ := newSimulationRouter(t)
+
+	w := postSimulation(router, "?format=csv", simulationBody)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "simulation_2024-01-01_2024-03-01.csv")
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 62)
+	assert.Equal(t, []string{"date", "Lump sum invested", "Lump sum value", "DCA invested", "DCA value",
+		"Value averaging invested", "Value averaging value", "Rebalance invested", "Rebalance value", "actual value"}, rows[0])
+	assert.Equal(t, []string{"2024-03-01", "1000.00", "1000.00", "300.00", "250.00", "300.00", "300.00", "1000.00", "1125.00", "100.00"}, rows[61])
+}
+
+func TestSimulation_Invalid(t *testing.T) {
+	router, _ := newSimulationRouter(t)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no strategies", `{"strategies": []}`, ErrSimulationNoStrategies.Error()},
+		{"unknown type", `{"strategies": [{"type": "martingale", "amount": 1, "targets": {"BTC": 1}}]}`, ErrSimulationInvalidType.Error()},
+		{"no targets", `{"strategies": [{"type": "dca", "amount": 1}]}`, ErrSimulationNoTargets.Error()},
+		{"bad cadence", `{"strategies": [{"type": "dca", "amount": 1, "cadence": "yearly", "targets": {"BTC": 1}}]}`, ErrSimulationInvalidCadence.Error()},
+		{"reversed range", `{"start": "2024-02-01T00:00:00Z", "end": "2024-01-01T00:00:00Z", "strategies": [{"type": "dca", "amount": 1, "targets": {"BTC": 1}}]}`, ErrSimulationInvalidRange.Error()},
+		{"no history", `{"end": "2024-03-01T00:00:00Z", "strategies": [{"type": "dca", "amount": 1, "targets": {"SOL": 1}}]}`, "no price history for SOL in the simulated range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postSimulation(router, "", tt.body)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			var apiErr APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+			assert.Equal(t, tt.want, apiErr.Error)
+		})
+	}
+}
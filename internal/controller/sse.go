@@ -1,36 +1,25 @@
 package controller
 
 import (
-	"io"
+	"time"
+
+	"hodlbook/pkg/integrations/broadcast"
 
 	"github.com/gin-gonic/gin"
 )
 
+var sseHeartbeatInterval = 15 * time.Second
+
 // SSEPrices godoc
 // @Summary Stream live prices
-// @Description Server-Sent Events endpoint for real-time price updates
+// @Description Server-Sent Events endpoint for real-time price updates. New clients receive the latest prices immediately; reconnecting clients resume from the Last-Event-ID header or last_event_id query parameter.
 // @Tags prices
 // @Produce text/event-stream
+// @Param last_event_id query int false "Resume after this event ID"
 // @Success 200 {string} string "SSE stream"
 // @Router /api/prices/stream [get]
-func SSEPrices(priceCh <-chan []byte) gin.HandlerFunc {
+func SSEPrices(hub *broadcast.Hub) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Content-Type", "text/event-stream")
-		c.Header("Cache-Control", "no-cache")
-		c.Header("Connection", "keep-alive")
-
-		c.Stream(func(w io.Writer) bool {
-			select {
-			case msg, ok := <-priceCh:
-				if !ok {
-					return false
-				}
-				c.SSEvent("prices", string(msg))
-				c.Writer.Flush()
-				return true
-			case <-c.Request.Context().Done():
-				return false
-			}
-		})
+		hub.Stream(c.Writer, c.Request, sseHeartbeatInterval)
 	}
 }
@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+	"hodlbook/pkg/integrations/wallets/evm"
+	"hodlbook/pkg/integrations/wallets/hdkey"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WalletRequest struct {
+	PortfolioID int64  `json:"portfolio_id"`
+	Name        string `json:"name"`
+	Chain       string `json:"chain"`
+	Address     string `json:"address"`
+	Tokens      string `json:"tokens"`
+	Mode        string `json:"mode"`
+}
+
+func (r *WalletRequest) apply(wallet *models.Wallet) {
+	wallet.PortfolioID = r.PortfolioID
+	if wallet.PortfolioID == 0 {
+		wallet.PortfolioID = models.DefaultPortfolioID
+	}
+	wallet.Name = strings.TrimSpace(r.Name)
+	wallet.Chain = strings.ToLower(strings.TrimSpace(r.Chain))
+	wallet.Address = strings.TrimSpace(r.Address)
+	wallet.Tokens = strings.TrimSpace(r.Tokens)
+	wallet.Mode = r.Mode
+	if wallet.Mode == "" {
+		wallet.Mode = models.WalletModePropose
+	}
+}
+
+// WalletResponse is a wallet with the balances last observed in it.
+type WalletResponse struct {
+	models.Wallet
+	Balances []models.WalletBalance `json:"balances"`
+}
+
+// validateWallet checks the chain is supported and the address is one its
+// balance source can read. Bitcoin wallets take an address or an extended
+// public key; EVM wallets take a 0x address.
+func validateWallet(wallet *models.Wallet) string {
+	if err := wallet.Validate(); err != nil {
+		return err.Error()
+	}
+	switch {
+	case wallet.Chain == wallets.ChainBitcoin:
+		if wallet.Address == "" {
+			return "address or extended public key is required"
+		}
+		if hdkey.IsExtendedKey(wallet.Address) {
+			if _, err := hdkey.ParseExtendedKey(wallet.Address); err != nil {
+				return err.Error()
+			}
+		}
+		if wallet.Tokens != "" {
+			return "tokens are only supported on EVM chains"
+		}
+	case wallets.IsEVMChain(wallet.Chain):
+		if !evm.IsAddress(wallet.Address) {
+			return "address must be a 0x prefixed 20 byte hex address"
+		}
+		tokens, _ := wallet.TokenList()
+		for _, token := range tokens {
+			if !evm.IsAddress(token.Contract) {
+				return "invalid contract address for " + token.Symbol
+			}
+		}
+	default:
+		return "unsupported chain: " + wallet.Chain
+	}
+	return ""
+}
+
+func (c *Controller) walletResponse(wallet models.Wallet) (WalletResponse, error) {
+	balances, err := c.repo.ListWalletBalances(wallet.ID)
+	if err != nil {
+		return WalletResponse{}, err
+	}
+	return WalletResponse{Wallet: wallet, Balances: balances}, nil
+}
+
+// ListWallets godoc
+// @Summary List wallets
+// @Description Get all watch-only wallets with their last observed balances
+// @Tags wallets
+// @Produce json
+// @Success 200 {array} WalletResponse
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets [get]
+func (c *Controller) ListWallets(ctx *gin.Context) {
+	list, err := c.repo.ListWallets()
+	if err != nil {
+		internalError(ctx, "failed to fetch wallets")
+		return
+	}
+
+	response := make([]WalletResponse, 0, len(list))
+	for _, wallet := range list {
+		item, err := c.walletResponse(wallet)
+		if err != nil {
+			internalError(ctx, "failed to fetch wallet balances")
+			return
+		}
+		response = append(response, item)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetWallet godoc
+// @Summary Get a wallet by ID
+// @Description Get a single watch-only wallet with its last observed balances
+// @Tags wallets
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Success 200 {object} WalletResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{id} [get]
+func (c *Controller) GetWallet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid wallet id")
+		return
+	}
+
+	wallet, err := c.repo.GetWalletByID(id)
+	if err != nil {
+		notFound(ctx, "wallet not found")
+		return
+	}
+
+	response, err := c.walletResponse(*wallet)
+	if err != nil {
+		internalError(ctx, "failed to fetch wallet balances")
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CreateWallet godoc
+// @Summary Create a wallet
+// @Description Watch a bitcoin address or extended public key, or an EVM address with optional ERC-20 tokens given as SYMBOL=contract pairs. Mode is propose (default) or flag.
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param wallet body WalletRequest true "Wallet"
+// @Success 201 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets [post]
+func (c *Controller) CreateWallet(ctx *gin.Context) {
+	var req WalletRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	var wallet models.Wallet
+	req.apply(&wallet)
+	if msg := validateWallet(&wallet); msg != "" {
+		badRequest(ctx, msg)
+		return
+	}
+	if !c.portfolioExists(wallet.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if err := c.repo.CreateWallet(&wallet); err != nil {
+		internalError(ctx, "failed to create wallet")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, wallet)
+}
+
+// UpdateWallet godoc
+// @Summary Update a wallet
+// @Description Update a watch-only wallet by its ID
+// @Tags wallets
+// @Accept json
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Param wallet body WalletRequest true "Wallet"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{id} [put]
+func (c *Controller) UpdateWallet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid wallet id")
+		return
+	}
+
+	wallet, err := c.repo.GetWalletByID(id)
+	if err != nil {
+		notFound(ctx, "wallet not found")
+		return
+	}
+
+	var req WalletRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		badRequestWithDetails(ctx, "invalid input", err.Error())
+		return
+	}
+
+	req.apply(wallet)
+	if msg := validateWallet(wallet); msg != "" {
+		badRequest(ctx, msg)
+		return
+	}
+	if !c.portfolioExists(wallet.PortfolioID) {
+		badRequest(ctx, "portfolio not found")
+		return
+	}
+
+	if err := c.repo.UpdateWallet(wallet); err != nil {
+		internalError(ctx, "failed to update wallet")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, wallet)
+}
+
+// DeleteWallet godoc
+// @Summary Delete a wallet
+// @Description Stop watching a wallet and forget its balances
+// @Tags wallets
+// @Param id path int true "Wallet ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{id} [delete]
+func (c *Controller) DeleteWallet(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid wallet id")
+		return
+	}
+
+	if err := c.repo.DeleteWallet(id); err != nil {
+		internalError(ctx, "failed to delete wallet")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListWalletDiscrepancies godoc
+// @Summary List wallet discrepancies
+// @Description Get differences between on-chain balances and recorded holdings, newest first
+// @Tags wallets
+// @Produce json
+// @Param status query string false "Filter by status (open, accepted, dismissed, resolved)"
+// @Success 200 {array} models.WalletDiscrepancy
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/discrepancies [get]
+func (c *Controller) ListWalletDiscrepancies(ctx *gin.Context) {
+	discrepancies, err := c.repo.ListWalletDiscrepancies(ctx.Query("status"))
+	if err != nil {
+		internalError(ctx, "failed to fetch wallet discrepancies")
+		return
+	}
+	ctx.JSON(http.StatusOK, discrepancies)
+}
+
+// AcceptWalletDiscrepancy godoc
+// @Summary Accept a wallet discrepancy
+// @Description Record the deposit or withdrawal that closes an open discrepancy, recomputed from the wallet's last balance and the entries tagged with it
+// @Tags wallets
+// @Produce json
+// @Param id path int true "Discrepancy ID"
+// @Success 201 {object} models.Asset
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/discrepancies/{id}/accept [post]
+func (c *Controller) AcceptWalletDiscrepancy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid discrepancy id")
+		return
+	}
+
+	asset, _, err := c.repo.AcceptWalletDiscrepancy(id, time.Now())
+	if err != nil {
+		discrepancyError(ctx, err, "failed to accept discrepancy")
+		return
+	}
+
+	c.publish(ctx.Request.Context(), events.TopicAssetCreated, *asset)
+	ctx.JSON(http.StatusCreated, asset)
+}
+
+// DismissWalletDiscrepancy godoc
+// @Summary Dismiss a wallet discrepancy
+// @Description Close an open discrepancy without recording anything. The same difference is not reported again.
+// @Tags wallets
+// @Produce json
+// @Param id path int true "Discrepancy ID"
+// @Success 200 {object} models.WalletDiscrepancy
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/discrepancies/{id}/dismiss [post]
+func (c *Controller) DismissWalletDiscrepancy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(ctx, "invalid discrepancy id")
+		return
+	}
+
+	discrepancy, err := c.repo.DismissWalletDiscrepancy(id, time.Now())
+	if err != nil {
+		discrepancyError(ctx, err, "failed to dismiss discrepancy")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, discrepancy)
+}
+
+func discrepancyError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		notFound(ctx, "discrepancy not found")
+	case errors.Is(err, repo.ErrDiscrepancyNotOpen), errors.Is(err, repo.ErrDiscrepancyNotProposed),
+		errors.Is(err, repo.ErrDiscrepancyStale):
+		errorResponse(ctx, http.StatusConflict, err.Error())
+	default:
+		internalError(ctx, message)
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyContextKey = "api_key"
+
+// requireScope authenticates API keys on a route group. Safe methods need
+// readScope, everything else needs writeScope. Requests without a key are let
+// through unless the handler was configured to require one, so the bundled UI
+// keeps working on trusted deployments. Otherwise the UI authenticates with
+// the key stored in its sign-in cookie.
+func (h *Handler) requireScope(readScope, writeScope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		raw := apiKeyFromRequest(ctx)
+		if raw == "" {
+			if h.requireAPIKey {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key required"})
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		key, err := h.repository.AuthenticateAPIKey(raw)
+		if err != nil {
+			if errors.Is(err, repo.ErrAPIKeyInvalid) || errors.Is(err, repo.ErrAPIKeyInactive) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify api key"})
+			return
+		}
+
+		scope := writeScope
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = readScope
+		}
+		if !key.HasScope(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key lacks scope " + scope})
+			return
+		}
+
+		ctx.Set(apiKeyContextKey, key)
+		ctx.Next()
+	}
+}
+
+func apiKeyFromRequest(ctx *gin.Context) string {
+	if key := ctx.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	auth := ctx.GetHeader("Authorization")
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := ctx.Cookie(models.APIKeyCookie); err == nil {
+		return cookie
+	}
+	return ""
+}
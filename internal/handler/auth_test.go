@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAuthRouter(t *testing.T, required bool) (*gin.Engine, *repo.Repository) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	repository, err := repo.New(db)
+	require.NoError(t, err)
+	require.NoError(t, repository.Migrate())
+
+	h := &Handler{repository: repository, requireAPIKey: required}
+	router := gin.New()
+	group := router.Group("/api/assets", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
+	group.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.POST("", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return router, repository
+}
+
+func TestRequireScope_Optional(t *testing.T) {
+	router, repository := newAuthRouter(t, false)
+	_, readKey, err := repository.CreateAPIKey("reader", []string{models.ScopeReadPortfolio}, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/assets", nil))
+	assert.Equal(t, http.StatusCreated, w.Code, "requests without a key pass")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/assets", nil)
+	req.Header.Set("X-API-Key", readKey)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "a key that is sent is still checked")
+}
+
+func TestRequireScope_Required(t *testing.T) {
+	router, repository := newAuthRouter(t, true)
+	_, writeKey, err := repository.CreateAPIKey("writer", []string{models.ScopeReadPortfolio, models.ScopeWriteTransactions}, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/assets", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/assets", nil)
+	req.Header.Set("Authorization", "Bearer "+writeKey)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/assets", nil)
+	req.AddCookie(&http.Cookie{Name: models.APIKeyCookie, Value: writeKey})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code, "the UI authenticates with its sign-in cookie")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/assets", nil)
+	req.AddCookie(&http.Cookie{Name: models.APIKeyCookie, Value: "hb_invalid"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
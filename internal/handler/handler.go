@@ -3,30 +3,37 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"hodlbook/internal/controller"
+	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
 	"hodlbook/internal/service"
+	"hodlbook/pkg/integrations/broadcast"
 	"hodlbook/pkg/types/cache"
-	"hodlbook/pkg/types/pubsub"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/prices"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	ErrNilEngine       = errors.New("engine is required")
-	ErrNilRepository   = errors.New("repository is required")
-	ErrNilPriceChannel = errors.New("price channel is required")
+	ErrNilEngine     = errors.New("engine is required")
+	ErrNilRepository = errors.New("repository is required")
 )
 
 type Handler struct {
-	engine          *gin.Engine
-	repository      *repo.Repository
-	priceCh         <-chan []byte
-	priceCHSet      bool
-	priceCache      cache.Cache[string, float64]
-	assetCreatedPub pubsub.Publisher
-	livePriceSvc    *service.LivePriceService
+	engine        *gin.Engine
+	repository    *repo.Repository
+	priceHub      *broadcast.Hub
+	priceCache    cache.Cache[string, float64]
+	events        events.Publisher
+	livePriceSvc  *service.LivePriceService
+	walletSvc     *service.WalletService
+	recurringSvc  *service.RecurringService
+	priceFetcher  prices.PriceFetcher
+	requireAPIKey bool
+	staleAfter    time.Duration
 }
 
 func (h *Handler) IsValid() error {
@@ -36,9 +43,6 @@ func (h *Handler) IsValid() error {
 	if h.repository == nil {
 		return ErrNilRepository
 	}
-	if h.priceCHSet && h.priceCh == nil {
-		return ErrNilPriceChannel
-	}
 	return nil
 }
 
@@ -56,10 +60,9 @@ func WithRepository(repository *repo.Repository) Option {
 	}
 }
 
-func WithPriceChannel(ch <-chan []byte) Option {
+func WithPriceHub(hub *broadcast.Hub) Option {
 	return func(h *Handler) {
-		h.priceCh = ch
-		h.priceCHSet = true
+		h.priceHub = hub
 	}
 }
 
@@ -69,9 +72,9 @@ func WithPriceCache(pc cache.Cache[string, float64]) Option {
 	}
 }
 
-func WithAssetCreatedPublisher(p pubsub.Publisher) Option {
+func WithEventPublisher(p events.Publisher) Option {
 	return func(h *Handler) {
-		h.assetCreatedPub = p
+		h.events = p
 	}
 }
 
@@ -81,6 +84,43 @@ func WithLivePriceService(svc *service.LivePriceService) Option {
 	}
 }
 
+// WithWalletService enables syncing wallets on demand.
+func WithWalletService(svc *service.WalletService) Option {
+	return func(h *Handler) {
+		h.walletSvc = svc
+	}
+}
+
+// WithRecurringService enables running due recurring transactions on demand.
+func WithRecurringService(svc *service.RecurringService) Option {
+	return func(h *Handler) {
+		h.recurringSvc = svc
+	}
+}
+
+// WithPriceFetcher sets the fetcher the API uses for currency search and for
+// recording prices of edited assets.
+func WithPriceFetcher(pf prices.PriceFetcher) Option {
+	return func(h *Handler) {
+		h.priceFetcher = pf
+	}
+}
+
+// WithPriceStaleAfter sets the age past which the API reports a price as
+// stale.
+func WithPriceStaleAfter(d time.Duration) Option {
+	return func(h *Handler) {
+		h.staleAfter = d
+	}
+}
+
+// WithRequireAPIKey rejects /api requests that do not carry an API key.
+func WithRequireAPIKey(required bool) Option {
+	return func(h *Handler) {
+		h.requireAPIKey = required
+	}
+}
+
 func New(opts ...Option) (*Handler, error) {
 	h := &Handler{}
 	for _, opt := range opts {
@@ -96,15 +136,46 @@ func (h *Handler) Setup() error {
 	ctrl, err := controller.New(
 		controller.WithRepository(h.repository),
 		controller.WithPriceCache(h.priceCache),
-		controller.WithAssetCreatedPublisher(h.assetCreatedPub),
+		controller.WithEventPublisher(h.events),
+		controller.WithPriceFetcher(h.priceFetcher),
+		controller.WithPriceStaleAfter(h.staleAfter),
 	)
 	if err != nil {
 		return err
 	}
 
+	h.engine.GET("/metrics", h.requireScope(models.ScopeReadPortfolio, models.ScopeAdmin), ctrl.Metrics)
+
 	api := h.engine.Group("/api")
 
-	assets := api.Group("/assets")
+	portfolios := api.Group("/portfolios", h.requireScope(models.ScopeReadPortfolio, models.ScopeAdmin))
+	portfolios.GET("", ctrl.ListPortfolios)
+	portfolios.POST("", ctrl.CreatePortfolio)
+	portfolios.GET("/:id", ctrl.GetPortfolio)
+	portfolios.PUT("/:id", ctrl.UpdatePortfolio)
+	portfolios.DELETE("/:id", ctrl.DeletePortfolio)
+
+	notifications := api.Group("/notifications", h.requireScope(models.ScopeAdmin, models.ScopeAdmin))
+	notifications.GET("", ctrl.ListNotificationChannels)
+	notifications.POST("", ctrl.CreateNotificationChannel)
+	notifications.PUT("/:id", ctrl.UpdateNotificationChannel)
+	notifications.DELETE("/:id", ctrl.DeleteNotificationChannel)
+	notifications.POST("/:id/test", ctrl.TestNotificationChannel)
+
+	keys := api.Group("/keys", h.requireScope(models.ScopeAdmin, models.ScopeAdmin))
+	keys.GET("", ctrl.ListAPIKeys)
+	keys.POST("", ctrl.CreateAPIKey)
+	keys.DELETE("/:id", ctrl.RevokeAPIKey)
+
+	alerts := api.Group("/alerts", h.requireScope(models.ScopeReadPortfolio, models.ScopeAdmin))
+	alerts.GET("", ctrl.ListAlerts)
+	alerts.POST("", ctrl.CreateAlert)
+	alerts.GET("/history", ctrl.ListAlertHistory)
+	alerts.GET("/:id", ctrl.GetAlert)
+	alerts.PUT("/:id", ctrl.UpdateAlert)
+	alerts.DELETE("/:id", ctrl.DeleteAlert)
+
+	assets := api.Group("/assets", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
 	assets.GET("", ctrl.ListAssets)
 	assets.POST("", ctrl.CreateAsset)
 	assets.GET("/symbols", ctrl.GetUniqueSymbols)
@@ -114,7 +185,7 @@ func (h *Handler) Setup() error {
 	assets.PUT("/:id", ctrl.UpdateAsset)
 	assets.DELETE("/:id", ctrl.DeleteAsset)
 
-	exchanges := api.Group("/exchanges")
+	exchanges := api.Group("/exchanges", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
 	exchanges.GET("", ctrl.ListExchanges)
 	exchanges.POST("", ctrl.CreateExchange)
 	exchanges.GET("/export", ctrl.ExportExchanges)
@@ -122,26 +193,71 @@ func (h *Handler) Setup() error {
 	exchanges.PUT("/:id", ctrl.UpdateExchange)
 	exchanges.DELETE("/:id", ctrl.DeleteExchange)
 
-	imports := api.Group("/imports")
+	wallets := api.Group("/wallets", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
+	wallets.GET("", ctrl.ListWallets)
+	wallets.POST("", ctrl.CreateWallet)
+	wallets.GET("/discrepancies", ctrl.ListWalletDiscrepancies)
+	wallets.POST("/discrepancies/:id/accept", ctrl.AcceptWalletDiscrepancy)
+	wallets.POST("/discrepancies/:id/dismiss", ctrl.DismissWalletDiscrepancy)
+	if h.walletSvc != nil {
+		wallets.POST("/sync", h.syncWallets)
+	}
+	wallets.GET("/:id", ctrl.GetWallet)
+	wallets.PUT("/:id", ctrl.UpdateWallet)
+	wallets.DELETE("/:id", ctrl.DeleteWallet)
+
+	reconciliations := api.Group("/reconciliations", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
+	reconciliations.GET("", ctrl.ListReconciliations)
+	reconciliations.POST("", ctrl.CreateReconciliation)
+	reconciliations.POST("/import", ctrl.ImportReconciliation)
+	reconciliations.GET("/:id", ctrl.GetReconciliation)
+	reconciliations.DELETE("/:id", ctrl.DeleteReconciliation)
+	reconciliations.POST("/:id/lines/:line_id/adjust", ctrl.AdjustReconciliationLine)
+
+	recurring := api.Group("/recurring", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
+	recurring.GET("", ctrl.ListRecurring)
+	recurring.POST("", ctrl.CreateRecurring)
+	recurring.GET("/runs", ctrl.ListRecurringRuns)
+	recurring.POST("/runs/:run_id/confirm", ctrl.ConfirmRecurringRun)
+	recurring.POST("/runs/:run_id/skip", ctrl.SkipRecurringRun)
+	if h.recurringSvc != nil {
+		recurring.POST("/run", h.runRecurring)
+	}
+	recurring.GET("/:id", ctrl.GetRecurring)
+	recurring.PUT("/:id", ctrl.UpdateRecurring)
+	recurring.DELETE("/:id", ctrl.DeleteRecurring)
+
+	imports := api.Group("/imports", h.requireScope(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
 	imports.GET("", ctrl.ListImportLogs)
 	imports.GET("/:id", ctrl.GetImportLog)
 	imports.POST("/:id/retry", ctrl.RetryImport)
 	imports.DELETE("/:id", ctrl.DeleteImportLog)
 
-	portfolio := api.Group("/portfolio")
+	portfolio := api.Group("/portfolio", h.requireScope(models.ScopeReadPortfolio, models.ScopeAdmin))
 	portfolio.GET("/summary", ctrl.PortfolioSummary)
 	portfolio.GET("/allocation", ctrl.PortfolioAllocation)
 	portfolio.GET("/performance", ctrl.PortfolioPerformance)
 	portfolio.GET("/history", ctrl.PortfolioHistory)
+	portfolio.GET("/targets", ctrl.GetAllocationTargets)
+	portfolio.PUT("/targets", ctrl.UpdateAllocationTargets)
+	portfolio.GET("/categories", ctrl.ListAssetCategories)
+	portfolio.PUT("/categories", ctrl.UpdateAssetCategories)
+	portfolio.GET("/rebalance", ctrl.PortfolioRebalance)
+
+	simulations := api.Group("/simulations", h.requireScope(models.ScopeReadPortfolio, models.ScopeReadPortfolio))
+	simulations.POST("", ctrl.RunSimulation)
 
-	prices := api.Group("/prices")
-	if h.priceCh != nil {
-		prices.GET("/stream", controller.SSEPrices(h.priceCh))
+	prices := api.Group("/prices", h.requireScope(models.ScopeReadPrices, models.ScopeAdmin))
+	if h.priceHub != nil {
+		prices.GET("/stream", controller.SSEPrices(h.priceHub))
 	}
 	prices.GET("", ctrl.ListPrices)
 	prices.GET("/currencies", ctrl.SearchCurrencies)
 	prices.GET("/deep-search", ctrl.DeepSearchCurrencies)
 	prices.GET("/deep-search/providers", ctrl.GetDeepSearchProviders)
+	prices.GET("/manual", ctrl.ListManualPrices)
+	prices.POST("/manual", ctrl.CreateManualPrice)
+	prices.DELETE("/manual/:id", ctrl.DeleteManualPrice)
 	if h.livePriceSvc != nil {
 		prices.GET("/deep-search/debug", h.debugDeepSearchAssets)
 		prices.POST("/sync", h.syncPrices)
@@ -169,9 +285,35 @@ func (h *Handler) syncPrices(ctx *gin.Context) {
 		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "live price service not available"})
 		return
 	}
-	if err := h.livePriceSvc.ForceSync(); err != nil {
+	if err := h.livePriceSvc.ForceSync(ctx.Request.Context()); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "prices synced"})
 }
+
+func (h *Handler) syncWallets(ctx *gin.Context) {
+	if h.walletSvc == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "wallet service not available"})
+		return
+	}
+	result, err := h.walletSvc.Sync(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) runRecurring(ctx *gin.Context) {
+	if h.recurringSvc == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "recurring service not available"})
+		return
+	}
+	result, err := h.recurringSvc.Run(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
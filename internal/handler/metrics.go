@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"hodlbook/pkg/integrations/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec(
+		"hodlbook_http_requests_total",
+		"HTTP requests by route and status.",
+		"method", "route", "status",
+	)
+	httpRequestDuration = metrics.NewHistogramVec(
+		"hodlbook_http_request_duration_seconds",
+		"HTTP request latency by route.",
+		nil, "method", "route",
+	)
+)
+
+// MetricsMiddleware records request counts and latency. Routes are labelled by
+// their registered pattern to keep label cardinality bounded.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := ctx.Request.Method
+		httpRequests.Inc(method, route, strconv.Itoa(ctx.Writer.Status()))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,95 @@
+package importexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// BalanceRow is a balance reported for a symbol, as read from a
+// reconciliation CSV. Portfolio is the portfolio ID or name given in the
+// file, empty when it has no portfolio column.
+type BalanceRow struct {
+	Row       int
+	Portfolio string
+	Symbol    string
+	Amount    decimal.Decimal
+}
+
+// ParseBalancesCSV reads reported balances from a CSV with symbol and
+// amount (or balance) columns and an optional portfolio column. Rows are
+// separated by semicolons like the other imports, or by commas as most
+// exchanges export them.
+func ParseBalancesCSV(data []byte) ([]BalanceRow, []RowError) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = ';'
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); !bytes.Contains(firstLine, []byte(";")) {
+		r.Comma = ','
+	}
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, []RowError{{Row: 0, Message: "invalid CSV format: " + err.Error()}}
+	}
+	if len(records) < 2 {
+		return nil, []RowError{{Row: 0, Message: "CSV file must have a header and at least one data row"}}
+	}
+
+	colIndex := make(map[string]int)
+	for i, col := range records[0] {
+		colIndex[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	amountCol, ok := colIndex["amount"]
+	if !ok {
+		amountCol, ok = colIndex["balance"]
+	}
+	symbolCol, hasSymbol := colIndex["symbol"]
+	if !ok || !hasSymbol {
+		return nil, []RowError{{Row: 1, Message: "CSV header must have symbol and amount columns"}}
+	}
+	portfolioCol, hasPortfolio := colIndex["portfolio"]
+
+	var balances []BalanceRow
+	var errors []RowError
+	for i, row := range records[1:] {
+		balance := BalanceRow{Row: i + 2}
+		rowData := make(map[string]string)
+
+		if symbolCol < len(row) {
+			balance.Symbol = strings.ToUpper(strings.TrimSpace(row[symbolCol]))
+			rowData["symbol"] = balance.Symbol
+		}
+		if hasPortfolio && portfolioCol < len(row) {
+			balance.Portfolio = strings.TrimSpace(row[portfolioCol])
+			rowData["portfolio"] = balance.Portfolio
+		}
+		var amountErr error
+		if amountCol < len(row) {
+			rowData["amount"] = strings.TrimSpace(row[amountCol])
+			balance.Amount, amountErr = decimal.NewFromString(rowData["amount"])
+		}
+
+		message := ""
+		switch {
+		case balance.Symbol == "":
+			message = "symbol is required"
+		case rowData["amount"] == "" || amountErr != nil:
+			message = "amount must be a number"
+		case balance.Amount.IsNegative():
+			message = "amount cannot be negative"
+		}
+		if message != "" {
+			rowDataJSON, _ := json.Marshal(rowData)
+			errors = append(errors, RowError{Row: balance.Row, Data: rowDataJSON, Message: message})
+			continue
+		}
+
+		balances = append(balances, balance)
+	}
+
+	return balances, errors
+}
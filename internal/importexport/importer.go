@@ -0,0 +1,244 @@
+package importexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/prices"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatKraken = "kraken"
+)
+
+var ErrUnsupportedFormat = errors.New("format must be csv, json or kraken")
+
+type Repository interface {
+	CreateAsset(asset *models.Asset) error
+	CreateExchange(exchange *models.Exchange) error
+	CreatePrice(price *models.Price) error
+	CreateImportLog(log *models.ImportLog) error
+	UpdateImportLog(log *models.ImportLog) error
+}
+
+// Importer stores parsed rows and records an import log for them. Assets
+// whose symbol no price provider knows are rejected so holdings can be valued.
+type Importer struct {
+	repo      Repository
+	publisher events.Publisher
+	supported func(ctx context.Context) (map[string]struct{}, map[string]float64)
+}
+
+// NewImporter creates an importer. The publisher may be nil; without a price
+// fetcher every symbol is reported as unsupported.
+func NewImporter(repo Repository, publisher events.Publisher, fetcher prices.PriceFetcher) *Importer {
+	return &Importer{
+		repo:      repo,
+		publisher: publisher,
+		supported: func(ctx context.Context) (map[string]struct{}, map[string]float64) {
+			return SupportedSymbols(ctx, fetcher)
+		},
+	}
+}
+
+type Result struct {
+	Log      *models.ImportLog
+	Imported int
+	Total    int
+	Errors   []RowError
+}
+
+// Import parses data in the given format and stores every valid row in the
+// portfolio.
+func (im *Importer) Import(ctx context.Context, portfolioID int64, filename, format string, data []byte) (*Result, error) {
+	var assets []models.Asset
+	var exchanges []models.Exchange
+	var rowErrors []RowError
+
+	entityType := "asset"
+	switch format {
+	case FormatCSV:
+		assets, rowErrors = ParseAssetsCSV(data)
+	case FormatJSON:
+		assets, rowErrors = ParseAssetsJSON(data)
+	case FormatKraken:
+		assets, exchanges, rowErrors = ParseKrakenLedger(data)
+		entityType = "transaction"
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	total := len(assets) + len(exchanges) + len(rowErrors)
+	importNote := fmt.Sprintf("%s imported", format)
+
+	imported, symbolErrors := im.storeAssets(ctx, portfolioID, importNote, assets)
+	rowErrors = append(rowErrors, symbolErrors...)
+
+	for _, exchange := range exchanges {
+		exchange.PortfolioID = portfolioID
+		exchange.Notes = withNote(exchange.Notes, importNote)
+		if err := im.repo.CreateExchange(&exchange); err != nil {
+			rowData, _ := json.Marshal(exchange)
+			rowErrors = append(rowErrors, RowError{Data: rowData, Message: err.Error()})
+			continue
+		}
+		imported++
+		im.publish(ctx, events.TopicExchangeCreated, exchange)
+	}
+
+	status := "completed"
+	if imported == 0 && len(rowErrors) > 0 {
+		status = "failed"
+	} else if len(rowErrors) > 0 {
+		status = "partial"
+	}
+
+	failedDataJSON, _ := json.Marshal(rowErrors)
+	importLog := &models.ImportLog{
+		PortfolioID:  portfolioID,
+		Filename:     filename,
+		Format:       format,
+		EntityType:   entityType,
+		TotalRows:    total,
+		ImportedRows: imported,
+		FailedRows:   len(rowErrors),
+		Status:       status,
+		FailedData:   string(failedDataJSON),
+	}
+	if err := im.repo.CreateImportLog(importLog); err != nil {
+		return nil, err
+	}
+	im.publishCompleted(ctx, importLog)
+
+	return &Result{
+		Log:      importLog,
+		Imported: imported,
+		Total:    total,
+		Errors:   rowErrors,
+	}, nil
+}
+
+// Retry imports corrected versions of the rows an earlier import rejected.
+func (im *Importer) Retry(ctx context.Context, importLog *models.ImportLog, assets []models.Asset) (*Result, error) {
+	var validAssets []models.Asset
+	var rowErrors []RowError
+
+	for i, asset := range assets {
+		if err := ValidateAsset(&asset); err != nil {
+			rowData, _ := json.Marshal(asset)
+			rowErrors = append(rowErrors, RowError{
+				Row:     i + 1,
+				Data:    rowData,
+				Message: err.Error(),
+			})
+			continue
+		}
+		validAssets = append(validAssets, asset)
+	}
+
+	importNote := fmt.Sprintf("%s imported", importLog.Format)
+	imported, symbolErrors := im.storeAssets(ctx, importLog.PortfolioID, importNote, validAssets)
+	rowErrors = append(rowErrors, symbolErrors...)
+
+	importLog.ImportedRows += imported
+	importLog.FailedRows = len(rowErrors)
+	if len(rowErrors) == 0 {
+		importLog.Status = "completed"
+		importLog.FailedData = "[]"
+	} else {
+		importLog.Status = "partial"
+		failedDataJSON, _ := json.Marshal(rowErrors)
+		importLog.FailedData = string(failedDataJSON)
+	}
+	if err := im.repo.UpdateImportLog(importLog); err != nil {
+		return nil, err
+	}
+	im.publishCompleted(ctx, importLog)
+
+	return &Result{
+		Log:      importLog,
+		Imported: imported,
+		Total:    len(assets),
+		Errors:   rowErrors,
+	}, nil
+}
+
+func (im *Importer) storeAssets(ctx context.Context, portfolioID int64, importNote string, assets []models.Asset) (int, []RowError) {
+	if len(assets) == 0 {
+		return 0, nil
+	}
+
+	supportedSymbols, currentPrices := im.supported(ctx)
+
+	var rowErrors []RowError
+	imported := 0
+	for i, asset := range assets {
+		asset.Symbol = strings.ToUpper(asset.Symbol)
+		if _, ok := supportedSymbols[asset.Symbol]; !ok {
+			rowData, _ := json.Marshal(asset)
+			rowErrors = append(rowErrors, RowError{
+				Row:     i + 1,
+				Data:    rowData,
+				Field:   "symbol",
+				Message: fmt.Sprintf("symbol %q is not supported by price providers", asset.Symbol),
+			})
+			continue
+		}
+
+		asset.PortfolioID = portfolioID
+		if asset.Timestamp.IsZero() {
+			asset.Timestamp = time.Now()
+		}
+		asset.Notes = withNote(asset.Notes, importNote)
+		if err := im.repo.CreateAsset(&asset); err != nil {
+			continue
+		}
+		imported++
+
+		// Create price entry at asset timestamp for USD value display
+		if price, ok := currentPrices[asset.Symbol]; ok {
+			im.repo.CreatePrice(&models.Price{
+				Symbol:    asset.Symbol,
+				Currency:  "USD",
+				Price:     decimal.NewFromFloat(price),
+				Timestamp: asset.Timestamp,
+			})
+		}
+		im.publish(ctx, events.TopicAssetCreated, asset)
+	}
+
+	return imported, rowErrors
+}
+
+func (im *Importer) publishCompleted(ctx context.Context, importLog *models.ImportLog) {
+	im.publish(ctx, events.TopicImportCompleted, events.ImportCompleted{
+		ImportID:    importLog.ID,
+		PortfolioID: importLog.PortfolioID,
+		Imported:    importLog.ImportedRows,
+		Failed:      importLog.FailedRows,
+		Status:      importLog.Status,
+	})
+}
+
+func (im *Importer) publish(ctx context.Context, topic string, payload any) {
+	if im.publisher != nil {
+		im.publisher.Publish(ctx, topic, payload)
+	}
+}
+
+func withNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+	return notes + " (" + note + ")"
+}
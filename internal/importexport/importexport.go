@@ -0,0 +1,226 @@
+package importexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/types/prices"
+
+	"github.com/shopspring/decimal"
+)
+
+type RowError struct {
+	Row     int             `json:"row"`
+	Data    json.RawMessage `json:"data"`
+	Field   string          `json:"field,omitempty"`
+	Message string          `json:"message"`
+}
+
+// AssetsToCSV writes assets in the semicolon separated layout ParseAssetsCSV
+// reads back.
+func AssetsToCSV(assets []models.Asset) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+
+	w.Write([]string{"symbol", "name", "amount", "transaction_type", "timestamp", "notes"})
+
+	for _, a := range assets {
+		w.Write([]string{
+			a.Symbol,
+			a.Name,
+			a.Amount.String(),
+			a.TransactionType,
+			a.Timestamp.Format(time.RFC3339),
+			a.Notes,
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes()
+}
+
+func ExchangesToCSV(exchanges []models.Exchange) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+
+	w.Write([]string{"from_symbol", "to_symbol", "from_amount", "to_amount", "fee", "fee_currency", "timestamp", "notes"})
+
+	for _, e := range exchanges {
+		w.Write([]string{
+			e.FromSymbol,
+			e.ToSymbol,
+			e.FromAmount.String(),
+			e.ToAmount.String(),
+			e.Fee.String(),
+			e.FeeCurrency,
+			e.Timestamp.Format(time.RFC3339),
+			e.Notes,
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes()
+}
+
+func ParseAssetsCSV(data []byte) ([]models.Asset, []RowError) {
+	var assets []models.Asset
+	var errors []RowError
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = ';'
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, []RowError{{Row: 0, Message: "invalid CSV format: " + err.Error()}}
+	}
+
+	if len(records) < 2 {
+		return nil, []RowError{{Row: 0, Message: "CSV file must have a header and at least one data row"}}
+	}
+
+	header := records[0]
+	colIndex := make(map[string]int)
+	for i, col := range header {
+		colIndex[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+
+	for i, row := range records[1:] {
+		rowNum := i + 2
+
+		asset := models.Asset{}
+		rowData := make(map[string]string)
+
+		if idx, ok := colIndex["symbol"]; ok && idx < len(row) {
+			asset.Symbol = strings.ToUpper(strings.TrimSpace(row[idx]))
+			rowData["symbol"] = asset.Symbol
+		}
+		if idx, ok := colIndex["name"]; ok && idx < len(row) {
+			asset.Name = strings.TrimSpace(row[idx])
+			rowData["name"] = asset.Name
+		}
+		if idx, ok := colIndex["amount"]; ok && idx < len(row) {
+			val := strings.TrimSpace(row[idx])
+			rowData["amount"] = val
+			if amt, err := decimal.NewFromString(val); err == nil {
+				asset.Amount = amt
+			}
+		}
+		if idx, ok := colIndex["transaction_type"]; ok && idx < len(row) {
+			asset.TransactionType = strings.ToLower(strings.TrimSpace(row[idx]))
+			rowData["transaction_type"] = asset.TransactionType
+		}
+		if idx, ok := colIndex["timestamp"]; ok && idx < len(row) {
+			val := strings.TrimSpace(row[idx])
+			rowData["timestamp"] = val
+			if t, err := time.Parse(time.RFC3339, val); err == nil {
+				asset.Timestamp = t
+			}
+		}
+		if idx, ok := colIndex["notes"]; ok && idx < len(row) {
+			asset.Notes = strings.TrimSpace(row[idx])
+			rowData["notes"] = asset.Notes
+		}
+
+		if err := ValidateAsset(&asset); err != nil {
+			rowDataJSON, _ := json.Marshal(rowData)
+			errors = append(errors, RowError{
+				Row:     rowNum,
+				Data:    rowDataJSON,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, errors
+}
+
+func ParseAssetsJSON(data []byte) ([]models.Asset, []RowError) {
+	var rawAssets []json.RawMessage
+	if err := json.Unmarshal(data, &rawAssets); err != nil {
+		return nil, []RowError{{Row: 0, Message: "invalid JSON format: " + err.Error()}}
+	}
+
+	var assets []models.Asset
+	var errors []RowError
+
+	for i, raw := range rawAssets {
+		rowNum := i + 1
+
+		var asset models.Asset
+		if err := json.Unmarshal(raw, &asset); err != nil {
+			errors = append(errors, RowError{
+				Row:     rowNum,
+				Data:    raw,
+				Message: "invalid asset format: " + err.Error(),
+			})
+			continue
+		}
+
+		asset.Symbol = strings.ToUpper(strings.TrimSpace(asset.Symbol))
+
+		if err := ValidateAsset(&asset); err != nil {
+			errors = append(errors, RowError{
+				Row:     rowNum,
+				Data:    raw,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, errors
+}
+
+func ValidateAsset(asset *models.Asset) error {
+	if asset.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if !asset.Amount.IsPositive() {
+		return fmt.Errorf("amount must be positive")
+	}
+	txType := strings.ToLower(asset.TransactionType)
+	if txType != "deposit" && txType != "withdrawal" && txType != "withdraw" {
+		return fmt.Errorf("transaction_type must be deposit or withdrawal")
+	}
+	if txType == "withdraw" {
+		asset.TransactionType = "withdrawal"
+	}
+	return nil
+}
+
+// SupportedSymbols returns the symbols the fetcher has prices for, with those
+// prices. Both maps are empty when fetcher is nil or fails.
+func SupportedSymbols(ctx context.Context, fetcher prices.PriceFetcher) (map[string]struct{}, map[string]float64) {
+	symbols := make(map[string]struct{})
+	priceMap := make(map[string]float64)
+
+	if fetcher == nil {
+		return symbols, priceMap
+	}
+	allPrices, err := fetcher.FetchAll(ctx)
+	if err != nil {
+		return symbols, priceMap
+	}
+
+	for _, p := range allPrices {
+		symbol := strings.ToUpper(p.Asset.Symbol)
+		symbols[symbol] = struct{}{}
+		priceMap[symbol] = p.Value
+	}
+
+	return symbols, priceMap
+}
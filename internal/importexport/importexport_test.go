@@ -0,0 +1,306 @@
+package importexport
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAssetsCSV(t *testing.T) {
+	csvData := `symbol;name;amount;transaction_type;timestamp;notes
+BTC;Bitcoin;1.5;deposit;2024-01-15T10:30:00Z;Test note
+ETH;Ethereum;10.0;withdrawal;;No timestamp`
+
+	assets, errors := ParseAssetsCSV([]byte(csvData))
+	assert.Len(t, assets, 2)
+	assert.Empty(t, errors)
+
+	assert.Equal(t, "BTC", assets[0].Symbol)
+	assert.Equal(t, "Bitcoin", assets[0].Name)
+	assert.Equal(t, "1.5", assets[0].Amount.String())
+	assert.Equal(t, "deposit", assets[0].TransactionType)
+	assert.Equal(t, "Test note", assets[0].Notes)
+
+	assert.Equal(t, "ETH", assets[1].Symbol)
+	assert.Equal(t, "withdrawal", assets[1].TransactionType)
+}
+
+func TestParseAssetsCSV_InvalidFormat(t *testing.T) {
+	csvData := `not a valid csv with proper structure`
+
+	assets, errors := ParseAssetsCSV([]byte(csvData))
+	assert.Empty(t, assets)
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Message, "at least one data row")
+}
+
+func TestParseAssetsCSV_ERC20Precision(t *testing.T) {
+	csvData := `symbol;amount;transaction_type
+USDC;1234.567890123456789012;deposit
+ETH;0.000000000000000001;deposit`
+
+	assets, errors := ParseAssetsCSV([]byte(csvData))
+	require.Empty(t, errors)
+	require.Len(t, assets, 2)
+	assert.Equal(t, "1234.567890123456789012", assets[0].Amount.String())
+	assert.Equal(t, "0.000000000000000001", assets[1].Amount.String(), "one wei")
+
+	exported := string(AssetsToCSV(assets))
+	assert.Contains(t, exported, ";1234.567890123456789012;")
+	assert.Contains(t, exported, ";0.000000000000000001;")
+
+	assets, errors = ParseAssetsJSON([]byte(`[
+		{"symbol": "USDC", "amount": 1234.567890123456789012, "transaction_type": "deposit"},
+		{"symbol": "ETH", "amount": "0.000000000000000001", "transaction_type": "deposit"}
+	]`))
+	require.Empty(t, errors)
+	assert.Equal(t, "1234.567890123456789012", assets[0].Amount.String(), "JSON numbers are not rounded through float64")
+	assert.Equal(t, "0.000000000000000001", assets[1].Amount.String())
+}
+
+func TestParseAssetsJSON(t *testing.T) {
+	jsonData := `[
+		{"symbol": "BTC", "name": "Bitcoin", "amount": 1.5, "transaction_type": "deposit"},
+		{"symbol": "ETH", "amount": 10.0, "transaction_type": "withdrawal"}
+	]`
+
+	assets, errors := ParseAssetsJSON([]byte(jsonData))
+	assert.Len(t, assets, 2)
+	assert.Empty(t, errors)
+
+	assert.Equal(t, "BTC", assets[0].Symbol)
+	assert.Equal(t, "1.5", assets[0].Amount.String())
+}
+
+func TestParseAssetsJSON_InvalidJSON(t *testing.T) {
+	jsonData := `not valid json`
+
+	assets, errors := ParseAssetsJSON([]byte(jsonData))
+	assert.Empty(t, assets)
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Message, "invalid JSON")
+}
+
+func TestValidateAsset(t *testing.T) {
+	validAsset := &models.Asset{Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "deposit"}
+	assert.NoError(t, ValidateAsset(validAsset))
+
+	missingSymbol := &models.Asset{Amount: decimal.NewFromInt(1), TransactionType: "deposit"}
+	assert.ErrorContains(t, ValidateAsset(missingSymbol), "symbol")
+
+	negativeAmount := &models.Asset{Symbol: "BTC", Amount: decimal.NewFromInt(-1), TransactionType: "deposit"}
+	assert.ErrorContains(t, ValidateAsset(negativeAmount), "amount")
+
+	invalidType := &models.Asset{Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "invalid"}
+	assert.ErrorContains(t, ValidateAsset(invalidType), "transaction_type")
+
+	withdrawAlias := &models.Asset{Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "withdraw"}
+	assert.NoError(t, ValidateAsset(withdrawAlias))
+	assert.Equal(t, "withdrawal", withdrawAlias.TransactionType)
+}
+
+func TestAssetsToCSV(t *testing.T) {
+	assets := []models.Asset{
+		{Symbol: "BTC", Name: "Bitcoin", Amount: decimal.RequireFromString("1.5"), TransactionType: "deposit", Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), Notes: "Test"},
+	}
+
+	csv := string(AssetsToCSV(assets))
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "symbol;name;amount;transaction_type;timestamp;notes", lines[0])
+	assert.Contains(t, lines[1], "BTC;Bitcoin;1.5;deposit;")
+}
+
+func TestExchangesToCSV(t *testing.T) {
+	exchanges := []models.Exchange{
+		{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.NewFromInt(15), Fee: decimal.RequireFromString("0.001"), FeeCurrency: "BTC", Timestamp: time.Now()},
+	}
+
+	csv := string(ExchangesToCSV(exchanges))
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "from_symbol;to_symbol;from_amount;to_amount;fee;fee_currency;timestamp;notes", lines[0])
+	assert.Contains(t, lines[1], "BTC;ETH;1;15;0.001;BTC;")
+}
+
+const krakenLedger = `"txid","refid","time","type","subtype","aclass","asset","wallet","amount","fee","balance"
+"L1","R1","2024-01-02 09:00:00","deposit","","currency","ZEUR","spot / main",1000.0000,0.0000,1000.0000
+"L2","T1","2024-01-03 10:15:00","trade","","currency","ZEUR","spot / main",-500.0000,1.2000,498.8000
+"L3","T1","2024-01-03 10:15:00","trade","","currency","XXBT","spot / main",0.0123000000,0.0000000000,0.0123000000
+"L4","S1","2024-01-05 00:00:00","staking","","currency","DOT.S","earn / bonded",0.5000000000,0.0000000000,0.5000000000
+"L5","W1","2024-01-06 12:00:00","withdrawal","","currency","XXBT","spot / main",-0.0100000000,0.0002000000,0.0021000000
+"L6","M1","2024-01-07 12:00:00","margin","","currency","ZEUR","spot / main",-1.0000,0.0000,497.8000
+`
+
+func TestParseKrakenLedger(t *testing.T) {
+	assets, exchanges, errors := ParseKrakenLedger([]byte(krakenLedger))
+
+	require.Len(t, assets, 3)
+	assert.Equal(t, "EUR", assets[0].Symbol)
+	assert.Equal(t, "deposit", assets[0].TransactionType)
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), assets[0].Timestamp)
+	assert.Equal(t, "DOT", assets[1].Symbol)
+	assert.Equal(t, "0.5", assets[1].Amount.String())
+	assert.Equal(t, "BTC", assets[2].Symbol)
+	assert.Equal(t, "withdraw", assets[2].TransactionType)
+	assert.Equal(t, "0.0102", assets[2].Amount.String())
+
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, "EUR", exchanges[0].FromSymbol)
+	assert.Equal(t, "BTC", exchanges[0].ToSymbol)
+	assert.Equal(t, "501.2", exchanges[0].FromAmount.String())
+	assert.Equal(t, "0.0123", exchanges[0].ToAmount.String())
+	assert.Equal(t, "1.2", exchanges[0].Fee.String())
+	assert.Equal(t, "EUR", exchanges[0].FeeCurrency)
+
+	require.Len(t, errors, 1)
+	assert.Equal(t, 7, errors[0].Row)
+	assert.Contains(t, errors[0].Message, "margin")
+}
+
+func TestParseKrakenLedger_UnmatchedTrade(t *testing.T) {
+	ledger := `txid,refid,time,type,asset,amount,fee
+L1,T1,2024-01-03 10:15:00,trade,ZEUR,-500,0
+`
+	_, exchanges, errors := ParseKrakenLedger([]byte(ledger))
+	assert.Empty(t, exchanges)
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Message, "T1")
+}
+
+func TestParseKrakenLedger_NotALedger(t *testing.T) {
+	_, _, errors := ParseKrakenLedger([]byte("symbol;amount\nBTC;1\n"))
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Message, "Kraken ledger")
+}
+
+func TestKrakenSymbol(t *testing.T) {
+	assert.Equal(t, "BTC", KrakenSymbol("XXBT"))
+	assert.Equal(t, "ETH", KrakenSymbol("ETH2.S"))
+	assert.Equal(t, "USD", KrakenSymbol("zusd"))
+	assert.Equal(t, "SOL", KrakenSymbol("SOL"))
+}
+
+type memoryRepo struct {
+	assets    []models.Asset
+	exchanges []models.Exchange
+	prices    []models.Price
+	logs      []models.ImportLog
+}
+
+func (m *memoryRepo) CreateAsset(asset *models.Asset) error {
+	asset.ID = int64(len(m.assets) + 1)
+	m.assets = append(m.assets, *asset)
+	return nil
+}
+
+func (m *memoryRepo) CreateExchange(exchange *models.Exchange) error {
+	exchange.ID = int64(len(m.exchanges) + 1)
+	m.exchanges = append(m.exchanges, *exchange)
+	return nil
+}
+
+func (m *memoryRepo) CreatePrice(price *models.Price) error {
+	m.prices = append(m.prices, *price)
+	return nil
+}
+
+func (m *memoryRepo) CreateImportLog(log *models.ImportLog) error {
+	log.ID = int64(len(m.logs) + 1)
+	m.logs = append(m.logs, *log)
+	return nil
+}
+
+func (m *memoryRepo) UpdateImportLog(log *models.ImportLog) error {
+	m.logs[log.ID-1] = *log
+	return nil
+}
+
+type recordingPublisher struct {
+	topics []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, topic string, _ any) error {
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func newTestImporter(repo *memoryRepo, publisher *recordingPublisher) *Importer {
+	im := NewImporter(repo, publisher, nil)
+	im.supported = func(context.Context) (map[string]struct{}, map[string]float64) {
+		return map[string]struct{}{"BTC": {}, "EUR": {}}, map[string]float64{"BTC": 50000}
+	}
+	return im
+}
+
+func TestImporter_Kraken(t *testing.T) {
+	repo := &memoryRepo{}
+	publisher := &recordingPublisher{}
+	im := newTestImporter(repo, publisher)
+
+	result, err := im.Import(context.Background(), 2, "ledgers.csv", FormatKraken, []byte(krakenLedger))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Imported, "EUR deposit, BTC withdrawal and the trade")
+	assert.Equal(t, 5, result.Total)
+	assert.Len(t, result.Errors, 2, "unsupported DOT and the margin row")
+	assert.Equal(t, "partial", result.Log.Status)
+	assert.Equal(t, "transaction", result.Log.EntityType)
+
+	require.Len(t, repo.exchanges, 1)
+	assert.Equal(t, int64(2), repo.exchanges[0].PortfolioID)
+	assert.Contains(t, repo.exchanges[0].Notes, "kraken imported")
+	assert.Len(t, repo.prices, 1)
+	assert.Equal(t, "import.completed", publisher.topics[len(publisher.topics)-1])
+}
+
+func TestImporter_Retry(t *testing.T) {
+	repo := &memoryRepo{}
+	im := newTestImporter(repo, &recordingPublisher{})
+
+	result, err := im.Import(context.Background(), 1, "assets.json", FormatJSON, []byte(`[{"symbol": "DOGE", "amount": 5, "transaction_type": "deposit"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "failed", result.Log.Status)
+
+	retried, err := im.Retry(context.Background(), result.Log, []models.Asset{{Symbol: "btc", Amount: decimal.RequireFromString("0.1"), TransactionType: "deposit"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried.Imported)
+	assert.Equal(t, "completed", repo.logs[0].Status)
+	assert.Equal(t, "BTC", repo.assets[0].Symbol)
+}
+
+func TestImporter_UnsupportedFormat(t *testing.T) {
+	_, err := NewImporter(&memoryRepo{}, nil, nil).Import(context.Background(), 1, "x.xml", "xml", nil)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestParseBalancesCSV(t *testing.T) {
+	balances, errors := ParseBalancesCSV([]byte(`Symbol,Balance,Portfolio
+btc,0.5,Kraken
+ETH,2.000000000000000001,
+SOL,-1,
+,3,`))
+	require.Len(t, balances, 2)
+	assert.Equal(t, BalanceRow{Row: 2, Portfolio: "Kraken", Symbol: "BTC", Amount: decimal.RequireFromString("0.5")}, balances[0])
+	assert.Equal(t, "2.000000000000000001", balances[1].Amount.String())
+
+	require.Len(t, errors, 2)
+	assert.Equal(t, 4, errors[0].Row)
+	assert.Equal(t, "amount cannot be negative", errors[0].Message)
+	assert.Equal(t, "symbol is required", errors[1].Message)
+
+	balances, errors = ParseBalancesCSV([]byte("symbol;amount\nBTC;1"))
+	assert.Empty(t, errors)
+	assert.Len(t, balances, 1)
+
+	_, errors = ParseBalancesCSV([]byte("symbol;name\nBTC;Bitcoin"))
+	require.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Message, "symbol and amount")
+}
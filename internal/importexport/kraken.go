@@ -0,0 +1,233 @@
+package importexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// krakenAssets maps Kraken's legacy asset codes to their common tickers.
+var krakenAssets = map[string]string{
+	"XXBT": "BTC",
+	"XBT":  "BTC",
+	"XXDG": "DOGE",
+	"XDG":  "DOGE",
+	"XETH": "ETH",
+	"ETH2": "ETH",
+	"XETC": "ETC",
+	"XLTC": "LTC",
+	"XXRP": "XRP",
+	"XXLM": "XLM",
+	"XXMR": "XMR",
+	"XZEC": "ZEC",
+	"XREP": "REP",
+	"XMLN": "MLN",
+	"ZUSD": "USD",
+	"ZEUR": "EUR",
+	"ZGBP": "GBP",
+	"ZCAD": "CAD",
+	"ZJPY": "JPY",
+	"ZAUD": "AUD",
+	"ZCHF": "CHF",
+}
+
+var krakenTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.0000",
+	time.RFC3339,
+}
+
+// KrakenSymbol normalises a Kraken ledger asset code such as XXBT or DOT.S.
+func KrakenSymbol(asset string) string {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if i := strings.Index(asset, "."); i > 0 {
+		asset = asset[:i]
+	}
+	if symbol, ok := krakenAssets[asset]; ok {
+		return symbol
+	}
+	return asset
+}
+
+type krakenEntry struct {
+	row    int
+	raw    map[string]string
+	refID  string
+	time   time.Time
+	kind   string
+	asset  string
+	amount decimal.Decimal
+	fee    decimal.Decimal
+}
+
+// ParseKrakenLedger reads a Kraken ledgers.csv export. Deposits, withdrawals
+// and staking rewards become assets; the two legs of a trade, matched by
+// refid, become an exchange. Other ledger types are reported as row errors.
+func ParseKrakenLedger(data []byte) ([]models.Asset, []models.Exchange, []RowError) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, []RowError{{Row: 0, Message: "invalid CSV format: " + err.Error()}}
+	}
+	if len(records) < 2 {
+		return nil, nil, []RowError{{Row: 0, Message: "CSV file must have a header and at least one data row"}}
+	}
+
+	colIndex := make(map[string]int)
+	for i, col := range records[0] {
+		colIndex[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range []string{"refid", "time", "type", "asset", "amount"} {
+		if _, ok := colIndex[col]; !ok {
+			return nil, nil, []RowError{{Row: 0, Message: fmt.Sprintf("missing %q column, expected a Kraken ledger export", col)}}
+		}
+	}
+
+	var assets []models.Asset
+	var rowErrors []RowError
+	trades := make(map[string][]krakenEntry)
+	var tradeOrder []string
+
+	for i, row := range records[1:] {
+		entry, err := parseKrakenRow(i+2, row, colIndex)
+		if err != nil {
+			rowErrors = append(rowErrors, entry.rowError(err.Error()))
+			continue
+		}
+
+		switch entry.kind {
+		case "deposit", "staking":
+			assets = append(assets, entry.toAsset(entry.amount.Sub(entry.fee), "deposit"))
+		case "earn":
+			if sub := entry.raw["subtype"]; sub != "" && sub != "reward" {
+				rowErrors = append(rowErrors, entry.rowError(fmt.Sprintf("unsupported earn subtype %q", sub)))
+				continue
+			}
+			assets = append(assets, entry.toAsset(entry.amount.Sub(entry.fee), "deposit"))
+		case "withdrawal":
+			assets = append(assets, entry.toAsset(entry.amount.Abs().Add(entry.fee), "withdraw"))
+		case "trade", "spend", "receive":
+			if _, ok := trades[entry.refID]; !ok {
+				tradeOrder = append(tradeOrder, entry.refID)
+			}
+			trades[entry.refID] = append(trades[entry.refID], entry)
+		default:
+			rowErrors = append(rowErrors, entry.rowError(fmt.Sprintf("unsupported ledger type %q", entry.kind)))
+		}
+	}
+
+	var exchanges []models.Exchange
+	for _, refID := range tradeOrder {
+		exchange, err := krakenExchange(trades[refID])
+		if err != nil {
+			for _, entry := range trades[refID] {
+				rowErrors = append(rowErrors, entry.rowError(err.Error()))
+			}
+			continue
+		}
+		exchanges = append(exchanges, exchange)
+	}
+
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	return assets, exchanges, rowErrors
+}
+
+func parseKrakenRow(rowNum int, row []string, colIndex map[string]int) (krakenEntry, error) {
+	entry := krakenEntry{row: rowNum, raw: make(map[string]string)}
+	for col, idx := range colIndex {
+		if idx < len(row) {
+			entry.raw[col] = strings.TrimSpace(row[idx])
+		}
+	}
+
+	entry.refID = entry.raw["refid"]
+	entry.kind = strings.ToLower(entry.raw["type"])
+	entry.asset = KrakenSymbol(entry.raw["asset"])
+	if entry.asset == "" {
+		return entry, fmt.Errorf("asset is required")
+	}
+
+	amount, err := decimal.NewFromString(entry.raw["amount"])
+	if err != nil {
+		return entry, fmt.Errorf("invalid amount %q", entry.raw["amount"])
+	}
+	entry.amount = amount
+
+	if raw := entry.raw["fee"]; raw != "" {
+		fee, err := decimal.NewFromString(raw)
+		if err != nil {
+			return entry, fmt.Errorf("invalid fee %q", raw)
+		}
+		entry.fee = fee
+	}
+
+	for _, layout := range krakenTimeLayouts {
+		if t, err := time.Parse(layout, entry.raw["time"]); err == nil {
+			entry.time = t
+			return entry, nil
+		}
+	}
+	return entry, fmt.Errorf("invalid time %q", entry.raw["time"])
+}
+
+func (e krakenEntry) toAsset(amount decimal.Decimal, txType string) models.Asset {
+	return models.Asset{
+		Symbol:          e.asset,
+		Name:            e.asset,
+		Amount:          amount,
+		TransactionType: txType,
+		Timestamp:       e.time,
+		Notes:           "kraken " + e.kind + " " + e.refID,
+	}
+}
+
+func (e krakenEntry) rowError(message string) RowError {
+	data, _ := json.Marshal(e.raw)
+	return RowError{Row: e.row, Data: data, Message: message}
+}
+
+func krakenExchange(legs []krakenEntry) (models.Exchange, error) {
+	var from, to *krakenEntry
+	for i := range legs {
+		switch {
+		case legs[i].amount.IsNegative() && from == nil:
+			from = &legs[i]
+		case legs[i].amount.IsPositive() && to == nil:
+			to = &legs[i]
+		default:
+			return models.Exchange{}, fmt.Errorf("trade %s must have exactly one outgoing and one incoming leg", legs[i].refID)
+		}
+	}
+	if from == nil || to == nil {
+		return models.Exchange{}, fmt.Errorf("trade %s must have exactly one outgoing and one incoming leg", legs[0].refID)
+	}
+
+	// Amounts are net of fees so holdings match the Kraken balances.
+	exchange := models.Exchange{
+		FromSymbol: from.asset,
+		ToSymbol:   to.asset,
+		FromAmount: from.amount.Neg().Add(from.fee),
+		ToAmount:   to.amount.Sub(to.fee),
+		Timestamp:  to.time,
+		Notes:      "kraken trade " + to.refID,
+	}
+	switch {
+	case from.fee.IsPositive():
+		exchange.Fee = from.fee
+		exchange.FeeCurrency = from.asset
+	case to.fee.IsPositive():
+		exchange.Fee = to.fee
+		exchange.FeeCurrency = to.asset
+	}
+	return exchange, nil
+}
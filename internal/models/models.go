@@ -1,18 +1,60 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"math"
+	"net/mail"
+	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	// Amounts and prices are sent as JSON numbers, as they were when they
+	// were float64. The digits are exact; decimal accepts numbers and quoted
+	// strings when reading.
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+const DefaultPortfolioID int64 = 1
+
+const (
+	ScopeReadPortfolio     = "read:portfolio"
+	ScopeReadPrices        = "read:prices"
+	ScopeWriteTransactions = "write:transactions"
+	ScopeAdmin             = "admin"
+)
+
+var APIKeyScopes = []string{ScopeReadPortfolio, ScopeReadPrices, ScopeWriteTransactions, ScopeAdmin}
+
+// APIKeyCookie holds the API key a browser signed in with, so the bundled UI
+// can reach /api when keys are required.
+const APIKeyCookie = "hodlbook_api_key"
+
+type Portfolio struct {
+	ID          int64     `json:"id"          gorm:"primaryKey"`
+	Name        string    `json:"name"        gorm:"uniqueIndex"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Amounts, fees and prices are decimals stored as text so that balances sum
+// exactly instead of accumulating float64 rounding dust.
 type Asset struct {
-	ID              int64     `json:"id"               gorm:"primaryKey"`
-	Symbol          string    `json:"symbol"           gorm:"index"`
-	Name            string    `json:"name"`
-	Amount          float64   `json:"amount"`
-	TransactionType string    `json:"transaction_type" gorm:"index"`
-	Notes           string    `json:"notes"`
-	PriceSource     *string   `json:"price_source,omitempty"`
-	Timestamp       time.Time `json:"timestamp"        gorm:"index"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int64           `json:"id"               gorm:"primaryKey"`
+	PortfolioID     int64           `json:"portfolio_id"     gorm:"index;index:idx_assets_portfolio_timestamp,priority:1;index:idx_assets_portfolio_symbol_timestamp,priority:1"`
+	WalletID        *int64          `json:"wallet_id,omitempty" gorm:"index"`
+	Symbol          string          `json:"symbol"           gorm:"index;index:idx_assets_portfolio_symbol_timestamp,priority:2"`
+	Name            string          `json:"name"`
+	Amount          decimal.Decimal `json:"amount"           gorm:"type:text"`
+	TransactionType string          `json:"transaction_type" gorm:"index"`
+	Notes           string          `json:"notes"`
+	PriceSource     *string         `json:"price_source,omitempty"`
+	Timestamp       time.Time       `json:"timestamp"        gorm:"index;index:idx_assets_portfolio_timestamp,priority:2;index:idx_assets_portfolio_symbol_timestamp,priority:3"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
 type AssetHistoricValue struct {
@@ -23,26 +65,126 @@ type AssetHistoricValue struct {
 }
 
 type Exchange struct {
-	ID          int64     `json:"id"          gorm:"primaryKey"`
-	FromSymbol  string    `json:"from_symbol" gorm:"index"`
-	ToSymbol    string    `json:"to_symbol"   gorm:"index"`
-	FromAmount  float64   `json:"from_amount"`
-	ToAmount    float64   `json:"to_amount"`
-	Fee         float64   `json:"fee"`
-	FeeCurrency string    `json:"fee_currency"`
-	Notes       string    `json:"notes"`
-	Timestamp   time.Time `json:"timestamp"   gorm:"index"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64           `json:"id"           gorm:"primaryKey"`
+	PortfolioID int64           `json:"portfolio_id" gorm:"index;index:idx_exchanges_portfolio_timestamp,priority:1"`
+	WalletID    *int64          `json:"wallet_id,omitempty" gorm:"index"`
+	FromSymbol  string          `json:"from_symbol"  gorm:"index"`
+	ToSymbol    string          `json:"to_symbol"    gorm:"index"`
+	FromAmount  decimal.Decimal `json:"from_amount"  gorm:"type:text"`
+	ToAmount    decimal.Decimal `json:"to_amount"    gorm:"type:text"`
+	Fee         decimal.Decimal `json:"fee"          gorm:"type:text"`
+	FeeCurrency string          `json:"fee_currency"`
+	Notes       string          `json:"notes"`
+	Timestamp   time.Time       `json:"timestamp"    gorm:"index;index:idx_exchanges_portfolio_timestamp,priority:2"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
 
+// NetHoldings nets deposits, withdrawals and exchanges dated up to until, or
+// all of them when until is zero. Amounts are summed as decimals so a fully
+// withdrawn asset ends at exactly zero.
+func NetHoldings(assets []Asset, exchanges []Exchange, until time.Time) map[string]decimal.Decimal {
+	holdings := make(map[string]decimal.Decimal)
+	for _, asset := range assets {
+		if !until.IsZero() && asset.Timestamp.After(until) {
+			continue
+		}
+		switch asset.TransactionType {
+		case "deposit":
+			holdings[asset.Symbol] = holdings[asset.Symbol].Add(asset.Amount)
+		case "withdraw":
+			holdings[asset.Symbol] = holdings[asset.Symbol].Sub(asset.Amount)
+		}
+	}
+
+	for _, ex := range exchanges {
+		if !until.IsZero() && ex.Timestamp.After(until) {
+			continue
+		}
+		holdings[ex.FromSymbol] = holdings[ex.FromSymbol].Sub(ex.FromAmount)
+		holdings[ex.ToSymbol] = holdings[ex.ToSymbol].Add(ex.ToAmount)
+	}
+	return holdings
+}
+
+// WalletHoldings nets the entries tagged with walletID. untagged holds the
+// symbols moved by entries that are not tagged with any wallet: a wallet's
+// balance of those cannot be told apart from holdings kept elsewhere.
+func WalletHoldings(assets []Asset, exchanges []Exchange, walletID int64) (holdings map[string]decimal.Decimal, untagged map[string]bool) {
+	untagged = make(map[string]bool)
+	var walletAssets []Asset
+	for _, asset := range assets {
+		switch {
+		case asset.WalletID == nil:
+			untagged[asset.Symbol] = true
+		case *asset.WalletID == walletID:
+			walletAssets = append(walletAssets, asset)
+		}
+	}
+
+	var walletExchanges []Exchange
+	for _, ex := range exchanges {
+		switch {
+		case ex.WalletID == nil:
+			untagged[ex.FromSymbol] = true
+			untagged[ex.ToSymbol] = true
+		case *ex.WalletID == walletID:
+			walletExchanges = append(walletExchanges, ex)
+		}
+	}
+	return NetHoldings(walletAssets, walletExchanges, time.Time{}), untagged
+}
+
+// PriceSourceManual marks prices entered by the user rather than fetched.
+const PriceSourceManual = "manual"
+
+// Price is a symbol's price at a point in time.
+//
+// The current price follows two rules. A manual price pins the symbol: until
+// it is deleted or its ExpiresAt passes, it is the current price and the
+// symbol is not fetched, however new the fetched prices are. When several
+// manual prices pin a symbol the newest one is used. Without a pin the newest
+// price wins, manual or fetched. A manual price entered for a past date is
+// stored already expired, so it only feeds history.
+//
+// Lookups at a past time ignore pins: the newest price at or before that time
+// wins, and a manual price wins over a fetched one with the same timestamp.
 type Price struct {
-	ID        int64     `json:"id"         gorm:"primaryKey"`
-	Symbol    string    `json:"symbol"     gorm:"index:idx_symbol_currency_time"`
-	Currency  string    `json:"currency"   gorm:"index:idx_symbol_currency_time"`
-	Price     float64   `json:"price"`
-	Timestamp time.Time `json:"timestamp"  gorm:"index:idx_symbol_currency_time"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64           `json:"id"                   gorm:"primaryKey"`
+	Symbol    string          `json:"symbol"               gorm:"index:idx_symbol_currency_time"`
+	Currency  string          `json:"currency"             gorm:"index:idx_symbol_currency_time"`
+	Price     decimal.Decimal `json:"price"                gorm:"type:text"`
+	Source    string          `json:"source"               gorm:"index"`
+	Timestamp time.Time       `json:"timestamp"            gorm:"index:idx_symbol_currency_time"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (p Price) IsManual() bool {
+	return p.Source == PriceSourceManual
+}
+
+// PinsAt reports whether p is a manual price that still pins its symbol at t.
+func (p Price) PinsAt(t time.Time) bool {
+	return p.IsManual() && (p.ExpiresAt == nil || p.ExpiresAt.After(t))
+}
+
+// LastKnownPrice is the current price for a symbol, fetched or entered by
+// hand, kept so prices are available again right after a restart.
+type LastKnownPrice struct {
+	Symbol    string          `json:"symbol"     gorm:"primaryKey"`
+	Price     decimal.Decimal `json:"price"      gorm:"type:text"`
+	Source    string          `json:"source"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// IsStale reports whether the price is older than maxAge at now. Manual
+// prices are never stale: nothing would refresh them.
+func (p LastKnownPrice) IsStale(now time.Time, maxAge time.Duration) bool {
+	if p.Source == PriceSourceManual {
+		return false
+	}
+	return now.Sub(p.FetchedAt) > maxAge
 }
 
 type Setting struct {
@@ -53,6 +195,7 @@ type Setting struct {
 
 type ImportLog struct {
 	ID           int64     `json:"id"            gorm:"primaryKey"`
+	PortfolioID  int64     `json:"portfolio_id"  gorm:"index"`
 	Filename     string    `json:"filename"`
 	Format       string    `json:"format"`
 	EntityType   string    `json:"entity_type"`
@@ -65,6 +208,605 @@ type ImportLog struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type APIKey struct {
+	ID         int64      `json:"id"           gorm:"primaryKey"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"            gorm:"uniqueIndex"`
+	Scopes     string     `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope reports whether the key grants scope. The admin scope grants all.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+func IsValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	AlertPriceAbove      = "price_above"
+	AlertPriceBelow      = "price_below"
+	AlertPriceChange     = "price_change"
+	AlertPortfolioAbove  = "portfolio_above"
+	AlertPortfolioBelow  = "portfolio_below"
+	AlertAllocationDrift = "allocation_drift"
+)
+
+const DefaultAlertCooldownMinutes = 60
+
+var (
+	ErrAlertInvalidType      = errors.New("invalid alert type")
+	ErrAlertSymbolRequired   = errors.New("symbol is required for this alert type")
+	ErrAlertInvalidThreshold = errors.New("threshold must be positive")
+	ErrAlertWindowRequired   = errors.New("window_minutes must be positive for price_change alerts")
+	ErrAlertInvalidTarget    = errors.New("target_percent must be between 0 and 100")
+)
+
+// AlertRule describes a condition evaluated against every live price update.
+// Threshold is a price for price rules, a USD value for portfolio rules and a
+// percentage for price_change (absolute move) and allocation_drift
+// (percentage points away from TargetPercent).
+type AlertRule struct {
+	ID              int64      `json:"id"               gorm:"primaryKey"`
+	Name            string     `json:"name"`
+	Type            string     `json:"type"             gorm:"index"`
+	Symbol          string     `json:"symbol"`
+	PortfolioID     int64      `json:"portfolio_id"`
+	Threshold       float64    `json:"threshold"`
+	WindowMinutes   int        `json:"window_minutes"`
+	TargetPercent   float64    `json:"target_percent"`
+	CooldownMinutes int        `json:"cooldown_minutes"`
+	Channels        string     `json:"channels"`
+	Enabled         bool       `json:"enabled"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (r *AlertRule) Validate() error {
+	switch r.Type {
+	case AlertPriceAbove, AlertPriceBelow, AlertPriceChange, AlertAllocationDrift:
+		if r.Symbol == "" {
+			return ErrAlertSymbolRequired
+		}
+	case AlertPortfolioAbove, AlertPortfolioBelow:
+	default:
+		return ErrAlertInvalidType
+	}
+	if r.Threshold <= 0 {
+		return ErrAlertInvalidThreshold
+	}
+	if r.Type == AlertPriceChange && r.WindowMinutes <= 0 {
+		return ErrAlertWindowRequired
+	}
+	if r.Type == AlertAllocationDrift && (r.TargetPercent < 0 || r.TargetPercent > 100) {
+		return ErrAlertInvalidTarget
+	}
+	return nil
+}
+
+// ChannelList returns the notification channels the rule delivers to. An
+// empty list means every configured channel.
+func (r *AlertRule) ChannelList() []string {
+	var channels []string
+	for _, c := range strings.Split(r.Channels, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
+
+type AlertEvent struct {
+	ID        int64     `json:"id"         gorm:"primaryKey"`
+	RuleID    int64     `json:"rule_id"    gorm:"index"`
+	RuleName  string    `json:"rule_name"`
+	Type      string    `json:"type"`
+	Symbol    string    `json:"symbol"`
+	Value     float64   `json:"value"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+const (
+	ChannelWebhook  = "webhook"
+	ChannelSMTP     = "smtp"
+	ChannelNtfy     = "ntfy"
+	ChannelGotify   = "gotify"
+	ChannelTelegram = "telegram"
+)
+
+var NotificationChannelTypes = []string{ChannelWebhook, ChannelSMTP, ChannelNtfy, ChannelGotify, ChannelTelegram}
+
+var (
+	ErrChannelNameRequired = errors.New("channel name is required")
+	ErrChannelInvalidType  = errors.New("invalid channel type")
+	ErrChannelURLRequired  = errors.New("url is required for this channel type")
+	ErrChannelTopic        = errors.New("topic is required for ntfy channels")
+	ErrChannelSecret       = errors.New("token is required for this channel type")
+	ErrChannelChatID       = errors.New("chat_id is required for telegram channels")
+	ErrChannelSMTP         = errors.New("smtp_host, from and to are required for smtp channels")
+	ErrChannelSMTPAddress  = errors.New("from and to must be valid email addresses")
+)
+
+// NotificationChannel is an outbound destination for alerts. Alert rules
+// reference channels by Name. Secret holds the webhook signing secret, the
+// ntfy, Gotify or Telegram token, or the SMTP password depending on Type.
+type NotificationChannel struct {
+	ID        int64     `json:"id"         gorm:"primaryKey"`
+	Name      string    `json:"name"       gorm:"uniqueIndex"`
+	Type      string    `json:"type"`
+	Enabled   bool      `json:"enabled"`
+	URL       string    `json:"url"`
+	Topic     string    `json:"topic"`
+	ChatID    string    `json:"chat_id"`
+	SMTPHost  string    `json:"smtp_host"`
+	SMTPPort  int       `json:"smtp_port"`
+	Username  string    `json:"username"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *NotificationChannel) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return ErrChannelNameRequired
+	}
+	switch c.Type {
+	case ChannelWebhook, ChannelGotify:
+		if c.URL == "" {
+			return ErrChannelURLRequired
+		}
+		if c.Type == ChannelGotify && c.Secret == "" {
+			return ErrChannelSecret
+		}
+	case ChannelNtfy:
+		if c.Topic == "" {
+			return ErrChannelTopic
+		}
+	case ChannelTelegram:
+		if c.Secret == "" {
+			return ErrChannelSecret
+		}
+		if c.ChatID == "" {
+			return ErrChannelChatID
+		}
+	case ChannelSMTP:
+		if c.SMTPHost == "" || c.From == "" || len(c.Recipients()) == 0 {
+			return ErrChannelSMTP
+		}
+		for _, addr := range append(c.Recipients(), c.From) {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return ErrChannelSMTPAddress
+			}
+		}
+	default:
+		return ErrChannelInvalidType
+	}
+	return nil
+}
+
+func (c *NotificationChannel) Recipients() []string {
+	var to []string
+	for _, addr := range strings.Split(c.To, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+const (
+	WalletModePropose = "propose"
+	WalletModeFlag    = "flag"
+)
+
+const (
+	DiscrepancyOpen      = "open"
+	DiscrepancyAccepted  = "accepted"
+	DiscrepancyDismissed = "dismissed"
+	DiscrepancyResolved  = "resolved"
+)
+
+var (
+	ErrWalletNameRequired = errors.New("wallet name is required")
+	ErrWalletInvalidMode  = errors.New("mode must be propose or flag")
+	ErrWalletTokens       = errors.New("tokens must be SYMBOL=contract pairs")
+)
+
+// Wallet is a watch-only address or extended public key whose on-chain
+// balances are reconciled against the deposits, withdrawals and exchanges
+// tagged with it through their WalletID. Tokens
+// lists the ERC-20 tokens watched on EVM chains as comma separated
+// SYMBOL=contract pairs. In propose mode a discrepancy comes with the
+// deposit or withdrawal that would close it; in flag mode it is only
+// reported. A symbol that also has untagged entries in the portfolio is only
+// ever flagged.
+type Wallet struct {
+	ID           int64      `json:"id"             gorm:"primaryKey"`
+	PortfolioID  int64      `json:"portfolio_id"   gorm:"index"`
+	Name         string     `json:"name"`
+	Chain        string     `json:"chain"`
+	Address      string     `json:"address"`
+	Tokens       string     `json:"tokens"`
+	Mode         string     `json:"mode"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (w *Wallet) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return ErrWalletNameRequired
+	}
+	if w.Mode != WalletModePropose && w.Mode != WalletModeFlag {
+		return ErrWalletInvalidMode
+	}
+	if _, err := w.TokenList(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WalletToken is one entry of Wallet.Tokens.
+type WalletToken struct {
+	Symbol   string `json:"symbol"`
+	Contract string `json:"contract"`
+}
+
+func (w *Wallet) TokenList() ([]WalletToken, error) {
+	var tokens []WalletToken
+	for _, entry := range strings.Split(w.Tokens, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		symbol, contract, ok := strings.Cut(entry, "=")
+		symbol, contract = strings.TrimSpace(symbol), strings.TrimSpace(contract)
+		if !ok || symbol == "" || contract == "" {
+			return nil, ErrWalletTokens
+		}
+		tokens = append(tokens, WalletToken{Symbol: strings.ToUpper(symbol), Contract: contract})
+	}
+	return tokens, nil
+}
+
+// WalletBalance is the balance of one symbol last observed in a wallet.
+type WalletBalance struct {
+	WalletID   int64           `json:"wallet_id"   gorm:"primaryKey"`
+	Symbol     string          `json:"symbol"      gorm:"primaryKey"`
+	Amount     decimal.Decimal `json:"amount"      gorm:"type:text"`
+	ObservedAt time.Time       `json:"observed_at"`
+}
+
+// WalletDiscrepancy is a difference between the balance a wallet holds of a
+// symbol and the holdings the entries tagged with it add up to. Difference
+// is Observed minus Recorded. ProposedType is the entry that would close the
+// difference, deposit or withdraw, and empty when the discrepancy is only
+// flagged. At most one discrepancy per wallet and symbol is open; it is
+// updated on every sync and resolved once the difference disappears.
+type WalletDiscrepancy struct {
+	ID           int64           `json:"id"            gorm:"primaryKey"`
+	PortfolioID  int64           `json:"portfolio_id"  gorm:"index"`
+	WalletID     int64           `json:"wallet_id"     gorm:"index:idx_wallet_discrepancies_wallet_symbol"`
+	Symbol       string          `json:"symbol"        gorm:"index:idx_wallet_discrepancies_wallet_symbol"`
+	Observed     decimal.Decimal `json:"observed"      gorm:"type:text"`
+	Recorded     decimal.Decimal `json:"recorded"      gorm:"type:text"`
+	Difference   decimal.Decimal `json:"difference"    gorm:"type:text"`
+	ProposedType string          `json:"proposed_type"`
+	Status       string          `json:"status"        gorm:"index"`
+	AssetID      *int64          `json:"asset_id"`
+	ObservedAt   time.Time       `json:"observed_at"`
+	ResolvedAt   *time.Time      `json:"resolved_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// Reconciliation compares the balances an exchange or wallet reported for a
+// portfolio at AsOf with the holdings its transactions add up to at that
+// time. Source is the uploaded file name, or empty for balances entered by
+// hand. Only reported symbols are compared.
+type Reconciliation struct {
+	ID          int64                `json:"id"           gorm:"primaryKey"`
+	PortfolioID int64                `json:"portfolio_id" gorm:"index"`
+	AsOf        time.Time            `json:"as_of"`
+	Source      string               `json:"source"`
+	Notes       string               `json:"notes"`
+	Lines       []ReconciliationLine `json:"lines"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// ReconciliationLine is one reported symbol. Difference is Reported minus
+// Recorded; AdjustmentAssetID is the deposit or withdrawal booked to close
+// it, if any.
+type ReconciliationLine struct {
+	ID                int64           `json:"id"                  gorm:"primaryKey"`
+	ReconciliationID  int64           `json:"reconciliation_id"   gorm:"index"`
+	Symbol            string          `json:"symbol"`
+	Reported          decimal.Decimal `json:"reported"            gorm:"type:text"`
+	Recorded          decimal.Decimal `json:"recorded"            gorm:"type:text"`
+	Difference        decimal.Decimal `json:"difference"          gorm:"type:text"`
+	AdjustmentAssetID *int64          `json:"adjustment_asset_id"`
+}
+
+const (
+	CadenceDaily    = "daily"
+	CadenceWeekly   = "weekly"
+	CadenceBiweekly = "biweekly"
+	CadenceMonthly  = "monthly"
+)
+
+const (
+	RecurringAmountCoin = "coin"
+	RecurringAmountFiat = "fiat"
+)
+
+const (
+	RecurringRunPending  = "pending"
+	RecurringRunExecuted = "executed"
+	RecurringRunSkipped  = "skipped"
+)
+
+var (
+	ErrRecurringNameRequired   = errors.New("recurring transaction name is required")
+	ErrRecurringInvalidType    = errors.New("type must be deposit, withdraw or exchange")
+	ErrRecurringSymbolRequired = errors.New("symbol is required")
+	ErrRecurringFromSymbol     = errors.New("from_symbol is required for exchanges and must differ from symbol")
+	ErrRecurringInvalidAmount  = errors.New("amount must be positive")
+	ErrRecurringAmountUnit     = errors.New("amount_unit must be coin or fiat")
+	ErrRecurringInvalidCadence = errors.New("cadence must be daily, weekly, biweekly or monthly")
+	ErrRecurringStartRequired  = errors.New("start_at is required")
+	ErrRecurringEndBeforeStart = errors.New("end_at must not be before start_at")
+)
+
+// RecurringTransaction is a schedule, such as a weekly DCA buy, that books
+// a deposit, withdrawal or exchange of FromSymbol into Symbol on every
+// occurrence from StartAt until EndAt. Amount is a quantity of Symbol, or
+// a value in the reference currency when AmountUnit is fiat, converted at
+// the price of the occurrence. With RequireConfirmation an occurrence is
+// only proposed as a pending run. Occurrences counts the occurrences
+// already processed and NextRunAt is the one after them.
+type RecurringTransaction struct {
+	ID                  int64           `json:"id"                   gorm:"primaryKey"`
+	PortfolioID         int64           `json:"portfolio_id"         gorm:"index"`
+	Name                string          `json:"name"`
+	Type                string          `json:"type"`
+	Symbol              string          `json:"symbol"`
+	FromSymbol          string          `json:"from_symbol"`
+	Amount              decimal.Decimal `json:"amount"               gorm:"type:text"`
+	AmountUnit          string          `json:"amount_unit"`
+	Cadence             string          `json:"cadence"`
+	StartAt             time.Time       `json:"start_at"`
+	EndAt               *time.Time      `json:"end_at"`
+	RequireConfirmation bool            `json:"require_confirmation"`
+	Enabled             bool            `json:"enabled"`
+	Occurrences         int             `json:"occurrences"`
+	NextRunAt           time.Time       `json:"next_run_at"          gorm:"index"`
+	LastError           string          `json:"last_error"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+func (r *RecurringTransaction) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrRecurringNameRequired
+	}
+	switch r.Type {
+	case "deposit", "withdraw":
+	case "exchange":
+		if r.FromSymbol == "" || r.FromSymbol == r.Symbol {
+			return ErrRecurringFromSymbol
+		}
+	default:
+		return ErrRecurringInvalidType
+	}
+	if r.Symbol == "" {
+		return ErrRecurringSymbolRequired
+	}
+	if !r.Amount.IsPositive() {
+		return ErrRecurringInvalidAmount
+	}
+	if r.AmountUnit != RecurringAmountCoin && r.AmountUnit != RecurringAmountFiat {
+		return ErrRecurringAmountUnit
+	}
+	switch r.Cadence {
+	case CadenceDaily, CadenceWeekly, CadenceBiweekly, CadenceMonthly:
+	default:
+		return ErrRecurringInvalidCadence
+	}
+	if r.StartAt.IsZero() {
+		return ErrRecurringStartRequired
+	}
+	if r.EndAt != nil && r.EndAt.Before(r.StartAt) {
+		return ErrRecurringEndBeforeStart
+	}
+	return nil
+}
+
+// Occurrence returns the time of the n-th occurrence, counting from zero
+// at StartAt.
+func (r *RecurringTransaction) Occurrence(n int) time.Time {
+	return CadenceOccurrence(r.StartAt, r.Cadence, n)
+}
+
+// CadenceOccurrence returns the n-th occurrence of a cadence, counting from
+// zero at start. Monthly occurrences keep the day of the month of start,
+// falling back to the last day of shorter months.
+func CadenceOccurrence(start time.Time, cadence string, n int) time.Time {
+	switch cadence {
+	case CadenceDaily:
+		return start.AddDate(0, 0, n)
+	case CadenceWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case CadenceBiweekly:
+		return start.AddDate(0, 0, 14*n)
+	default:
+		year, month, day := start.Date()
+		hour, minute, second := start.Clock()
+		month += time.Month(n)
+		if last := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location()).Day(); day > last {
+			day = last
+		}
+		return time.Date(year, month, day, hour, minute, second, start.Nanosecond(), start.Location())
+	}
+}
+
+// Reschedule moves the schedule to its first occurrence at or after from.
+func (r *RecurringTransaction) Reschedule(from time.Time) {
+	r.Occurrences = 0
+	for r.Occurrence(r.Occurrences).Before(from) {
+		r.Occurrences++
+	}
+	r.NextRunAt = r.Occurrence(r.Occurrences)
+}
+
+// Advance marks the next occurrence as processed.
+func (r *RecurringTransaction) Advance() {
+	r.Occurrences++
+	r.NextRunAt = r.Occurrence(r.Occurrences)
+}
+
+// Finished reports whether every occurrence up to EndAt was processed.
+func (r *RecurringTransaction) Finished() bool {
+	return r.EndAt != nil && r.NextRunAt.After(*r.EndAt)
+}
+
+// RecurringRun is one occurrence of a recurring transaction with the
+// amounts computed for it. Price is the reference currency price of Symbol
+// the amounts were converted at, zero when none was needed. An executed
+// run points at the asset entry or exchange it booked; a pending one waits
+// to be confirmed or skipped.
+type RecurringRun struct {
+	ID          int64           `json:"id"           gorm:"primaryKey"`
+	RecurringID int64           `json:"recurring_id" gorm:"index"`
+	PortfolioID int64           `json:"portfolio_id"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Symbol      string          `json:"symbol"`
+	Amount      decimal.Decimal `json:"amount"       gorm:"type:text"`
+	FromSymbol  string          `json:"from_symbol"`
+	FromAmount  decimal.Decimal `json:"from_amount"  gorm:"type:text"`
+	Price       decimal.Decimal `json:"price"        gorm:"type:text"`
+	PriceSource string          `json:"price_source"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	Status      string          `json:"status"       gorm:"index"`
+	AssetID     *int64          `json:"asset_id"`
+	ExchangeID  *int64          `json:"exchange_id"`
+	ResolvedAt  *time.Time      `json:"resolved_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+var (
+	ErrTargetSymbolOrCategory = errors.New("a target needs either a symbol or a category")
+	ErrTargetInvalidWeight    = errors.New("target weight must be between 0 and 100")
+	ErrTargetDuplicate        = errors.New("a symbol or category can only be targeted once")
+	ErrTargetsTotal           = errors.New("target weights must add up to 100")
+	ErrCategoryRequired       = errors.New("symbol and category are required")
+)
+
+// AllocationTarget is the share of the value of a portfolio wanted in a
+// symbol or in a category of symbols, in percent. A symbol with its own
+// target does not count towards its category. PortfolioID zero holds the
+// targets of all portfolios together.
+type AllocationTarget struct {
+	ID          int64     `json:"id"           gorm:"primaryKey"`
+	PortfolioID int64     `json:"portfolio_id" gorm:"index"`
+	Symbol      string    `json:"symbol"`
+	Category    string    `json:"category"`
+	Weight      float64   `json:"weight"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (t *AllocationTarget) Validate() error {
+	if (t.Symbol == "") == (t.Category == "") {
+		return ErrTargetSymbolOrCategory
+	}
+	if t.Weight <= 0 || t.Weight > 100 {
+		return ErrTargetInvalidWeight
+	}
+	return nil
+}
+
+// Key identifies what a target applies to; categories are prefixed so that
+// they cannot collide with a symbol.
+func (t *AllocationTarget) Key() string {
+	if t.Category != "" {
+		return "category:" + t.Category
+	}
+	return t.Symbol
+}
+
+// ValidateTargets checks a complete set of targets: each is valid, nothing
+// is targeted twice and the weights add up to 100. An empty set clears the
+// targets.
+func ValidateTargets(targets []AllocationTarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(targets))
+	var total float64
+	for i := range targets {
+		if err := targets[i].Validate(); err != nil {
+			return err
+		}
+		if seen[targets[i].Key()] {
+			return ErrTargetDuplicate
+		}
+		seen[targets[i].Key()] = true
+		total += targets[i].Weight
+	}
+	if math.Abs(total-100) > 0.01 {
+		return ErrTargetsTotal
+	}
+	return nil
+}
+
+// AssetCategory groups a symbol with others, such as stablecoins, so that a
+// single target can cover them.
+type AssetCategory struct {
+	Symbol    string    `json:"symbol"     gorm:"primaryKey"`
+	Category  string    `json:"category"   gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Portfolio) TableName() string {
+	return "portfolios"
+}
+
 func (Asset) TableName() string {
 	return "assets"
 }
@@ -84,3 +826,55 @@ func (Price) TableName() string {
 func (ImportLog) TableName() string {
 	return "import_logs"
 }
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+func (AlertEvent) TableName() string {
+	return "alert_events"
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+func (Wallet) TableName() string {
+	return "wallets"
+}
+
+func (WalletBalance) TableName() string {
+	return "wallet_balances"
+}
+
+func (WalletDiscrepancy) TableName() string {
+	return "wallet_discrepancies"
+}
+
+func (Reconciliation) TableName() string {
+	return "reconciliations"
+}
+
+func (ReconciliationLine) TableName() string {
+	return "reconciliation_lines"
+}
+
+func (RecurringTransaction) TableName() string {
+	return "recurring_transactions"
+}
+
+func (RecurringRun) TableName() string {
+	return "recurring_runs"
+}
+
+func (AllocationTarget) TableName() string {
+	return "allocation_targets"
+}
+
+func (AssetCategory) TableName() string {
+	return "asset_categories"
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"time"
+
+	"hodlbook/internal/models"
+)
+
+func (r *Repository) CreateAlertRule(rule *models.AlertRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *Repository) GetAlertRuleByID(id int64) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *Repository) ListAlertRules() ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := r.db.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *Repository) ListEnabledAlertRules() ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := r.db.Where("enabled = ?", true).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *Repository) UpdateAlertRule(rule *models.AlertRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *Repository) DeleteAlertRule(id int64) error {
+	return r.db.Delete(&models.AlertRule{}, id).Error
+}
+
+func (r *Repository) MarkAlertRuleTriggered(id int64, at time.Time) error {
+	return r.db.Model(&models.AlertRule{}).Where("id = ?", id).UpdateColumn("last_triggered_at", at.UTC()).Error
+}
+
+func (r *Repository) CreateAlertEvent(event *models.AlertEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *Repository) ListAlertEvents(limit int) ([]models.AlertEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	var events []models.AlertEvent
+	if err := r.db.Order("created_at DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRepository_EnabledAndTriggered(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	enabled := &models.AlertRule{Type: models.AlertPriceAbove, Symbol: "BTC", Threshold: 1, Enabled: true}
+	disabled := &models.AlertRule{Type: models.AlertPriceBelow, Symbol: "ETH", Threshold: 1}
+	require.NoError(t, repository.CreateAlertRule(enabled))
+	require.NoError(t, repository.CreateAlertRule(disabled))
+
+	rules, err := repository.ListEnabledAlertRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, enabled.ID, rules[0].ID)
+
+	at := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repository.MarkAlertRuleTriggered(enabled.ID, at))
+
+	got, err := repository.GetAlertRuleByID(enabled.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastTriggeredAt)
+	require.True(t, at.Equal(*got.LastTriggeredAt))
+}
+
+func TestAlertRepository_EventsNewestFirst(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repository.CreateAlertEvent(&models.AlertEvent{
+			RuleID:    1,
+			Message:   "event",
+			Value:     float64(i),
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	events, err := repository.ListAlertEvents(2)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, 2.0, events[0].Value)
+}
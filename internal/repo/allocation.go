@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListAllocationTargets returns the targets of a portfolio, largest first.
+// Unlike other lists, portfolioID zero selects the targets set for all
+// portfolios together rather than every portfolio's.
+func (r *Repository) ListAllocationTargets(portfolioID int64) ([]models.AllocationTarget, error) {
+	var targets []models.AllocationTarget
+	err := r.db.Where("portfolio_id = ?", portfolioID).
+		Order("weight DESC").Order("id ASC").
+		Find(&targets).Error
+	return targets, err
+}
+
+// ReplaceAllocationTargets swaps the targets of a portfolio for new ones.
+func (r *Repository) ReplaceAllocationTargets(portfolioID int64, targets []models.AllocationTarget) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("portfolio_id = ?", portfolioID).Delete(&models.AllocationTarget{}).Error; err != nil {
+			return err
+		}
+		for i := range targets {
+			targets[i].ID = 0
+			targets[i].PortfolioID = portfolioID
+		}
+		if len(targets) == 0 {
+			return nil
+		}
+		return tx.Create(&targets).Error
+	})
+}
+
+func (r *Repository) ListAssetCategories() ([]models.AssetCategory, error) {
+	var categories []models.AssetCategory
+	err := r.db.Order("category ASC").Order("symbol ASC").Find(&categories).Error
+	return categories, err
+}
+
+// ReplaceAssetCategories swaps every symbol category for new ones.
+func (r *Repository) ReplaceAssetCategories(categories []models.AssetCategory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.AssetCategory{}).Error; err != nil {
+			return err
+		}
+		if len(categories) == 0 {
+			return nil
+		}
+		return tx.Create(&categories).Error
+	})
+}
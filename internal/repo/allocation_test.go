@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocationRepository_ReplaceTargetsAndCategories(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	require.NoError(t, repository.ReplaceAllocationTargets(1, []models.AllocationTarget{
+		{Symbol: "ETH", Weight: 30},
+		{Symbol: "BTC", Weight: 50},
+		{Category: "Stablecoins", Weight: 20},
+	}))
+	require.NoError(t, repository.ReplaceAllocationTargets(0, []models.AllocationTarget{{Symbol: "BTC", Weight: 100}}))
+
+	targets, err := repository.ListAllocationTargets(1)
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+	require.Equal(t, "BTC", targets[0].Symbol)
+	require.Equal(t, int64(1), targets[0].PortfolioID)
+	require.Equal(t, "Stablecoins", targets[2].Category)
+
+	require.NoError(t, repository.ReplaceAllocationTargets(1, []models.AllocationTarget{{Symbol: "SOL", Weight: 100}}))
+	targets, err = repository.ListAllocationTargets(1)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "SOL", targets[0].Symbol)
+
+	all, err := repository.ListAllocationTargets(0)
+	require.NoError(t, err)
+	require.Len(t, all, 1, "portfolio zero keeps its own targets")
+
+	require.NoError(t, repository.ReplaceAssetCategories([]models.AssetCategory{
+		{Symbol: "USDT", Category: "Stablecoins"},
+		{Symbol: "USDC", Category: "Stablecoins"},
+	}))
+	require.NoError(t, repository.ReplaceAssetCategories([]models.AssetCategory{
+		{Symbol: "USDC", Category: "Stablecoins"},
+		{Symbol: "DAI", Category: "Stablecoins"},
+	}))
+	categories, err := repository.ListAssetCategories()
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	require.Equal(t, "DAI", categories[0].Symbol)
+}
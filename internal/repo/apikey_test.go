@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRepository_CreateAndAuthenticate(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	key, raw, err := repository.CreateAPIKey("grafana", []string{models.ScopeReadPortfolio, "bogus"}, nil)
+	require.NoError(t, err)
+	require.NotZero(t, key.ID)
+	require.Equal(t, models.ScopeReadPortfolio, key.Scopes)
+	require.Equal(t, raw[:len(key.Prefix)], key.Prefix)
+	require.NotEqual(t, raw, key.KeyHash)
+
+	got, err := repository.AuthenticateAPIKey(raw)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, got.ID)
+	require.NotNil(t, got.LastUsedAt)
+	require.True(t, got.HasScope(models.ScopeReadPortfolio))
+	require.False(t, got.HasScope(models.ScopeWriteTransactions))
+
+	_, err = repository.AuthenticateAPIKey(raw + "x")
+	require.ErrorIs(t, err, ErrAPIKeyInvalid)
+}
+
+func TestAPIKeyRepository_NoValidScopes(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	_, _, err = repository.CreateAPIKey("empty", []string{"bogus"}, nil)
+	require.ErrorIs(t, err, ErrAPIKeyNoScopes)
+}
+
+func TestAPIKeyRepository_RevokeAndExpire(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	key, raw, err := repository.CreateAPIKey("display", []string{models.ScopeAdmin}, nil)
+	require.NoError(t, err)
+	require.NoError(t, repository.RevokeAPIKey(key.ID))
+
+	_, err = repository.AuthenticateAPIKey(raw)
+	require.ErrorIs(t, err, ErrAPIKeyInactive)
+
+	past := time.Now().Add(-time.Hour)
+	_, raw, err = repository.CreateAPIKey("old", []string{models.ScopeReadPrices}, &past)
+	require.NoError(t, err)
+
+	_, err = repository.AuthenticateAPIKey(raw)
+	require.ErrorIs(t, err, ErrAPIKeyInactive)
+
+	keys, err := repository.ListAPIKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+}
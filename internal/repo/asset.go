@@ -3,25 +3,66 @@ package repo
 import (
 	"hodlbook/internal/models"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// AssetFilter selects and orders assets. SortBy is one of date, type, symbol
+// or amount and defaults to date; SortDir defaults to desc. After and Before
+// take a cursor from a previous result and override Offset.
 type AssetFilter struct {
+	PortfolioID     int64
 	Symbol          string
+	Symbols         []string
 	TransactionType string
+	Search          string
 	StartDate       *time.Time
 	EndDate         *time.Time
+	SortBy          string
+	SortDir         string
+	After           string
+	Before          string
 	Limit           int
 	Offset          int
 }
 
 type AssetListResult struct {
-	Assets []models.Asset `json:"assets"`
-	Total  int64          `json:"total"`
-	Limit  int            `json:"limit"`
-	Offset int            `json:"offset"`
+	Assets     []models.Asset `json:"assets"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
+}
+
+var assetSortColumns = map[string]sortColumn{
+	"date":   timestampColumn("timestamp"),
+	"type":   textColumn("transaction_type"),
+	"symbol": textColumn("symbol"),
+	"amount": decimalColumn("amount"),
+}
+
+func assetCursor(sortBy string) func(models.Asset) Cursor {
+	return func(a models.Asset) Cursor {
+		var value string
+		switch sortBy {
+		case "type":
+			value = a.TransactionType
+		case "symbol":
+			value = a.Symbol
+		case "amount":
+			value = a.Amount.String()
+		default:
+			value = timestampValue(a.Timestamp)
+		}
+		return Cursor{Value: value, ID: a.ID}
+	}
 }
 
 func (r *Repository) CreateAsset(asset *models.Asset) error {
+	if asset.PortfolioID == 0 {
+		asset.PortfolioID = models.DefaultPortfolioID
+	}
 	return r.db.Create(asset).Error
 }
 
@@ -41,6 +82,14 @@ func (r *Repository) GetAllAssets() ([]models.Asset, error) {
 	return assets, nil
 }
 
+func (r *Repository) GetAssetsByPortfolio(portfolioID int64) ([]models.Asset, error) {
+	var assets []models.Asset
+	if err := scopePortfolio(r.db, portfolioID).Order("timestamp DESC").Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
 func (r *Repository) GetAssetsBySymbol(symbol string) ([]models.Asset, error) {
 	var assets []models.Asset
 	if err := r.db.Where("symbol = ?", symbol).Order("timestamp DESC").Find(&assets).Error; err != nil {
@@ -67,34 +116,45 @@ func (r *Repository) DeleteAsset(id int64) error {
 
 func (r *Repository) GetAssetsByDateRange(startDate, endDate time.Time) ([]models.Asset, error) {
 	var assets []models.Asset
-	if err := r.db.Where("timestamp BETWEEN ? AND ?", startDate, endDate).Order("timestamp DESC").Find(&assets).Error; err != nil {
+	if err := r.db.Where("timestamp BETWEEN ? AND ?", startDate.UTC(), endDate.UTC()).Order("timestamp DESC").Find(&assets).Error; err != nil {
 		return nil, err
 	}
 	return assets, nil
 }
 
-func (r *Repository) GetTotalBySymbolAndType(symbol string, txType string) (float64, error) {
-	var total float64
-	if err := r.db.Model(&models.Asset{}).Where("symbol = ? AND transaction_type = ?", symbol, txType).Pluck("COALESCE(SUM(amount), 0)", &total).Error; err != nil {
-		return 0, err
+// GetTotalBySymbolAndType sums amounts in Go: they are stored as text, which
+// SQL SUM would round through floating point.
+func (r *Repository) GetTotalBySymbolAndType(symbol string, txType string) (decimal.Decimal, error) {
+	var amounts []decimal.Decimal
+	if err := r.db.Model(&models.Asset{}).Where("symbol = ? AND transaction_type = ?", symbol, txType).Pluck("amount", &amounts).Error; err != nil {
+		return decimal.Zero, err
 	}
-	return total, nil
+	return decimal.Sum(decimal.Zero, amounts...), nil
 }
 
 func (r *Repository) ListAssets(filter AssetFilter) (*AssetListResult, error) {
-	query := r.db.Model(&models.Asset{})
+	page, err := newKeyset(assetSortColumns, filter.SortBy, filter.SortDir, filter.Limit, filter.Offset, filter.After, filter.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	query := scopePortfolio(r.db.Model(&models.Asset{}), filter.PortfolioID)
 
-	if filter.Symbol != "" {
-		query = query.Where("symbol = ?", filter.Symbol)
+	symbols := nonEmpty(append([]string{filter.Symbol}, filter.Symbols...))
+	if len(symbols) > 0 {
+		query = query.Where("symbol IN ?", symbols)
 	}
 	if filter.TransactionType != "" {
 		query = query.Where("transaction_type = ?", filter.TransactionType)
 	}
+	if filter.Search != "" {
+		query = query.Where(`LOWER(notes) LIKE ? ESCAPE '\'`, likePattern(filter.Search))
+	}
 	if filter.StartDate != nil {
-		query = query.Where("timestamp >= ?", *filter.StartDate)
+		query = query.Where("timestamp >= ?", filter.StartDate.UTC())
 	}
 	if filter.EndDate != nil {
-		query = query.Where("timestamp <= ?", *filter.EndDate)
+		query = query.Where("timestamp <= ?", filter.EndDate.UTC())
 	}
 
 	var total int64
@@ -102,29 +162,23 @@ func (r *Repository) ListAssets(filter AssetFilter) (*AssetListResult, error) {
 		return nil, err
 	}
 
-	limit := filter.Limit
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset := filter.Offset
-	if offset < 0 {
-		offset = 0
+	query, err = page.apply(query)
+	if err != nil {
+		return nil, err
 	}
-
 	var assets []models.Asset
-	if err := query.Order("timestamp DESC").Limit(limit).Offset(offset).Find(&assets).Error; err != nil {
+	if err := query.Find(&assets).Error; err != nil {
 		return nil, err
 	}
+	assets, next, prev := pageRows(page, assets, assetCursor(filter.SortBy))
 
 	return &AssetListResult{
-		Assets: assets,
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
+		Assets:     assets,
+		Total:      total,
+		Limit:      page.limit,
+		Offset:     page.offset,
+		NextCursor: next,
+		PrevCursor: prev,
 	}, nil
 }
 
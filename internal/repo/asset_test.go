@@ -4,6 +4,7 @@ import (
 	"hodlbook/internal/models"
 	"testing"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,8 +40,8 @@ func TestAssetRepository_MultipleWithSameSymbol(t *testing.T) {
 	repository, err := New(db)
 	require.NoError(t, err)
 
-	asset1 := &models.Asset{Symbol: "ETH", Name: "Ethereum", TransactionType: "deposit", Amount: 1.0}
-	asset2 := &models.Asset{Symbol: "ETH", Name: "Ethereum", TransactionType: "deposit", Amount: 2.0}
+	asset1 := &models.Asset{Symbol: "ETH", Name: "Ethereum", TransactionType: "deposit", Amount: decimal.NewFromInt(1)}
+	asset2 := &models.Asset{Symbol: "ETH", Name: "Ethereum", TransactionType: "deposit", Amount: decimal.NewFromInt(2)}
 
 	require.NoError(t, repository.CreateAsset(asset1))
 	require.NoError(t, repository.CreateAsset(asset2))
@@ -49,3 +50,24 @@ func TestAssetRepository_MultipleWithSameSymbol(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, assets, 2)
 }
+
+func TestAssetRepository_TotalIsExact(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repository.CreateAsset(&models.Asset{Symbol: "BTC", TransactionType: "deposit", Amount: decimal.RequireFromString("0.1")}))
+	}
+	wei := decimal.RequireFromString("1.000000000000000001")
+	require.NoError(t, repository.CreateAsset(&models.Asset{Symbol: "USDC", TransactionType: "deposit", Amount: wei}))
+	require.NoError(t, repository.CreateAsset(&models.Asset{Symbol: "USDC", TransactionType: "deposit", Amount: wei}))
+
+	total, err := repository.GetTotalBySymbolAndType("BTC", "deposit")
+	require.NoError(t, err)
+	require.Equal(t, "0.3", total.String())
+
+	total, err = repository.GetTotalBySymbolAndType("USDC", "deposit")
+	require.NoError(t, err)
+	require.Equal(t, "2.000000000000000002", total.String())
+}
@@ -2,6 +2,8 @@ package repo
 
 import (
 	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
 )
 
 func (r *Repository) Insert(value *models.AssetHistoricValue) error {
@@ -14,6 +16,19 @@ func (r *Repository) SelectAllBySymbol(symbol string) ([]models.AssetHistoricVal
 	return values, err
 }
 
+// ReplaceHistoricValues swaps the whole value series of a symbol for values.
+func (r *Repository) ReplaceHistoricValues(symbol string, values []models.AssetHistoricValue) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("symbol = ?", symbol).Delete(&models.AssetHistoricValue{}).Error; err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(values, 500).Error
+	})
+}
+
 func (r *Repository) GetHistoricSymbols() ([]string, error) {
 	var symbols []string
 	err := r.db.Model(&models.AssetHistoricValue{}).Distinct("symbol").Pluck("symbol", &symbols).Error
@@ -30,3 +30,31 @@ func TestAssetHistoricValues(t *testing.T) {
 	assert.Equal(t, historicValue.Value, values[0].Value)
 	assert.Equal(t, historicValue.Symbol, values[0].Symbol)
 }
+
+func TestAssetHistoricValues_Replace(t *testing.T) {
+	db := setupTestDB(t)
+	repo := &Repository{db: db}
+
+	now := time.Now().UTC()
+	assert.NoError(t, repo.Insert(&models.AssetHistoricValue{Symbol: "BTC", Value: 1, Timestamp: now}))
+	assert.NoError(t, repo.Insert(&models.AssetHistoricValue{Symbol: "ETH", Value: 2, Timestamp: now}))
+
+	assert.NoError(t, repo.ReplaceHistoricValues("BTC", []models.AssetHistoricValue{
+		{Symbol: "BTC", Value: 10, Timestamp: now.Add(-time.Hour)},
+		{Symbol: "BTC", Value: 20, Timestamp: now},
+	}))
+
+	values, err := repo.SelectAllBySymbol("BTC")
+	assert.NoError(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, 20.0, values[0].Value)
+
+	values, err = repo.SelectAllBySymbol("ETH")
+	assert.NoError(t, err)
+	assert.Len(t, values, 1, "other symbols are left alone")
+
+	assert.NoError(t, repo.ReplaceHistoricValues("BTC", nil))
+	values, err = repo.SelectAllBySymbol("BTC")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
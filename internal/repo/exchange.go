@@ -5,24 +5,67 @@ import (
 	"time"
 )
 
+// ExchangeFilter selects and orders exchanges. Symbols match either side of
+// an exchange. SortBy is one of date, from_symbol, to_symbol, from_amount or
+// to_amount and defaults to date; SortDir defaults to desc. After and Before
+// take a cursor from a previous result and override Offset.
 type ExchangeFilter struct {
-	FromSymbol *string
-	ToSymbol   *string
-	Symbol     *string
-	StartDate  *time.Time
-	EndDate    *time.Time
-	Limit      int
-	Offset     int
+	PortfolioID int64
+	FromSymbol  *string
+	ToSymbol    *string
+	Symbol      *string
+	Symbols     []string
+	Search      string
+	StartDate   *time.Time
+	EndDate     *time.Time
+	SortBy      string
+	SortDir     string
+	After       string
+	Before      string
+	Limit       int
+	Offset      int
 }
 
 type ExchangeListResult struct {
-	Exchanges []models.Exchange `json:"exchanges"`
-	Total     int64             `json:"total"`
-	Limit     int               `json:"limit"`
-	Offset    int               `json:"offset"`
+	Exchanges  []models.Exchange `json:"exchanges"`
+	Total      int64             `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+}
+
+var exchangeSortColumns = map[string]sortColumn{
+	"date":        timestampColumn("timestamp"),
+	"from_symbol": textColumn("from_symbol"),
+	"to_symbol":   textColumn("to_symbol"),
+	"from_amount": decimalColumn("from_amount"),
+	"to_amount":   decimalColumn("to_amount"),
+}
+
+func exchangeCursor(sortBy string) func(models.Exchange) Cursor {
+	return func(e models.Exchange) Cursor {
+		var value string
+		switch sortBy {
+		case "from_symbol":
+			value = e.FromSymbol
+		case "to_symbol":
+			value = e.ToSymbol
+		case "from_amount":
+			value = e.FromAmount.String()
+		case "to_amount":
+			value = e.ToAmount.String()
+		default:
+			value = timestampValue(e.Timestamp)
+		}
+		return Cursor{Value: value, ID: e.ID}
+	}
 }
 
 func (r *Repository) CreateExchange(exchange *models.Exchange) error {
+	if exchange.PortfolioID == 0 {
+		exchange.PortfolioID = models.DefaultPortfolioID
+	}
 	return r.db.Create(exchange).Error
 }
 
@@ -42,6 +85,14 @@ func (r *Repository) GetAllExchanges() ([]models.Exchange, error) {
 	return exchanges, nil
 }
 
+func (r *Repository) GetExchangesByPortfolio(portfolioID int64) ([]models.Exchange, error) {
+	var exchanges []models.Exchange
+	if err := scopePortfolio(r.db, portfolioID).Order("timestamp DESC").Find(&exchanges).Error; err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
 func (r *Repository) GetExchangesBySymbol(symbol string) ([]models.Exchange, error) {
 	var exchanges []models.Exchange
 	if err := r.db.Where("from_symbol = ? OR to_symbol = ?", symbol, symbol).Order("timestamp DESC").Find(&exchanges).Error; err != nil {
@@ -68,7 +119,7 @@ func (r *Repository) GetExchangesByToSymbol(symbol string) ([]models.Exchange, e
 
 func (r *Repository) GetExchangesByDateRange(startDate, endDate time.Time) ([]models.Exchange, error) {
 	var exchanges []models.Exchange
-	if err := r.db.Where("timestamp BETWEEN ? AND ?", startDate, endDate).Order("timestamp DESC").Find(&exchanges).Error; err != nil {
+	if err := r.db.Where("timestamp BETWEEN ? AND ?", startDate.UTC(), endDate.UTC()).Order("timestamp DESC").Find(&exchanges).Error; err != nil {
 		return nil, err
 	}
 	return exchanges, nil
@@ -108,10 +159,19 @@ func (r *Repository) GetUniqueExchangeSymbols() ([]string, error) {
 }
 
 func (r *Repository) ListExchanges(filter ExchangeFilter) (*ExchangeListResult, error) {
-	query := r.db.Model(&models.Exchange{})
+	page, err := newKeyset(exchangeSortColumns, filter.SortBy, filter.SortDir, filter.Limit, filter.Offset, filter.After, filter.Before)
+	if err != nil {
+		return nil, err
+	}
 
+	query := scopePortfolio(r.db.Model(&models.Exchange{}), filter.PortfolioID)
+
+	symbols := nonEmpty(filter.Symbols)
 	if filter.Symbol != nil {
-		query = query.Where("from_symbol = ? OR to_symbol = ?", *filter.Symbol, *filter.Symbol)
+		symbols = append(symbols, *filter.Symbol)
+	}
+	if len(symbols) > 0 {
+		query = query.Where("(from_symbol IN ? OR to_symbol IN ?)", symbols, symbols)
 	} else {
 		if filter.FromSymbol != nil {
 			query = query.Where("from_symbol = ?", *filter.FromSymbol)
@@ -120,11 +180,14 @@ func (r *Repository) ListExchanges(filter ExchangeFilter) (*ExchangeListResult,
 			query = query.Where("to_symbol = ?", *filter.ToSymbol)
 		}
 	}
+	if filter.Search != "" {
+		query = query.Where(`LOWER(notes) LIKE ? ESCAPE '\'`, likePattern(filter.Search))
+	}
 	if filter.StartDate != nil {
-		query = query.Where("timestamp >= ?", *filter.StartDate)
+		query = query.Where("timestamp >= ?", filter.StartDate.UTC())
 	}
 	if filter.EndDate != nil {
-		query = query.Where("timestamp <= ?", *filter.EndDate)
+		query = query.Where("timestamp <= ?", filter.EndDate.UTC())
 	}
 
 	var total int64
@@ -132,28 +195,22 @@ func (r *Repository) ListExchanges(filter ExchangeFilter) (*ExchangeListResult,
 		return nil, err
 	}
 
-	limit := filter.Limit
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset := filter.Offset
-	if offset < 0 {
-		offset = 0
+	query, err = page.apply(query)
+	if err != nil {
+		return nil, err
 	}
-
 	var exchanges []models.Exchange
-	if err := query.Order("timestamp DESC").Limit(limit).Offset(offset).Find(&exchanges).Error; err != nil {
+	if err := query.Find(&exchanges).Error; err != nil {
 		return nil, err
 	}
+	exchanges, next, prev := pageRows(page, exchanges, exchangeCursor(filter.SortBy))
 
 	return &ExchangeListResult{
-		Exchanges: exchanges,
-		Total:     total,
-		Limit:     limit,
-		Offset:    offset,
+		Exchanges:  exchanges,
+		Total:      total,
+		Limit:      page.limit,
+		Offset:     page.offset,
+		NextCursor: next,
+		PrevCursor: prev,
 	}, nil
 }
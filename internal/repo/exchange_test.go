@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,9 +17,9 @@ func TestExchangeRepository_CRUD(t *testing.T) {
 	exchange := &models.Exchange{
 		FromSymbol:  "BTC",
 		ToSymbol:    "ETH",
-		FromAmount:  1.0,
-		ToAmount:    30000.0,
-		Fee:         0.001,
+		FromAmount:  decimal.NewFromInt(1),
+		ToAmount:    decimal.NewFromInt(30000),
+		Fee:         decimal.RequireFromString("0.001"),
 		FeeCurrency: "BTC",
 		Notes:       "test exchange",
 		Timestamp:   time.Now(),
@@ -29,14 +30,15 @@ func TestExchangeRepository_CRUD(t *testing.T) {
 
 	got, err := repository.GetExchangeByID(exchange.ID)
 	require.NoError(t, err)
-	require.Equal(t, exchange.FromAmount, got.FromAmount)
-	require.Equal(t, exchange.ToAmount, got.ToAmount)
+	require.Equal(t, "1", got.FromAmount.String())
+	require.Equal(t, "30000", got.ToAmount.String())
+	require.Equal(t, "0.001", got.Fee.String())
 
-	exchange.ToAmount = 31000.0
+	exchange.ToAmount = decimal.NewFromInt(31000)
 	require.NoError(t, repository.UpdateExchange(exchange))
 	got, err = repository.GetExchangeByID(exchange.ID)
 	require.NoError(t, err)
-	require.Equal(t, 31000.0, got.ToAmount)
+	require.Equal(t, "31000", got.ToAmount.String())
 
 	exchanges, err := repository.GetAllExchanges()
 	require.NoError(t, err)
@@ -52,8 +54,8 @@ func TestExchangeRepository_GetBySymbol(t *testing.T) {
 	repository, err := New(db)
 	require.NoError(t, err)
 
-	exchange1 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 1.0, ToAmount: 15.0, Timestamp: time.Now()}
-	exchange2 := &models.Exchange{FromSymbol: "ETH", ToSymbol: "SOL", FromAmount: 15.0, ToAmount: 30000.0, Timestamp: time.Now()}
+	exchange1 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.NewFromInt(15), Timestamp: time.Now()}
+	exchange2 := &models.Exchange{FromSymbol: "ETH", ToSymbol: "SOL", FromAmount: decimal.NewFromInt(15), ToAmount: decimal.NewFromInt(30000), Timestamp: time.Now()}
 
 	require.NoError(t, repository.CreateExchange(exchange1))
 	require.NoError(t, repository.CreateExchange(exchange2))
@@ -80,8 +82,8 @@ func TestExchangeRepository_GetByDateRange(t *testing.T) {
 	yesterday := now.Add(-24 * time.Hour)
 	twoDaysAgo := now.Add(-48 * time.Hour)
 
-	exchange1 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 1.0, ToAmount: 15.0, Timestamp: twoDaysAgo}
-	exchange2 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: 2.0, ToAmount: 30.0, Timestamp: now}
+	exchange1 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.NewFromInt(15), Timestamp: twoDaysAgo}
+	exchange2 := &models.Exchange{FromSymbol: "BTC", ToSymbol: "ETH", FromAmount: decimal.NewFromInt(2), ToAmount: decimal.NewFromInt(30), Timestamp: now}
 
 	require.NoError(t, repository.CreateExchange(exchange1))
 	require.NoError(t, repository.CreateExchange(exchange2))
@@ -89,5 +91,5 @@ func TestExchangeRepository_GetByDateRange(t *testing.T) {
 	exchanges, err := repository.GetExchangesByDateRange(yesterday, now.Add(time.Hour))
 	require.NoError(t, err)
 	require.Len(t, exchanges, 1)
-	require.Equal(t, 2.0, exchanges[0].FromAmount)
+	require.Equal(t, "2", exchanges[0].FromAmount.String())
 }
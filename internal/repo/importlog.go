@@ -5,6 +5,9 @@ import (
 )
 
 func (r *Repository) CreateImportLog(log *models.ImportLog) error {
+	if log.PortfolioID == 0 {
+		log.PortfolioID = models.DefaultPortfolioID
+	}
 	return r.db.Create(log).Error
 }
 
@@ -24,6 +27,14 @@ func (r *Repository) ListImportLogs() ([]models.ImportLog, error) {
 	return logs, nil
 }
 
+func (r *Repository) ListImportLogsByPortfolio(portfolioID int64) ([]models.ImportLog, error) {
+	var logs []models.ImportLog
+	if err := scopePortfolio(r.db, portfolioID).Order("created_at DESC").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
 func (r *Repository) UpdateImportLog(log *models.ImportLog) error {
 	return r.db.Save(log).Error
 }
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+)
+
+// SaveLastKnownPrices stores each price as the latest for its symbol,
+// replacing the one stored before.
+func (r *Repository) SaveLastKnownPrices(prices []models.LastKnownPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+	for i := range prices {
+		prices[i].FetchedAt = prices[i].FetchedAt.UTC()
+	}
+	return r.db.Clauses(lastKnownUpsert).Create(&prices).Error
+}
+
+func (r *Repository) GetLastKnownPrices() ([]models.LastKnownPrice, error) {
+	var prices []models.LastKnownPrice
+	if err := r.db.Order("symbol").Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// GetLastKnownPrice returns nil when no price was stored for the symbol.
+func (r *Repository) GetLastKnownPrice(symbol string) (*models.LastKnownPrice, error) {
+	var prices []models.LastKnownPrice
+	if err := r.db.Where("symbol = ?", symbol).Limit(1).Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	if len(prices) == 0 {
+		return nil, nil
+	}
+	return &prices[0], nil
+}
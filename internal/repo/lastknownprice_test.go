@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastKnownPrices_SaveReplacesPerSymbol(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repository.SaveLastKnownPrices([]models.LastKnownPrice{
+		{Symbol: "BTC", Price: decimal.NewFromInt(40000), Source: "kraken", FetchedAt: first},
+		{Symbol: "ETH", Price: decimal.RequireFromString("2200.5"), Source: "binance", FetchedAt: first},
+	}))
+
+	second := first.Add(time.Minute)
+	require.NoError(t, repository.SaveLastKnownPrices([]models.LastKnownPrice{
+		{Symbol: "BTC", Price: decimal.NewFromInt(41000), Source: "coingecko", FetchedAt: second},
+	}))
+
+	prices, err := repository.GetLastKnownPrices()
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	require.Equal(t, "BTC", prices[0].Symbol)
+	require.Equal(t, "41000", prices[0].Price.String())
+	require.Equal(t, "coingecko", prices[0].Source)
+	require.True(t, prices[0].FetchedAt.Equal(second))
+	require.Equal(t, "2200.5", prices[1].Price.String())
+
+	eth, err := repository.GetLastKnownPrice("ETH")
+	require.NoError(t, err)
+	require.True(t, eth.FetchedAt.Equal(first))
+
+	missing, err := repository.GetLastKnownPrice("SOL")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestLastKnownPrice_IsStale(t *testing.T) {
+	now := time.Now()
+	price := models.LastKnownPrice{FetchedAt: now.Add(-10 * time.Minute)}
+
+	require.True(t, price.IsStale(now, 5*time.Minute))
+	require.False(t, price.IsStale(now, 15*time.Minute))
+}
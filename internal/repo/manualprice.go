@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"time"
+
+	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateManualPrice stores a price entered by hand. It becomes the current
+// price when it is the newest manual price pinning the symbol, or, when
+// nothing pins the symbol, when it is newer than the last known price. The
+// new current price is returned, nil when it did not change.
+func (r *Repository) CreateManualPrice(price *models.Price) (current *models.LastKnownPrice, err error) {
+	price.Source = models.PriceSourceManual
+	if price.Currency == "" {
+		price.Currency = "USD"
+	}
+	price.Timestamp = price.Timestamp.UTC()
+	if price.ExpiresAt != nil {
+		expiresAt := price.ExpiresAt.UTC()
+		price.ExpiresAt = &expiresAt
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(price).Error; err != nil {
+			return err
+		}
+
+		pin, err := pinningManualPrice(tx, price.Symbol, time.Now())
+		if err != nil {
+			return err
+		}
+		if pin != nil {
+			if pin.ID != price.ID {
+				return nil
+			}
+			current = manualLastKnown(price)
+			return upsertLastKnown(tx, current)
+		}
+
+		var known []models.LastKnownPrice
+		if err := tx.Where("symbol = ?", price.Symbol).Limit(1).Find(&known).Error; err != nil {
+			return err
+		}
+		if len(known) > 0 && !price.Timestamp.After(known[0].FetchedAt) {
+			return nil
+		}
+
+		current = manualLastKnown(price)
+		return upsertLastKnown(tx, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// ListManualPrices returns the manual prices for symbol, or for every symbol
+// when it is empty, newest first.
+func (r *Repository) ListManualPrices(symbol string) ([]models.Price, error) {
+	query := r.db.Where("source = ?", models.PriceSourceManual)
+	if symbol != "" {
+		query = query.Where("symbol = ?", symbol)
+	}
+
+	var prices []models.Price
+	if err := query.Order("timestamp DESC").Order("id DESC").Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// GetLatestManualPrices returns one manual price per symbol: the newest one
+// still pinning the symbol, or the newest one when none does.
+func (r *Repository) GetLatestManualPrices() ([]models.Price, error) {
+	prices, err := r.ListManualPrices("")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	index := make(map[string]int)
+	latest := make([]models.Price, 0, len(prices))
+	for _, p := range prices {
+		i, seen := index[p.Symbol]
+		switch {
+		case !seen:
+			index[p.Symbol] = len(latest)
+			latest = append(latest, p)
+		case p.PinsAt(now) && !latest[i].PinsAt(now):
+			latest[i] = p
+		}
+	}
+	return latest, nil
+}
+
+// DeleteManualPrice removes a manual price. When it was the symbol's current
+// price, the newest remaining pinning manual price takes its place, then the
+// newest remaining manual price, or the symbol is left without one until the
+// next fetch. The symbol's current price is returned, nil when it has none.
+func (r *Repository) DeleteManualPrice(id int64) (symbol string, current *models.LastKnownPrice, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var price models.Price
+		if err := tx.Where("source = ?", models.PriceSourceManual).First(&price, id).Error; err != nil {
+			return err
+		}
+		symbol = price.Symbol
+		if err := tx.Delete(&price).Error; err != nil {
+			return err
+		}
+
+		var known []models.LastKnownPrice
+		if err := tx.Where("symbol = ?", price.Symbol).Limit(1).Find(&known).Error; err != nil {
+			return err
+		}
+		if len(known) == 0 {
+			return nil
+		}
+		current = &known[0]
+		if current.Source != models.PriceSourceManual || !current.FetchedAt.Equal(price.Timestamp) {
+			return nil
+		}
+
+		next, err := pinningManualPrice(tx, price.Symbol, time.Now())
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			var remaining []models.Price
+			if err := tx.Where("source = ? AND symbol = ?", models.PriceSourceManual, price.Symbol).
+				Order("timestamp DESC").Order("id DESC").
+				Limit(1).
+				Find(&remaining).Error; err != nil {
+				return err
+			}
+			if len(remaining) == 0 {
+				current = nil
+				return tx.Delete(&models.LastKnownPrice{}, "symbol = ?", price.Symbol).Error
+			}
+			next = &remaining[0]
+		}
+		current = manualLastKnown(next)
+		return upsertLastKnown(tx, current)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return symbol, current, nil
+}
+
+// pinningManualPrice returns the newest manual price pinning symbol at now,
+// nil when none does.
+func pinningManualPrice(tx *gorm.DB, symbol string, now time.Time) (*models.Price, error) {
+	var pins []models.Price
+	if err := tx.Where("source = ? AND symbol = ?", models.PriceSourceManual, symbol).
+		Where("expires_at IS NULL OR expires_at > ?", now.UTC()).
+		Order("timestamp DESC").Order("id DESC").
+		Limit(1).
+		Find(&pins).Error; err != nil {
+		return nil, err
+	}
+	if len(pins) == 0 {
+		return nil, nil
+	}
+	return &pins[0], nil
+}
+
+func manualLastKnown(price *models.Price) *models.LastKnownPrice {
+	return &models.LastKnownPrice{
+		Symbol:    price.Symbol,
+		Price:     price.Price,
+		Source:    models.PriceSourceManual,
+		FetchedAt: price.Timestamp,
+	}
+}
+
+func upsertLastKnown(tx *gorm.DB, price *models.LastKnownPrice) error {
+	return tx.Clauses(lastKnownUpsert).Create(price).Error
+}
+
+var lastKnownUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "symbol"}},
+	DoUpdates: clause.AssignmentColumns([]string{"price", "source", "fetched_at"}),
+}
@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateManualPrice_BecomesCurrentWhenNewest(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	fetchedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repository.SaveLastKnownPrices([]models.LastKnownPrice{
+		{Symbol: "BTC", Price: decimal.NewFromInt(60000), Source: "kraken", FetchedAt: fetchedAt},
+	}))
+
+	backfillAt := fetchedAt.AddDate(0, -6, 0)
+	backfill := &models.Price{Symbol: "BTC", Price: decimal.NewFromInt(30000), Timestamp: backfillAt, ExpiresAt: &backfillAt}
+	current, err := repository.CreateManualPrice(backfill)
+	require.NoError(t, err)
+	require.Nil(t, current, "an older manual price does not replace the fetched one")
+	require.Equal(t, models.PriceSourceManual, backfill.Source)
+	require.Equal(t, "USD", backfill.Currency)
+
+	override := &models.Price{Symbol: "BTC", Price: decimal.NewFromInt(61000), Timestamp: fetchedAt.Add(time.Hour)}
+	current, err = repository.CreateManualPrice(override)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	require.Equal(t, "61000", current.Price.String())
+
+	known, err := repository.GetLastKnownPrice("BTC")
+	require.NoError(t, err)
+	require.Equal(t, models.PriceSourceManual, known.Source)
+	require.True(t, known.FetchedAt.Equal(override.Timestamp))
+}
+
+func TestCreateManualPrice_PinsUntilDeleted(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repository.SaveLastKnownPrices([]models.LastKnownPrice{
+		{Symbol: "ETH", Price: decimal.NewFromInt(3000), Source: "kraken", FetchedAt: now},
+	}))
+
+	pin := &models.Price{Symbol: "ETH", Price: decimal.NewFromInt(2500), Timestamp: now.Add(-time.Hour)}
+	current, err := repository.CreateManualPrice(pin)
+	require.NoError(t, err)
+	require.NotNil(t, current, "a pinning price replaces a newer fetched one")
+	require.Equal(t, "2500", current.Price.String())
+
+	historyAt := now.Add(-time.Minute)
+	history := &models.Price{Symbol: "ETH", Price: decimal.NewFromInt(2600), Timestamp: historyAt, ExpiresAt: &historyAt}
+	current, err = repository.CreateManualPrice(history)
+	require.NoError(t, err)
+	require.Nil(t, current, "an expired price does not replace the pin, however new")
+
+	latest, err := repository.GetLatestManualPrices()
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	require.Equal(t, pin.ID, latest[0].ID)
+
+	_, current, err = repository.DeleteManualPrice(pin.ID)
+	require.NoError(t, err)
+	require.Equal(t, "2600", current.Price.String(), "without a pin the newest manual price takes over")
+}
+
+func TestGetPriceAtTime_PrefersManualOnTie(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repository.CreatePrice(&models.Price{Symbol: "ETH", Currency: "USD", Price: decimal.NewFromInt(2000), Timestamp: at}))
+	_, err := repository.CreateManualPrice(&models.Price{Symbol: "ETH", Price: decimal.NewFromInt(2100), Timestamp: at})
+	require.NoError(t, err)
+	require.NoError(t, repository.CreatePrice(&models.Price{Symbol: "ETH", Currency: "USD", Price: decimal.NewFromInt(2500), Timestamp: at.Add(time.Hour)}))
+
+	price, err := repository.GetPriceAtTime("ETH", "USD", at.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "2100", price.Price.String())
+
+	price, err = repository.GetPriceAtTime("ETH", "USD", at.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "2500", price.Price.String(), "a newer fetched price wins")
+}
+
+func TestDeleteManualPrice_FallsBackToPreviousManualPrice(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	older := &models.Price{Symbol: "SOL", Price: decimal.NewFromInt(90), Timestamp: at}
+	newer := &models.Price{Symbol: "SOL", Price: decimal.NewFromInt(100), Timestamp: at.Add(time.Hour)}
+	for _, p := range []*models.Price{older, newer} {
+		_, err := repository.CreateManualPrice(p)
+		require.NoError(t, err)
+	}
+
+	latest, err := repository.GetLatestManualPrices()
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	require.Equal(t, newer.ID, latest[0].ID)
+
+	symbol, current, err := repository.DeleteManualPrice(newer.ID)
+	require.NoError(t, err)
+	require.Equal(t, "SOL", symbol)
+	require.Equal(t, "90", current.Price.String())
+
+	_, current, err = repository.DeleteManualPrice(older.ID)
+	require.NoError(t, err)
+	require.Nil(t, current)
+	known, err := repository.GetLastKnownPrice("SOL")
+	require.NoError(t, err)
+	require.Nil(t, known)
+
+	fetched := &models.Price{Symbol: "SOL", Currency: "USD", Price: decimal.NewFromInt(95), Timestamp: at}
+	require.NoError(t, repository.CreatePrice(fetched))
+	_, _, err = repository.DeleteManualPrice(fetched.ID)
+	require.Error(t, err, "fetched prices cannot be deleted as manual ones")
+}
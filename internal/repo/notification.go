@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+)
+
+func (r *Repository) CreateNotificationChannel(channel *models.NotificationChannel) error {
+	return r.db.Create(channel).Error
+}
+
+func (r *Repository) GetNotificationChannelByID(id int64) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := r.db.First(&channel, id).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *Repository) ListNotificationChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if err := r.db.Order("name ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (r *Repository) ListEnabledNotificationChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if err := r.db.Where("enabled = ?", true).Order("name ASC").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (r *Repository) UpdateNotificationChannel(channel *models.NotificationChannel) error {
+	return r.db.Save(channel).Error
+}
+
+func (r *Repository) DeleteNotificationChannel(id int64) error {
+	return r.db.Delete(&models.NotificationChannel{}, id).Error
+}
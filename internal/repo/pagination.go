@@ -0,0 +1,219 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field or direction")
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Cursor marks the row a keyset page continues from: its value in the sort
+// column and its ID, which orders rows with equal values.
+type Cursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe token.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// sortColumn is an ORDER BY expression together with how a cursor value is
+// bound when comparing against it.
+type sortColumn struct {
+	expr        string
+	placeholder string
+	parse       func(string) (any, error)
+}
+
+func timestampColumn(expr string) sortColumn {
+	return sortColumn{expr: expr, placeholder: "?", parse: func(v string) (any, error) {
+		return time.Parse(time.RFC3339Nano, v)
+	}}
+}
+
+func textColumn(expr string) sortColumn {
+	return sortColumn{expr: expr, placeholder: "?", parse: func(v string) (any, error) {
+		return v, nil
+	}}
+}
+
+// decimalColumn sorts a text amount column numerically. SQLite casts to a
+// float, so amounts differing beyond ~15 significant digits may tie; the ID
+// still gives a stable order.
+func decimalColumn(column string) sortColumn {
+	return sortColumn{
+		expr:        "CAST(" + column + " AS NUMERIC)",
+		placeholder: "CAST(? AS NUMERIC)",
+		parse: func(v string) (any, error) {
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, err
+			}
+			return d.String(), nil
+		},
+	}
+}
+
+func timestampValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// keyset holds the resolved ordering and position of a list query.
+type keyset struct {
+	column   sortColumn
+	desc     bool
+	limit    int
+	offset   int
+	cursor   *Cursor
+	backward bool
+}
+
+func newKeyset(columns map[string]sortColumn, sortBy, sortDir string, limit, offset int, after, before string) (*keyset, error) {
+	if sortBy == "" {
+		sortBy = "date"
+	}
+	column, ok := columns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSort, sortBy)
+	}
+
+	k := &keyset{column: column, limit: limit, offset: offset}
+	switch sortDir {
+	case "", SortDesc:
+		k.desc = true
+	case SortAsc:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSort, sortDir)
+	}
+
+	if k.limit <= 0 {
+		k.limit = defaultListLimit
+	}
+	if k.limit > maxListLimit {
+		k.limit = maxListLimit
+	}
+	if k.offset < 0 {
+		k.offset = 0
+	}
+
+	token := after
+	if before != "" {
+		token = before
+		k.backward = true
+	}
+	if token != "" {
+		cursor, err := DecodeCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		k.cursor = &cursor
+		k.offset = 0
+	}
+	return k, nil
+}
+
+// apply orders the query and positions it after the cursor, or at the offset
+// when there is none. One extra row is fetched to tell whether more follow.
+// Paging backward walks the reversed order; pageRows flips the rows back.
+func (k *keyset) apply(query *gorm.DB) (*gorm.DB, error) {
+	desc := k.desc != k.backward
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if k.cursor != nil {
+		value, err := k.column.parse(k.cursor.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		expr, ph := k.column.expr, k.column.placeholder
+		// A row-value comparison lets the database seek the index; the
+		// equivalent OR of two comparisons makes SQLite scan.
+		query = query.Where(fmt.Sprintf("(%s, id) %s (%s, ?)", expr, cmp, ph), value, k.cursor.ID)
+	}
+
+	return query.
+		Order(fmt.Sprintf("%s %s, id %s", k.column.expr, dir, dir)).
+		Limit(k.limit + 1).
+		Offset(k.offset), nil
+}
+
+// pageRows trims the extra row apply fetched and returns cursors for the
+// neighbouring pages, empty where there is none.
+func pageRows[T any](k *keyset, rows []T, cursorOf func(T) Cursor) ([]T, string, string) {
+	more := len(rows) > k.limit
+	if more {
+		rows = rows[:k.limit]
+	}
+	if k.backward {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	hasNext, hasPrev := more, k.cursor != nil || k.offset > 0
+	if k.backward {
+		hasNext, hasPrev = true, more
+	}
+
+	var next, prev string
+	if hasNext {
+		next = cursorOf(rows[len(rows)-1]).Encode()
+	}
+	if hasPrev {
+		prev = cursorOf(rows[0]).Encode()
+	}
+	return rows, next, prev
+}
+
+// likePattern matches text containing s, case-insensitively when compared
+// against LOWER(column) with ESCAPE '\'.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+func nonEmpty(symbols []string) []string {
+	out := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
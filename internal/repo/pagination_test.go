@@ -0,0 +1,275 @@
+package repo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newListTestRepo(t testing.TB) *Repository {
+	repository, err := New(setupTestDB(t))
+	require.NoError(t, err)
+	return repository
+}
+
+func assetIDs(assets []models.Asset) []int64 {
+	ids := make([]int64, len(assets))
+	for i, a := range assets {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func TestListAssets_KeysetWalksEveryRowOnce(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 25; i++ {
+		// Pairs of rows share a timestamp so ties are broken by ID.
+		require.NoError(t, repository.CreateAsset(&models.Asset{
+			Symbol:          "BTC",
+			Amount:          decimal.NewFromInt(int64(i + 1)),
+			TransactionType: "deposit",
+			Timestamp:       base.Add(time.Duration(i/2) * time.Hour),
+		}))
+	}
+
+	var forward [][]int64
+	filter := AssetFilter{Limit: 10}
+	for {
+		result, err := repository.ListAssets(filter)
+		require.NoError(t, err)
+		require.EqualValues(t, 25, result.Total)
+		forward = append(forward, assetIDs(result.Assets))
+		if result.NextCursor == "" {
+			break
+		}
+		filter.After = result.NextCursor
+	}
+	require.Len(t, forward, 3)
+
+	seen := make(map[int64]bool)
+	var previous int64 = 1 << 62
+	for _, page := range forward {
+		for _, id := range page {
+			require.False(t, seen[id], "asset %d listed twice", id)
+			seen[id] = true
+			require.Less(t, id, previous, "desc date order breaks ties by descending ID")
+			previous = id
+		}
+	}
+	require.Len(t, seen, 25)
+
+	last, err := repository.ListAssets(filter)
+	require.NoError(t, err)
+	back, err := repository.ListAssets(AssetFilter{Limit: 10, Before: last.PrevCursor})
+	require.NoError(t, err)
+	require.Equal(t, forward[1], assetIDs(back.Assets))
+	require.NotEmpty(t, back.NextCursor)
+	require.NotEmpty(t, back.PrevCursor)
+
+	first, err := repository.ListAssets(AssetFilter{Limit: 10, Before: back.PrevCursor})
+	require.NoError(t, err)
+	require.Equal(t, forward[0], assetIDs(first.Assets))
+	require.Empty(t, first.PrevCursor)
+}
+
+func TestListAssets_SortsAmountsNumerically(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	for _, amount := range []string{"9", "10.5", "0.00000001", "100", "2"} {
+		require.NoError(t, repository.CreateAsset(&models.Asset{
+			Symbol:          "ETH",
+			Amount:          decimal.RequireFromString(amount),
+			TransactionType: "deposit",
+			Timestamp:       time.Now(),
+		}))
+	}
+
+	var amounts []string
+	filter := AssetFilter{SortBy: "amount", SortDir: SortAsc, Limit: 2}
+	for {
+		result, err := repository.ListAssets(filter)
+		require.NoError(t, err)
+		for _, a := range result.Assets {
+			amounts = append(amounts, a.Amount.String())
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		filter.After = result.NextCursor
+	}
+	require.Equal(t, []string{"0.00000001", "2", "9", "10.5", "100"}, amounts)
+}
+
+func TestListAssets_FiltersSymbolsAndNotes(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	for _, a := range []models.Asset{
+		{Symbol: "BTC", Notes: "Cold storage"},
+		{Symbol: "ETH", Notes: "cold wallet"},
+		{Symbol: "SOL", Notes: "cold"},
+		{Symbol: "BTC", Notes: "100% matched"},
+		{Symbol: "ETH", Notes: "exchange"},
+	} {
+		a.Amount = decimal.NewFromInt(1)
+		a.TransactionType = "deposit"
+		a.Timestamp = time.Now()
+		require.NoError(t, repository.CreateAsset(&a))
+	}
+
+	result, err := repository.ListAssets(AssetFilter{Symbols: []string{"BTC", "ETH"}, Search: "COLD"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.Total)
+
+	result, err = repository.ListAssets(AssetFilter{Search: "100%"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, result.Total)
+
+	result, err = repository.ListAssets(AssetFilter{Search: "%"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, result.Total, "wildcards in the search text match literally")
+}
+
+func TestListAssets_RejectsInvalidSortAndCursor(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	_, err := repository.ListAssets(AssetFilter{SortBy: "notes"})
+	require.ErrorIs(t, err, ErrInvalidSort)
+	_, err = repository.ListAssets(AssetFilter{SortDir: "sideways"})
+	require.ErrorIs(t, err, ErrInvalidSort)
+	_, err = repository.ListAssets(AssetFilter{After: "not-a-cursor"})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = repository.ListAssets(AssetFilter{SortBy: "amount", After: Cursor{Value: "abc", ID: 1}.Encode()})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListExchanges_SymbolsMatchEitherSide(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	for _, pair := range [][2]string{{"BTC", "ETH"}, {"ETH", "SOL"}, {"USDT", "BTC"}, {"SOL", "USDT"}} {
+		require.NoError(t, repository.CreateExchange(&models.Exchange{
+			FromSymbol: pair[0],
+			ToSymbol:   pair[1],
+			FromAmount: decimal.NewFromInt(1),
+			ToAmount:   decimal.NewFromInt(2),
+			Notes:      pair[0] + " to " + pair[1],
+			Timestamp:  time.Now(),
+		}))
+	}
+
+	result, err := repository.ListExchanges(ExchangeFilter{Symbols: []string{"BTC"}})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.Total)
+
+	result, err = repository.ListExchanges(ExchangeFilter{Symbols: []string{"BTC"}, Search: "usdt"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, result.Total)
+
+	result, err = repository.ListExchanges(ExchangeFilter{SortBy: "from_symbol", SortDir: SortAsc, Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, "BTC", result.Exchanges[0].FromSymbol)
+	require.NotEmpty(t, result.NextCursor)
+
+	result, err = repository.ListExchanges(ExchangeFilter{SortBy: "from_symbol", SortDir: SortAsc, Limit: 3, After: result.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, result.Exchanges, 1)
+	require.Equal(t, "USDT", result.Exchanges[0].FromSymbol)
+}
+
+const benchmarkRows = 100_000
+
+var benchmarkSymbols = []string{"BTC", "ETH", "SOL", "ADA", "DOT", "XRP", "DOGE", "LTC", "LINK", "AVAX"}
+
+func seedBenchmarkRepo(b *testing.B) *Repository {
+	b.Helper()
+	repository := newListTestRepo(b)
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assets := make([]models.Asset, 0, benchmarkRows)
+	exchanges := make([]models.Exchange, 0, benchmarkRows)
+	for i := 0; i < benchmarkRows; i++ {
+		symbol := benchmarkSymbols[i%len(benchmarkSymbols)]
+		ts := base.Add(time.Duration(i) * time.Minute)
+		assets = append(assets, models.Asset{
+			PortfolioID:     models.DefaultPortfolioID,
+			Symbol:          symbol,
+			Amount:          decimal.New(int64(i%5000+1), -4),
+			TransactionType: "deposit",
+			Notes:           fmt.Sprintf("batch %d", i%100),
+			Timestamp:       ts,
+		})
+		exchanges = append(exchanges, models.Exchange{
+			PortfolioID: models.DefaultPortfolioID,
+			FromSymbol:  symbol,
+			ToSymbol:    benchmarkSymbols[(i+1)%len(benchmarkSymbols)],
+			FromAmount:  decimal.New(int64(i%5000+1), -4),
+			ToAmount:    decimal.New(int64(i%700+1), -2),
+			Notes:       fmt.Sprintf("batch %d", i%100),
+			Timestamp:   ts,
+		})
+	}
+	require.NoError(b, repository.db.CreateInBatches(assets, 500).Error)
+	require.NoError(b, repository.db.CreateInBatches(exchanges, 500).Error)
+	return repository
+}
+
+func BenchmarkListAssets(b *testing.B) {
+	repository := seedBenchmarkRepo(b)
+
+	deep, err := repository.ListAssets(AssetFilter{PortfolioID: models.DefaultPortfolioID, Offset: 90_000})
+	require.NoError(b, err)
+
+	cases := []struct {
+		name   string
+		filter AssetFilter
+	}{
+		{"FirstPage", AssetFilter{PortfolioID: models.DefaultPortfolioID}},
+		{"DeepOffset", AssetFilter{PortfolioID: models.DefaultPortfolioID, Offset: 90_000}},
+		{"DeepKeyset", AssetFilter{PortfolioID: models.DefaultPortfolioID, After: deep.NextCursor}},
+		{"Symbols", AssetFilter{PortfolioID: models.DefaultPortfolioID, Symbols: []string{"BTC", "ETH"}}},
+		{"Search", AssetFilter{PortfolioID: models.DefaultPortfolioID, Search: "batch 42"}},
+		{"SortAmount", AssetFilter{PortfolioID: models.DefaultPortfolioID, SortBy: "amount"}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repository.ListAssets(tc.filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListExchanges(b *testing.B) {
+	repository := seedBenchmarkRepo(b)
+
+	deep, err := repository.ListExchanges(ExchangeFilter{PortfolioID: models.DefaultPortfolioID, Offset: 90_000})
+	require.NoError(b, err)
+
+	cases := []struct {
+		name   string
+		filter ExchangeFilter
+	}{
+		{"FirstPage", ExchangeFilter{PortfolioID: models.DefaultPortfolioID}},
+		{"DeepOffset", ExchangeFilter{PortfolioID: models.DefaultPortfolioID, Offset: 90_000}},
+		{"DeepKeyset", ExchangeFilter{PortfolioID: models.DefaultPortfolioID, After: deep.NextCursor}},
+		{"Symbols", ExchangeFilter{PortfolioID: models.DefaultPortfolioID, Symbols: []string{"BTC"}}},
+		{"Search", ExchangeFilter{PortfolioID: models.DefaultPortfolioID, Search: "batch 42"}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repository.ListExchanges(tc.filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"errors"
+
+	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrDefaultPortfolio = errors.New("default portfolio cannot be deleted")
+
+func (r *Repository) CreatePortfolio(portfolio *models.Portfolio) error {
+	return r.db.Create(portfolio).Error
+}
+
+func (r *Repository) GetPortfolioByID(id int64) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	if err := r.db.First(&portfolio, id).Error; err != nil {
+		return nil, err
+	}
+	return &portfolio, nil
+}
+
+func (r *Repository) ListPortfolios() ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	if err := r.db.Order("id ASC").Find(&portfolios).Error; err != nil {
+		return nil, err
+	}
+	return portfolios, nil
+}
+
+func (r *Repository) UpdatePortfolio(portfolio *models.Portfolio) error {
+	return r.db.Save(portfolio).Error
+}
+
+// DeletePortfolio removes a portfolio together with every row scoped to it:
+// assets, exchanges, import logs, alert rules, watched wallets with their
+// balances and discrepancies, reconciliations with their lines, and
+// recurring transactions with their runs, and allocation targets.
+func (r *Repository) DeletePortfolio(id int64) error {
+	if id == models.DefaultPortfolioID {
+		return ErrDefaultPortfolio
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		wallets := tx.Model(&models.Wallet{}).Select("id").Where("portfolio_id = ?", id)
+		if err := tx.Where("wallet_id IN (?)", wallets).Delete(&models.WalletBalance{}).Error; err != nil {
+			return err
+		}
+		reconciliations := tx.Model(&models.Reconciliation{}).Select("id").Where("portfolio_id = ?", id)
+		if err := tx.Where("reconciliation_id IN (?)", reconciliations).Delete(&models.ReconciliationLine{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{
+			&models.Asset{},
+			&models.Exchange{},
+			&models.ImportLog{},
+			&models.AlertRule{},
+			&models.Wallet{},
+			&models.WalletDiscrepancy{},
+			&models.Reconciliation{},
+			&models.RecurringTransaction{},
+			&models.RecurringRun{},
+			&models.AllocationTarget{},
+		} {
+			if err := tx.Where("portfolio_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Portfolio{}, id).Error
+	})
+}
+
+// scopePortfolio restricts a query to a single portfolio. A zero ID leaves the
+// query untouched so callers get the consolidated view across all portfolios.
+func scopePortfolio(query *gorm.DB, portfolioID int64) *gorm.DB {
+	if portfolioID > 0 {
+		return query.Where("portfolio_id = ?", portfolioID)
+	}
+	return query
+}
+
+func (r *Repository) ensureDefaultPortfolio() error {
+	portfolio := models.Portfolio{ID: models.DefaultPortfolioID}
+	if err := r.db.Where(&portfolio).Attrs(models.Portfolio{Name: "Default"}).FirstOrCreate(&portfolio).Error; err != nil {
+		return err
+	}
+
+	// The default portfolio is inserted with an explicit ID, which PostgreSQL
+	// does not feed back into the serial sequence.
+	if r.db.Dialector.Name() == "postgres" {
+		if err := r.db.Exec("SELECT setval(pg_get_serial_sequence('portfolios', 'id'), (SELECT MAX(id) FROM portfolios))").Error; err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{"assets", "exchanges", "import_logs"} {
+		if err := r.db.Exec("UPDATE "+table+" SET portfolio_id = ? WHERE portfolio_id IS NULL OR portfolio_id = 0", models.DefaultPortfolioID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolioRepository_CRUD(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	portfolio := &models.Portfolio{Name: "Treasury", Description: "Team funds"}
+	require.NoError(t, repository.CreatePortfolio(portfolio))
+	require.NotZero(t, portfolio.ID)
+
+	got, err := repository.GetPortfolioByID(portfolio.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Treasury", got.Name)
+
+	got.Name = "Treasury 2"
+	require.NoError(t, repository.UpdatePortfolio(got))
+
+	portfolios, err := repository.ListPortfolios()
+	require.NoError(t, err)
+	require.Len(t, portfolios, 2)
+	require.Equal(t, "Treasury 2", portfolios[1].Name)
+
+	reconciliation := &models.Reconciliation{PortfolioID: portfolio.ID, AsOf: time.Now(), Lines: []models.ReconciliationLine{{Symbol: "BTC"}}}
+	require.NoError(t, repository.CreateReconciliation(reconciliation))
+
+	recurring := &models.RecurringTransaction{PortfolioID: portfolio.ID, Name: "Weekly BTC", Type: "deposit", Symbol: "BTC", Amount: decimal.NewFromInt(1), AmountUnit: models.RecurringAmountCoin, Cadence: models.CadenceWeekly, StartAt: time.Now(), Enabled: true}
+	recurring.Reschedule(recurring.StartAt)
+	require.NoError(t, repository.CreateRecurring(recurring))
+	require.NoError(t, repository.SaveRecurringRun(recurring, &models.RecurringRun{RecurringID: recurring.ID, PortfolioID: portfolio.ID, Type: "deposit", Symbol: "BTC", Amount: decimal.NewFromInt(1), Status: models.RecurringRunExecuted}))
+
+	require.NoError(t, repository.ReplaceAllocationTargets(portfolio.ID, []models.AllocationTarget{{Symbol: "BTC", Weight: 100}}))
+	require.NoError(t, repository.ReplaceAllocationTargets(0, []models.AllocationTarget{{Symbol: "ETH", Weight: 100}}))
+
+	require.NoError(t, repository.DeletePortfolio(portfolio.ID))
+	_, err = repository.GetPortfolioByID(portfolio.ID)
+	require.Error(t, err)
+}
+
+func TestPortfolioRepository_DeleteCascades(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	portfolio := &models.Portfolio{Name: "Closed"}
+	require.NoError(t, repository.CreatePortfolio(portfolio))
+	require.NoError(t, repository.CreateAlertRule(&models.AlertRule{Type: models.AlertPortfolioAbove, PortfolioID: portfolio.ID, Threshold: 1000, Enabled: true}))
+	require.NoError(t, repository.CreateAlertRule(&models.AlertRule{Type: models.AlertPortfolioAbove, Threshold: 1000, Enabled: true}))
+
+	wallet := &models.Wallet{PortfolioID: portfolio.ID, Name: "Cold", Chain: "bitcoin", Address: "bc1q", Mode: models.WalletModePropose}
+	require.NoError(t, repository.CreateWallet(wallet))
+	require.NoError(t, repository.SaveWalletSync(wallet.ID, time.Now(), []models.WalletBalance{{Symbol: "BTC", Amount: decimal.NewFromInt(1)}}, nil))
+	require.NoError(t, repository.SaveWalletDiscrepancy(&models.WalletDiscrepancy{PortfolioID: portfolio.ID, Symbol: "BTC", Status: models.DiscrepancyOpen}))
+
+	reconciliation := &models.Reconciliation{PortfolioID: portfolio.ID, AsOf: time.Now(), Lines: []models.ReconciliationLine{{Symbol: "BTC"}}}
+	require.NoError(t, repository.CreateReconciliation(reconciliation))
+
+	recurring := &models.RecurringTransaction{PortfolioID: portfolio.ID, Name: "Weekly BTC", Type: "deposit", Symbol: "BTC", Amount: decimal.NewFromInt(1), AmountUnit: models.RecurringAmountCoin, Cadence: models.CadenceWeekly, StartAt: time.Now(), Enabled: true}
+	recurring.Reschedule(recurring.StartAt)
+	require.NoError(t, repository.CreateRecurring(recurring))
+	require.NoError(t, repository.SaveRecurringRun(recurring, &models.RecurringRun{RecurringID: recurring.ID, PortfolioID: portfolio.ID, Type: "deposit", Symbol: "BTC", Amount: decimal.NewFromInt(1), Status: models.RecurringRunExecuted}))
+
+	require.NoError(t, repository.ReplaceAllocationTargets(portfolio.ID, []models.AllocationTarget{{Symbol: "BTC", Weight: 100}}))
+	require.NoError(t, repository.ReplaceAllocationTargets(0, []models.AllocationTarget{{Symbol: "ETH", Weight: 100}}))
+
+	require.NoError(t, repository.DeletePortfolio(portfolio.ID))
+
+	rules, err := repository.ListAlertRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1, "rules of other portfolios are kept")
+	require.Zero(t, rules[0].PortfolioID)
+
+	wallets, err := repository.ListWallets()
+	require.NoError(t, err)
+	require.Empty(t, wallets)
+	balances, err := repository.ListWalletBalances(wallet.ID)
+	require.NoError(t, err)
+	require.Empty(t, balances)
+	discrepancies, err := repository.ListWalletDiscrepancies("")
+	require.NoError(t, err)
+	require.Empty(t, discrepancies)
+
+	reconciliations, err := repository.ListReconciliations(0)
+	require.NoError(t, err)
+	require.Empty(t, reconciliations)
+	var lines int64
+	require.NoError(t, db.Model(&models.ReconciliationLine{}).Count(&lines).Error)
+	require.Zero(t, lines)
+
+	due, err := repository.ListDueRecurring(time.Now().AddDate(1, 0, 0))
+	require.NoError(t, err)
+	require.Empty(t, due, "a deleted portfolio books nothing more")
+	runs, err := repository.ListRecurringRuns(0, "")
+	require.NoError(t, err)
+	require.Empty(t, runs)
+	assets, err := repository.GetAssetsByPortfolio(portfolio.ID)
+	require.NoError(t, err)
+	require.Empty(t, assets)
+
+	targets, err := repository.ListAllocationTargets(portfolio.ID)
+	require.NoError(t, err)
+	require.Empty(t, targets)
+	targets, err = repository.ListAllocationTargets(0)
+	require.NoError(t, err)
+	require.Len(t, targets, 1, "the all-portfolios targets are kept")
+}
+
+func TestPortfolioRepository_MigrateCreatesDefault(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Asset{}))
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec("INSERT INTO assets (symbol, amount, transaction_type) VALUES ('BTC', 1, 'deposit')").Error)
+	require.NoError(t, repository.Migrate())
+
+	portfolio, err := repository.GetPortfolioByID(models.DefaultPortfolioID)
+	require.NoError(t, err)
+	require.Equal(t, "Default", portfolio.Name)
+
+	assets, err := repository.GetAssetsByPortfolio(models.DefaultPortfolioID)
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+
+	require.ErrorIs(t, repository.DeletePortfolio(models.DefaultPortfolioID), ErrDefaultPortfolio)
+}
+
+func TestPortfolioRepository_Scoping(t *testing.T) {
+	repository := newListTestRepo(t)
+
+	personal := &models.Portfolio{Name: "Personal"}
+	require.NoError(t, repository.CreatePortfolio(personal))
+
+	require.NoError(t, repository.CreateAsset(&models.Asset{Symbol: "BTC", Amount: decimal.NewFromInt(1), TransactionType: "deposit"}))
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: personal.ID, Symbol: "ETH", Amount: decimal.NewFromInt(2), TransactionType: "deposit"}))
+	require.NoError(t, repository.CreateExchange(&models.Exchange{PortfolioID: personal.ID, FromSymbol: "ETH", ToSymbol: "BTC", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.RequireFromString("0.05")}))
+
+	defaultAssets, err := repository.GetAssetsByPortfolio(models.DefaultPortfolioID)
+	require.NoError(t, err)
+	require.Len(t, defaultAssets, 1)
+	require.Equal(t, "BTC", defaultAssets[0].Symbol)
+
+	allAssets, err := repository.GetAssetsByPortfolio(0)
+	require.NoError(t, err)
+	require.Len(t, allAssets, 2)
+
+	listed, err := repository.ListAssets(AssetFilter{PortfolioID: personal.ID})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, listed.Total)
+
+	exchanges, err := repository.GetExchangesByPortfolio(models.DefaultPortfolioID)
+	require.NoError(t, err)
+	require.Empty(t, exchanges)
+
+	require.NoError(t, repository.DeletePortfolio(personal.ID))
+	allAssets, err = repository.GetAssetsByPortfolio(0)
+	require.NoError(t, err)
+	require.Len(t, allAssets, 1)
+	exchanges, err = repository.GetExchangesByPortfolio(0)
+	require.NoError(t, err)
+	require.Empty(t, exchanges)
+}
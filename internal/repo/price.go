@@ -3,6 +3,8 @@ package repo
 import (
 	"hodlbook/internal/models"
 	"time"
+
+	"gorm.io/gorm/clause"
 )
 
 func (r *Repository) CreatePrice(price *models.Price) error {
@@ -47,7 +49,7 @@ func (r *Repository) GetPricesBySymbolAndCurrency(symbol, currency string) ([]mo
 
 func (r *Repository) GetPricesByDateRange(symbol, currency string, startDate, endDate time.Time) ([]models.Price, error) {
 	var prices []models.Price
-	if err := r.db.Where("symbol = ? AND currency = ? AND timestamp BETWEEN ? AND ?", symbol, currency, startDate, endDate).
+	if err := r.db.Where("symbol = ? AND currency = ? AND timestamp BETWEEN ? AND ?", symbol, currency, startDate.UTC(), endDate.UTC()).
 		Order("timestamp ASC").
 		Find(&prices).Error; err != nil {
 		return nil, err
@@ -64,13 +66,17 @@ func (r *Repository) DeletePrice(id int64) error {
 }
 
 func (r *Repository) DeletePricesOlderThan(date time.Time) error {
-	return r.db.Where("timestamp < ?", date).Delete(&models.Price{}).Error
+	return r.db.Where("timestamp < ?", date.UTC()).Delete(&models.Price{}).Error
 }
 
+// GetPriceAtTime returns the newest price at or before timestamp, preferring
+// a manual price over a fetched one with the same timestamp. It returns nil
+// when there is none.
 func (r *Repository) GetPriceAtTime(symbol, currency string, timestamp time.Time) (*models.Price, error) {
 	var prices []models.Price
-	if err := r.db.Where("symbol = ? AND currency = ? AND timestamp <= ?", symbol, currency, timestamp).
+	if err := r.db.Where("symbol = ? AND currency = ? AND timestamp <= ?", symbol, currency, timestamp.UTC()).
 		Order("timestamp DESC").
+		Order(clause.Expr{SQL: "CASE WHEN source = ? THEN 0 ELSE 1 END", Vars: []any{models.PriceSourceManual}}).
 		Limit(1).
 		Find(&prices).Error; err != nil {
 		return nil, err
@@ -101,7 +107,7 @@ func (r *Repository) GetPricesAtTimes(queries []AssetPriceQuery, currency string
 		if result[q.Symbol] == nil {
 			result[q.Symbol] = make(map[int64]float64)
 		}
-		result[q.Symbol][q.Timestamp.Unix()] = price.Price
+		result[q.Symbol][q.Timestamp.Unix()] = price.Price.InexactFloat64()
 	}
 
 	return result, nil
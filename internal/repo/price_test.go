@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,7 +17,7 @@ func TestPriceRepository_CRUD(t *testing.T) {
 	price := &models.Price{
 		Symbol:    "BTC",
 		Currency:  "USD",
-		Price:     123.45,
+		Price:     decimal.RequireFromString("123.45"),
 		Timestamp: time.Now(),
 	}
 
@@ -25,13 +26,13 @@ func TestPriceRepository_CRUD(t *testing.T) {
 
 	got, err := repository.GetPriceByID(price.ID)
 	require.NoError(t, err)
-	require.Equal(t, price.Price, got.Price)
+	require.Equal(t, "123.45", got.Price.String())
 
-	price.Price = 200.0
+	price.Price = decimal.NewFromInt(200)
 	require.NoError(t, repository.UpdatePrice(price))
 	got, err = repository.GetPriceByID(price.ID)
 	require.NoError(t, err)
-	require.Equal(t, 200.0, got.Price)
+	require.Equal(t, "200", got.Price.String())
 
 	prices, err := repository.GetPricesBySymbolAndCurrency("BTC", "USD")
 	require.NoError(t, err)
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"errors"
+
+	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLineAdjusted   = errors.New("reconciliation line is already adjusted")
+	ErrLineReconciled = errors.New("reconciliation line has no difference")
+)
+
+// ReconciliationAdjustmentNote is the note of entries booked to close a
+// reconciliation difference.
+const ReconciliationAdjustmentNote = "Reconciliation adjustment"
+
+// CreateReconciliation stores a reconciliation together with its lines.
+func (r *Repository) CreateReconciliation(reconciliation *models.Reconciliation) error {
+	return r.db.Create(reconciliation).Error
+}
+
+func (r *Repository) GetReconciliationByID(id int64) (*models.Reconciliation, error) {
+	var reconciliation models.Reconciliation
+	if err := r.db.Preload("Lines", orderLines).First(&reconciliation, id).Error; err != nil {
+		return nil, err
+	}
+	return &reconciliation, nil
+}
+
+// ListReconciliations returns the reconciliations of a portfolio, or of all
+// portfolios when portfolioID is zero, newest first.
+func (r *Repository) ListReconciliations(portfolioID int64) ([]models.Reconciliation, error) {
+	var reconciliations []models.Reconciliation
+	if err := scopePortfolio(r.db, portfolioID).
+		Preload("Lines", orderLines).
+		Order("as_of DESC").Order("id DESC").
+		Find(&reconciliations).Error; err != nil {
+		return nil, err
+	}
+	return reconciliations, nil
+}
+
+// DeleteReconciliation removes a reconciliation and its lines. Adjustments
+// booked from it are kept.
+func (r *Repository) DeleteReconciliation(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.ReconciliationLine{}, "reconciliation_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Reconciliation{}, id).Error
+	})
+}
+
+// AdjustReconciliationLine books the deposit or withdrawal that closes the
+// difference of a line, dated at the reconciliation, and returns it.
+func (r *Repository) AdjustReconciliationLine(reconciliationID, lineID int64) (*models.Asset, error) {
+	var asset *models.Asset
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var reconciliation models.Reconciliation
+		if err := tx.First(&reconciliation, reconciliationID).Error; err != nil {
+			return err
+		}
+		var line models.ReconciliationLine
+		if err := tx.Where("reconciliation_id = ?", reconciliationID).First(&line, lineID).Error; err != nil {
+			return err
+		}
+		if line.AdjustmentAssetID != nil {
+			return ErrLineAdjusted
+		}
+		if line.Difference.IsZero() {
+			return ErrLineReconciled
+		}
+
+		transactionType := "deposit"
+		if line.Difference.IsNegative() {
+			transactionType = "withdraw"
+		}
+		asset = &models.Asset{
+			PortfolioID:     reconciliation.PortfolioID,
+			Symbol:          line.Symbol,
+			Name:            line.Symbol,
+			Amount:          line.Difference.Abs(),
+			TransactionType: transactionType,
+			Notes:           ReconciliationAdjustmentNote,
+			Timestamp:       reconciliation.AsOf,
+		}
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&line).UpdateColumn("adjustment_asset_id", asset.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+func orderLines(db *gorm.DB) *gorm.DB {
+	return db.Order("symbol ASC")
+}
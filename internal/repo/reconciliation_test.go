@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestReconciliationRepository_AdjustLine(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	asOf := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+	reconciliation := &models.Reconciliation{
+		PortfolioID: 1,
+		AsOf:        asOf,
+		Lines: []models.ReconciliationLine{
+			{Symbol: "ETH", Reported: decimal.RequireFromString("2"), Recorded: decimal.RequireFromString("2")},
+			{Symbol: "BTC", Reported: decimal.RequireFromString("0.4"), Recorded: decimal.RequireFromString("0.5"), Difference: decimal.RequireFromString("-0.1")},
+		},
+	}
+	require.NoError(t, repository.CreateReconciliation(reconciliation))
+
+	got, err := repository.GetReconciliationByID(reconciliation.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Lines, 2)
+	require.Equal(t, "BTC", got.Lines[0].Symbol)
+	btc, eth := got.Lines[0], got.Lines[1]
+
+	asset, err := repository.AdjustReconciliationLine(reconciliation.ID, btc.ID)
+	require.NoError(t, err)
+	require.Equal(t, "withdraw", asset.TransactionType)
+	require.Equal(t, "0.1", asset.Amount.String())
+	require.True(t, asOf.Equal(asset.Timestamp))
+
+	_, err = repository.AdjustReconciliationLine(reconciliation.ID, btc.ID)
+	require.ErrorIs(t, err, ErrLineAdjusted)
+	_, err = repository.AdjustReconciliationLine(reconciliation.ID, eth.ID)
+	require.ErrorIs(t, err, ErrLineReconciled)
+	_, err = repository.AdjustReconciliationLine(reconciliation.ID+1, btc.ID)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	list, err := repository.ListReconciliations(1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, asset.ID, *list[0].Lines[0].AdjustmentAssetID)
+
+	require.NoError(t, repository.DeleteReconciliation(reconciliation.ID))
+	list, err = repository.ListReconciliations(0)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	kept, err := repository.GetAssetByID(asset.ID)
+	require.NoError(t, err)
+	require.Equal(t, ReconciliationAdjustmentNote, kept.Notes)
+}
@@ -0,0 +1,217 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRunNotPending     = errors.New("recurring run is not pending")
+	ErrRecurringAdvanced = errors.New("recurring occurrence was already run")
+)
+
+// RecurringNote prefixes the note of entries booked by a recurring
+// transaction.
+const RecurringNote = "Recurring"
+
+func (r *Repository) CreateRecurring(recurring *models.RecurringTransaction) error {
+	return r.db.Create(recurring).Error
+}
+
+func (r *Repository) GetRecurringByID(id int64) (*models.RecurringTransaction, error) {
+	var recurring models.RecurringTransaction
+	if err := r.db.First(&recurring, id).Error; err != nil {
+		return nil, err
+	}
+	return &recurring, nil
+}
+
+// ListRecurring returns the recurring transactions of a portfolio, or of
+// all portfolios when portfolioID is zero.
+func (r *Repository) ListRecurring(portfolioID int64) ([]models.RecurringTransaction, error) {
+	var list []models.RecurringTransaction
+	if err := scopePortfolio(r.db, portfolioID).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListDueRecurring returns the enabled recurring transactions with an
+// occurrence at or before now that is not past their end.
+func (r *Repository) ListDueRecurring(now time.Time) ([]models.RecurringTransaction, error) {
+	var list []models.RecurringTransaction
+	if err := r.db.
+		Where("enabled = ? AND next_run_at <= ?", true, now.UTC()).
+		Where("end_at IS NULL OR next_run_at <= end_at").
+		Order("next_run_at ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *Repository) UpdateRecurring(recurring *models.RecurringTransaction) error {
+	return r.db.Save(recurring).Error
+}
+
+// DeleteRecurring removes a recurring transaction and its pending runs.
+// Entries it booked, and the runs recording them, are kept.
+func (r *Repository) DeleteRecurring(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RecurringRun{}, "recurring_id = ? AND status = ?", id, models.RecurringRunPending).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.RecurringTransaction{}, id).Error
+	})
+}
+
+// SaveRecurringRun stores the run of the next occurrence of a recurring
+// transaction, booking its entry when it is executed, and moves the
+// schedule past it. It fails with ErrRecurringAdvanced when the occurrence
+// was already saved since recurring was read, so racing runs cannot book
+// it twice.
+func (r *Repository) SaveRecurringRun(recurring *models.RecurringTransaction, run *models.RecurringRun) error {
+	next := *recurring
+	next.Advance()
+	next.LastError = ""
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecurringTransaction{}).
+			Where("id = ? AND occurrences = ?", recurring.ID, recurring.Occurrences).
+			Updates(map[string]any{
+				"occurrences": next.Occurrences,
+				"next_run_at": next.NextRunAt,
+				"last_error":  "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecurringAdvanced
+		}
+
+		if run.Status == models.RecurringRunExecuted {
+			if err := bookRecurringRun(tx, run); err != nil {
+				return err
+			}
+		}
+		return tx.Create(run).Error
+	})
+	if err != nil {
+		return err
+	}
+	*recurring = next
+	return nil
+}
+
+// SetRecurringError records why the next occurrence could not run. It is
+// retried on the next check.
+func (r *Repository) SetRecurringError(id int64, message string) error {
+	return r.db.Model(&models.RecurringTransaction{}).Where("id = ?", id).UpdateColumn("last_error", message).Error
+}
+
+// ListRecurringRuns returns the runs of a recurring transaction, or of all
+// of them when recurringID is zero, optionally filtered by status, newest
+// first.
+func (r *Repository) ListRecurringRuns(recurringID int64, status string) ([]models.RecurringRun, error) {
+	query := r.db.Order("scheduled_at DESC").Order("id DESC")
+	if recurringID > 0 {
+		query = query.Where("recurring_id = ?", recurringID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var runs []models.RecurringRun
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ConfirmRecurringRun books the entry of a pending run at its scheduled
+// time.
+func (r *Repository) ConfirmRecurringRun(id int64, at time.Time) (*models.RecurringRun, error) {
+	return r.resolveRecurringRun(id, at, models.RecurringRunExecuted)
+}
+
+// SkipRecurringRun closes a pending run without booking anything.
+func (r *Repository) SkipRecurringRun(id int64, at time.Time) (*models.RecurringRun, error) {
+	return r.resolveRecurringRun(id, at, models.RecurringRunSkipped)
+}
+
+func (r *Repository) resolveRecurringRun(id int64, at time.Time, status string) (*models.RecurringRun, error) {
+	var run models.RecurringRun
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&run, id).Error; err != nil {
+			return err
+		}
+		if run.Status != models.RecurringRunPending {
+			return ErrRunNotPending
+		}
+		if status == models.RecurringRunExecuted {
+			if err := bookRecurringRun(tx, &run); err != nil {
+				return err
+			}
+		}
+
+		resolvedAt := at.UTC()
+		run.Status = status
+		run.ResolvedAt = &resolvedAt
+		return tx.Save(&run).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// bookRecurringRun creates the asset entry or exchange of a run and records
+// the price it was converted at, so its cost basis uses the same price.
+func bookRecurringRun(tx *gorm.DB, run *models.RecurringRun) error {
+	notes := RecurringNote + ": " + run.Name
+	if run.Type == "exchange" {
+		exchange := &models.Exchange{
+			PortfolioID: run.PortfolioID,
+			FromSymbol:  run.FromSymbol,
+			ToSymbol:    run.Symbol,
+			FromAmount:  run.FromAmount,
+			ToAmount:    run.Amount,
+			Notes:       notes,
+			Timestamp:   run.ScheduledAt,
+		}
+		if err := tx.Create(exchange).Error; err != nil {
+			return err
+		}
+		run.ExchangeID = &exchange.ID
+	} else {
+		asset := &models.Asset{
+			PortfolioID:     run.PortfolioID,
+			Symbol:          run.Symbol,
+			Name:            run.Symbol,
+			Amount:          run.Amount,
+			TransactionType: run.Type,
+			Notes:           notes,
+			Timestamp:       run.ScheduledAt,
+		}
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+		run.AssetID = &asset.ID
+	}
+
+	if !run.Price.IsPositive() {
+		return nil
+	}
+	return tx.Create(&models.Price{
+		Symbol:    run.Symbol,
+		Currency:  "USD",
+		Price:     run.Price,
+		Timestamp: run.ScheduledAt,
+	}).Error
+}
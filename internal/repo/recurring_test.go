@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurringRepository_DueAndRuns(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	recurring := &models.RecurringTransaction{
+		PortfolioID: 1,
+		Name:        "Monthly BTC",
+		Type:        "exchange",
+		Symbol:      "BTC",
+		FromSymbol:  "USDT",
+		Amount:      decimal.NewFromInt(100),
+		AmountUnit:  models.RecurringAmountFiat,
+		Cadence:     models.CadenceMonthly,
+		StartAt:     start,
+		EndAt:       &end,
+		Enabled:     true,
+	}
+	recurring.Reschedule(start)
+	require.NoError(t, repository.CreateRecurring(recurring))
+
+	due, err := repository.ListDueRecurring(start.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, due)
+	due, err = repository.ListDueRecurring(start)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	executed := &models.RecurringRun{
+		RecurringID: recurring.ID,
+		PortfolioID: 1,
+		Name:        recurring.Name,
+		Type:        "exchange",
+		Symbol:      "BTC",
+		Amount:      decimal.RequireFromString("0.0025"),
+		FromSymbol:  "USDT",
+		FromAmount:  decimal.NewFromInt(100),
+		Price:       decimal.NewFromInt(40000),
+		ScheduledAt: recurring.NextRunAt,
+		Status:      models.RecurringRunExecuted,
+	}
+	stale := *recurring
+	require.NoError(t, repository.SaveRecurringRun(recurring, executed))
+	require.NotNil(t, executed.ExchangeID)
+
+	duplicate := *executed
+	duplicate.ID, duplicate.ExchangeID = 0, nil
+	require.ErrorIs(t, repository.SaveRecurringRun(&stale, &duplicate), ErrRecurringAdvanced)
+	require.Nil(t, duplicate.ExchangeID, "a stale run books nothing")
+	require.Equal(t, 0, stale.Occurrences)
+	exchanges, err := repository.GetAllExchanges()
+	require.NoError(t, err)
+	require.Len(t, exchanges, 1)
+
+	stored, err := repository.GetRecurringByID(recurring.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.Occurrences)
+	require.True(t, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC).Equal(stored.NextRunAt), "monthly runs fall back to the last day of the month")
+
+	exchange, err := repository.GetExchangeByID(*executed.ExchangeID)
+	require.NoError(t, err)
+	require.Equal(t, "USDT", exchange.FromSymbol)
+	require.Equal(t, "0.0025", exchange.ToAmount.String())
+	require.Equal(t, RecurringNote+": Monthly BTC", exchange.Notes)
+	price, err := repository.GetPriceAtTime("BTC", "USD", start)
+	require.NoError(t, err)
+	require.Equal(t, "40000", price.Price.String())
+
+	pending := *executed
+	pending.ID, pending.ExchangeID = 0, nil
+	pending.ScheduledAt = stored.NextRunAt
+	pending.Status = models.RecurringRunPending
+	require.NoError(t, repository.SaveRecurringRun(stored, &pending))
+	require.Nil(t, pending.ExchangeID)
+
+	due, err = repository.ListDueRecurring(time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, due, 1, "the March 31 run is still due")
+
+	runs, err := repository.ListRecurringRuns(recurring.ID, models.RecurringRunPending)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+
+	confirmed, err := repository.ConfirmRecurringRun(pending.ID, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, models.RecurringRunExecuted, confirmed.Status)
+	require.NotNil(t, confirmed.ExchangeID)
+	require.NotNil(t, confirmed.ResolvedAt)
+
+	_, err = repository.SkipRecurringRun(pending.ID, time.Now())
+	require.ErrorIs(t, err, ErrRunNotPending)
+
+	require.NoError(t, repository.DeleteRecurring(recurring.ID))
+	runs, err = repository.ListRecurringRuns(0, "")
+	require.NoError(t, err)
+	require.Len(t, runs, 2, "runs that booked an entry are kept")
+}
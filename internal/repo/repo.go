@@ -2,14 +2,21 @@ package repo
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
 	"hodlbook/internal/models"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 var ErrNilDatabase = errors.New("database cannot be nil")
 
+// Repository wraps the database. Time arguments are converted to UTC before
+// they are compared with stored timestamps, which are UTC as well: SQLite
+// compares timestamps as text, so mixed offsets would order incorrectly.
 type Repository struct {
 	db *gorm.DB
 }
@@ -21,14 +28,34 @@ func New(db *gorm.DB) (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
+var migrationModels = []any{
+	&models.Portfolio{},
+	&models.Asset{},
+	&models.Exchange{},
+	&models.Price{},
+	&models.LastKnownPrice{},
+	&models.AssetHistoricValue{},
+	&models.ImportLog{},
+	&models.APIKey{},
+	&models.AlertRule{},
+	&models.AlertEvent{},
+	&models.NotificationChannel{},
+	&models.Wallet{},
+	&models.WalletBalance{},
+	&models.WalletDiscrepancy{},
+	&models.Reconciliation{},
+	&models.ReconciliationLine{},
+	&models.RecurringTransaction{},
+	&models.RecurringRun{},
+	&models.AllocationTarget{},
+	&models.AssetCategory{},
+}
+
 func (r *Repository) Migrate() error {
-	if err := r.db.AutoMigrate(
-		&models.Asset{},
-		&models.Exchange{},
-		&models.Price{},
-		&models.AssetHistoricValue{},
-		&models.ImportLog{},
-	); err != nil {
+	if err := r.migrateDecimalColumns(); err != nil {
+		return err
+	}
+	if err := r.db.AutoMigrate(migrationModels...); err != nil {
 		return err
 	}
 
@@ -36,5 +63,135 @@ func (r *Repository) Migrate() error {
 	r.db.Exec("DROP INDEX IF EXISTS idx_assets_symbol_unique")
 	r.db.Exec("DROP INDEX IF EXISTS idx_assets_symbol")
 
+	return r.ensureDefaultPortfolio()
+}
+
+// decimalColumns held float64 values before amounts and prices became
+// decimals stored as text.
+var decimalColumns = []struct {
+	model   any
+	table   string
+	columns []string
+}{
+	{&models.Asset{}, "assets", []string{"amount"}},
+	{&models.Exchange{}, "exchanges", []string{"from_amount", "to_amount", "fee"}},
+	{&models.Price{}, "prices", []string{"price"}},
+}
+
+// migrateDecimalColumns converts numeric amount and price columns to text and
+// rewrites every value in canonical decimal form, e.g. 1.0e-05 as 0.00001.
+func (r *Repository) migrateDecimalColumns() error {
+	migrator := r.db.Migrator()
+	for _, dc := range decimalColumns {
+		if !migrator.HasTable(dc.model) {
+			continue
+		}
+		columnTypes, err := migrator.ColumnTypes(dc.model)
+		if err != nil {
+			return err
+		}
+
+		var converted []string
+		for _, columnType := range columnTypes {
+			if !slices.Contains(dc.columns, columnType.Name()) || strings.EqualFold(columnType.DatabaseTypeName(), "text") {
+				continue
+			}
+			if err := migrator.AlterColumn(dc.model, columnType.Name()); err != nil {
+				return fmt.Errorf("converting %s.%s to decimal: %w", dc.table, columnType.Name(), err)
+			}
+			converted = append(converted, columnType.Name())
+		}
+		if len(converted) == 0 {
+			continue
+		}
+		if err := r.normalizeDecimals(dc.table, converted); err != nil {
+			return fmt.Errorf("normalizing %s decimals: %w", dc.table, err)
+		}
+	}
 	return nil
 }
+
+func (r *Repository) normalizeDecimals(table string, columns []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		rows, err := tx.Table(table).Select(append([]string{"id"}, columns...)).Rows()
+		if err != nil {
+			return err
+		}
+
+		updates := make(map[int64]map[string]any)
+		for rows.Next() {
+			var id int64
+			values := make([]decimal.NullDecimal, len(columns))
+			dest := []any{&id}
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return err
+			}
+
+			update := make(map[string]any)
+			for i, value := range values {
+				if value.Valid {
+					update[columns[i]] = value.Decimal.String()
+				}
+			}
+			updates[id] = update
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, update := range updates {
+			if len(update) == 0 {
+				continue
+			}
+			if err := tx.Table(table).Where("id = ?", id).UpdateColumns(update).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrationStatus describes how far a model's table is behind its struct.
+type MigrationStatus struct {
+	Table          string   `json:"table"`
+	Exists         bool     `json:"exists"`
+	MissingColumns []string `json:"missing_columns,omitempty"`
+}
+
+func (s MigrationStatus) UpToDate() bool {
+	return s.Exists && len(s.MissingColumns) == 0
+}
+
+// MigrationStatus reports, per model, whether its table exists and which
+// columns Migrate would still add.
+func (r *Repository) MigrationStatus() ([]MigrationStatus, error) {
+	migrator := r.db.Migrator()
+
+	statuses := make([]MigrationStatus, 0, len(migrationModels))
+	for _, model := range migrationModels {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+
+		status := MigrationStatus{
+			Table:  stmt.Schema.Table,
+			Exists: migrator.HasTable(model),
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !status.Exists || !migrator.HasColumn(model, field.DBName) {
+				status.MissingColumns = append(status.MissingColumns, field.DBName)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -1,23 +1,128 @@
 package repo
 
 import (
+	"fmt"
 	"hodlbook/internal/models"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+// postgresDSNEnv points the repo tests at a PostgreSQL server instead of an
+// in-memory SQLite database, e.g. via make test-postgres.
+const postgresDSNEnv = "HODLBOOK_TEST_POSTGRES_DSN"
+
+// openTestDB returns an empty database. On PostgreSQL every test gets its own
+// schema, dropped when the test ends.
+func openTestDB(t testing.TB) *gorm.DB {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		return db
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), &gorm.Config{})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// setupTestDB returns a database whose schema is built by Migrate, the same
+// path the application takes, so both engines run the real migrations.
+func setupTestDB(t testing.TB) *gorm.DB {
+	db := openTestDB(t)
+	repository, err := New(db)
 	require.NoError(t, err)
-	require.NoError(t, db.AutoMigrate(
-		&models.Asset{},
-		&models.AssetHistoricValue{},
-		&models.Exchange{},
-		&models.Price{},
-		&models.ImportLog{},
-	))
+	require.NoError(t, repository.Migrate())
 	return db
 }
+
+func TestMigrationStatus(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Portfolio{}))
+	require.NoError(t, db.Migrator().DropColumn(&models.Portfolio{}, "description"))
+
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	statuses, err := repository.MigrationStatus()
+	require.NoError(t, err)
+	require.Equal(t, "portfolios", statuses[0].Table)
+	require.True(t, statuses[0].Exists)
+	require.Equal(t, []string{"description"}, statuses[0].MissingColumns)
+	require.False(t, statuses[1].Exists)
+
+	require.NoError(t, repository.Migrate())
+	statuses, err = repository.MigrationStatus()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		require.True(t, status.UpToDate(), status.Table)
+	}
+}
+
+func TestMigrate_ConvertsFloatColumnsToDecimal(t *testing.T) {
+	db := openTestDB(t)
+
+	type legacyAsset struct {
+		ID              int64
+		Symbol          string
+		Amount          float64
+		TransactionType string
+	}
+	require.NoError(t, db.Table("assets").AutoMigrate(&legacyAsset{}))
+	require.NoError(t, db.Table("assets").Create(&[]legacyAsset{
+		{Symbol: "BTC", Amount: 0.1, TransactionType: "deposit"},
+		{Symbol: "BTC", Amount: 0.00001, TransactionType: "deposit"},
+		{Symbol: "ETH", Amount: 100, TransactionType: "deposit"},
+	}).Error)
+
+	repository, err := New(db)
+	require.NoError(t, err)
+	require.NoError(t, repository.Migrate())
+
+	var stored []string
+	require.NoError(t, db.Table("assets").Order("id").Pluck("amount", &stored).Error)
+	require.Equal(t, []string{"0.1", "0.00001", "100"}, stored)
+
+	total, err := repository.GetTotalBySymbolAndType("BTC", "deposit")
+	require.NoError(t, err)
+	require.Equal(t, "0.10001", total.String())
+
+	require.NoError(t, repository.Migrate(), "migrating again is a no-op")
+}
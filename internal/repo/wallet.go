@@ -0,0 +1,260 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDiscrepancyNotOpen     = errors.New("discrepancy is not open")
+	ErrDiscrepancyNotProposed = errors.New("discrepancy has no proposed entry")
+	ErrDiscrepancyStale       = errors.New("discrepancy no longer applies")
+)
+
+// WalletReconciliationNote is the note of entries created by accepting a
+// wallet discrepancy.
+const WalletReconciliationNote = "Wallet reconciliation"
+
+func (r *Repository) CreateWallet(wallet *models.Wallet) error {
+	return r.db.Create(wallet).Error
+}
+
+func (r *Repository) GetWalletByID(id int64) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := r.db.First(&wallet, id).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *Repository) ListWallets() ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if err := r.db.Order("id ASC").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+func (r *Repository) UpdateWallet(wallet *models.Wallet) error {
+	return r.db.Save(wallet).Error
+}
+
+// DeleteWallet removes a wallet with its observed balances. Entries tagged
+// with it are untagged and discrepancies are kept as a record of past syncs.
+func (r *Repository) DeleteWallet(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.WalletBalance{}, "wallet_id = ?", id).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&models.Asset{}, &models.Exchange{}} {
+			if err := tx.Model(model).Where("wallet_id = ?", id).UpdateColumn("wallet_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Wallet{}, id).Error
+	})
+}
+
+// SaveWalletSync records the outcome of syncing a wallet. On success the
+// observed balances replace the previous ones; on failure they are kept and
+// only the error is stored.
+func (r *Repository) SaveWalletSync(id int64, at time.Time, balances []models.WalletBalance, syncErr error) error {
+	if syncErr != nil {
+		return r.db.Model(&models.Wallet{}).Where("id = ?", id).UpdateColumn("last_error", syncErr.Error()).Error
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.WalletBalance{}, "wallet_id = ?", id).Error; err != nil {
+			return err
+		}
+		for i := range balances {
+			balances[i].WalletID = id
+			balances[i].ObservedAt = at.UTC()
+		}
+		if len(balances) > 0 {
+			if err := tx.Create(&balances).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Wallet{}).Where("id = ?", id).UpdateColumns(map[string]any{
+			"last_synced_at": at.UTC(),
+			"last_error":     "",
+		}).Error
+	})
+}
+
+// ListWalletBalances returns the balances last observed in a wallet, or in
+// every wallet when walletID is zero.
+func (r *Repository) ListWalletBalances(walletID int64) ([]models.WalletBalance, error) {
+	query := r.db.Order("wallet_id ASC").Order("symbol ASC")
+	if walletID != 0 {
+		query = query.Where("wallet_id = ?", walletID)
+	}
+
+	var balances []models.WalletBalance
+	if err := query.Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// ListWalletDiscrepancies returns discrepancies with the given status, or
+// all of them when it is empty, newest first.
+func (r *Repository) ListWalletDiscrepancies(status string) ([]models.WalletDiscrepancy, error) {
+	query := r.db.Order("observed_at DESC").Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var discrepancies []models.WalletDiscrepancy
+	if err := query.Find(&discrepancies).Error; err != nil {
+		return nil, err
+	}
+	return discrepancies, nil
+}
+
+// GetLatestWalletDiscrepancy returns the newest discrepancy of a symbol in a
+// wallet, nil when there is none.
+func (r *Repository) GetLatestWalletDiscrepancy(walletID int64, symbol string) (*models.WalletDiscrepancy, error) {
+	var discrepancies []models.WalletDiscrepancy
+	if err := r.db.Where("wallet_id = ? AND symbol = ?", walletID, symbol).
+		Order("id DESC").
+		Limit(1).
+		Find(&discrepancies).Error; err != nil {
+		return nil, err
+	}
+	if len(discrepancies) == 0 {
+		return nil, nil
+	}
+	return &discrepancies[0], nil
+}
+
+func (r *Repository) SaveWalletDiscrepancy(discrepancy *models.WalletDiscrepancy) error {
+	return r.db.Save(discrepancy).Error
+}
+
+// AcceptWalletDiscrepancy books the entry that closes an open discrepancy
+// and returns it along with the accepted discrepancy. The difference is
+// recomputed from the wallet's last observed balance and the entries tagged
+// with it, so entries added since the sync are not booked twice.
+func (r *Repository) AcceptWalletDiscrepancy(id int64, at time.Time) (*models.Asset, *models.WalletDiscrepancy, error) {
+	var asset *models.Asset
+	var discrepancy models.WalletDiscrepancy
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&discrepancy, id).Error; err != nil {
+			return err
+		}
+		if discrepancy.Status != models.DiscrepancyOpen {
+			return ErrDiscrepancyNotOpen
+		}
+		if discrepancy.ProposedType == "" {
+			return ErrDiscrepancyNotProposed
+		}
+		if err := refreshDiscrepancy(tx, &discrepancy); err != nil {
+			return err
+		}
+
+		asset = &models.Asset{
+			PortfolioID:     discrepancy.PortfolioID,
+			WalletID:        &discrepancy.WalletID,
+			Symbol:          discrepancy.Symbol,
+			Name:            discrepancy.Symbol,
+			Amount:          discrepancy.Difference.Abs(),
+			TransactionType: discrepancy.ProposedType,
+			Notes:           WalletReconciliationNote,
+			Timestamp:       at.UTC(),
+		}
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+
+		resolvedAt := at.UTC()
+		discrepancy.Status = models.DiscrepancyAccepted
+		discrepancy.AssetID = &asset.ID
+		discrepancy.ResolvedAt = &resolvedAt
+		return tx.Save(&discrepancy).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return asset, &discrepancy, nil
+}
+
+// refreshDiscrepancy recomputes a proposed discrepancy from the current
+// state of its wallet.
+func refreshDiscrepancy(tx *gorm.DB, discrepancy *models.WalletDiscrepancy) error {
+	var wallet models.Wallet
+	if err := tx.First(&wallet, discrepancy.WalletID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDiscrepancyStale
+		}
+		return err
+	}
+
+	observed := decimal.Zero
+	var balances []models.WalletBalance
+	if err := tx.Where("wallet_id = ? AND symbol = ?", wallet.ID, discrepancy.Symbol).Find(&balances).Error; err != nil {
+		return err
+	}
+	if len(balances) > 0 {
+		observed = balances[0].Amount
+	}
+
+	var assets []models.Asset
+	if err := tx.Where("portfolio_id = ?", wallet.PortfolioID).Find(&assets).Error; err != nil {
+		return err
+	}
+	var exchanges []models.Exchange
+	if err := tx.Where("portfolio_id = ?", wallet.PortfolioID).Find(&exchanges).Error; err != nil {
+		return err
+	}
+	holdings, untagged := models.WalletHoldings(assets, exchanges, wallet.ID)
+	if untagged[discrepancy.Symbol] {
+		return ErrDiscrepancyNotProposed
+	}
+
+	recorded := holdings[discrepancy.Symbol]
+	difference := observed.Sub(recorded)
+	if difference.IsZero() {
+		return ErrDiscrepancyStale
+	}
+
+	discrepancy.Observed = observed
+	discrepancy.Recorded = recorded
+	discrepancy.Difference = difference
+	discrepancy.ProposedType = "deposit"
+	if difference.IsNegative() {
+		discrepancy.ProposedType = "withdraw"
+	}
+	return nil
+}
+
+// DismissWalletDiscrepancy closes an open discrepancy without booking
+// anything. The same difference is not reported again.
+func (r *Repository) DismissWalletDiscrepancy(id int64, at time.Time) (*models.WalletDiscrepancy, error) {
+	var discrepancy models.WalletDiscrepancy
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&discrepancy, id).Error; err != nil {
+			return err
+		}
+		if discrepancy.Status != models.DiscrepancyOpen {
+			return ErrDiscrepancyNotOpen
+		}
+
+		resolvedAt := at.UTC()
+		discrepancy.Status = models.DiscrepancyDismissed
+		discrepancy.ResolvedAt = &resolvedAt
+		return tx.Save(&discrepancy).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &discrepancy, nil
+}
@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"errors"
+	"hodlbook/internal/models"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletRepository_SaveWalletSync(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{PortfolioID: 1, Name: "Cold", Chain: "bitcoin", Address: "bc1q", Mode: models.WalletModePropose}
+	require.NoError(t, repository.CreateWallet(wallet))
+
+	at := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repository.SaveWalletSync(wallet.ID, at, []models.WalletBalance{
+		{Symbol: "BTC", Amount: decimal.RequireFromString("1.5")},
+	}, nil))
+
+	require.NoError(t, repository.SaveWalletSync(wallet.ID, at.Add(time.Hour), nil, errors.New("timeout")))
+
+	got, err := repository.GetWalletByID(wallet.ID)
+	require.NoError(t, err)
+	require.Equal(t, "timeout", got.LastError)
+	require.NotNil(t, got.LastSyncedAt)
+	require.True(t, at.Equal(*got.LastSyncedAt))
+
+	balances, err := repository.ListWalletBalances(wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	require.Equal(t, "1.5", balances[0].Amount.String())
+
+	require.NoError(t, repository.DeleteWallet(wallet.ID))
+	balances, err = repository.ListWalletBalances(0)
+	require.NoError(t, err)
+	require.Empty(t, balances)
+}
+
+func TestWalletRepository_AcceptAndDismissDiscrepancy(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{PortfolioID: 1, Name: "Cold", Chain: "bitcoin", Address: "bc1q", Mode: models.WalletModePropose}
+	require.NoError(t, repository.CreateWallet(wallet))
+	now := time.Now().UTC()
+	require.NoError(t, repository.SaveWalletSync(wallet.ID, now, []models.WalletBalance{
+		{Symbol: "BTC", Amount: decimal.RequireFromString("0.75")},
+	}, nil))
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, WalletID: &wallet.ID, Symbol: "BTC", Amount: decimal.RequireFromString("1"), TransactionType: "deposit", Timestamp: now}))
+
+	proposed := &models.WalletDiscrepancy{
+		PortfolioID:  1,
+		WalletID:     wallet.ID,
+		Symbol:       "BTC",
+		Observed:     decimal.RequireFromString("0.75"),
+		Recorded:     decimal.RequireFromString("1"),
+		Difference:   decimal.RequireFromString("-0.25"),
+		ProposedType: "withdraw",
+		Status:       models.DiscrepancyOpen,
+		ObservedAt:   now,
+	}
+	flagged := &models.WalletDiscrepancy{
+		PortfolioID: 1,
+		WalletID:    wallet.ID,
+		Symbol:      "ETH",
+		Difference:  decimal.RequireFromString("2"),
+		Status:      models.DiscrepancyOpen,
+		ObservedAt:  now,
+	}
+	require.NoError(t, repository.SaveWalletDiscrepancy(proposed))
+	require.NoError(t, repository.SaveWalletDiscrepancy(flagged))
+
+	// A withdrawal entered since the sync closes part of the difference.
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, WalletID: &wallet.ID, Symbol: "BTC", Amount: decimal.RequireFromString("0.1"), TransactionType: "withdraw", Timestamp: now}))
+
+	asset, accepted, err := repository.AcceptWalletDiscrepancy(proposed.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, "withdraw", asset.TransactionType)
+	require.Equal(t, "0.15", asset.Amount.String())
+	require.Equal(t, wallet.ID, *asset.WalletID)
+	require.Equal(t, WalletReconciliationNote, asset.Notes)
+	require.Equal(t, models.DiscrepancyAccepted, accepted.Status)
+	require.Equal(t, "-0.15", accepted.Difference.String())
+	require.Equal(t, asset.ID, *accepted.AssetID)
+
+	_, _, err = repository.AcceptWalletDiscrepancy(proposed.ID, now)
+	require.ErrorIs(t, err, ErrDiscrepancyNotOpen)
+	_, _, err = repository.AcceptWalletDiscrepancy(flagged.ID, now)
+	require.ErrorIs(t, err, ErrDiscrepancyNotProposed)
+
+	dismissed, err := repository.DismissWalletDiscrepancy(flagged.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, models.DiscrepancyDismissed, dismissed.Status)
+
+	open, err := repository.ListWalletDiscrepancies(models.DiscrepancyOpen)
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	latest, err := repository.GetLatestWalletDiscrepancy(wallet.ID, "ETH")
+	require.NoError(t, err)
+	require.Equal(t, flagged.ID, latest.ID)
+
+	latest, err = repository.GetLatestWalletDiscrepancy(wallet.ID, "SOL")
+	require.NoError(t, err)
+	require.Nil(t, latest)
+}
+
+func TestWalletRepository_AcceptRefusesStaleDiscrepancy(t *testing.T) {
+	db := setupTestDB(t)
+	repository, err := New(db)
+	require.NoError(t, err)
+
+	wallet := &models.Wallet{PortfolioID: 1, Name: "Cold", Chain: "bitcoin", Address: "bc1q", Mode: models.WalletModePropose}
+	require.NoError(t, repository.CreateWallet(wallet))
+	now := time.Now().UTC()
+	require.NoError(t, repository.SaveWalletSync(wallet.ID, now, []models.WalletBalance{
+		{Symbol: "BTC", Amount: decimal.RequireFromString("1")},
+	}, nil))
+
+	discrepancy := &models.WalletDiscrepancy{
+		PortfolioID:  1,
+		WalletID:     wallet.ID,
+		Symbol:       "BTC",
+		Observed:     decimal.RequireFromString("1"),
+		Difference:   decimal.RequireFromString("1"),
+		ProposedType: "deposit",
+		Status:       models.DiscrepancyOpen,
+		ObservedAt:   now,
+	}
+	require.NoError(t, repository.SaveWalletDiscrepancy(discrepancy))
+
+	deposit := &models.Asset{PortfolioID: 1, WalletID: &wallet.ID, Symbol: "BTC", Amount: decimal.RequireFromString("1"), TransactionType: "deposit", Timestamp: now}
+	require.NoError(t, repository.CreateAsset(deposit))
+	_, _, err = repository.AcceptWalletDiscrepancy(discrepancy.ID, now)
+	require.ErrorIs(t, err, ErrDiscrepancyStale, "the difference was already booked")
+
+	require.NoError(t, repository.DeleteAsset(deposit.ID))
+	require.NoError(t, repository.CreateAsset(&models.Asset{PortfolioID: 1, Symbol: "BTC", Amount: decimal.RequireFromString("1"), TransactionType: "deposit", Timestamp: now}))
+	_, _, err = repository.AcceptWalletDiscrepancy(discrepancy.ID, now)
+	require.ErrorIs(t, err, ErrDiscrepancyNotProposed, "untagged entries of the symbol may already hold the difference")
+
+	tagged := &models.Exchange{PortfolioID: 1, WalletID: &wallet.ID, FromSymbol: "USDT", ToSymbol: "SOL", FromAmount: decimal.NewFromInt(1), ToAmount: decimal.NewFromInt(1), Timestamp: now}
+	require.NoError(t, repository.CreateExchange(tagged))
+	require.NoError(t, repository.DeleteWallet(wallet.ID))
+	_, _, err = repository.AcceptWalletDiscrepancy(discrepancy.ID, now)
+	require.ErrorIs(t, err, ErrDiscrepancyStale)
+
+	untagged, err := repository.GetExchangeByID(tagged.ID)
+	require.NoError(t, err)
+	require.Nil(t, untagged.WalletID, "deleting a wallet untags its entries")
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/integrations/eventbus"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/notify"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidAlertConfig = errors.New("invalid alert service config")
+
+const notifyTimeout = 10 * time.Second
+
+type AlertRepository interface {
+	ListEnabledAlertRules() ([]models.AlertRule, error)
+	MarkAlertRuleTriggered(id int64, at time.Time) error
+	CreateAlertEvent(event *models.AlertEvent) error
+	GetAssetsByPortfolio(portfolioID int64) ([]models.Asset, error)
+	GetExchangesByPortfolio(portfolioID int64) ([]models.Exchange, error)
+	ListEnabledNotificationChannels() ([]models.NotificationChannel, error)
+}
+
+type pricePoint struct {
+	at    time.Time
+	price float64
+}
+
+type AlertService struct {
+	ctx       context.Context
+	logger    *slog.Logger
+	repo      AlertRepository
+	notifiers []notify.Notifier
+	bus       events.Subscriber
+	now       func() time.Time
+
+	mu      sync.Mutex
+	history map[string][]pricePoint
+	active  map[int64]bool
+}
+
+type AlertOption func(*AlertService)
+
+func WithAlertContext(ctx context.Context) AlertOption {
+	return func(s *AlertService) {
+		s.ctx = ctx
+	}
+}
+
+func WithAlertLogger(l *slog.Logger) AlertOption {
+	return func(s *AlertService) {
+		s.logger = l
+	}
+}
+
+func WithAlertRepo(r AlertRepository) AlertOption {
+	return func(s *AlertService) {
+		s.repo = r
+	}
+}
+
+func WithAlertBus(b events.Subscriber) AlertOption {
+	return func(s *AlertService) {
+		s.bus = b
+	}
+}
+
+func WithAlertNotifiers(n ...notify.Notifier) AlertOption {
+	return func(s *AlertService) {
+		s.notifiers = append(s.notifiers, n...)
+	}
+}
+
+func (s *AlertService) IsValid() error {
+	switch {
+	case s.ctx == nil:
+		return errors.Wrap(ErrInvalidAlertConfig, "ctx cannot be nil")
+	case s.logger == nil:
+		return errors.Wrap(ErrInvalidAlertConfig, "logger cannot be nil")
+	case s.repo == nil:
+		return errors.Wrap(ErrInvalidAlertConfig, "repo cannot be nil")
+	case s.bus == nil:
+		return errors.Wrap(ErrInvalidAlertConfig, "bus cannot be nil")
+	default:
+		return nil
+	}
+}
+
+func NewAlertService(opts ...AlertOption) (*AlertService, error) {
+	s := &AlertService{
+		now:     time.Now,
+		history: make(map[string][]pricePoint),
+		active:  make(map[int64]bool),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *AlertService) Start() error {
+	return s.bus.Subscribe(events.TopicPricesUpdated, eventbus.Handle(s.handlePrices), events.SubscribeOptions{
+		Name:      "alerts",
+		QueueSize: 10,
+		Policy:    events.DropOldest,
+	})
+}
+
+func (s *AlertService) handlePrices(_ context.Context, priceMap events.PricesUpdated) error {
+	return s.Evaluate(priceMap)
+}
+
+// Evaluate checks every enabled rule against a fresh price map. Rules fire
+// when their condition holds and their cooldown has elapsed; they rearm
+// once the condition clears. Notifications are sent after the rules are
+// evaluated so slow channels do not hold up the next price update.
+func (s *AlertService) Evaluate(priceMap map[string]float64) error {
+	rules, err := s.repo.ListEnabledAlertRules()
+	if err != nil {
+		return errors.Wrap(err, "failed to load alert rules")
+	}
+
+	now := s.now()
+
+	s.mu.Lock()
+	s.recordPrices(priceMap, now, rules)
+
+	var fired []firedAlert
+	holdings := make(map[int64]map[string]float64)
+	for i := range rules {
+		rule := &rules[i]
+
+		value, triggered, ok := s.check(rule, priceMap, holdings, now)
+		if !ok {
+			continue
+		}
+		if !triggered {
+			delete(s.active, rule.ID)
+			continue
+		}
+		// A rule that triggers during its cooldown stays armed and fires
+		// once the cooldown has elapsed if its condition still holds.
+		if s.active[rule.ID] || !cooldownElapsed(rule, now) {
+			continue
+		}
+		s.active[rule.ID] = true
+		fired = append(fired, s.fire(rule, value, now))
+	}
+	s.mu.Unlock()
+
+	for _, alert := range fired {
+		s.deliver(alert.rule, alert.notification)
+	}
+	return nil
+}
+
+// firedAlert is a rule that fired along with the notification to send.
+type firedAlert struct {
+	rule         *models.AlertRule
+	notification notify.Notification
+}
+
+func cooldownElapsed(rule *models.AlertRule, now time.Time) bool {
+	if rule.LastTriggeredAt == nil {
+		return true
+	}
+	cooldown := rule.CooldownMinutes
+	if cooldown <= 0 {
+		cooldown = models.DefaultAlertCooldownMinutes
+	}
+	return now.Sub(*rule.LastTriggeredAt) >= time.Duration(cooldown)*time.Minute
+}
+
+func (s *AlertService) recordPrices(priceMap map[string]float64, now time.Time, rules []models.AlertRule) {
+	var maxWindow int
+	for _, rule := range rules {
+		if rule.Type == models.AlertPriceChange && rule.WindowMinutes > maxWindow {
+			maxWindow = rule.WindowMinutes
+		}
+	}
+	cutoff := now.Add(-time.Duration(maxWindow+5) * time.Minute)
+
+	for symbol, price := range priceMap {
+		if price <= 0 {
+			continue
+		}
+		points := append(s.history[symbol], pricePoint{at: now, price: price})
+		i := 0
+		for i < len(points)-1 && points[i+1].at.Before(cutoff) {
+			i++
+		}
+		s.history[symbol] = points[i:]
+	}
+}
+
+// check returns the observed value, whether the rule condition holds and
+// whether there was enough data to decide.
+func (s *AlertService) check(rule *models.AlertRule, priceMap map[string]float64, holdings map[int64]map[string]float64, now time.Time) (float64, bool, bool) {
+	switch rule.Type {
+	case models.AlertPriceAbove, models.AlertPriceBelow:
+		price := priceMap[rule.Symbol]
+		if price <= 0 {
+			return 0, false, false
+		}
+		if rule.Type == models.AlertPriceAbove {
+			return price, price >= rule.Threshold, true
+		}
+		return price, price <= rule.Threshold, true
+
+	case models.AlertPriceChange:
+		price := priceMap[rule.Symbol]
+		ref, ok := s.referencePrice(rule.Symbol, now.Add(-time.Duration(rule.WindowMinutes)*time.Minute))
+		if price <= 0 || !ok {
+			return 0, false, false
+		}
+		change := (price - ref) / ref * 100
+		return change, math.Abs(change) >= rule.Threshold, true
+
+	case models.AlertPortfolioAbove, models.AlertPortfolioBelow:
+		held, err := s.holdings(rule.PortfolioID, holdings)
+		if err != nil {
+			s.logger.Error("failed to calculate holdings for alert", "rule", rule.ID, "error", err)
+			return 0, false, false
+		}
+		total := portfolioValue(held, priceMap)
+		if rule.Type == models.AlertPortfolioAbove {
+			return total, total >= rule.Threshold, true
+		}
+		return total, total <= rule.Threshold, true
+
+	case models.AlertAllocationDrift:
+		held, err := s.holdings(rule.PortfolioID, holdings)
+		if err != nil {
+			s.logger.Error("failed to calculate holdings for alert", "rule", rule.ID, "error", err)
+			return 0, false, false
+		}
+		total := portfolioValue(held, priceMap)
+		if total <= 0 {
+			return 0, false, false
+		}
+		allocation := held[rule.Symbol] * priceMap[rule.Symbol] / total * 100
+		return allocation, math.Abs(allocation-rule.TargetPercent) >= rule.Threshold, true
+	}
+
+	return 0, false, false
+}
+
+// referencePrice returns the most recent recorded price at or before at.
+func (s *AlertService) referencePrice(symbol string, at time.Time) (float64, bool) {
+	points := s.history[symbol]
+	for i := len(points) - 1; i >= 0; i-- {
+		if !points[i].at.After(at) {
+			return points[i].price, true
+		}
+	}
+	return 0, false
+}
+
+func (s *AlertService) holdings(portfolioID int64, memo map[int64]map[string]float64) (map[string]float64, error) {
+	if held, ok := memo[portfolioID]; ok {
+		return held, nil
+	}
+
+	assets, err := s.repo.GetAssetsByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := s.repo.GetExchangesByPortfolio(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Balances are netted exactly and only converted for valuation.
+	balances := models.NetHoldings(assets, exchanges, time.Time{})
+	held := make(map[string]float64, len(balances))
+	for symbol, amount := range balances {
+		held[symbol] = amount.InexactFloat64()
+	}
+	memo[portfolioID] = held
+	return held, nil
+}
+
+func portfolioValue(held map[string]float64, priceMap map[string]float64) float64 {
+	var total float64
+	for symbol, amount := range held {
+		if amount > 0 {
+			total += amount * priceMap[symbol]
+		}
+	}
+	return total
+}
+
+func alertMessage(rule *models.AlertRule, value float64) string {
+	switch rule.Type {
+	case models.AlertPriceAbove:
+		return fmt.Sprintf("%s is at $%.8g, above $%.8g", rule.Symbol, value, rule.Threshold)
+	case models.AlertPriceBelow:
+		return fmt.Sprintf("%s is at $%.8g, below $%.8g", rule.Symbol, value, rule.Threshold)
+	case models.AlertPriceChange:
+		return fmt.Sprintf("%s moved %+.2f%% in the last %d minutes", rule.Symbol, value, rule.WindowMinutes)
+	case models.AlertPortfolioAbove:
+		return fmt.Sprintf("Portfolio value is $%.2f, above $%.2f", value, rule.Threshold)
+	case models.AlertPortfolioBelow:
+		return fmt.Sprintf("Portfolio value is $%.2f, below $%.2f", value, rule.Threshold)
+	case models.AlertAllocationDrift:
+		return fmt.Sprintf("%s allocation is %.2f%%, target %.2f%%", rule.Symbol, value, rule.TargetPercent)
+	}
+	return rule.Name
+}
+
+// fire records that a rule fired and returns the notification to send.
+func (s *AlertService) fire(rule *models.AlertRule, value float64, now time.Time) firedAlert {
+	message := alertMessage(rule, value)
+
+	event := &models.AlertEvent{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Type:     rule.Type,
+		Symbol:   rule.Symbol,
+		Value:    value,
+		Message:  message,
+	}
+	if err := s.repo.CreateAlertEvent(event); err != nil {
+		s.logger.Error("failed to record alert event", "rule", rule.ID, "error", err)
+	}
+	if err := s.repo.MarkAlertRuleTriggered(rule.ID, now); err != nil {
+		s.logger.Error("failed to update alert rule", "rule", rule.ID, "error", err)
+	}
+
+	title := rule.Name
+	if title == "" {
+		title = "HodlBook alert"
+	}
+	return firedAlert{
+		rule: rule,
+		notification: notify.Notification{
+			Title:     title,
+			Message:   message,
+			Timestamp: now,
+		},
+	}
+}
+
+func (s *AlertService) deliver(rule *models.AlertRule, n notify.Notification) {
+	wanted := make(map[string]bool)
+	for _, name := range rule.ChannelList() {
+		wanted[name] = true
+	}
+
+	for _, notifier := range s.activeNotifiers() {
+		if len(wanted) > 0 && !wanted[notifier.Name()] {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(s.ctx, notifyTimeout)
+		if err := notifier.Notify(ctx, n); err != nil {
+			s.logger.Error("failed to deliver alert", "rule", rule.ID, "channel", notifier.Name(), "error", err)
+		}
+		cancel()
+	}
+}
+
+func (s *AlertService) activeNotifiers() []notify.Notifier {
+	notifiers := append([]notify.Notifier(nil), s.notifiers...)
+
+	channels, err := s.repo.ListEnabledNotificationChannels()
+	if err != nil {
+		s.logger.Error("failed to load notification channels", "error", err)
+		return notifiers
+	}
+	for i := range channels {
+		n, err := NewChannelNotifier(&channels[i])
+		if err != nil {
+			s.logger.Error("failed to build notifier", "channel", channels[i].Name, "error", err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/types/notify"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alertDiscardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type mockAlertRepo struct {
+	mu        sync.Mutex
+	rules     []models.AlertRule
+	events    []models.AlertEvent
+	assets    []models.Asset
+	exchanges []models.Exchange
+	channels  []models.NotificationChannel
+}
+
+func (m *mockAlertRepo) ListEnabledAlertRules() ([]models.AlertRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rules []models.AlertRule
+	for _, r := range m.rules {
+		if r.Enabled {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+func (m *mockAlertRepo) MarkAlertRuleTriggered(id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules[i].LastTriggeredAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *mockAlertRepo) CreateAlertEvent(event *models.AlertEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *mockAlertRepo) GetAssetsByPortfolio(int64) ([]models.Asset, error) {
+	return m.assets, nil
+}
+
+func (m *mockAlertRepo) GetExchangesByPortfolio(int64) ([]models.Exchange, error) {
+	return m.exchanges, nil
+}
+
+func (m *mockAlertRepo) ListEnabledNotificationChannels() ([]models.NotificationChannel, error) {
+	return m.channels, nil
+}
+
+type recordingNotifier struct {
+	name string
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Name() string {
+	return n.name
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg notify.Notification) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func newTestAlertService(t *testing.T, repo *mockAlertRepo, notifiers ...notify.Notifier) (*AlertService, *time.Time) {
+	svc, err := NewAlertService(
+		WithAlertContext(context.Background()),
+		WithAlertLogger(alertDiscardLogger),
+		WithAlertRepo(repo),
+		WithAlertBus(newTestBus(t)),
+		WithAlertNotifiers(notifiers...),
+	)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestAlertService_InvalidConfig(t *testing.T) {
+	_, err := NewAlertService(WithAlertLogger(alertDiscardLogger))
+	assert.ErrorIs(t, err, ErrInvalidAlertConfig)
+}
+
+func TestAlertService_PriceAboveFiresOncePerCrossing(t *testing.T) {
+	repo := &mockAlertRepo{rules: []models.AlertRule{
+		{ID: 1, Name: "BTC high", Type: models.AlertPriceAbove, Symbol: "BTC", Threshold: 100000, Enabled: true, CooldownMinutes: 1},
+	}}
+	notifier := &recordingNotifier{name: "log"}
+	svc, now := newTestAlertService(t, repo, notifier)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 99000}))
+	assert.Empty(t, repo.events)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 101000}))
+	require.Len(t, repo.events, 1)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "BTC high", notifier.sent[0].Title)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 102000}))
+	assert.Len(t, repo.events, 1, "should not fire again while the condition holds")
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 98000}))
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 101000}))
+	assert.Len(t, repo.events, 2, "should fire again after rearming")
+}
+
+func TestAlertService_Cooldown(t *testing.T) {
+	repo := &mockAlertRepo{rules: []models.AlertRule{
+		{ID: 1, Type: models.AlertPriceBelow, Symbol: "ETH", Threshold: 2000, Enabled: true, CooldownMinutes: 60},
+	}}
+	svc, now := newTestAlertService(t, repo)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 1900}))
+	require.Len(t, repo.events, 1)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 2100}))
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 1900}))
+	assert.Len(t, repo.events, 1, "cooldown should suppress the second crossing")
+}
+
+func TestAlertService_FiresAfterCooldownWhenStillTriggered(t *testing.T) {
+	repo := &mockAlertRepo{rules: []models.AlertRule{
+		{ID: 1, Type: models.AlertPriceBelow, Symbol: "ETH", Threshold: 2000, Enabled: true, CooldownMinutes: 60},
+	}}
+	svc, now := newTestAlertService(t, repo)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 1900}))
+	require.Len(t, repo.events, 1)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 2100}))
+	*now = now.Add(time.Minute)
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 1900}))
+	require.Len(t, repo.events, 1, "triggered during the cooldown")
+
+	*now = now.Add(time.Hour)
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 1850}))
+	assert.Len(t, repo.events, 2, "still triggered once the cooldown has elapsed")
+}
+
+// blockingNotifier holds every delivery until release is closed.
+type blockingNotifier struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (n *blockingNotifier) Name() string {
+	return "slow"
+}
+
+func (n *blockingNotifier) Notify(context.Context, notify.Notification) error {
+	n.once.Do(func() { close(n.started) })
+	<-n.release
+	return nil
+}
+
+func TestAlertService_DeliveryDoesNotBlockEvaluation(t *testing.T) {
+	repo := &mockAlertRepo{rules: []models.AlertRule{
+		{ID: 1, Type: models.AlertPriceAbove, Symbol: "BTC", Threshold: 100000, Enabled: true},
+	}}
+	notifier := &blockingNotifier{started: make(chan struct{}), release: make(chan struct{})}
+	svc, _ := newTestAlertService(t, repo, notifier)
+
+	delivered := make(chan error, 1)
+	go func() { delivered <- svc.Evaluate(map[string]float64{"BTC": 101000}) }()
+	<-notifier.started
+
+	evaluated := make(chan error, 1)
+	go func() { evaluated <- svc.Evaluate(map[string]float64{"BTC": 102000}) }()
+	select {
+	case err := <-evaluated:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("evaluation waited for a notification to be delivered")
+	}
+
+	close(notifier.release)
+	require.NoError(t, <-delivered)
+	assert.Len(t, repo.events, 1)
+}
+
+func TestAlertService_PriceChange(t *testing.T) {
+	repo := &mockAlertRepo{rules: []models.AlertRule{
+		{ID: 1, Type: models.AlertPriceChange, Symbol: "SOL", Threshold: 10, WindowMinutes: 5, Enabled: true},
+	}}
+	svc, now := newTestAlertService(t, repo)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, svc.Evaluate(map[string]float64{"SOL": 100}))
+		*now = now.Add(time.Minute)
+	}
+	assert.Empty(t, repo.events)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"SOL": 85}))
+	require.Len(t, repo.events, 1)
+	assert.InDelta(t, -15, repo.events[0].Value, 0.001)
+}
+
+func TestAlertService_PortfolioAndAllocation(t *testing.T) {
+	repo := &mockAlertRepo{
+		rules: []models.AlertRule{
+			{ID: 1, Type: models.AlertPortfolioAbove, Threshold: 5000, Enabled: true},
+			{ID: 2, Type: models.AlertAllocationDrift, Symbol: "BTC", TargetPercent: 50, Threshold: 10, Enabled: true, Channels: "ntfy"},
+		},
+		assets: []models.Asset{
+			{Symbol: "BTC", Amount: decimal.RequireFromString("0.1"), TransactionType: "deposit"},
+			{Symbol: "ETH", Amount: decimal.NewFromInt(1), TransactionType: "deposit"},
+		},
+	}
+	logNotifier := &recordingNotifier{name: "log"}
+	ntfyNotifier := &recordingNotifier{name: "ntfy"}
+	svc, _ := newTestAlertService(t, repo, logNotifier, ntfyNotifier)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"BTC": 50000, "ETH": 2000}))
+
+	require.Len(t, repo.events, 2)
+	assert.Equal(t, models.AlertPortfolioAbove, repo.events[0].Type)
+	assert.InDelta(t, 7000, repo.events[0].Value, 0.001)
+	assert.Equal(t, models.AlertAllocationDrift, repo.events[1].Type)
+	assert.InDelta(t, 71.43, repo.events[1].Value, 0.01)
+
+	assert.Len(t, logNotifier.sent, 1)
+	assert.Len(t, ntfyNotifier.sent, 2)
+}
+
+func TestAlertService_DeliversToConfiguredChannels(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := &mockAlertRepo{
+		rules: []models.AlertRule{
+			{ID: 1, Name: "ETH high", Type: models.AlertPriceAbove, Symbol: "ETH", Threshold: 3000, Enabled: true, Channels: "ops-hook"},
+		},
+		channels: []models.NotificationChannel{
+			{Name: "ops-hook", Type: models.ChannelWebhook, URL: server.URL, Enabled: true},
+			{Name: "other-hook", Type: models.ChannelWebhook, URL: server.URL, Enabled: true},
+		},
+	}
+	svc, _ := newTestAlertService(t, repo)
+
+	require.NoError(t, svc.Evaluate(map[string]float64{"ETH": 3100}))
+	require.Len(t, received, 1)
+	assert.Contains(t, received[0], "ETH high")
+}
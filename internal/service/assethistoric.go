@@ -2,14 +2,13 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"time"
 
 	"hodlbook/internal/models"
-	"hodlbook/pkg/integrations/wmPubsub"
+	"hodlbook/pkg/integrations/eventbus"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
-	"hodlbook/pkg/types/pubsub"
 
 	"github.com/pkg/errors"
 )
@@ -26,8 +25,7 @@ type AssetHistoricService struct {
 	logger       *slog.Logger
 	priceFetcher prices.PriceFetcher
 	repo         AssetHistoricRepository
-	subscriber   pubsub.Subscriber
-	ch           chan []byte
+	bus          events.Subscriber
 }
 
 type AssetHistoricOption func(*AssetHistoricService)
@@ -56,9 +54,9 @@ func WithAssetHistoricRepo(r AssetHistoricRepository) AssetHistoricOption {
 	}
 }
 
-func WithAssetHistoricChannel(ch chan []byte) AssetHistoricOption {
+func WithAssetHistoricBus(b events.Subscriber) AssetHistoricOption {
 	return func(s *AssetHistoricService) {
-		s.ch = ch
+		s.bus = b
 	}
 }
 
@@ -72,8 +70,8 @@ func (s *AssetHistoricService) IsValid() error {
 		return errors.Wrap(ErrInvalidAssetHistoricConfig, "price fetcher cannot be nil")
 	case s.repo == nil:
 		return errors.Wrap(ErrInvalidAssetHistoricConfig, "repo cannot be nil")
-	case s.ch == nil:
-		return errors.Wrap(ErrInvalidAssetHistoricConfig, "channel cannot be nil")
+	case s.bus == nil:
+		return errors.Wrap(ErrInvalidAssetHistoricConfig, "bus cannot be nil")
 	default:
 		return nil
 	}
@@ -90,32 +88,17 @@ func NewAssetHistoricService(opts ...AssetHistoricOption) (*AssetHistoricService
 		return nil, err
 	}
 
-	s.subscriber = wmPubsub.New(
-		wmPubsub.WithContext(s.ctx),
-		wmPubsub.WithLogger(s.logger),
-		wmPubsub.WithTopic("asset-created"),
-		wmPubsub.WithChannel(s.ch),
-		wmPubsub.WithHandler(s.handleAssetCreated),
-	)
-
 	return s, nil
 }
 
 func (s *AssetHistoricService) Start() error {
-	return s.subscriber.Subscribe()
-}
-
-func (s *AssetHistoricService) Publisher() pubsub.Publisher {
-	return s.subscriber.(pubsub.Publisher)
+	return s.bus.Subscribe(events.TopicAssetCreated, eventbus.Handle(s.handleAssetCreated), events.SubscribeOptions{
+		Name:   "asset_historic",
+		Policy: events.Block,
+	})
 }
 
-func (s *AssetHistoricService) handleAssetCreated(data []byte) error {
-	var asset models.Asset
-	if err := json.Unmarshal(data, &asset); err != nil {
-		s.logger.Error("failed to unmarshal asset", "error", err)
-		return err
-	}
-
+func (s *AssetHistoricService) handleAssetCreated(ctx context.Context, asset models.Asset) error {
 	history, err := s.repo.SelectAllBySymbol(asset.Symbol)
 	if err != nil {
 		s.logger.Error("failed to check existing history", "symbol", asset.Symbol, "error", err)
@@ -134,7 +117,7 @@ func (s *AssetHistoricService) handleAssetCreated(data []byte) error {
 		},
 	}
 
-	if err := s.priceFetcher.FetchMany(pricePair); err != nil {
+	if err := s.priceFetcher.FetchMany(ctx, pricePair); err != nil {
 		s.logger.Error("failed to fetch price for new asset", "symbol", asset.Symbol, "error", err)
 		return err
 	}
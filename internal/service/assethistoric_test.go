@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 	"log/slog"
 	"sync"
@@ -10,7 +9,9 @@ import (
 	"time"
 
 	"hodlbook/internal/models"
+	"hodlbook/pkg/integrations/eventbus"
 	pricesPkg "hodlbook/pkg/integrations/prices"
+	"hodlbook/pkg/types/events"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -56,7 +57,7 @@ func TestAssetHistoricService_InvalidConfig(t *testing.T) {
 	ctx := context.Background()
 	fetcher := pricesPkg.NewPriceService()
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	tests := []struct {
 		name string
@@ -66,27 +67,27 @@ func TestAssetHistoricService_InvalidConfig(t *testing.T) {
 			WithAssetHistoricLogger(assetHistoricDiscardLogger),
 			WithAssetHistoricFetcher(fetcher),
 			WithAssetHistoricRepo(repo),
-			WithAssetHistoricChannel(ch),
+			WithAssetHistoricBus(bus),
 		}},
 		{"no logger", []AssetHistoricOption{
 			WithAssetHistoricContext(ctx),
 			WithAssetHistoricFetcher(fetcher),
 			WithAssetHistoricRepo(repo),
-			WithAssetHistoricChannel(ch),
+			WithAssetHistoricBus(bus),
 		}},
 		{"no fetcher", []AssetHistoricOption{
 			WithAssetHistoricContext(ctx),
 			WithAssetHistoricLogger(assetHistoricDiscardLogger),
 			WithAssetHistoricRepo(repo),
-			WithAssetHistoricChannel(ch),
+			WithAssetHistoricBus(bus),
 		}},
 		{"no repo", []AssetHistoricOption{
 			WithAssetHistoricContext(ctx),
 			WithAssetHistoricLogger(assetHistoricDiscardLogger),
 			WithAssetHistoricFetcher(fetcher),
-			WithAssetHistoricChannel(ch),
+			WithAssetHistoricBus(bus),
 		}},
-		{"no channel", []AssetHistoricOption{
+		{"no bus", []AssetHistoricOption{
 			WithAssetHistoricContext(ctx),
 			WithAssetHistoricLogger(assetHistoricDiscardLogger),
 			WithAssetHistoricFetcher(fetcher),
@@ -106,35 +107,34 @@ func TestAssetHistoricService_ValidConfig(t *testing.T) {
 	ctx := context.Background()
 	fetcher := pricesPkg.NewPriceService()
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 
 	require.NoError(t, err)
 	assert.NotNil(t, svc)
-	assert.NotNil(t, svc.Publisher())
 }
 
 func TestAssetHistoricService_HandleAssetCreated(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	fetcher := pricesPkg.NewPriceService()
+	fetcher := newFakePriceService(t, map[string]float64{"BTC": 64000})
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 	require.NoError(t, err)
 
@@ -143,16 +143,13 @@ func TestAssetHistoricService_HandleAssetCreated(t *testing.T) {
 		Symbol: "BTC",
 		Name:   "Bitcoin",
 	}
-	data, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	err = svc.handleAssetCreated(data)
+	err = svc.handleAssetCreated(ctx, asset)
 	require.NoError(t, err)
 
 	values := repo.GetValues("BTC")
 	assert.Len(t, values, 1)
 	assert.Equal(t, "BTC", values[0].Symbol)
-	assert.Greater(t, values[0].Value, float64(0))
+	assert.Equal(t, 64000.0, values[0].Value)
 	assert.False(t, values[0].Timestamp.IsZero())
 }
 
@@ -165,14 +162,14 @@ func TestAssetHistoricService_HandleAssetCreatedSkipsExisting(t *testing.T) {
 	repo.values["BTC"] = []models.AssetHistoricValue{
 		{Symbol: "BTC", Value: 50000, Timestamp: time.Now()},
 	}
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 	require.NoError(t, err)
 
@@ -181,35 +178,32 @@ func TestAssetHistoricService_HandleAssetCreatedSkipsExisting(t *testing.T) {
 		Symbol: "BTC",
 		Name:   "Bitcoin",
 	}
-	data, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	err = svc.handleAssetCreated(data)
+	err = svc.handleAssetCreated(ctx, asset)
 	require.NoError(t, err)
 
 	values := repo.GetValues("BTC")
 	assert.Len(t, values, 1)
 }
 
-func TestAssetHistoricService_HandleAssetCreatedInvalidJSON(t *testing.T) {
+func TestAssetHistoricService_HandleAssetCreatedWrongPayload(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	fetcher := pricesPkg.NewPriceService()
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 	require.NoError(t, err)
 
-	err = svc.handleAssetCreated([]byte("invalid json"))
-	assert.Error(t, err)
+	err = eventbus.Handle(svc.handleAssetCreated)(ctx, events.Event{Topic: events.TopicAssetCreated, Payload: "invalid"})
+	assert.ErrorIs(t, err, eventbus.ErrUnexpectedType)
 }
 
 func TestAssetHistoricService_Start(t *testing.T) {
@@ -218,14 +212,14 @@ func TestAssetHistoricService_Start(t *testing.T) {
 
 	fetcher := pricesPkg.NewPriceService()
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 	require.NoError(t, err)
 
@@ -237,16 +231,16 @@ func TestAssetHistoricService_PublishAndHandle(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	fetcher := pricesPkg.NewPriceService()
+	fetcher := newFakePriceService(t, map[string]float64{"ETH": 3200})
 	repo := newMockAssetHistoricRepo()
-	ch := make(chan []byte, 10)
+	bus := newTestBus(t)
 
 	svc, err := NewAssetHistoricService(
 		WithAssetHistoricContext(ctx),
 		WithAssetHistoricLogger(assetHistoricDiscardLogger),
 		WithAssetHistoricFetcher(fetcher),
 		WithAssetHistoricRepo(repo),
-		WithAssetHistoricChannel(ch),
+		WithAssetHistoricBus(bus),
 	)
 	require.NoError(t, err)
 
@@ -258,10 +252,7 @@ func TestAssetHistoricService_PublishAndHandle(t *testing.T) {
 		Symbol: "ETH",
 		Name:   "Ethereum",
 	}
-	data, err := json.Marshal(asset)
-	require.NoError(t, err)
-
-	err = svc.Publisher().Publish(data)
+	err = bus.Publish(ctx, events.TopicAssetCreated, asset)
 	require.NoError(t, err)
 
 	timeout := time.After(5 * time.Second)
@@ -276,7 +267,7 @@ func TestAssetHistoricService_PublishAndHandle(t *testing.T) {
 			values := repo.GetValues("ETH")
 			if len(values) > 0 {
 				assert.Equal(t, "ETH", values[0].Symbol)
-				assert.Greater(t, values[0].Value, float64(0))
+				assert.Equal(t, 3200.0, values[0].Value)
 				return
 			}
 		}
@@ -19,6 +19,9 @@ type HistoricValueRepository interface {
 	GetUniqueSymbols() ([]string, error)
 	GetHistoricSymbols() ([]string, error)
 	Insert(value *models.AssetHistoricValue) error
+	SelectAllBySymbol(symbol string) ([]models.AssetHistoricValue, error)
+	ReplaceHistoricValues(symbol string, values []models.AssetHistoricValue) error
+	GetPriceAtTime(symbol, currency string, timestamp time.Time) (*models.Price, error)
 }
 
 type HistoricPriceService struct {
@@ -27,6 +30,7 @@ type HistoricPriceService struct {
 	priceFetcher prices.PriceFetcher
 	repo         HistoricValueRepository
 	scheduler    scheduler.Scheduler
+	targetHour   int
 }
 
 type HistoricPriceOption func(*HistoricPriceService)
@@ -55,6 +59,13 @@ func WithHistoricPriceRepo(r HistoricValueRepository) HistoricPriceOption {
 	}
 }
 
+// WithHistoricPriceTargetHour sets the UTC hour of the daily snapshot.
+func WithHistoricPriceTargetHour(hour int) HistoricPriceOption {
+	return func(s *HistoricPriceService) {
+		s.targetHour = hour
+	}
+}
+
 func (s *HistoricPriceService) IsValid() error {
 	switch {
 	case s.ctx == nil:
@@ -65,6 +76,8 @@ func (s *HistoricPriceService) IsValid() error {
 		return errors.Wrap(ErrInvalidHistoricPriceConfig, "price fetcher cannot be nil")
 	case s.repo == nil:
 		return errors.Wrap(ErrInvalidHistoricPriceConfig, "repo cannot be nil")
+	case s.targetHour < 0 || s.targetHour > 23:
+		return errors.Wrap(ErrInvalidHistoricPriceConfig, "target hour must be between 0 and 23")
 	default:
 		return nil
 	}
@@ -82,11 +95,12 @@ func NewHistoricPriceService(opts ...HistoricPriceOption) (*HistoricPriceService
 	}
 
 	sched, err := tickerScheduler.New(
+		tickerScheduler.WithName("historic_price"),
 		tickerScheduler.WithContext(s.ctx),
 		tickerScheduler.WithLogger(s.logger),
 		tickerScheduler.WithInterval(scheduler.IntervalDaily),
 		tickerScheduler.WithHandler(s.tick),
-		tickerScheduler.WithTargetHour(0), // midnight UTC
+		tickerScheduler.WithTargetHour(s.targetHour),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create scheduler")
@@ -97,13 +111,13 @@ func NewHistoricPriceService(opts ...HistoricPriceOption) (*HistoricPriceService
 }
 
 func (s *HistoricPriceService) Start() error {
-	if err := s.addMissingSymbols(); err != nil {
+	if err := s.addMissingSymbols(s.ctx); err != nil {
 		s.logger.Error("failed to add missing symbols on startup", "error", err)
 	}
 	return s.scheduler.Start()
 }
 
-func (s *HistoricPriceService) addMissingSymbols() error {
+func (s *HistoricPriceService) addMissingSymbols(ctx context.Context) error {
 	allSymbols, err := s.repo.GetUniqueSymbols()
 	if err != nil {
 		return errors.Wrap(err, "failed to get all symbols")
@@ -142,12 +156,15 @@ func (s *HistoricPriceService) addMissingSymbols() error {
 		}
 	}
 
-	if err := s.priceFetcher.FetchMany(pricePairs...); err != nil {
+	now := time.Now()
+	if err := s.fetchPrices(ctx, pricePairs, now); err != nil {
 		return errors.Wrap(err, "failed to fetch prices for missing symbols")
 	}
 
-	now := time.Now()
 	for i, symbol := range missing {
+		if pricePairs[i].Value <= 0 {
+			continue
+		}
 		historicValue := &models.AssetHistoricValue{
 			Symbol:    symbol,
 			Value:     pricePairs[i].Value,
@@ -166,11 +183,85 @@ func (s *HistoricPriceService) addMissingSymbols() error {
 	return nil
 }
 
+// FillMissing records a first historic value for every held symbol that has
+// none yet.
+func (s *HistoricPriceService) FillMissing(ctx context.Context) error {
+	return s.addMissingSymbols(ctx)
+}
+
+// RebuildHistory recomputes the daily value series of every symbol with
+// history from the stored prices, from its first recorded day to today. Each
+// day gets the newest price at the snapshot hour; days before the first
+// stored price keep the values recorded for them. It returns how many daily
+// values were recomputed.
+func (s *HistoricPriceService) RebuildHistory(ctx context.Context) (int, error) {
+	symbols, err := s.repo.GetHistoricSymbols()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get historic symbols")
+	}
+
+	now := time.Now().UTC()
+	rebuilt := 0
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return rebuilt, err
+		}
+		count, err := s.rebuildSymbol(symbol, now)
+		if err != nil {
+			return rebuilt, errors.Wrapf(err, "failed to rebuild history of %s", symbol)
+		}
+		rebuilt += count
+	}
+	return rebuilt, nil
+}
+
+func (s *HistoricPriceService) rebuildSymbol(symbol string, now time.Time) (int, error) {
+	existing, err := s.repo.SelectAllBySymbol(symbol)
+	if err != nil || len(existing) == 0 {
+		return 0, err
+	}
+
+	recorded := make(map[time.Time][]models.AssetHistoricValue)
+	first := now
+	for _, value := range existing {
+		day := value.Timestamp.UTC().Truncate(24 * time.Hour)
+		recorded[day] = append(recorded[day], value)
+		if day.Before(first) {
+			first = day
+		}
+	}
+
+	var series []models.AssetHistoricValue
+	rebuilt := 0
+	for day := first.Truncate(24 * time.Hour); !day.After(now); day = day.AddDate(0, 0, 1) {
+		at := day.Add(time.Duration(s.targetHour) * time.Hour)
+		if at.After(now) {
+			at = now
+		}
+		price, err := s.repo.GetPriceAtTime(symbol, "USD", at)
+		if err != nil {
+			return 0, err
+		}
+		if price == nil {
+			series = append(series, recorded[day]...)
+			continue
+		}
+		series = append(series, models.AssetHistoricValue{
+			Symbol:    symbol,
+			Value:     price.Price.InexactFloat64(),
+			Timestamp: at,
+		})
+		rebuilt++
+	}
+
+	return rebuilt, s.repo.ReplaceHistoricValues(symbol, series)
+}
+
 func (s *HistoricPriceService) Stop() {
 	s.scheduler.Stop()
 }
 
-func (s *HistoricPriceService) tick() error {
+func (s *HistoricPriceService) tick(ctx context.Context) error {
 	symbols, err := s.repo.GetUniqueSymbols()
 	if err != nil {
 		return errors.Wrap(err, "failed to get symbols from DB")
@@ -190,12 +281,15 @@ func (s *HistoricPriceService) tick() error {
 		}
 	}
 
-	if err := s.priceFetcher.FetchMany(pricePairs...); err != nil {
+	now := time.Now()
+	if err := s.fetchPrices(ctx, pricePairs, now); err != nil {
 		return errors.Wrap(err, "failed to fetch prices")
 	}
 
-	now := time.Now()
 	for i, symbol := range symbols {
+		if pricePairs[i].Value <= 0 {
+			continue
+		}
 		historicValue := &models.AssetHistoricValue{
 			Symbol:    symbol,
 			Value:     pricePairs[i].Value,
@@ -213,3 +307,35 @@ func (s *HistoricPriceService) tick() error {
 	s.logger.Info("stored historic prices", "count", len(symbols))
 	return nil
 }
+
+// fetchPrices fetches the pairs and fills those the providers could not
+// price, for instance in offline mode, from the newest stored price, manual
+// or fetched. It fails only when no pair ends up with a price.
+func (s *HistoricPriceService) fetchPrices(ctx context.Context, pairs []*prices.Price, at time.Time) error {
+	fetchErr := s.priceFetcher.FetchMany(ctx, pairs...)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	filled := 0
+	for _, pair := range pairs {
+		if pair.Value > 0 {
+			filled++
+			continue
+		}
+		stored, err := s.repo.GetPriceAtTime(pair.Asset.Symbol, "USD", at)
+		if err != nil || stored == nil {
+			continue
+		}
+		pair.Value = stored.Price.InexactFloat64()
+		filled++
+	}
+
+	if fetchErr != nil {
+		if filled == 0 {
+			return fetchErr
+		}
+		s.logger.Warn("using stored prices where fetching failed", "error", fetchErr)
+	}
+	return nil
+}
@@ -6,10 +6,12 @@ import (
 	"log/slog"
 	"sync"
 	"testing"
+	"time"
 
 	"hodlbook/internal/models"
 	pricesPkg "hodlbook/pkg/integrations/prices"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,6 +22,8 @@ type mockHistoricRepo struct {
 	symbols         []string
 	historicSymbols []string
 	values          []models.AssetHistoricValue
+	prices          map[string]models.Price
+	history         []models.Price
 	mu              sync.Mutex
 	insertErr       error
 }
@@ -55,6 +59,48 @@ func (m *mockHistoricRepo) Insert(value *models.AssetHistoricValue) error {
 	return nil
 }
 
+func (m *mockHistoricRepo) SelectAllBySymbol(symbol string) ([]models.AssetHistoricValue, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var values []models.AssetHistoricValue
+	for _, v := range m.values {
+		if v.Symbol == symbol {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (m *mockHistoricRepo) ReplaceHistoricValues(symbol string, values []models.AssetHistoricValue) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := values
+	for _, v := range m.values {
+		if v.Symbol != symbol {
+			kept = append(kept, v)
+		}
+	}
+	m.values = kept
+	return nil
+}
+
+func (m *mockHistoricRepo) GetPriceAtTime(symbol, currency string, timestamp time.Time) (*models.Price, error) {
+	if m.history != nil {
+		var newest *models.Price
+		for i, p := range m.history {
+			if p.Symbol == symbol && !p.Timestamp.After(timestamp) && (newest == nil || p.Timestamp.After(newest.Timestamp)) {
+				newest = &m.history[i]
+			}
+		}
+		return newest, nil
+	}
+	price, ok := m.prices[symbol]
+	if !ok || price.Timestamp.After(timestamp) {
+		return nil, nil
+	}
+	return &price, nil
+}
+
 func (m *mockHistoricRepo) GetValues() []models.AssetHistoricValue {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -90,6 +136,13 @@ func TestHistoricPriceService_InvalidConfig(t *testing.T) {
 			WithHistoricPriceLogger(historicDiscardLogger),
 			WithHistoricPriceFetcher(fetcher),
 		}},
+		{"target hour out of range", []HistoricPriceOption{
+			WithHistoricPriceContext(ctx),
+			WithHistoricPriceLogger(historicDiscardLogger),
+			WithHistoricPriceFetcher(fetcher),
+			WithHistoricPriceRepo(repo),
+			WithHistoricPriceTargetHour(24),
+		}},
 	}
 
 	for _, tt := range tests {
@@ -104,7 +157,7 @@ func TestHistoricPriceService_Tick(t *testing.T) {
 	ctx, cancel := context.WithCancel(t.Context())
 	defer cancel()
 
-	fetcher := pricesPkg.NewPriceService()
+	fetcher := newFakePriceService(t, map[string]float64{"BTC": 64000, "ETH": 3200})
 	repo := &mockHistoricRepo{
 		symbols: []string{"BTC", "ETH"},
 	}
@@ -117,7 +170,7 @@ func TestHistoricPriceService_Tick(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	err = svc.tick()
+	err = svc.tick(ctx)
 	require.NoError(t, err)
 
 	values := repo.GetValues()
@@ -135,8 +188,39 @@ func TestHistoricPriceService_Tick(t *testing.T) {
 
 	require.NotNil(t, btcValue)
 	require.NotNil(t, ethValue)
-	assert.Greater(t, btcValue.Value, float64(0))
-	assert.Greater(t, ethValue.Value, float64(0))
+	assert.Equal(t, 64000.0, btcValue.Value)
+	assert.Equal(t, 3200.0, ethValue.Value)
+}
+
+func TestHistoricPriceService_TickOfflineUsesStoredPrices(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	fetcher := pricesPkg.NewPriceService(pricesPkg.WithOffline(true))
+	repo := &mockHistoricRepo{
+		symbols: []string{"BTC", "ETH"},
+		prices: map[string]models.Price{
+			"BTC": {Symbol: "BTC", Price: decimal.NewFromInt(42000), Source: models.PriceSourceManual, Timestamp: time.Now().Add(-time.Hour)},
+		},
+	}
+
+	svc, err := NewHistoricPriceService(
+		WithHistoricPriceContext(ctx),
+		WithHistoricPriceLogger(historicDiscardLogger),
+		WithHistoricPriceFetcher(fetcher),
+		WithHistoricPriceRepo(repo),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.tick(ctx))
+
+	values := repo.GetValues()
+	require.Len(t, values, 1, "symbols without any price are skipped rather than stored as zero")
+	assert.Equal(t, "BTC", values[0].Symbol)
+	assert.Equal(t, 42000.0, values[0].Value)
+
+	repo.prices = nil
+	assert.ErrorIs(t, svc.tick(ctx), pricesPkg.ErrOffline)
 }
 
 func TestHistoricPriceService_TickEmptyAssets(t *testing.T) {
@@ -156,9 +240,53 @@ func TestHistoricPriceService_TickEmptyAssets(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	err = svc.tick()
+	err = svc.tick(ctx)
 	require.NoError(t, err)
 
 	values := repo.GetValues()
 	assert.Len(t, values, 0)
 }
+
+func TestHistoricPriceService_RebuildHistory(t *testing.T) {
+	ctx := t.Context()
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+	repo := &mockHistoricRepo{
+		values: []models.AssetHistoricValue{
+			{Symbol: "BTC", Value: 1, Timestamp: day.Add(10 * time.Hour)},
+			{Symbol: "BTC", Value: 2, Timestamp: day.AddDate(0, 0, 1).Add(10 * time.Hour)},
+			{Symbol: "BTC", Value: 3, Timestamp: day.AddDate(0, 0, 1).Add(20 * time.Hour)},
+			{Symbol: "ETH", Value: 5, Timestamp: day.AddDate(0, 0, 2)},
+		},
+		history: []models.Price{
+			{Symbol: "BTC", Price: decimal.NewFromInt(200), Timestamp: day.AddDate(0, 0, 1)},
+			{Symbol: "BTC", Price: decimal.NewFromInt(300), Timestamp: day.AddDate(0, 0, 2)},
+		},
+	}
+
+	svc, err := NewHistoricPriceService(
+		WithHistoricPriceContext(ctx),
+		WithHistoricPriceLogger(historicDiscardLogger),
+		WithHistoricPriceFetcher(pricesPkg.NewPriceService(pricesPkg.WithOffline(true))),
+		WithHistoricPriceRepo(repo),
+		WithHistoricPriceTargetHour(12),
+	)
+	require.NoError(t, err)
+
+	rebuilt, err := svc.RebuildHistory(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rebuilt, "one value a day from the first day with a stored price to today")
+
+	btc, err := repo.SelectAllBySymbol("BTC")
+	require.NoError(t, err)
+	require.Len(t, btc, 4)
+	assert.Equal(t, 1.0, btc[0].Value, "a day before the first stored price keeps its value")
+	assert.Equal(t, 200.0, btc[1].Value, "two values of one day are replaced by the price at the snapshot hour")
+	assert.Equal(t, day.AddDate(0, 0, 1).Add(12*time.Hour), btc[1].Timestamp)
+	assert.Equal(t, 300.0, btc[2].Value)
+	assert.Equal(t, 300.0, btc[3].Value)
+
+	eth, err := repo.SelectAllBySymbol("ETH")
+	require.NoError(t, err)
+	require.Len(t, eth, 1, "without stored prices the recorded values stay")
+	assert.Equal(t, 5.0, eth[0].Value)
+}
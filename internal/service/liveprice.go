@@ -2,20 +2,19 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"sync"
 	"time"
 
 	"hodlbook/internal/models"
-	priceService "hodlbook/pkg/integrations/prices"
 	tickerScheduler "hodlbook/pkg/integrations/scheduler"
 	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
-	"hodlbook/pkg/types/pubsub"
 	"hodlbook/pkg/types/scheduler"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 var ErrInvalidLivePriceConfig = errors.New("invalid live price service config")
@@ -23,6 +22,9 @@ var ErrInvalidLivePriceConfig = errors.New("invalid live price service config")
 type AssetRepository interface {
 	GetAllAssets() ([]models.Asset, error)
 	GetUniqueExchangeSymbols() ([]string, error)
+	GetLastKnownPrices() ([]models.LastKnownPrice, error)
+	SaveLastKnownPrices(prices []models.LastKnownPrice) error
+	GetLatestManualPrices() ([]models.Price, error)
 }
 
 type assetMeta struct {
@@ -35,13 +37,19 @@ type LivePriceService struct {
 	logger       *slog.Logger
 	cache        cache.Cache[string, float64]
 	priceFetcher prices.PriceFetcher
-	publisher    pubsub.Publisher
+	publisher    events.Publisher
 	repo         AssetRepository
 	scheduler    scheduler.Scheduler
 	syncInterval time.Duration
+	interval     time.Duration
 	lastSync     time.Time
 	assetMeta    map[string]assetMeta
 	assetMetaMu  sync.RWMutex
+	offline      bool
+	// asOf is when each cached price was fetched or entered, so a manual
+	// price that does not pin its symbol only replaces an older one.
+	asOf   map[string]time.Time
+	asOfMu sync.Mutex
 }
 
 type LivePriceOption func(*LivePriceService)
@@ -70,7 +78,7 @@ func WithLivePriceFetcher(f prices.PriceFetcher) LivePriceOption {
 	}
 }
 
-func WithLivePricePublisher(p pubsub.Publisher) LivePriceOption {
+func WithLivePricePublisher(p events.Publisher) LivePriceOption {
 	return func(s *LivePriceService) {
 		s.publisher = p
 	}
@@ -88,6 +96,21 @@ func WithLivePriceSyncInterval(d time.Duration) LivePriceOption {
 	}
 }
 
+// WithLivePriceInterval sets how often prices are fetched and published.
+func WithLivePriceInterval(d time.Duration) LivePriceOption {
+	return func(s *LivePriceService) {
+		s.interval = d
+	}
+}
+
+// WithLivePriceOffline stops fetching from providers; only manual prices are
+// published.
+func WithLivePriceOffline(offline bool) LivePriceOption {
+	return func(s *LivePriceService) {
+		s.offline = offline
+	}
+}
+
 func (s *LivePriceService) IsValid() error {
 	switch {
 	case s.ctx == nil:
@@ -110,7 +133,9 @@ func (s *LivePriceService) IsValid() error {
 func NewLivePriceService(opts ...LivePriceOption) (*LivePriceService, error) {
 	s := &LivePriceService{
 		syncInterval: time.Hour,
+		interval:     scheduler.IntervalMinute,
 		assetMeta:    make(map[string]assetMeta),
+		asOf:         make(map[string]time.Time),
 	}
 
 	for _, opt := range opts {
@@ -122,9 +147,10 @@ func NewLivePriceService(opts ...LivePriceOption) (*LivePriceService, error) {
 	}
 
 	sched, err := tickerScheduler.New(
+		tickerScheduler.WithName("live_price"),
 		tickerScheduler.WithContext(s.ctx),
 		tickerScheduler.WithLogger(s.logger),
-		tickerScheduler.WithInterval(scheduler.IntervalMinute),
+		tickerScheduler.WithInterval(s.interval),
 		tickerScheduler.WithHandler(s.tick),
 	)
 	if err != nil {
@@ -136,7 +162,11 @@ func NewLivePriceService(opts ...LivePriceOption) (*LivePriceService, error) {
 }
 
 func (s *LivePriceService) Start() error {
-	if err := s.tick(); err != nil {
+	if err := s.warmCache(); err != nil {
+		s.logger.Error("failed to load last known prices", "error", err)
+	}
+
+	if err := s.tick(s.ctx); err != nil {
 		s.logger.Error("initial tick failed", "error", err)
 	}
 
@@ -147,21 +177,38 @@ func (s *LivePriceService) Stop() {
 	s.scheduler.Stop()
 }
 
-func (s *LivePriceService) ForceSync() error {
+// ForceSync reloads the tracked assets and fetches their prices now. The
+// fetch is aborted when ctx is done.
+func (s *LivePriceService) ForceSync(ctx context.Context) error {
 	if err := s.syncFromDB(); err != nil {
 		return err
 	}
-	return s.fetchAndPublish()
+	return s.fetchAndPublish(ctx)
 }
 
-func (s *LivePriceService) tick() error {
+func (s *LivePriceService) tick(ctx context.Context) error {
 	if len(s.cache.Keys()) == 0 || time.Since(s.lastSync) >= s.syncInterval {
 		if err := s.syncFromDB(); err != nil {
 			s.logger.Error("DB sync failed", "error", err)
 		}
 	}
 
-	return s.fetchAndPublish()
+	return s.fetchAndPublish(ctx)
+}
+
+// warmCache seeds the cache with the prices stored by earlier runs, so they
+// are served while the first fetch is still in progress or failing.
+func (s *LivePriceService) warmCache() error {
+	stored, err := s.repo.GetLastKnownPrices()
+	if err != nil {
+		return err
+	}
+	for _, p := range stored {
+		s.cache.Set(p.Symbol, p.Price.InexactFloat64())
+		s.setAsOf(p.Symbol, p.FetchedAt)
+	}
+	s.logger.Info("loaded last known prices", "count", len(stored))
+	return nil
 }
 
 func (s *LivePriceService) syncFromDB() error {
@@ -253,16 +300,31 @@ func (s *LivePriceService) GetCustomSourceAssetsWithPrices() []CustomSourceAsset
 	return result
 }
 
-func (s *LivePriceService) fetchAndPublish() error {
+func (s *LivePriceService) fetchAndPublish(ctx context.Context) error {
 	symbols := s.cache.Keys()
 	if len(symbols) == 0 {
 		return nil
 	}
 
+	manual, err := s.repo.GetLatestManualPrices()
+	if err != nil {
+		s.logger.Error("failed to load manual prices", "error", err)
+	}
+	now := time.Now()
+	pinned := make(map[string]bool)
+	for _, p := range manual {
+		if p.PinsAt(now) {
+			pinned[p.Symbol] = true
+		}
+	}
+
 	s.assetMetaMu.RLock()
 	customSourceSymbols := make(map[string]assetMeta)
 	regularSymbols := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
+		if pinned[symbol] {
+			continue
+		}
 		if meta, ok := s.assetMeta[symbol]; ok && meta.PriceSource != "" {
 			customSourceSymbols[symbol] = meta
 		} else {
@@ -272,6 +334,28 @@ func (s *LivePriceService) fetchAndPublish() error {
 	s.assetMetaMu.RUnlock()
 
 	priceMap := make(map[string]float64)
+	var fetched []models.LastKnownPrice
+	record := func(p *prices.Price) {
+		// A failed fetch leaves the last known price in place.
+		if p.Value <= 0 {
+			return
+		}
+		now := time.Now()
+		s.cache.Set(p.Asset.Symbol, p.Value)
+		s.setAsOf(p.Asset.Symbol, now)
+		priceMap[p.Asset.Symbol] = p.Value
+		fetched = append(fetched, models.LastKnownPrice{
+			Symbol:    p.Asset.Symbol,
+			Price:     decimal.NewFromFloat(p.Value),
+			Source:    p.Source,
+			FetchedAt: now,
+		})
+	}
+
+	if s.offline {
+		regularSymbols = nil
+		customSourceSymbols = nil
+	}
 
 	if len(regularSymbols) > 0 {
 		pricePairs := make([]*prices.Price, len(regularSymbols))
@@ -281,18 +365,16 @@ func (s *LivePriceService) fetchAndPublish() error {
 			}
 		}
 
-		if err := s.priceFetcher.FetchMany(pricePairs...); err != nil {
+		if err := s.priceFetcher.FetchMany(ctx, pricePairs...); err != nil {
 			s.logger.Error("failed to fetch regular prices", "error", err)
 		}
 
 		for _, p := range pricePairs {
-			s.cache.Set(p.Asset.Symbol, p.Value)
-			priceMap[p.Asset.Symbol] = p.Value
+			record(p)
 		}
 	}
 
 	if len(customSourceSymbols) > 0 {
-		fetcher := priceService.NewPriceService()
 		for symbol, meta := range customSourceSymbols {
 			price := &prices.Price{
 				Asset: prices.Asset{
@@ -300,21 +382,24 @@ func (s *LivePriceService) fetchAndPublish() error {
 					Name:   meta.Name,
 				},
 			}
-			if err := fetcher.FetchBySource(meta.PriceSource, price); err != nil {
+			if err := s.fetchBySource(ctx, meta.PriceSource, price); err != nil {
 				s.logger.Debug("failed to fetch custom source price", "symbol", symbol, "source", meta.PriceSource, "error", err)
 				continue
 			}
-			s.cache.Set(symbol, price.Value)
-			priceMap[symbol] = price.Value
+			if price.Source == "" {
+				price.Source = meta.PriceSource
+			}
+			record(price)
 		}
 	}
 
-	data, err := json.Marshal(priceMap)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal prices")
+	if err := s.repo.SaveLastKnownPrices(fetched); err != nil {
+		s.logger.Error("failed to save last known prices", "error", err)
 	}
 
-	if err := s.publisher.Publish(data); err != nil {
+	s.applyManualPrices(symbols, manual, now, priceMap)
+
+	if err := s.publisher.Publish(s.ctx, events.TopicPricesUpdated, events.PricesUpdated(priceMap)); err != nil {
 		return errors.Wrap(err, "failed to publish prices")
 	}
 
@@ -322,3 +407,42 @@ func (s *LivePriceService) fetchAndPublish() error {
 	return nil
 }
 
+// applyManualPrices uses each symbol's manual price when it pins the symbol
+// at now, and otherwise only when the price just fetched or last known is not
+// newer. See models.Price for the rule.
+func (s *LivePriceService) applyManualPrices(symbols []string, manual []models.Price, now time.Time, priceMap map[string]float64) {
+	tracked := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		tracked[symbol] = true
+	}
+
+	s.asOfMu.Lock()
+	defer s.asOfMu.Unlock()
+	for _, p := range manual {
+		if !tracked[p.Symbol] {
+			continue
+		}
+		if asOf, ok := s.asOf[p.Symbol]; ok && asOf.After(p.Timestamp) && !p.PinsAt(now) {
+			continue
+		}
+		value := p.Price.InexactFloat64()
+		s.cache.Set(p.Symbol, value)
+		s.asOf[p.Symbol] = p.Timestamp
+		priceMap[p.Symbol] = value
+	}
+}
+
+func (s *LivePriceService) setAsOf(symbol string, t time.Time) {
+	s.asOfMu.Lock()
+	s.asOf[symbol] = t
+	s.asOfMu.Unlock()
+}
+
+// fetchBySource asks the price source an asset was added with, falling back
+// to the default providers when the fetcher cannot choose one.
+func (s *LivePriceService) fetchBySource(ctx context.Context, source string, price *prices.Price) error {
+	if fetcher, ok := s.priceFetcher.(prices.SourceFetcher); ok {
+		return fetcher.FetchBySource(ctx, source, price)
+	}
+	return s.priceFetcher.Fetch(ctx, price)
+}
@@ -2,26 +2,56 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"testing"
 	"time"
 
 	"hodlbook/internal/models"
+	"hodlbook/pkg/integrations/eventbus"
 	"hodlbook/pkg/integrations/memcache"
 	pricesPkg "hodlbook/pkg/integrations/prices"
-	"hodlbook/pkg/integrations/wmPubsub"
+	"hodlbook/pkg/integrations/prices/fakeprovider"
+	"hodlbook/pkg/types/events"
+	pricesTypes "hodlbook/pkg/types/prices"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
 
+func newTestBus(t *testing.T) *eventbus.Bus {
+	t.Helper()
+	bus, err := eventbus.New(eventbus.WithContext(context.Background()), eventbus.WithLogger(discardLogger))
+	require.NoError(t, err)
+	t.Cleanup(func() { bus.Close(context.Background()) })
+	return bus
+}
+
+// newFakePriceService returns a price service whose providers are a local
+// fake quoting symbol at price, so tests run without network access.
+func newFakePriceService(t *testing.T, quotes map[string]float64) *pricesPkg.PriceService {
+	t.Helper()
+	server := fakeprovider.New()
+	t.Cleanup(server.Close)
+	for symbol, price := range quotes {
+		server.SetPrice(symbol, "", price)
+	}
+	return pricesPkg.NewPriceService(
+		pricesPkg.WithBaseURL(pricesTypes.SourceKraken, server.KrakenURL()),
+		pricesPkg.WithBaseURL(pricesTypes.SourceBinance, server.BinanceURL()),
+		pricesPkg.WithBaseURL(pricesTypes.SourceCoinGecko, server.CoinGeckoURL()),
+	)
+}
+
 type mockAssetRepo struct {
 	assets          []models.Asset
 	exchangeSymbols []string
+	lastKnown       map[string]models.LastKnownPrice
+	manual          []models.Price
 }
 
 func (m *mockAssetRepo) GetAllAssets() ([]models.Asset, error) {
@@ -32,12 +62,62 @@ func (m *mockAssetRepo) GetUniqueExchangeSymbols() ([]string, error) {
 	return m.exchangeSymbols, nil
 }
 
+func (m *mockAssetRepo) GetLastKnownPrices() ([]models.LastKnownPrice, error) {
+	var result []models.LastKnownPrice
+	for _, p := range m.lastKnown {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (m *mockAssetRepo) SaveLastKnownPrices(prices []models.LastKnownPrice) error {
+	if m.lastKnown == nil {
+		m.lastKnown = make(map[string]models.LastKnownPrice)
+	}
+	for _, p := range prices {
+		m.lastKnown[p.Symbol] = p
+	}
+	return nil
+}
+
+func (m *mockAssetRepo) GetLatestManualPrices() ([]models.Price, error) {
+	return m.manual, nil
+}
+
+// staticFetcher answers with fixed prices and fails for any other symbol.
+type staticFetcher struct {
+	prices map[string]float64
+}
+
+func (f *staticFetcher) Fetch(_ context.Context, price *pricesTypes.Price) error {
+	value, ok := f.prices[price.Asset.Symbol]
+	if !ok {
+		return errors.New("no price")
+	}
+	price.Value = value
+	price.Source = "static"
+	return nil
+}
+
+func (f *staticFetcher) FetchMany(ctx context.Context, pairs ...*pricesTypes.Price) error {
+	var err error
+	for _, pair := range pairs {
+		if fetchErr := f.Fetch(ctx, pair); fetchErr != nil {
+			err = fetchErr
+		}
+	}
+	return err
+}
+
+func (f *staticFetcher) FetchAll(context.Context) ([]pricesTypes.Price, error) {
+	return nil, errors.New("not implemented")
+}
+
 func TestLivePriceService_InvalidConfig(t *testing.T) {
 	ctx := context.Background()
 	cache := memcache.New[string, float64]()
 	fetcher := pricesPkg.NewPriceService()
-	ch := make(chan []byte, 10)
-	pub := wmPubsub.New(wmPubsub.WithChannel(ch), wmPubsub.WithContext(ctx))
+	pub := newTestBus(t)
 	repo := &mockAssetRepo{}
 
 	tests := []struct {
@@ -102,8 +182,7 @@ func TestLivePriceService_SyncFromDB(t *testing.T) {
 
 	cache := memcache.New[string, float64]()
 	fetcher := pricesPkg.NewPriceService()
-	ch := make(chan []byte, 10)
-	pub := wmPubsub.New(wmPubsub.WithChannel(ch), wmPubsub.WithContext(ctx))
+	pub := newTestBus(t)
 	repo := &mockAssetRepo{
 		assets: []models.Asset{
 			{ID: 1, Symbol: "BTC", Name: "Bitcoin"},
@@ -139,9 +218,13 @@ func TestLivePriceService_FetchAndPublish(t *testing.T) {
 	cache.Set("BTC", 0)
 	cache.Set("ETH", 0)
 
-	fetcher := pricesPkg.NewPriceService()
-	ch := make(chan []byte, 10)
-	pub := wmPubsub.New(wmPubsub.WithChannel(ch), wmPubsub.WithContext(ctx))
+	fetcher := newFakePriceService(t, map[string]float64{"BTC": 64000, "ETH": 3200})
+	pub := newTestBus(t)
+	ch := make(chan events.PricesUpdated, 1)
+	require.NoError(t, pub.Subscribe(events.TopicPricesUpdated, eventbus.Handle(func(_ context.Context, p events.PricesUpdated) error {
+		ch <- p
+		return nil
+	}), events.SubscribeOptions{}))
 	repo := &mockAssetRepo{}
 
 	svc, err := NewLivePriceService(
@@ -154,26 +237,155 @@ func TestLivePriceService_FetchAndPublish(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	err = svc.fetchAndPublish()
+	err = svc.fetchAndPublish(ctx)
 	require.NoError(t, err)
 
 	btcPrice, ok := cache.Get("BTC")
 	assert.True(t, ok)
-	assert.Greater(t, btcPrice, float64(0))
+	assert.Equal(t, 64000.0, btcPrice)
 
 	ethPrice, ok := cache.Get("ETH")
 	assert.True(t, ok)
-	assert.Greater(t, ethPrice, float64(0))
+	assert.Equal(t, 3200.0, ethPrice)
+
+	select {
+	case prices := <-ch:
+		assert.Equal(t, 64000.0, prices["BTC"])
+		assert.Equal(t, 3200.0, prices["ETH"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("did not receive published prices")
+	}
+}
+
+func TestLivePriceService_KeepsLastKnownPrices(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	fetchedAt := time.Now().Add(-time.Hour)
+	repo := &mockAssetRepo{
+		assets: []models.Asset{
+			{ID: 1, Symbol: "BTC", Name: "Bitcoin"},
+			{ID: 2, Symbol: "ETH", Name: "Ethereum"},
+		},
+		lastKnown: map[string]models.LastKnownPrice{
+			"ETH": {Symbol: "ETH", Price: decimal.NewFromInt(3000), Source: "kraken", FetchedAt: fetchedAt},
+		},
+	}
+	cache := memcache.New[string, float64]()
+
+	svc, err := NewLivePriceService(
+		WithLivePriceContext(ctx),
+		WithLivePriceLogger(discardLogger),
+		WithLivePriceCache(cache),
+		WithLivePriceFetcher(&staticFetcher{prices: map[string]float64{"BTC": 50000}}),
+		WithLivePricePublisher(newTestBus(t)),
+		WithLivePriceRepo(repo),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.warmCache())
+	require.NoError(t, svc.tick(ctx))
+
+	btc, _ := cache.Get("BTC")
+	assert.Equal(t, 50000.0, btc)
+	eth, _ := cache.Get("ETH")
+	assert.Equal(t, 3000.0, eth, "a failed fetch keeps the stored price")
+
+	assert.Equal(t, "50000", repo.lastKnown["BTC"].Price.String())
+	assert.Equal(t, "static", repo.lastKnown["BTC"].Source)
+	assert.True(t, repo.lastKnown["ETH"].FetchedAt.Equal(fetchedAt))
+}
+
+func TestLivePriceService_NewestPriceWins(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	repo := &mockAssetRepo{
+		assets: []models.Asset{
+			{ID: 1, Symbol: "BTC", Name: "Bitcoin"},
+			{ID: 2, Symbol: "ETH", Name: "Ethereum"},
+			{ID: 3, Symbol: "SOL", Name: "Solana"},
+			{ID: 4, Symbol: "LTC", Name: "Litecoin"},
+		},
+		lastKnown: map[string]models.LastKnownPrice{
+			"ETH": {Symbol: "ETH", Price: decimal.NewFromInt(3000), Source: "kraken", FetchedAt: now.Add(-time.Hour)},
+			"SOL": {Symbol: "SOL", Price: decimal.NewFromInt(150), Source: "kraken", FetchedAt: now.Add(-time.Minute)},
+		},
+		manual: []models.Price{
+			{Symbol: "BTC", Price: decimal.NewFromInt(40000), Source: models.PriceSourceManual, Timestamp: now.Add(-time.Hour), ExpiresAt: &expired},
+			{Symbol: "ETH", Price: decimal.NewFromInt(3100), Source: models.PriceSourceManual, Timestamp: now.Add(-time.Minute), ExpiresAt: &expired},
+			{Symbol: "SOL", Price: decimal.NewFromInt(140), Source: models.PriceSourceManual, Timestamp: now.Add(-time.Hour), ExpiresAt: &expired},
+			{Symbol: "LTC", Price: decimal.NewFromInt(80), Source: models.PriceSourceManual, Timestamp: now.Add(-time.Hour)},
+		},
+	}
+	cache := memcache.New[string, float64]()
+	fetcher := &staticFetcher{prices: map[string]float64{"BTC": 50000, "LTC": 90}}
+
+	svc, err := NewLivePriceService(
+		WithLivePriceContext(ctx),
+		WithLivePriceLogger(discardLogger),
+		WithLivePriceCache(cache),
+		WithLivePriceFetcher(fetcher),
+		WithLivePricePublisher(newTestBus(t)),
+		WithLivePriceRepo(repo),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.warmCache())
+	require.NoError(t, svc.tick(ctx))
+
+	btc, _ := cache.Get("BTC")
+	assert.Equal(t, 50000.0, btc, "a fresh fetch beats an expired manual price")
+	eth, _ := cache.Get("ETH")
+	assert.Equal(t, 3100.0, eth, "an expired manual price still beats an older fetched one")
+	sol, _ := cache.Get("SOL")
+	assert.Equal(t, 150.0, sol)
+	ltc, _ := cache.Get("LTC")
+	assert.Equal(t, 80.0, ltc, "a pinning manual price beats a fresh fetch")
+	assert.NotContains(t, repo.lastKnown, "LTC", "pinned symbols are not fetched")
+}
+
+func TestLivePriceService_OfflineUsesManualPrices(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	repo := &mockAssetRepo{
+		assets: []models.Asset{{ID: 1, Symbol: "BTC", Name: "Bitcoin"}},
+		manual: []models.Price{
+			{Symbol: "BTC", Price: decimal.NewFromInt(42000), Source: models.PriceSourceManual, Timestamp: time.Now().Add(-24 * time.Hour)},
+		},
+	}
+	cache := memcache.New[string, float64]()
+	fetcher := &staticFetcher{prices: map[string]float64{"BTC": 50000}}
+	pub := newTestBus(t)
+	ch := make(chan events.PricesUpdated, 1)
+	require.NoError(t, pub.Subscribe(events.TopicPricesUpdated, eventbus.Handle(func(_ context.Context, p events.PricesUpdated) error {
+		ch <- p
+		return nil
+	}), events.SubscribeOptions{}))
+
+	svc, err := NewLivePriceService(
+		WithLivePriceContext(ctx),
+		WithLivePriceLogger(discardLogger),
+		WithLivePriceCache(cache),
+		WithLivePriceFetcher(fetcher),
+		WithLivePricePublisher(pub),
+		WithLivePriceRepo(repo),
+		WithLivePriceOffline(true),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.tick(ctx))
+
+	btc, _ := cache.Get("BTC")
+	assert.Equal(t, 42000.0, btc)
+	assert.Empty(t, repo.lastKnown, "nothing is fetched offline")
 
 	select {
-	case data := <-ch:
-		var prices map[string]float64
-		err := json.Unmarshal(data, &prices)
-		require.NoError(t, err)
-		assert.Contains(t, prices, "BTC")
-		assert.Contains(t, prices, "ETH")
-		assert.Greater(t, prices["BTC"], float64(0))
-		assert.Greater(t, prices["ETH"], float64(0))
+	case prices := <-ch:
+		assert.Equal(t, 42000.0, prices["BTC"])
 	case <-time.After(2 * time.Second):
 		t.Fatal("did not receive published prices")
 	}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"hodlbook/internal/models"
+	notifyIntegration "hodlbook/pkg/integrations/notify"
+	"hodlbook/pkg/types/notify"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownChannelType = errors.New("unknown notification channel type")
+
+var (
+	notifyAttempts = 3
+	notifyBackoff  = time.Second
+)
+
+// NewChannelNotifier builds a notifier for a configured channel. Deliveries
+// are retried with exponential backoff.
+func NewChannelNotifier(ch *models.NotificationChannel) (notify.Notifier, error) {
+	var n notify.Notifier
+
+	switch ch.Type {
+	case models.ChannelWebhook:
+		w := notifyIntegration.NewWebhookNotifier(ch.URL, ch.Secret)
+		w.Channel = ch.Name
+		n = w
+	case models.ChannelNtfy:
+		nt := notifyIntegration.NewNtfyNotifier(ch.URL, ch.Topic, ch.Secret)
+		nt.Channel = ch.Name
+		n = nt
+	case models.ChannelGotify:
+		g := notifyIntegration.NewGotifyNotifier(ch.URL, ch.Secret)
+		g.Channel = ch.Name
+		n = g
+	case models.ChannelTelegram:
+		t := notifyIntegration.NewTelegramNotifier(ch.Secret, ch.ChatID)
+		if ch.URL != "" {
+			t.BaseURL = ch.URL
+		}
+		t.Channel = ch.Name
+		n = t
+	case models.ChannelSMTP:
+		s := notifyIntegration.NewSMTPNotifier(ch.SMTPHost, ch.SMTPPort, ch.Username, ch.Secret, ch.From, ch.Recipients())
+		s.Channel = ch.Name
+		n = s
+	default:
+		return nil, errors.Wrap(ErrUnknownChannelType, ch.Type)
+	}
+
+	return notifyIntegration.NewRetryNotifier(n, notifyAttempts, notifyBackoff), nil
+}
+
+func SendTestNotification(ctx context.Context, channel *models.NotificationChannel) error {
+	notifier, err := NewChannelNotifier(channel)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return notifier.Notify(ctx, notify.Notification{
+		Title:     "HodlBook test notification",
+		Message:   "Channel " + channel.Name + " is configured correctly.",
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"hodlbook/internal/models"
+	tickerScheduler "hodlbook/pkg/integrations/scheduler"
+	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
+	"hodlbook/pkg/types/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidRecurringConfig = errors.New("invalid recurring service config")
+
+const (
+	// recurringLiveWindow is how recent an occurrence must be to be
+	// converted at the live price rather than a recorded one.
+	recurringLiveWindow = time.Hour
+	// recurringPriceMaxAge is how far from an occurrence a recorded price
+	// may be to be used for it.
+	recurringPriceMaxAge = 48 * time.Hour
+	// maxRecurringRuns bounds the occurrences caught up in one check.
+	maxRecurringRuns = 500
+	// recurringDecimals is the precision of converted coin amounts.
+	recurringDecimals = 8
+)
+
+const (
+	RecurringPriceLive     = "live"
+	RecurringPriceHistoric = "historic"
+)
+
+type RecurringRepository interface {
+	ListDueRecurring(now time.Time) ([]models.RecurringTransaction, error)
+	SaveRecurringRun(recurring *models.RecurringTransaction, run *models.RecurringRun) error
+	SetRecurringError(id int64, message string) error
+	GetPriceAtTime(symbol, currency string, timestamp time.Time) (*models.Price, error)
+	SelectAllBySymbol(symbol string) ([]models.AssetHistoricValue, error)
+	GetAssetByID(id int64) (*models.Asset, error)
+	GetExchangeByID(id int64) (*models.Exchange, error)
+}
+
+// RecurringRunResult summarises one check of the recurring transactions.
+type RecurringRunResult struct {
+	Executed int `json:"executed"`
+	Pending  int `json:"pending"`
+	Failed   int `json:"failed"`
+}
+
+// RecurringService books the occurrences of recurring transactions as they
+// come due, converting fiat amounts at the live price for current
+// occurrences and at the recorded price for ones caught up after downtime.
+type RecurringService struct {
+	ctx        context.Context
+	logger     *slog.Logger
+	repo       RecurringRepository
+	priceCache cache.Cache[string, float64]
+	publisher  events.Publisher
+	interval   time.Duration
+	scheduler  scheduler.Scheduler
+	now        func() time.Time
+
+	// runMu serializes runs so the scheduler and a manual run never book
+	// the same occurrence.
+	runMu sync.Mutex
+}
+
+type RecurringOption func(*RecurringService)
+
+func WithRecurringContext(ctx context.Context) RecurringOption {
+	return func(s *RecurringService) {
+		s.ctx = ctx
+	}
+}
+
+func WithRecurringLogger(l *slog.Logger) RecurringOption {
+	return func(s *RecurringService) {
+		s.logger = l
+	}
+}
+
+func WithRecurringRepo(r RecurringRepository) RecurringOption {
+	return func(s *RecurringService) {
+		s.repo = r
+	}
+}
+
+func WithRecurringPriceCache(c cache.Cache[string, float64]) RecurringOption {
+	return func(s *RecurringService) {
+		s.priceCache = c
+	}
+}
+
+// WithRecurringPublisher announces the entries booked by the service.
+func WithRecurringPublisher(p events.Publisher) RecurringOption {
+	return func(s *RecurringService) {
+		s.publisher = p
+	}
+}
+
+func WithRecurringInterval(d time.Duration) RecurringOption {
+	return func(s *RecurringService) {
+		s.interval = d
+	}
+}
+
+func (s *RecurringService) IsValid() error {
+	switch {
+	case s.ctx == nil:
+		return errors.Wrap(ErrInvalidRecurringConfig, "ctx cannot be nil")
+	case s.logger == nil:
+		return errors.Wrap(ErrInvalidRecurringConfig, "logger cannot be nil")
+	case s.repo == nil:
+		return errors.Wrap(ErrInvalidRecurringConfig, "repo cannot be nil")
+	case s.priceCache == nil:
+		return errors.Wrap(ErrInvalidRecurringConfig, "price cache cannot be nil")
+	case s.interval <= 0:
+		return errors.Wrap(ErrInvalidRecurringConfig, "interval must be positive")
+	default:
+		return nil
+	}
+}
+
+func NewRecurringService(opts ...RecurringOption) (*RecurringService, error) {
+	s := &RecurringService{
+		interval: time.Minute,
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.IsValid(); err != nil {
+		return nil, err
+	}
+
+	sched, err := tickerScheduler.New(
+		tickerScheduler.WithName("recurring_transactions"),
+		tickerScheduler.WithContext(s.ctx),
+		tickerScheduler.WithLogger(s.logger),
+		tickerScheduler.WithInterval(s.interval),
+		tickerScheduler.WithInitialDelay(s.interval),
+		tickerScheduler.WithHandler(s.tick),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create scheduler")
+	}
+	s.scheduler = sched
+
+	return s, nil
+}
+
+func (s *RecurringService) Start() error {
+	return s.scheduler.Start()
+}
+
+func (s *RecurringService) Stop() {
+	s.scheduler.Stop()
+}
+
+func (s *RecurringService) tick(ctx context.Context) error {
+	result, err := s.Run(ctx)
+	if err != nil {
+		return err
+	}
+	if *result != (RecurringRunResult{}) {
+		s.logger.Info("recurring transactions run",
+			"executed", result.Executed,
+			"pending", result.Pending,
+			"failed", result.Failed,
+		)
+	}
+	return nil
+}
+
+// Run processes every occurrence that is due, oldest first. An occurrence
+// that cannot be priced records the error on its recurring transaction and
+// is retried on the next run, holding back the ones after it.
+func (s *RecurringService) Run(ctx context.Context) (*RecurringRunResult, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	now := s.now()
+	due, err := s.repo.ListDueRecurring(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list due recurring transactions")
+	}
+
+	result := &RecurringRunResult{}
+	processed := 0
+	for i := range due {
+		recurring := &due[i]
+		for processed < maxRecurringRuns && !recurring.NextRunAt.After(now) && !recurring.Finished() {
+			run, err := s.buildRun(recurring, now)
+			if err != nil {
+				s.logger.Warn("failed to run recurring transaction", "name", recurring.Name, "scheduled_at", recurring.NextRunAt, "error", err)
+				if err := s.repo.SetRecurringError(recurring.ID, err.Error()); err != nil {
+					return nil, errors.Wrap(err, "failed to record recurring error")
+				}
+				result.Failed++
+				break
+			}
+			if err := s.repo.SaveRecurringRun(recurring, run); err != nil {
+				return nil, errors.Wrapf(err, "failed to save run of %s", recurring.Name)
+			}
+			processed++
+
+			if run.Status == models.RecurringRunPending {
+				result.Pending++
+				continue
+			}
+			result.Executed++
+			s.publishRun(ctx, run)
+		}
+	}
+	return result, nil
+}
+
+// buildRun computes the amounts of the next occurrence. Fiat amounts and
+// exchanges need prices; a coin deposit or withdrawal records the price
+// when one is known.
+func (s *RecurringService) buildRun(recurring *models.RecurringTransaction, now time.Time) (*models.RecurringRun, error) {
+	at := recurring.NextRunAt
+	run := &models.RecurringRun{
+		RecurringID: recurring.ID,
+		PortfolioID: recurring.PortfolioID,
+		Name:        recurring.Name,
+		Type:        recurring.Type,
+		Symbol:      recurring.Symbol,
+		Amount:      recurring.Amount,
+		FromSymbol:  recurring.FromSymbol,
+		ScheduledAt: at,
+		Status:      models.RecurringRunExecuted,
+	}
+	if recurring.RequireConfirmation {
+		run.Status = models.RecurringRunPending
+	}
+
+	price, source, err := s.priceAt(recurring.Symbol, at, now)
+	needsPrice := recurring.AmountUnit == models.RecurringAmountFiat || recurring.Type == "exchange"
+	if err != nil && needsPrice {
+		return nil, err
+	}
+	if err == nil {
+		run.Price, run.PriceSource = price, source
+	}
+
+	value := recurring.Amount
+	if recurring.AmountUnit == models.RecurringAmountFiat {
+		run.Amount = recurring.Amount.Div(price).Round(recurringDecimals)
+	} else {
+		value = recurring.Amount.Mul(price)
+	}
+
+	if recurring.Type == "exchange" {
+		fromPrice, _, err := s.priceAt(recurring.FromSymbol, at, now)
+		if err != nil {
+			return nil, err
+		}
+		run.FromAmount = value.Div(fromPrice).Round(recurringDecimals)
+	}
+	return run, nil
+}
+
+// priceAt returns the reference currency price of a symbol at an
+// occurrence and where it came from.
+func (s *RecurringService) priceAt(symbol string, at, now time.Time) (decimal.Decimal, string, error) {
+	if isDollarPegged(symbol) {
+		return decimal.NewFromInt(1), "", nil
+	}
+
+	if now.Sub(at) <= recurringLiveWindow {
+		if price, ok := s.priceCache.Get(symbol); ok && price > 0 {
+			return decimal.NewFromFloat(price), RecurringPriceLive, nil
+		}
+	}
+
+	recorded, err := s.repo.GetPriceAtTime(symbol, "USD", at)
+	if err != nil {
+		return decimal.Zero, "", errors.Wrapf(err, "failed to read price of %s", symbol)
+	}
+	if recorded != nil && at.Sub(recorded.Timestamp) <= recurringPriceMaxAge {
+		return recorded.Price, RecurringPriceHistoric, nil
+	}
+
+	history, err := s.repo.SelectAllBySymbol(symbol)
+	if err != nil {
+		return decimal.Zero, "", errors.Wrapf(err, "failed to read price history of %s", symbol)
+	}
+	var closest *models.AssetHistoricValue
+	for i := range history {
+		if history[i].Value > 0 && (closest == nil || absDuration(history[i].Timestamp.Sub(at)) < absDuration(closest.Timestamp.Sub(at))) {
+			closest = &history[i]
+		}
+	}
+	if closest != nil && absDuration(closest.Timestamp.Sub(at)) <= recurringPriceMaxAge {
+		return decimal.NewFromFloat(closest.Value), RecurringPriceHistoric, nil
+	}
+
+	return decimal.Zero, "", fmt.Errorf("no price for %s at %s", symbol, at.UTC().Format(time.RFC3339))
+}
+
+func (s *RecurringService) publishRun(ctx context.Context, run *models.RecurringRun) {
+	if s.publisher == nil {
+		return
+	}
+	switch {
+	case run.AssetID != nil:
+		if asset, err := s.repo.GetAssetByID(*run.AssetID); err == nil {
+			s.publisher.Publish(ctx, events.TopicAssetCreated, *asset)
+		}
+	case run.ExchangeID != nil:
+		if exchange, err := s.repo.GetExchangeByID(*run.ExchangeID); err == nil {
+			s.publisher.Publish(ctx, events.TopicExchangeCreated, *exchange)
+		}
+	}
+}
+
+// isDollarPegged reports whether a symbol is worth one unit of the
+// reference currency, as price providers assume.
+func isDollarPegged(symbol string) bool {
+	return symbol == "USD" || symbol == "USDT" || symbol == "USDC"
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/integrations/memcache"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRecurringRepo struct {
+	recurring []models.RecurringTransaction
+	runs      []models.RecurringRun
+	prices    []models.Price
+	history   []models.AssetHistoricValue
+	nextID    int64
+}
+
+func (m *mockRecurringRepo) ListDueRecurring(now time.Time) ([]models.RecurringTransaction, error) {
+	var due []models.RecurringTransaction
+	for _, r := range m.recurring {
+		if r.Enabled && !r.NextRunAt.After(now) && !r.Finished() {
+			due = append(due, r)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockRecurringRepo) SaveRecurringRun(recurring *models.RecurringTransaction, run *models.RecurringRun) error {
+	m.nextID++
+	run.ID = m.nextID
+	if run.Status == models.RecurringRunExecuted {
+		id := m.nextID
+		if run.Type == "exchange" {
+			run.ExchangeID = &id
+		} else {
+			run.AssetID = &id
+		}
+	}
+	m.runs = append(m.runs, *run)
+	recurring.Advance()
+	recurring.LastError = ""
+	m.store(*recurring)
+	return nil
+}
+
+func (m *mockRecurringRepo) SetRecurringError(id int64, message string) error {
+	for i := range m.recurring {
+		if m.recurring[i].ID == id {
+			m.recurring[i].LastError = message
+		}
+	}
+	return nil
+}
+
+func (m *mockRecurringRepo) store(recurring models.RecurringTransaction) {
+	for i := range m.recurring {
+		if m.recurring[i].ID == recurring.ID {
+			m.recurring[i] = recurring
+		}
+	}
+}
+
+func (m *mockRecurringRepo) GetPriceAtTime(symbol, _ string, timestamp time.Time) (*models.Price, error) {
+	var found *models.Price
+	for i, p := range m.prices {
+		if p.Symbol == symbol && !p.Timestamp.After(timestamp) && (found == nil || p.Timestamp.After(found.Timestamp)) {
+			found = &m.prices[i]
+		}
+	}
+	return found, nil
+}
+
+func (m *mockRecurringRepo) SelectAllBySymbol(symbol string) ([]models.AssetHistoricValue, error) {
+	var values []models.AssetHistoricValue
+	for _, v := range m.history {
+		if v.Symbol == symbol {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (m *mockRecurringRepo) GetAssetByID(int64) (*models.Asset, error) {
+	return nil, errors.New("not found")
+}
+
+func (m *mockRecurringRepo) GetExchangeByID(int64) (*models.Exchange, error) {
+	return nil, errors.New("not found")
+}
+
+func newTestRecurringService(t *testing.T, repo *mockRecurringRepo, now time.Time, live map[string]float64) *RecurringService {
+	cache := memcache.New[string, float64]()
+	for symbol, price := range live {
+		cache.Set(symbol, price)
+	}
+	svc, err := NewRecurringService(
+		WithRecurringContext(context.Background()),
+		WithRecurringLogger(alertDiscardLogger),
+		WithRecurringRepo(repo),
+		WithRecurringPriceCache(cache),
+	)
+	require.NoError(t, err)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func weeklyBuy(start time.Time) models.RecurringTransaction {
+	r := models.RecurringTransaction{
+		ID:          1,
+		PortfolioID: 1,
+		Name:        "Weekly BTC",
+		Type:        "exchange",
+		Symbol:      "BTC",
+		FromSymbol:  "USDT",
+		Amount:      decimal.NewFromInt(100),
+		AmountUnit:  models.RecurringAmountFiat,
+		Cadence:     models.CadenceWeekly,
+		StartAt:     start,
+		Enabled:     true,
+	}
+	r.Reschedule(start)
+	return r
+}
+
+func TestRecurringService_CatchesUpWithHistoricPrices(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 14).Add(10 * time.Minute)
+	repo := &mockRecurringRepo{
+		recurring: []models.RecurringTransaction{weeklyBuy(start)},
+		prices: []models.Price{
+			{Symbol: "BTC", Price: decimal.NewFromInt(40000), Timestamp: start.Add(-time.Hour)},
+		},
+		history: []models.AssetHistoricValue{
+			{Symbol: "BTC", Value: 50000, Timestamp: start.AddDate(0, 0, 7).Add(2 * time.Hour)},
+		},
+	}
+	svc := newTestRecurringService(t, repo, now, map[string]float64{"BTC": 80000})
+
+	result, err := svc.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, RecurringRunResult{Executed: 3}, *result)
+	require.Len(t, repo.runs, 3)
+
+	first, second, third := repo.runs[0], repo.runs[1], repo.runs[2]
+	assert.Equal(t, "0.0025", first.Amount.String())
+	assert.Equal(t, "100", first.FromAmount.String())
+	assert.Equal(t, RecurringPriceHistoric, first.PriceSource)
+	assert.Equal(t, "0.002", second.Amount.String(), "the closest daily snapshot prices the second week")
+	assert.Equal(t, RecurringPriceLive, third.PriceSource)
+	assert.Equal(t, "0.00125", third.Amount.String())
+	assert.NotNil(t, third.ExchangeID)
+
+	assert.Equal(t, 3, repo.recurring[0].Occurrences)
+	assert.True(t, start.AddDate(0, 0, 21).Equal(repo.recurring[0].NextRunAt))
+
+	result, err = svc.Run(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Executed, "nothing is due until next week")
+}
+
+func TestRecurringService_ConcurrentRunsBookOnce(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	repo := &mockRecurringRepo{
+		recurring: []models.RecurringTransaction{weeklyBuy(start)},
+		prices: []models.Price{
+			{Symbol: "BTC", Price: decimal.NewFromInt(40000), Timestamp: start},
+		},
+	}
+	svc := newTestRecurringService(t, repo, start.Add(time.Minute), map[string]float64{"BTC": 40000})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Run(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, repo.runs, 1, "a scheduled tick and a manual run book the occurrence once")
+	assert.Equal(t, 1, repo.recurring[0].Occurrences)
+}
+
+func TestRecurringService_PendingConfirmationAndCoinAmounts(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	recurring := weeklyBuy(start)
+	recurring.Type = "deposit"
+	recurring.FromSymbol = ""
+	recurring.Amount = decimal.RequireFromString("0.01")
+	recurring.AmountUnit = models.RecurringAmountCoin
+	recurring.RequireConfirmation = true
+	repo := &mockRecurringRepo{recurring: []models.RecurringTransaction{recurring}}
+	svc := newTestRecurringService(t, repo, start, nil)
+
+	result, err := svc.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, RecurringRunResult{Pending: 1}, *result)
+	require.Len(t, repo.runs, 1)
+	run := repo.runs[0]
+	assert.Equal(t, models.RecurringRunPending, run.Status)
+	assert.Equal(t, "0.01", run.Amount.String())
+	assert.True(t, run.Price.IsZero(), "a coin deposit does not need a price")
+	assert.Nil(t, run.AssetID)
+}
+
+func TestRecurringService_MissingPriceHoldsTheSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	recurring := weeklyBuy(start)
+	recurring.EndAt = &end
+	repo := &mockRecurringRepo{recurring: []models.RecurringTransaction{recurring}}
+	svc := newTestRecurringService(t, repo, start.AddDate(0, 1, 0), map[string]float64{"BTC": 80000})
+
+	result, err := svc.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, RecurringRunResult{Failed: 1}, *result)
+	assert.Empty(t, repo.runs)
+	assert.Equal(t, "no price for BTC at 2024-01-01T09:00:00Z", repo.recurring[0].LastError)
+	assert.Zero(t, repo.recurring[0].Occurrences)
+
+	repo.prices = []models.Price{{Symbol: "BTC", Price: decimal.NewFromInt(40000), Timestamp: start}}
+	result, err = svc.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Executed)
+	assert.Equal(t, 1, result.Failed, "the next week is too far from the only price")
+}
+
+func TestRecurringService_InvalidConfig(t *testing.T) {
+	_, err := NewRecurringService(WithRecurringContext(context.Background()), WithRecurringLogger(alertDiscardLogger))
+	assert.ErrorIs(t, err, ErrInvalidRecurringConfig)
+}
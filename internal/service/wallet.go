@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"hodlbook/internal/models"
+	tickerScheduler "hodlbook/pkg/integrations/scheduler"
+	"hodlbook/pkg/types/scheduler"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidWalletConfig = errors.New("invalid wallet service config")
+
+const (
+	// walletInitialDelay keeps the first sync off the startup path.
+	walletInitialDelay = time.Minute
+	walletFetchTimeout = 2 * time.Minute
+)
+
+type WalletRepository interface {
+	ListWallets() ([]models.Wallet, error)
+	SaveWalletSync(id int64, at time.Time, balances []models.WalletBalance, syncErr error) error
+	ListWalletBalances(walletID int64) ([]models.WalletBalance, error)
+	GetLatestWalletDiscrepancy(walletID int64, symbol string) (*models.WalletDiscrepancy, error)
+	SaveWalletDiscrepancy(discrepancy *models.WalletDiscrepancy) error
+	GetAssetsByPortfolio(portfolioID int64) ([]models.Asset, error)
+	GetExchangesByPortfolio(portfolioID int64) ([]models.Exchange, error)
+}
+
+// WalletSyncResult summarises one sync of every wallet.
+type WalletSyncResult struct {
+	Synced        int `json:"synced"`
+	Failed        int `json:"failed"`
+	Discrepancies int `json:"discrepancies"`
+}
+
+// WalletService periodically reads the balances of watch-only wallets and
+// reconciles each with the holdings the transactions tagged with it add up
+// to, recording a discrepancy for every symbol that disagrees.
+type WalletService struct {
+	ctx       context.Context
+	logger    *slog.Logger
+	repo      WalletRepository
+	fetchers  map[string]wallets.BalanceFetcher
+	interval  time.Duration
+	scheduler scheduler.Scheduler
+	now       func() time.Time
+}
+
+type WalletOption func(*WalletService)
+
+func WithWalletContext(ctx context.Context) WalletOption {
+	return func(s *WalletService) {
+		s.ctx = ctx
+	}
+}
+
+func WithWalletLogger(l *slog.Logger) WalletOption {
+	return func(s *WalletService) {
+		s.logger = l
+	}
+}
+
+func WithWalletRepo(r WalletRepository) WalletOption {
+	return func(s *WalletService) {
+		s.repo = r
+	}
+}
+
+// WithWalletFetcher sets the fetcher that reads balances on chain. Wallets
+// on a chain without a fetcher fail to sync.
+func WithWalletFetcher(chain string, f wallets.BalanceFetcher) WalletOption {
+	return func(s *WalletService) {
+		s.fetchers[chain] = f
+	}
+}
+
+func WithWalletInterval(d time.Duration) WalletOption {
+	return func(s *WalletService) {
+		s.interval = d
+	}
+}
+
+func (s *WalletService) IsValid() error {
+	switch {
+	case s.ctx == nil:
+		return errors.Wrap(ErrInvalidWalletConfig, "ctx cannot be nil")
+	case s.logger == nil:
+		return errors.Wrap(ErrInvalidWalletConfig, "logger cannot be nil")
+	case s.repo == nil:
+		return errors.Wrap(ErrInvalidWalletConfig, "repo cannot be nil")
+	case s.interval <= 0:
+		return errors.Wrap(ErrInvalidWalletConfig, "interval must be positive")
+	default:
+		return nil
+	}
+}
+
+func NewWalletService(opts ...WalletOption) (*WalletService, error) {
+	s := &WalletService{
+		fetchers: make(map[string]wallets.BalanceFetcher),
+		interval: time.Hour,
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.IsValid(); err != nil {
+		return nil, err
+	}
+
+	sched, err := tickerScheduler.New(
+		tickerScheduler.WithName("wallet_sync"),
+		tickerScheduler.WithContext(s.ctx),
+		tickerScheduler.WithLogger(s.logger),
+		tickerScheduler.WithInterval(s.interval),
+		tickerScheduler.WithInitialDelay(walletInitialDelay),
+		tickerScheduler.WithHandler(s.tick),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create scheduler")
+	}
+	s.scheduler = sched
+
+	return s, nil
+}
+
+func (s *WalletService) Start() error {
+	return s.scheduler.Start()
+}
+
+func (s *WalletService) Stop() {
+	s.scheduler.Stop()
+}
+
+func (s *WalletService) tick(ctx context.Context) error {
+	result, err := s.Sync(ctx)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("wallets synced",
+		"synced", result.Synced,
+		"failed", result.Failed,
+		"discrepancies", result.Discrepancies,
+	)
+	return nil
+}
+
+// Sync fetches the balances of every wallet and reconciles those that
+// synced. A wallet that fails keeps its previous balances and records the
+// error.
+func (s *WalletService) Sync(ctx context.Context) (*WalletSyncResult, error) {
+	list, err := s.repo.ListWallets()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list wallets")
+	}
+
+	result := &WalletSyncResult{}
+	failed := make(map[int64]bool)
+	for i := range list {
+		wallet := &list[i]
+		if err := s.syncWallet(ctx, wallet); err != nil {
+			s.logger.Warn("failed to sync wallet", "wallet", wallet.Name, "chain", wallet.Chain, "error", err)
+			failed[wallet.ID] = true
+			result.Failed++
+			continue
+		}
+		result.Synced++
+	}
+
+	balances, err := s.repo.ListWalletBalances(0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list wallet balances")
+	}
+	byWallet := make(map[int64][]models.WalletBalance)
+	for _, b := range balances {
+		byWallet[b.WalletID] = append(byWallet[b.WalletID], b)
+	}
+
+	byPortfolio := make(map[int64][]models.Wallet)
+	for _, wallet := range list {
+		byPortfolio[wallet.PortfolioID] = append(byPortfolio[wallet.PortfolioID], wallet)
+	}
+
+	for portfolioID, portfolioWallets := range byPortfolio {
+		open, err := s.reconcile(portfolioID, portfolioWallets, byWallet, failed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to reconcile portfolio %d", portfolioID)
+		}
+		result.Discrepancies += open
+	}
+
+	return result, nil
+}
+
+func (s *WalletService) syncWallet(ctx context.Context, wallet *models.Wallet) error {
+	fetchErr := s.fetch(ctx, wallet)
+	if fetchErr == nil {
+		return nil
+	}
+	if err := s.repo.SaveWalletSync(wallet.ID, s.now(), nil, fetchErr); err != nil {
+		return errors.Wrap(err, "failed to save sync error")
+	}
+	return fetchErr
+}
+
+func (s *WalletService) fetch(ctx context.Context, wallet *models.Wallet) error {
+	fetcher, ok := s.fetchers[wallet.Chain]
+	if !ok {
+		return fmt.Errorf("no balance source configured for chain %s", wallet.Chain)
+	}
+
+	walletTokens, err := wallet.TokenList()
+	if err != nil {
+		return err
+	}
+	tokens := make([]wallets.Token, len(walletTokens))
+	for i, t := range walletTokens {
+		tokens[i] = wallets.Token{Symbol: t.Symbol, Contract: t.Contract}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, walletFetchTimeout)
+	defer cancel()
+
+	fetched, err := fetcher.FetchBalances(ctx, wallet.Address, tokens)
+	if err != nil {
+		return err
+	}
+
+	balances := make([]models.WalletBalance, 0, len(fetched))
+	for _, b := range fetched {
+		balances = append(balances, models.WalletBalance{Symbol: b.Symbol, Amount: b.Amount})
+	}
+	return errors.Wrap(s.repo.SaveWalletSync(wallet.ID, s.now(), balances, nil), "failed to save balances")
+}
+
+// reconcile compares the balances of each of a portfolio's wallets with the
+// holdings of the entries tagged with it and returns how many discrepancies
+// remain open. Only symbols the wallet reports are compared. An entry is
+// proposed only for wallets in propose mode, and never for a symbol that
+// untagged entries also move, as the wallet's share of those is unknown.
+func (s *WalletService) reconcile(portfolioID int64, portfolioWallets []models.Wallet, byWallet map[int64][]models.WalletBalance, failed map[int64]bool) (int, error) {
+	assets, err := s.repo.GetAssetsByPortfolio(portfolioID)
+	if err != nil {
+		return 0, err
+	}
+	exchanges, err := s.repo.GetExchangesByPortfolio(portfolioID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now().UTC()
+	open := 0
+	for i := range portfolioWallets {
+		wallet := &portfolioWallets[i]
+		if failed[wallet.ID] {
+			continue
+		}
+		recorded, untagged := models.WalletHoldings(assets, exchanges, wallet.ID)
+		for _, b := range byWallet[wallet.ID] {
+			propose := wallet.Mode == models.WalletModePropose && !untagged[b.Symbol]
+			isOpen, err := s.reconcileSymbol(wallet, b.Symbol, b.Amount, recorded[b.Symbol], propose, now)
+			if err != nil {
+				return 0, err
+			}
+			if isOpen {
+				open++
+			}
+		}
+	}
+	return open, nil
+}
+
+func (s *WalletService) reconcileSymbol(wallet *models.Wallet, symbol string, observed, recorded decimal.Decimal, propose bool, now time.Time) (bool, error) {
+	difference := observed.Sub(recorded)
+
+	latest, err := s.repo.GetLatestWalletDiscrepancy(wallet.ID, symbol)
+	if err != nil {
+		return false, err
+	}
+
+	if latest != nil && latest.Status == models.DiscrepancyOpen {
+		if difference.IsZero() {
+			latest.Status = models.DiscrepancyResolved
+			latest.ResolvedAt = &now
+			return false, s.repo.SaveWalletDiscrepancy(latest)
+		}
+		fillDiscrepancy(latest, observed, recorded, propose, now)
+		return true, s.repo.SaveWalletDiscrepancy(latest)
+	}
+
+	if difference.IsZero() {
+		return false, nil
+	}
+	if latest != nil && latest.Status == models.DiscrepancyDismissed && latest.Difference.Equal(difference) {
+		return false, nil
+	}
+
+	discrepancy := &models.WalletDiscrepancy{
+		PortfolioID: wallet.PortfolioID,
+		WalletID:    wallet.ID,
+		Symbol:      symbol,
+		Status:      models.DiscrepancyOpen,
+	}
+	fillDiscrepancy(discrepancy, observed, recorded, propose, now)
+	return true, s.repo.SaveWalletDiscrepancy(discrepancy)
+}
+
+func fillDiscrepancy(d *models.WalletDiscrepancy, observed, recorded decimal.Decimal, propose bool, now time.Time) {
+	d.Observed = observed
+	d.Recorded = recorded
+	d.Difference = observed.Sub(recorded)
+	d.ObservedAt = now
+	d.ProposedType = ""
+	if propose {
+		d.ProposedType = "deposit"
+		if d.Difference.IsNegative() {
+			d.ProposedType = "withdraw"
+		}
+	}
+}
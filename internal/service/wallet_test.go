@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWalletRepo struct {
+	wallets       []models.Wallet
+	balances      map[int64][]models.WalletBalance
+	discrepancies []models.WalletDiscrepancy
+	assets        []models.Asset
+	exchanges     []models.Exchange
+}
+
+func (m *mockWalletRepo) ListWallets() ([]models.Wallet, error) {
+	return m.wallets, nil
+}
+
+func (m *mockWalletRepo) SaveWalletSync(id int64, at time.Time, balances []models.WalletBalance, syncErr error) error {
+	for i := range m.wallets {
+		if m.wallets[i].ID != id {
+			continue
+		}
+		if syncErr != nil {
+			m.wallets[i].LastError = syncErr.Error()
+			return nil
+		}
+		m.wallets[i].LastError = ""
+		m.wallets[i].LastSyncedAt = &at
+	}
+	for i := range balances {
+		balances[i].WalletID = id
+	}
+	m.balances[id] = balances
+	return nil
+}
+
+func (m *mockWalletRepo) ListWalletBalances(int64) ([]models.WalletBalance, error) {
+	var all []models.WalletBalance
+	for _, b := range m.balances {
+		all = append(all, b...)
+	}
+	return all, nil
+}
+
+func (m *mockWalletRepo) GetLatestWalletDiscrepancy(walletID int64, symbol string) (*models.WalletDiscrepancy, error) {
+	for i := len(m.discrepancies) - 1; i >= 0; i-- {
+		d := m.discrepancies[i]
+		if d.WalletID == walletID && d.Symbol == symbol {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockWalletRepo) SaveWalletDiscrepancy(discrepancy *models.WalletDiscrepancy) error {
+	if discrepancy.ID == 0 {
+		discrepancy.ID = int64(len(m.discrepancies) + 1)
+		m.discrepancies = append(m.discrepancies, *discrepancy)
+		return nil
+	}
+	m.discrepancies[discrepancy.ID-1] = *discrepancy
+	return nil
+}
+
+func (m *mockWalletRepo) GetAssetsByPortfolio(int64) ([]models.Asset, error) {
+	return m.assets, nil
+}
+
+func (m *mockWalletRepo) GetExchangesByPortfolio(int64) ([]models.Exchange, error) {
+	return m.exchanges, nil
+}
+
+type staticBalances map[string][]wallets.Balance
+
+func (s staticBalances) FetchBalances(_ context.Context, address string, _ []wallets.Token) ([]wallets.Balance, error) {
+	balances, ok := s[address]
+	if !ok {
+		return nil, errors.New("address not found")
+	}
+	return balances, nil
+}
+
+func btc(amount string) []wallets.Balance {
+	return []wallets.Balance{{Symbol: "BTC", Amount: decimal.RequireFromString(amount)}}
+}
+
+func inWallet(id int64) *int64 {
+	return &id
+}
+
+func newTestWalletService(t *testing.T, repo *mockWalletRepo, fetcher wallets.BalanceFetcher) *WalletService {
+	svc, err := NewWalletService(
+		WithWalletContext(context.Background()),
+		WithWalletLogger(alertDiscardLogger),
+		WithWalletRepo(repo),
+		WithWalletFetcher(wallets.ChainBitcoin, fetcher),
+	)
+	require.NoError(t, err)
+	return svc
+}
+
+func TestWalletService_ProposesAndResolves(t *testing.T) {
+	repo := &mockWalletRepo{
+		wallets: []models.Wallet{
+			{ID: 1, PortfolioID: 1, Name: "cold", Chain: wallets.ChainBitcoin, Address: "a", Mode: models.WalletModePropose},
+			{ID: 2, PortfolioID: 1, Name: "hot", Chain: wallets.ChainBitcoin, Address: "b", Mode: models.WalletModePropose},
+		},
+		balances: make(map[int64][]models.WalletBalance),
+		assets: []models.Asset{
+			{WalletID: inWallet(1), Symbol: "BTC", Amount: decimal.RequireFromString("1"), TransactionType: "deposit"},
+			{WalletID: inWallet(2), Symbol: "BTC", Amount: decimal.RequireFromString("0.5"), TransactionType: "deposit"},
+		},
+	}
+	fetcher := staticBalances{"a": btc("1.25"), "b": btc("0.5")}
+	svc := newTestWalletService(t, repo, fetcher)
+
+	result, err := svc.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, WalletSyncResult{Synced: 2, Discrepancies: 1}, *result)
+	require.Len(t, repo.discrepancies, 1)
+	d := repo.discrepancies[0]
+	assert.Equal(t, int64(1), d.WalletID)
+	assert.Equal(t, "1.25", d.Observed.String())
+	assert.Equal(t, "1", d.Recorded.String(), "only entries tagged with the wallet count")
+	assert.Equal(t, "0.25", d.Difference.String())
+	assert.Equal(t, "deposit", d.ProposedType)
+
+	// The wallet moved on: the open discrepancy follows it.
+	fetcher["a"] = btc("0.85")
+	_, err = svc.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repo.discrepancies, 1)
+	assert.Equal(t, "-0.15", repo.discrepancies[0].Difference.String())
+	assert.Equal(t, "withdraw", repo.discrepancies[0].ProposedType)
+
+	// Recorded holdings catch up, resolving it.
+	repo.assets = append(repo.assets, models.Asset{WalletID: inWallet(1), Symbol: "BTC", Amount: decimal.RequireFromString("0.15"), TransactionType: "withdraw"})
+	result, err = svc.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Discrepancies)
+	assert.Equal(t, models.DiscrepancyResolved, repo.discrepancies[0].Status)
+	assert.NotNil(t, repo.discrepancies[0].ResolvedAt)
+}
+
+func TestWalletService_UntaggedEntriesAreOnlyFlagged(t *testing.T) {
+	repo := &mockWalletRepo{
+		wallets: []models.Wallet{
+			{ID: 1, PortfolioID: 1, Chain: wallets.ChainBitcoin, Address: "a", Mode: models.WalletModePropose},
+		},
+		balances: make(map[int64][]models.WalletBalance),
+		assets: []models.Asset{
+			{Symbol: "BTC", Amount: decimal.RequireFromString("2"), TransactionType: "deposit"},
+		},
+	}
+	svc := newTestWalletService(t, repo, staticBalances{"a": btc("0.5")})
+
+	_, err := svc.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repo.discrepancies, 1)
+	d := repo.discrepancies[0]
+	assert.True(t, d.Recorded.IsZero(), "holdings kept on an exchange are not the wallet's")
+	assert.Equal(t, "0.5", d.Difference.String())
+	assert.Empty(t, d.ProposedType, "the wallet's share of the untagged deposit is unknown")
+}
+
+func TestWalletService_FlagModeAndDismissed(t *testing.T) {
+	repo := &mockWalletRepo{
+		wallets: []models.Wallet{
+			{ID: 1, PortfolioID: 1, Chain: wallets.ChainBitcoin, Address: "a", Mode: models.WalletModePropose},
+			{ID: 2, PortfolioID: 1, Chain: wallets.ChainBitcoin, Address: "b", Mode: models.WalletModeFlag},
+		},
+		balances: make(map[int64][]models.WalletBalance),
+	}
+	svc := newTestWalletService(t, repo, staticBalances{"a": btc("1"), "b": btc("1")})
+
+	_, err := svc.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repo.discrepancies, 2)
+	byWallet := map[int64]models.WalletDiscrepancy{}
+	for _, d := range repo.discrepancies {
+		byWallet[d.WalletID] = d
+	}
+	assert.Equal(t, "deposit", byWallet[1].ProposedType)
+	assert.Empty(t, byWallet[2].ProposedType, "a flag mode wallet only reports")
+
+	repo.discrepancies[0].Status = models.DiscrepancyDismissed
+	repo.discrepancies[1].Status = models.DiscrepancyDismissed
+	_, err = svc.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, repo.discrepancies, 2, "a dismissed difference is not reported again")
+}
+
+func TestWalletService_FailedWalletIsNotReconciled(t *testing.T) {
+	repo := &mockWalletRepo{
+		wallets: []models.Wallet{
+			{ID: 1, PortfolioID: 1, Chain: wallets.ChainBitcoin, Address: "a", Mode: models.WalletModePropose},
+			{ID: 2, PortfolioID: 1, Chain: wallets.ChainBitcoin, Address: "missing", Mode: models.WalletModePropose},
+			{ID: 3, PortfolioID: 2, Chain: wallets.ChainEthereum, Address: "0x", Mode: models.WalletModePropose},
+		},
+		balances: map[int64][]models.WalletBalance{
+			2: {{WalletID: 2, Symbol: "BTC", Amount: decimal.RequireFromString("3")}},
+		},
+	}
+	svc := newTestWalletService(t, repo, staticBalances{"a": btc("1")})
+
+	result, err := svc.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Synced)
+	assert.Equal(t, 2, result.Failed)
+	require.Len(t, repo.discrepancies, 1, "the stale balances of a failed wallet are not compared")
+	assert.Equal(t, int64(1), repo.discrepancies[0].WalletID)
+	assert.Equal(t, "address not found", repo.wallets[1].LastError)
+	assert.Contains(t, repo.wallets[2].LastError, "no balance source configured")
+}
+
+func TestWalletService_InvalidConfig(t *testing.T) {
+	_, err := NewWalletService(WithWalletContext(context.Background()), WithWalletLogger(alertDiscardLogger))
+	assert.ErrorIs(t, err, ErrInvalidWalletConfig)
+}
@@ -0,0 +1,14 @@
+package ui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// FS returns the templates and static assets compiled into the binary.
+func FS() fs.FS {
+	return files
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// netHoldings nets deposits, withdrawals and exchanges dated up to until, or
+// all of them when until is zero, converting to float64 only for valuation.
+func netHoldings(assets []models.Asset, exchanges []models.Exchange, until time.Time) map[string]float64 {
+	balances := models.NetHoldings(assets, exchanges, until)
+	holdings := make(map[string]float64, len(balances))
+	for symbol, amount := range balances {
+		holdings[symbol] = amount.InexactFloat64()
+	}
+	return holdings
+}
+
+var errInvalidAmount = errors.New("amount must be a positive number")
+
+// parseAmount reads a decimal form field. An empty optional field is zero;
+// required fields must be positive and optional ones must not be negative.
+func parseAmount(raw string, required bool) (decimal.Decimal, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" && !required {
+		return decimal.Zero, nil
+	}
+	amount, err := decimal.NewFromString(raw)
+	if err != nil || amount.IsNegative() || (required && amount.IsZero()) {
+		return decimal.Zero, errInvalidAmount
+	}
+	return amount, nil
+}
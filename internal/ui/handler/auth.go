@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sessionMaxAge = 30 * 24 * 60 * 60
+
+// authenticate guards UI routes with the same API keys as /api. Safe methods
+// need readScope, everything else needs writeScope. The key comes from the
+// sign-in cookie; without one the request is let through unless keys are
+// required, in which case pages redirect to /login.
+func (h *WebHandler) authenticate(readScope, writeScope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, err := c.Cookie(models.APIKeyCookie)
+		if err != nil || raw == "" {
+			if h.requireAPIKey {
+				unauthorized(c)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := h.repo.AuthenticateAPIKey(raw)
+		if err != nil {
+			if errors.Is(err, repo.ErrAPIKeyInvalid) || errors.Is(err, repo.ErrAPIKeyInactive) {
+				clearSession(c)
+				unauthorized(c)
+				return
+			}
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		scope := writeScope
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = readScope
+		}
+		if !key.HasScope(scope) {
+			c.Header("HX-Trigger", `{"show-toast": {"message": "This API key lacks the `+scope+` scope", "type": "error"}}`)
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// unauthorized sends htmx requests and page loads to the sign-in page.
+func unauthorized(c *gin.Context) {
+	login := "/login?next=" + url.QueryEscape(c.Request.URL.RequestURI())
+	switch {
+	case c.GetHeader("HX-Request") != "":
+		c.Header("HX-Redirect", login)
+		c.AbortWithStatus(http.StatusUnauthorized)
+	case c.Request.Method == http.MethodGet && strings.Contains(c.GetHeader("Accept"), "text/html"):
+		c.Redirect(http.StatusSeeOther, login)
+		c.Abort()
+	default:
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+type LoginPageData struct {
+	Next  string
+	Error string
+}
+
+func (h *WebHandler) LoginPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "login.html", LoginPageData{Next: safeNext(c.Query("next"))})
+}
+
+func (h *WebHandler) Login(c *gin.Context) {
+	next := safeNext(c.PostForm("next"))
+	raw := strings.TrimSpace(c.PostForm("api_key"))
+	if raw == "" {
+		c.HTML(http.StatusUnauthorized, "login.html", LoginPageData{Next: next, Error: "API key is required"})
+		return
+	}
+
+	if _, err := h.repo.AuthenticateAPIKey(raw); err != nil {
+		message := "Failed to verify API key"
+		if errors.Is(err, repo.ErrAPIKeyInvalid) || errors.Is(err, repo.ErrAPIKeyInactive) {
+			message = "Invalid or inactive API key"
+		}
+		c.HTML(http.StatusUnauthorized, "login.html", LoginPageData{Next: next, Error: message})
+		return
+	}
+
+	// SameSite=Strict keeps other sites from riding on the cookie, since /api
+	// accepts it in place of a key header.
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(models.APIKeyCookie, raw, sessionMaxAge, "/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusSeeOther, next)
+}
+
+func (h *WebHandler) Logout(c *gin.Context) {
+	clearSession(c)
+	c.Redirect(http.StatusSeeOther, "/login")
+}
+
+func clearSession(c *gin.Context) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(models.APIKeyCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+}
+
+// safeNext only follows local paths after sign-in.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newWebRouter(t *testing.T, required bool) (*gin.Engine, *repo.Repository) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	repository, err := repo.New(db)
+	require.NoError(t, err)
+	require.NoError(t, repository.Migrate())
+
+	router := gin.New()
+	h, err := New(WithEngine(router), WithRepository(repository), WithRequireAPIKey(required))
+	require.NoError(t, err)
+	require.NoError(t, h.Setup())
+	return router, repository
+}
+
+func createKeyRequest(cookie string) *http.Request {
+	form := url.Values{"name": {"minted"}, "scopes": {models.ScopeAdmin}}
+	req := httptest.NewRequest(http.MethodPost, "/partials/settings/api-keys/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: models.APIKeyCookie, Value: cookie})
+	}
+	return req
+}
+
+func TestAuthenticate_Optional(t *testing.T) {
+	router, repository := newWebRouter(t, false)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, createKeyRequest(""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	keys, err := repository.ListAPIKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestAuthenticate_Required(t *testing.T) {
+	router, repository := newWebRouter(t, true)
+	_, readKey, err := repository.CreateAPIKey("reader", []string{models.ScopeReadPortfolio, models.ScopeWriteTransactions}, nil)
+	require.NoError(t, err)
+	_, adminKey, err := repository.CreateAPIKey("admin", []string{models.ScopeAdmin}, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, createKeyRequest(""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("HX-Redirect"), "/login")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, createKeyRequest(readKey))
+	assert.Equal(t, http.StatusForbidden, w.Code, "managing keys needs the admin scope")
+
+	keys, err := repository.ListAPIKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 2, "no key is minted without admin credentials")
+
+	req := httptest.NewRequest(http.MethodGet, "/settings", nil)
+	req.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login?next=%2Fsettings", w.Header().Get("Location"))
+
+	form := url.Values{"api_key": {adminKey}, "next": {"/settings"}}
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/settings", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, models.APIKeyCookie, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, createKeyRequest(cookies[0].Value))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	keys, err = repository.ListAPIKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+}
+
+func TestLogin_RejectsInvalidKey(t *testing.T) {
+	router, _ := newWebRouter(t, true)
+
+	form := url.Values{"api_key": {"hb_invalid"}, "next": {"//evil.example"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	assert.Contains(t, w.Body.String(), "Invalid or inactive API key")
+	assert.Contains(t, w.Body.String(), `value="/"`, "only local paths are followed after sign-in")
+}
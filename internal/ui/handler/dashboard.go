@@ -56,14 +56,16 @@ type SummaryData struct {
 }
 
 func (h *DashboardHandler) Summary(c *gin.Context) {
-	holdings, totalValue := h.calculatePortfolio()
+	portfolioID := selectedPortfolioID(c)
+
+	holdings, totalValue := h.calculatePortfolio(portfolioID)
 
 	var totalCost, totalPnL float64
 	var bestSymbol string
 	var bestPnLPct float64 = -999999
 
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	for symbol, amount := range holdings {
 		if amount <= 0 {
@@ -115,6 +117,8 @@ type ChartData struct {
 }
 
 func (h *DashboardHandler) Chart(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	rangeParam := c.DefaultQuery("range", "30d")
 	days := parseDays(rangeParam)
 
@@ -144,7 +148,7 @@ func (h *DashboardHandler) Chart(c *gin.Context) {
 		labelStr := date.Format("Jan 2")
 
 		endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-		holdings := h.calculateHoldingsAtDate(endOfDay)
+		holdings := h.calculateHoldingsAtDate(portfolioID, endOfDay)
 
 		var dailyValue float64
 		for symbol, amount := range holdings {
@@ -211,7 +215,9 @@ var chartColors = []string{
 }
 
 func (h *DashboardHandler) Allocation(c *gin.Context) {
-	holdings, totalValue := h.calculatePortfolio()
+	portfolioID := selectedPortfolioID(c)
+
+	holdings, totalValue := h.calculatePortfolio(portfolioID)
 
 	var items []AllocationItem
 	i := 0
@@ -270,10 +276,12 @@ type HoldingItem struct {
 }
 
 func (h *DashboardHandler) Holdings(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	sortBy := c.DefaultQuery("sort", "value")
 	sortDir := c.DefaultQuery("dir", "desc")
 
-	items := h.buildHoldingsItems()
+	items := h.buildHoldingsItems(portfolioID)
 	sortHoldingsItems(items, sortBy, sortDir)
 
 	if len(items) > 5 {
@@ -293,10 +301,10 @@ func (h *DashboardHandler) Holdings(c *gin.Context) {
 	c.HTML(http.StatusOK, "holdings_table.html", data)
 }
 
-func (h *DashboardHandler) buildHoldingsItems() []HoldingItem {
-	holdings, _ := h.calculatePortfolio()
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+func (h *DashboardHandler) buildHoldingsItems(portfolioID int64) []HoldingItem {
+	holdings, _ := h.calculatePortfolio(portfolioID)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	var items []HoldingItem
 	for symbol, amount := range holdings {
@@ -372,9 +380,11 @@ type transactionEntry struct {
 }
 
 func (h *DashboardHandler) Transactions(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	var entries []transactionEntry
 
-	assets, _ := h.repo.GetAllAssets()
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
 	for _, asset := range assets {
 		typeClass := "neutral"
 		switch asset.TransactionType {
@@ -390,13 +400,13 @@ func (h *DashboardHandler) Transactions(c *gin.Context) {
 				Type:      asset.TransactionType,
 				TypeClass: typeClass,
 				Symbol:    asset.Symbol,
-				Amount:    formatAmount(asset.Amount),
+				Amount:    formatAmount(asset.Amount.InexactFloat64()),
 				Date:      asset.Timestamp.Format("Jan 2, 2006"),
 			},
 		})
 	}
 
-	exchanges, _ := h.repo.GetAllExchanges()
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
 	for _, ex := range exchanges {
 		entries = append(entries, transactionEntry{
 			timestamp: ex.Timestamp,
@@ -405,9 +415,9 @@ func (h *DashboardHandler) Transactions(c *gin.Context) {
 				TypeClass:  "neutral",
 				IsExchange: true,
 				FromSymbol: ex.FromSymbol,
-				FromAmount: formatAmount(ex.FromAmount),
+				FromAmount: formatAmount(ex.FromAmount.InexactFloat64()),
 				ToSymbol:   ex.ToSymbol,
-				ToAmount:   formatAmount(ex.ToAmount),
+				ToAmount:   formatAmount(ex.ToAmount.InexactFloat64()),
 				Date:       ex.Timestamp.Format("Jan 2, 2006"),
 			},
 		})
@@ -434,31 +444,14 @@ func (h *DashboardHandler) Transactions(c *gin.Context) {
 	c.HTML(http.StatusOK, "dashboard_transactions.html", data)
 }
 
-func (h *DashboardHandler) calculateHoldings() map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings
+func (h *DashboardHandler) calculateHoldings(portfolioID int64) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, time.Time{})
 }
 
-func (h *DashboardHandler) calculatePortfolio() (holdings map[string]float64, totalValue float64) {
-	holdings = h.calculateHoldings()
+func (h *DashboardHandler) calculatePortfolio(portfolioID int64) (holdings map[string]float64, totalValue float64) {
+	holdings = h.calculateHoldings(portfolioID)
 
 	for symbol, amount := range holdings {
 		if amount <= 0 {
@@ -478,7 +471,7 @@ type dashboardCostBasisEvent struct {
 	exchange  *models.Exchange
 }
 
-func (h *DashboardHandler) calculateCostBasis(assets []models.Asset) map[string]float64 {
+func (h *DashboardHandler) calculateCostBasis(portfolioID int64, assets []models.Asset) map[string]float64 {
 	costBasis := make(map[string]float64)
 	runningHoldings := make(map[string]float64)
 
@@ -491,7 +484,7 @@ func (h *DashboardHandler) calculateCostBasis(assets []models.Asset) map[string]
 		})
 	}
 
-	exchanges, _ := h.repo.GetAllExchanges()
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
 	for i := range exchanges {
 		events = append(events, dashboardCostBasisEvent{
 			timestamp: exchanges[i].Timestamp,
@@ -511,25 +504,25 @@ func (h *DashboardHandler) calculateCostBasis(assets []models.Asset) map[string]
 			switch asset.TransactionType {
 			case "deposit":
 				price := h.getPriceAtTime(asset.Symbol, asset.Timestamp)
-				costBasis[asset.Symbol] += asset.Amount * price
-				runningHoldings[asset.Symbol] += asset.Amount
+				costBasis[asset.Symbol] += asset.Amount.InexactFloat64() * price
+				runningHoldings[asset.Symbol] += asset.Amount.InexactFloat64()
 			case "withdraw":
 				if runningHoldings[asset.Symbol] > 0 {
 					avgCost := costBasis[asset.Symbol] / runningHoldings[asset.Symbol]
-					costBasis[asset.Symbol] -= asset.Amount * avgCost
+					costBasis[asset.Symbol] -= asset.Amount.InexactFloat64() * avgCost
 				}
-				runningHoldings[asset.Symbol] -= asset.Amount
+				runningHoldings[asset.Symbol] -= asset.Amount.InexactFloat64()
 			}
 		case "exchange":
 			ex := event.exchange
 			if runningHoldings[ex.FromSymbol] > 0 {
 				avgCost := costBasis[ex.FromSymbol] / runningHoldings[ex.FromSymbol]
-				transferredCost := ex.FromAmount * avgCost
+				transferredCost := ex.FromAmount.InexactFloat64() * avgCost
 				costBasis[ex.FromSymbol] -= transferredCost
 				costBasis[ex.ToSymbol] += transferredCost
 			}
-			runningHoldings[ex.FromSymbol] -= ex.FromAmount
-			runningHoldings[ex.ToSymbol] += ex.ToAmount
+			runningHoldings[ex.FromSymbol] -= ex.FromAmount.InexactFloat64()
+			runningHoldings[ex.ToSymbol] += ex.ToAmount.InexactFloat64()
 		}
 	}
 
@@ -539,38 +532,16 @@ func (h *DashboardHandler) calculateCostBasis(assets []models.Asset) map[string]
 func (h *DashboardHandler) getPriceAtTime(symbol string, timestamp time.Time) float64 {
 	priceRecord, err := h.repo.GetPriceAtTime(symbol, "USD", timestamp)
 	if err == nil && priceRecord != nil {
-		return priceRecord.Price
+		return priceRecord.Price.InexactFloat64()
 	}
 	price, _ := h.priceCache.Get(symbol)
 	return price
 }
 
-func (h *DashboardHandler) calculateHoldingsAtDate(targetDate time.Time) map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-	for _, asset := range assets {
-		if asset.Timestamp.After(targetDate) {
-			continue
-		}
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-	for _, ex := range exchanges {
-		if ex.Timestamp.After(targetDate) {
-			continue
-		}
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings
+func (h *DashboardHandler) calculateHoldingsAtDate(portfolioID int64, targetDate time.Time) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, targetDate)
 }
 
 func parseDays(rangeStr string) int {
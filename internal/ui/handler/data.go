@@ -21,10 +21,11 @@ func NewDataHandler(renderer *Renderer, repository *repo.Repository) *DataHandle
 }
 
 type DataPageData struct {
-	Title      string
-	PageTitle  string
-	ActivePage string
-	Imports    []ImportLogView
+	Title       string
+	PageTitle   string
+	ActivePage  string
+	PortfolioID int64
+	Imports     []ImportLogView
 }
 
 type ImportLogView struct {
@@ -41,7 +42,9 @@ type ImportLogView struct {
 }
 
 func (h *DataHandler) Index(c *gin.Context) {
-	logs, _ := h.repo.ListImportLogs()
+	portfolioID := selectedPortfolioID(c)
+
+	logs, _ := h.repo.ListImportLogsByPortfolio(portfolioID)
 
 	var imports []ImportLogView
 	for _, log := range logs {
@@ -60,16 +63,19 @@ func (h *DataHandler) Index(c *gin.Context) {
 	}
 
 	data := DataPageData{
-		Title:      "Data",
-		PageTitle:  "Data Management",
-		ActivePage: "data",
-		Imports:    imports,
+		Title:       "Data",
+		PageTitle:   "Data Management",
+		ActivePage:  "data",
+		PortfolioID: portfolioID,
+		Imports:     imports,
 	}
 	h.renderer.HTML(c, http.StatusOK, "data", data)
 }
 
 func (h *DataHandler) ImportHistory(c *gin.Context) {
-	logs, _ := h.repo.ListImportLogs()
+	portfolioID := selectedPortfolioID(c)
+
+	logs, _ := h.repo.ListImportLogsByPortfolio(portfolioID)
 
 	var imports []ImportLogView
 	for _, log := range logs {
@@ -11,9 +11,11 @@ import (
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
 	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type ExchangesHandler struct {
@@ -21,14 +23,16 @@ type ExchangesHandler struct {
 	repo         *repo.Repository
 	priceCache   cache.Cache[string, float64]
 	priceFetcher prices.PriceFetcher
+	events       events.Publisher
 }
 
-func NewExchangesHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64], priceFetcher prices.PriceFetcher) *ExchangesHandler {
+func NewExchangesHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64], priceFetcher prices.PriceFetcher, publisher events.Publisher) *ExchangesHandler {
 	return &ExchangesHandler{
 		renderer:     renderer,
 		repo:         repository,
 		priceCache:   priceCache,
 		priceFetcher: priceFetcher,
+		events:       publisher,
 	}
 }
 
@@ -42,8 +46,10 @@ type ExchangesPageData struct {
 }
 
 func (h *ExchangesHandler) Index(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	symbols, _ := h.repo.GetUniqueSymbols()
-	holdings := h.calculateHoldings()
+	holdings := h.calculateHoldings(portfolioID)
 	prices := h.getAllPrices()
 
 	holdingsJSON, _ := json.Marshal(holdings)
@@ -67,6 +73,10 @@ type ExchangesTableData struct {
 	TotalPages      int
 	HasPrev         bool
 	HasNext         bool
+	PrevCursor      string
+	NextCursor      string
+	SortBy          string
+	SortDir         string
 	TotalPnLUSD     string
 	TotalPnLPercent string
 	TotalPnLPositive bool
@@ -76,12 +86,12 @@ type ExchangeRow struct {
 	ID            int64
 	FromSymbol    string
 	FromAmount    string
-	FromAmountRaw float64
+	FromAmountRaw string
 	ToSymbol      string
 	ToAmount      string
-	ToAmountRaw   float64
+	ToAmountRaw   string
 	Fee           string
-	FeeRaw        float64
+	FeeRaw        string
 	FeeCurrency   string
 	Rate          string
 	RatePair      string
@@ -96,62 +106,32 @@ type ExchangeRow struct {
 }
 
 func (h *ExchangesHandler) Table(c *gin.Context) {
-	fromSymbol := c.Query("from_symbol")
-	toSymbol := c.Query("to_symbol")
-	fromDate := c.Query("from")
-	toDate := c.Query("to")
-	pageStr := c.DefaultQuery("page", "1")
-
-	page, _ := strconv.Atoi(pageStr)
-	if page < 1 {
-		page = 1
-	}
-	limit := 20
-
-	var fromTime, toTime *time.Time
-	if fromDate != "" {
-		t, err := time.Parse("2006-01-02", fromDate)
-		if err == nil {
-			fromTime = &t
-		}
-	}
-	if toDate != "" {
-		t, err := time.Parse("2006-01-02", toDate)
-		if err == nil {
-			endOfDay := t.Add(24*time.Hour - time.Second)
-			toTime = &endOfDay
-		}
-	}
+	q := parseTableQuery(c)
 
 	filter := repo.ExchangeFilter{
-		Limit:  limit,
-		Offset: (page - 1) * limit,
-	}
-	if fromSymbol != "" {
+		PortfolioID: selectedPortfolioID(c),
+		Search:      q.Search,
+		StartDate:   q.StartDate,
+		EndDate:     q.EndDate,
+		SortBy:      q.SortBy,
+		SortDir:     q.SortDir,
+		After:       q.After,
+		Before:      q.Before,
+		Limit:       tablePageSize,
+	}
+	if fromSymbol := c.Query("from_symbol"); fromSymbol != "" {
 		filter.FromSymbol = &fromSymbol
 	}
-	if toSymbol != "" {
+	if toSymbol := c.Query("to_symbol"); toSymbol != "" {
 		filter.ToSymbol = &toSymbol
 	}
-	if fromTime != nil {
-		filter.StartDate = fromTime
-	}
-	if toTime != nil {
-		filter.EndDate = toTime
-	}
 
 	result, err := h.repo.ListExchanges(filter)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load exchanges")
+		tableError(c, err, "Failed to load exchanges")
 		return
 	}
 	exchanges := result.Exchanges
-	total := int(result.Total)
-
-	totalPages := (total + limit - 1) / limit
-	if totalPages < 1 {
-		totalPages = 1
-	}
 
 	var rows []ExchangeRow
 	var totalCostBasis, totalCurrentValue float64
@@ -162,8 +142,8 @@ func (h *ExchangesHandler) Table(c *gin.Context) {
 		var pnlUSD, pnlPercent string
 		var pnlPositive bool
 
-		if ex.FromAmount > 0 && ex.ToAmount > 0 {
-			rate := ex.FromAmount / ex.ToAmount
+		if ex.FromAmount.IsPositive() && ex.ToAmount.IsPositive() {
+			rate := ex.FromAmount.Div(ex.ToAmount).InexactFloat64()
 			rateStr = formatExchangeRate(rate)
 			ratePair = ex.ToSymbol + ex.FromSymbol
 
@@ -174,8 +154,8 @@ func (h *ExchangesHandler) Table(c *gin.Context) {
 				marketRateStr = formatExchangeRate(currentMarketRate)
 				marketRatePair = ex.ToSymbol + ex.FromSymbol
 
-				costBasisUSD := ex.FromAmount * fromPrice
-				currentValueUSD := ex.ToAmount * toPrice
+				costBasisUSD := ex.FromAmount.InexactFloat64() * fromPrice
+				currentValueUSD := ex.ToAmount.InexactFloat64() * toPrice
 				pnl := currentValueUSD - costBasisUSD
 				pnlPct := 0.0
 				if costBasisUSD > 0 {
@@ -193,13 +173,13 @@ func (h *ExchangesHandler) Table(c *gin.Context) {
 		rows = append(rows, ExchangeRow{
 			ID:             ex.ID,
 			FromSymbol:     ex.FromSymbol,
-			FromAmount:     formatAmount(ex.FromAmount),
-			FromAmountRaw:  ex.FromAmount,
+			FromAmount:     formatAmount(ex.FromAmount.InexactFloat64()),
+			FromAmountRaw:  ex.FromAmount.String(),
 			ToSymbol:       ex.ToSymbol,
-			ToAmount:       formatAmount(ex.ToAmount),
-			ToAmountRaw:    ex.ToAmount,
-			Fee:            formatAmount(ex.Fee),
-			FeeRaw:         ex.Fee,
+			ToAmount:       formatAmount(ex.ToAmount.InexactFloat64()),
+			ToAmountRaw:    ex.ToAmount.String(),
+			Fee:            formatAmount(ex.Fee.InexactFloat64()),
+			FeeRaw:         ex.Fee.String(),
 			FeeCurrency:    ex.FeeCurrency,
 			Rate:           rateStr,
 			RatePair:       ratePair,
@@ -223,10 +203,14 @@ func (h *ExchangesHandler) Table(c *gin.Context) {
 	data := ExchangesTableData{
 		Exchanges:        rows,
 		Empty:            len(rows) == 0,
-		Page:             page,
-		TotalPages:       totalPages,
-		HasPrev:          page > 1,
-		HasNext:          page < totalPages,
+		Page:             q.Page,
+		TotalPages:       totalPages(result.Total),
+		HasPrev:          result.PrevCursor != "",
+		HasNext:          result.NextCursor != "",
+		PrevCursor:       result.PrevCursor,
+		NextCursor:       result.NextCursor,
+		SortBy:           q.SortBy,
+		SortDir:          q.SortDir,
 		TotalPnLUSD:      formatCurrency(totalPnL, "USD"),
 		TotalPnLPercent:  formatPercent(totalPnLPct),
 		TotalPnLPositive: totalPnL >= 0,
@@ -238,15 +222,26 @@ func (h *ExchangesHandler) Table(c *gin.Context) {
 
 type CreateExchangeRequest struct {
 	FromSymbol  string  `form:"from_symbol" binding:"required"`
-	FromAmount  float64 `form:"from_amount" binding:"required"`
+	FromAmount  string  `form:"from_amount" binding:"required"`
 	ToSymbol    string  `form:"to_symbol" binding:"required"`
-	ToAmount    float64 `form:"to_amount" binding:"required"`
-	Fee         float64 `form:"fee"`
+	ToAmount    string  `form:"to_amount" binding:"required"`
+	Fee         string  `form:"fee"`
 	FeeCurrency string  `form:"fee_currency"`
 	Timestamp   string  `form:"timestamp" binding:"required"`
 	Notes       string  `form:"notes"`
 }
 
+func (r *CreateExchangeRequest) amounts() (from, to, fee decimal.Decimal, err error) {
+	if from, err = parseAmount(r.FromAmount, true); err != nil {
+		return
+	}
+	if to, err = parseAmount(r.ToAmount, true); err != nil {
+		return
+	}
+	fee, err = parseAmount(r.Fee, false)
+	return
+}
+
 func (h *ExchangesHandler) Create(c *gin.Context) {
 	var req CreateExchangeRequest
 	if err := c.ShouldBind(&req); err != nil {
@@ -261,6 +256,13 @@ func (h *ExchangesHandler) Create(c *gin.Context) {
 		return
 	}
 
+	fromAmount, toAmount, fee, err := req.amounts()
+	if err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Amounts must be positive numbers", "type": "error"}}`)
+		h.Table(c)
+		return
+	}
+
 	timestamp, err := time.Parse("2006-01-02T15:04", req.Timestamp)
 	if err != nil {
 		c.Header("HX-Trigger", `{"show-toast": {"message": "Invalid date format", "type": "error"}}`)
@@ -269,11 +271,12 @@ func (h *ExchangesHandler) Create(c *gin.Context) {
 	}
 
 	exchange := &models.Exchange{
+		PortfolioID: selectedPortfolioID(c),
 		FromSymbol:  req.FromSymbol,
-		FromAmount:  req.FromAmount,
+		FromAmount:  fromAmount,
 		ToSymbol:    req.ToSymbol,
-		ToAmount:    req.ToAmount,
-		Fee:         req.Fee,
+		ToAmount:    toAmount,
+		Fee:         fee,
 		FeeCurrency: req.FeeCurrency,
 		Timestamp:   timestamp,
 		Notes:       req.Notes,
@@ -284,6 +287,7 @@ func (h *ExchangesHandler) Create(c *gin.Context) {
 		h.Table(c)
 		return
 	}
+	publish(c, h.events, events.TopicExchangeCreated, *exchange)
 
 	h.Table(c)
 }
@@ -310,6 +314,13 @@ func (h *ExchangesHandler) Update(c *gin.Context) {
 		return
 	}
 
+	fromAmount, toAmount, fee, err := req.amounts()
+	if err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Amounts must be positive numbers", "type": "error"}}`)
+		h.Table(c)
+		return
+	}
+
 	timestamp, err := time.Parse("2006-01-02T15:04", req.Timestamp)
 	if err != nil {
 		c.Header("HX-Trigger", `{"show-toast": {"message": "Invalid date format", "type": "error"}}`)
@@ -325,10 +336,10 @@ func (h *ExchangesHandler) Update(c *gin.Context) {
 	}
 
 	exchange.FromSymbol = req.FromSymbol
-	exchange.FromAmount = req.FromAmount
+	exchange.FromAmount = fromAmount
 	exchange.ToSymbol = req.ToSymbol
-	exchange.ToAmount = req.ToAmount
-	exchange.Fee = req.Fee
+	exchange.ToAmount = toAmount
+	exchange.Fee = fee
 	exchange.FeeCurrency = req.FeeCurrency
 	exchange.Timestamp = timestamp
 	exchange.Notes = req.Notes
@@ -338,6 +349,7 @@ func (h *ExchangesHandler) Update(c *gin.Context) {
 		h.Table(c)
 		return
 	}
+	publish(c, h.events, events.TopicExchangeUpdated, *exchange)
 
 	h.Table(c)
 }
@@ -351,7 +363,7 @@ func (h *ExchangesHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.DeleteExchange(id); err != nil {
+	if err := h.deleteExchange(c, id); err != nil {
 		c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to delete exchange", "type": "error"}}`)
 		h.Table(c)
 		return
@@ -372,7 +384,7 @@ func (h *ExchangesHandler) BulkDelete(c *gin.Context) {
 
 	deleted := 0
 	for _, id := range req.IDs {
-		if err := h.repo.DeleteExchange(id); err == nil {
+		if err := h.deleteExchange(c, id); err == nil {
 			deleted++
 		}
 	}
@@ -381,28 +393,22 @@ func (h *ExchangesHandler) BulkDelete(c *gin.Context) {
 	h.Table(c)
 }
 
-func (h *ExchangesHandler) calculateHoldings() map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
+func (h *ExchangesHandler) deleteExchange(c *gin.Context, id int64) error {
+	exchange, err := h.repo.GetExchangeByID(id)
+	if err != nil {
+		return err
 	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
+	if err := h.repo.DeleteExchange(id); err != nil {
+		return err
 	}
+	publish(c, h.events, events.TopicExchangeDeleted, *exchange)
+	return nil
+}
 
-	return holdings
+func (h *ExchangesHandler) calculateHoldings(portfolioID int64) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, time.Time{})
 }
 
 func (h *ExchangesHandler) getAllPrices() map[string]float64 {
@@ -415,12 +421,16 @@ func (h *ExchangesHandler) getAllPrices() map[string]float64 {
 }
 
 func (h *ExchangesHandler) GetHoldings(c *gin.Context) {
-	holdings := h.calculateHoldings()
+	portfolioID := selectedPortfolioID(c)
+
+	holdings := h.calculateHoldings(portfolioID)
 	c.JSON(http.StatusOK, holdings)
 }
 
 func (h *ExchangesHandler) RefreshPrices(c *gin.Context) {
-	exchanges, err := h.repo.GetAllExchanges()
+	portfolioID := selectedPortfolioID(c)
+
+	exchanges, err := h.repo.GetExchangesByPortfolio(portfolioID)
 	if err != nil {
 		c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to load exchanges", "type": "error"}}`)
 		h.Table(c)
@@ -441,7 +451,7 @@ func (h *ExchangesHandler) RefreshPrices(c *gin.Context) {
 	}
 
 	if len(pricePairs) > 0 {
-		if err := h.priceFetcher.FetchMany(pricePairs...); err != nil {
+		if err := h.priceFetcher.FetchMany(c.Request.Context(), pricePairs...); err != nil {
 			c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to fetch prices", "type": "error"}}`)
 			h.Table(c)
 			return
@@ -3,10 +3,16 @@ package handler
 import (
 	"errors"
 	"html/template"
-	"path/filepath"
+	"io/fs"
+	"net/http"
+	"time"
 
+	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
+	"hodlbook/internal/ui"
+	"hodlbook/pkg/integrations/broadcast"
 	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
 
 	"github.com/gin-gonic/gin"
@@ -18,12 +24,18 @@ var (
 )
 
 type WebHandler struct {
-	engine       *gin.Engine
-	repo         *repo.Repository
-	priceCache   cache.Cache[string, float64]
-	priceFetcher prices.PriceFetcher
-	renderer     *Renderer
-	templatesDir string
+	engine        *gin.Engine
+	repo          *repo.Repository
+	priceCache    cache.Cache[string, float64]
+	priceFetcher  prices.PriceFetcher
+	events        events.Publisher
+	liveBus       events.Subscriber
+	liveHub       *broadcast.Hub
+	renderer      *Renderer
+	fsys          fs.FS
+	devMode       bool
+	requireAPIKey bool
+	staleAfter    time.Duration
 }
 
 type Option func(*WebHandler)
@@ -52,15 +64,57 @@ func WithPriceFetcher(pf prices.PriceFetcher) Option {
 	}
 }
 
-func WithTemplatesDir(dir string) Option {
+// WithPriceStaleAfter sets the age past which prices are flagged as stale.
+func WithPriceStaleAfter(d time.Duration) Option {
 	return func(h *WebHandler) {
-		h.templatesDir = dir
+		h.staleAfter = d
+	}
+}
+
+func WithEventPublisher(p events.Publisher) Option {
+	return func(h *WebHandler) {
+		h.events = p
+	}
+}
+
+// WithLiveUpdates pushes view refresh events to open pages over Server-Sent
+// Events whenever prices or transactions change.
+func WithLiveUpdates(bus events.Subscriber, hub *broadcast.Hub) Option {
+	return func(h *WebHandler) {
+		h.liveBus = bus
+		h.liveHub = hub
+	}
+}
+
+// WithFS sets where templates/ and static/ are read from. Defaults to the
+// assets embedded in the binary.
+func WithFS(fsys fs.FS) Option {
+	return func(h *WebHandler) {
+		h.fsys = fsys
+	}
+}
+
+// WithDevMode re-parses templates on every request so edits show up without
+// a restart.
+func WithDevMode(dev bool) Option {
+	return func(h *WebHandler) {
+		h.devMode = dev
+	}
+}
+
+// WithRequireAPIKey makes the UI ask for an API key before serving anything,
+// matching the /api setting. Settings always need the admin scope once signed
+// in.
+func WithRequireAPIKey(required bool) Option {
+	return func(h *WebHandler) {
+		h.requireAPIKey = required
 	}
 }
 
 func New(opts ...Option) (*WebHandler, error) {
 	h := &WebHandler{
-		templatesDir: "./internal/ui/templates",
+		fsys:       ui.FS(),
+		staleAfter: 5 * time.Minute,
 	}
 	for _, opt := range opts {
 		opt(h)
@@ -71,14 +125,26 @@ func New(opts ...Option) (*WebHandler, error) {
 	if h.repo == nil {
 		return nil, ErrNilRepository
 	}
-	h.renderer = NewRenderer(h.templatesDir)
+	templates, err := fs.Sub(h.fsys, "templates")
+	if err != nil {
+		return nil, err
+	}
+	h.renderer = NewRenderer(templates, h.devMode)
 	return h, nil
 }
 
 func (h *WebHandler) Setup() error {
-	h.engine.Static("/static", "./internal/ui/static")
+	static, err := fs.Sub(h.fsys, "static")
+	if err != nil {
+		return err
+	}
+	h.engine.StaticFS("/static", http.FS(static))
 
-	h.engine.SetFuncMap(template.FuncMap{
+	templates, err := fs.Sub(h.fsys, "templates")
+	if err != nil {
+		return err
+	}
+	partials, err := newPartialRender(templates, template.FuncMap{
 		"safeJS": func(s string) template.JS {
 			return template.JS(s)
 		},
@@ -88,57 +154,102 @@ func (h *WebHandler) Setup() error {
 		"sub": func(a, b int) int {
 			return a - b
 		},
-	})
-
-	partialsPath := filepath.Join(h.templatesDir, "partials", "*.html")
-	h.engine.LoadHTMLGlob(partialsPath)
+	}, h.devMode)
+	if err != nil {
+		return err
+	}
+	h.engine.HTMLRender = partials
 
 	dashboard := NewDashboardHandler(h.renderer, h.repo, h.priceCache)
 	portfolio := NewPortfolioHandler(h.renderer, h.repo, h.priceCache)
-	assets := NewAssetsPageHandler(h.renderer, h.repo, h.priceCache, h.priceFetcher)
-	exchanges := NewExchangesHandler(h.renderer, h.repo, h.priceCache, h.priceFetcher)
-	pricesHandler := NewPricesHandler(h.renderer, h.repo, h.priceCache)
+	assets := NewAssetsPageHandler(h.renderer, h.repo, h.priceCache, h.priceFetcher, h.events)
+	exchanges := NewExchangesHandler(h.renderer, h.repo, h.priceCache, h.priceFetcher, h.events)
+	pricesHandler := NewPricesHandler(h.renderer, h.repo, h.priceCache, h.staleAfter)
 	dataHandler := NewDataHandler(h.renderer, h.repo)
-
-	h.engine.GET("/", dashboard.Index)
-	h.engine.GET("/partials/dashboard/summary", dashboard.Summary)
-	h.engine.GET("/partials/dashboard/chart", dashboard.Chart)
-	h.engine.GET("/partials/dashboard/allocation", dashboard.Allocation)
-	h.engine.GET("/partials/dashboard/holdings", dashboard.Holdings)
-	h.engine.GET("/partials/dashboard/transactions", dashboard.Transactions)
-
-	h.engine.GET("/portfolio", portfolio.Index)
-	h.engine.GET("/partials/portfolio/summary", portfolio.Summary)
-	h.engine.GET("/partials/portfolio/chart", portfolio.Chart)
-	h.engine.GET("/partials/portfolio/holdings", portfolio.Holdings)
-	h.engine.GET("/partials/portfolio/performance", portfolio.Performance)
-
-	h.engine.GET("/assets", assets.Index)
-	h.engine.GET("/partials/assets/table", assets.Table)
-	h.engine.GET("/partials/assets/holdings", assets.Holdings)
-	h.engine.POST("/partials/assets/create", assets.Create)
-	h.engine.POST("/partials/assets/update/:id", assets.Update)
-	h.engine.DELETE("/partials/assets/delete/:id", assets.Delete)
-	h.engine.POST("/partials/assets/bulk-delete", assets.BulkDelete)
-	h.engine.GET("/api/ui/assets", assets.GetAssets)
-	h.engine.GET("/api/ui/cryptos", assets.GetSupportedCryptos)
-
-	h.engine.GET("/exchanges", exchanges.Index)
-	h.engine.GET("/partials/exchanges/table", exchanges.Table)
-	h.engine.POST("/partials/exchanges/create", exchanges.Create)
-	h.engine.POST("/partials/exchanges/update/:id", exchanges.Update)
-	h.engine.DELETE("/partials/exchanges/delete/:id", exchanges.Delete)
-	h.engine.POST("/partials/exchanges/bulk-delete", exchanges.BulkDelete)
-	h.engine.POST("/partials/exchanges/refresh-prices", exchanges.RefreshPrices)
-	h.engine.GET("/api/ui/holdings", exchanges.GetHoldings)
-
-	h.engine.GET("/prices", pricesHandler.Index)
-	h.engine.GET("/partials/prices/table", pricesHandler.Table)
-
-	h.engine.GET("/data", dataHandler.Index)
-	h.engine.GET("/partials/data/import-history", dataHandler.ImportHistory)
+	recurring := NewRecurringHandler(h.renderer, h.repo)
+	simulations := NewSimulationsHandler(h.renderer, h.repo)
+	portfolios := NewPortfoliosHandler(h.repo)
+	settings := NewSettingsHandler(h.renderer, h.repo)
+
+	h.engine.GET("/login", h.LoginPage)
+	h.engine.POST("/login", h.Login)
+	h.engine.POST("/logout", h.Logout)
+
+	ui := h.engine.Group("", h.authenticate(models.ScopeReadPortfolio, models.ScopeWriteTransactions))
+	admin := h.engine.Group("", h.authenticate(models.ScopeAdmin, models.ScopeAdmin))
+
+	ui.GET("/", dashboard.Index)
+	ui.GET("/partials/dashboard/summary", dashboard.Summary)
+	ui.GET("/partials/dashboard/chart", dashboard.Chart)
+	ui.GET("/partials/dashboard/allocation", dashboard.Allocation)
+	ui.GET("/partials/dashboard/holdings", dashboard.Holdings)
+	ui.GET("/partials/dashboard/transactions", dashboard.Transactions)
+
+	ui.GET("/portfolio", portfolio.Index)
+	ui.GET("/partials/portfolio/summary", portfolio.Summary)
+	ui.GET("/partials/portfolio/chart", portfolio.Chart)
+	ui.GET("/partials/portfolio/holdings", portfolio.Holdings)
+	ui.GET("/partials/portfolio/performance", portfolio.Performance)
+
+	ui.GET("/assets", assets.Index)
+	ui.GET("/partials/assets/table", assets.Table)
+	ui.GET("/partials/assets/holdings", assets.Holdings)
+	ui.POST("/partials/assets/create", assets.Create)
+	ui.POST("/partials/assets/update/:id", assets.Update)
+	ui.DELETE("/partials/assets/delete/:id", assets.Delete)
+	ui.POST("/partials/assets/bulk-delete", assets.BulkDelete)
+	ui.GET("/api/ui/assets", assets.GetAssets)
+	ui.GET("/api/ui/cryptos", assets.GetSupportedCryptos)
+
+	ui.GET("/exchanges", exchanges.Index)
+	ui.GET("/partials/exchanges/table", exchanges.Table)
+	ui.POST("/partials/exchanges/create", exchanges.Create)
+	ui.POST("/partials/exchanges/update/:id", exchanges.Update)
+	ui.DELETE("/partials/exchanges/delete/:id", exchanges.Delete)
+	ui.POST("/partials/exchanges/bulk-delete", exchanges.BulkDelete)
+	ui.POST("/partials/exchanges/refresh-prices", exchanges.RefreshPrices)
+	ui.GET("/api/ui/holdings", exchanges.GetHoldings)
+
+	ui.GET("/prices", pricesHandler.Index)
+	ui.GET("/partials/prices/table", pricesHandler.Table)
+	ui.GET("/partials/prices/manual", pricesHandler.ManualPrices)
+
+	ui.GET("/data", dataHandler.Index)
+	ui.GET("/partials/data/import-history", dataHandler.ImportHistory)
+
+	ui.GET("/recurring", recurring.Index)
+	ui.GET("/partials/recurring/schedules", recurring.Schedules)
+	ui.GET("/partials/recurring/runs", recurring.Runs)
+
+	ui.GET("/simulations", simulations.Index)
+
+	ui.GET("/partials/portfolios/switcher", portfolios.Switcher)
+	h.engine.POST("/partials/portfolios/select", h.authenticate(models.ScopeReadPortfolio, models.ScopeReadPortfolio), portfolios.Select)
+
+	admin.GET("/settings", settings.Index)
+	admin.GET("/partials/settings/api-keys", settings.APIKeys)
+	admin.POST("/partials/settings/api-keys/create", settings.CreateAPIKey)
+	admin.DELETE("/partials/settings/api-keys/revoke/:id", settings.RevokeAPIKey)
+	admin.GET("/partials/settings/notifications", settings.NotificationChannels)
+	admin.POST("/partials/settings/notifications/create", settings.CreateNotificationChannel)
+	admin.POST("/partials/settings/notifications/test/:id", settings.TestNotificationChannel)
+	admin.DELETE("/partials/settings/notifications/delete/:id", settings.DeleteNotificationChannel)
 
 	h.engine.GET("/api/health", Health)
 
+	if h.liveBus != nil && h.liveHub != nil {
+		live := NewLiveUpdates(h.liveHub)
+		if err := live.Subscribe(h.liveBus); err != nil {
+			return err
+		}
+		ui.GET("/partials/live/stream", live.Stream)
+	}
+
 	return nil
 }
+
+func publish(c *gin.Context, p events.Publisher, topic string, payload any) {
+	if p != nil {
+		p.Publish(c.Request.Context(), topic, payload)
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hodlbook/pkg/integrations/broadcast"
+	"hodlbook/pkg/types/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Live update events. Each names a view fragment; pages refetch the fragment
+// with hx-trigger="sse:<event>" when it arrives.
+const (
+	LiveDashboardSummary    = "dashboard_summary"
+	LiveDashboardHoldings   = "dashboard_holdings"
+	LiveDashboardAllocation = "dashboard_allocation"
+	LivePortfolioHoldings   = "portfolio_holdings"
+)
+
+var liveViews = []string{
+	LiveDashboardSummary,
+	LiveDashboardHoldings,
+	LiveDashboardAllocation,
+	LivePortfolioHoldings,
+}
+
+var liveTopics = []string{
+	events.TopicPricesUpdated,
+	"asset.*",
+	"exchange.*",
+	events.TopicImportCompleted,
+}
+
+var (
+	liveDebounce          = 500 * time.Millisecond
+	liveHeartbeatInterval = 15 * time.Second
+)
+
+// LiveUpdates turns price and transaction events into view refresh events on
+// a broadcast hub. Bursts such as a bulk import are coalesced into a single
+// refresh per view.
+type LiveUpdates struct {
+	hub *broadcast.Hub
+
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+}
+
+func NewLiveUpdates(hub *broadcast.Hub) *LiveUpdates {
+	return &LiveUpdates{
+		hub:     hub,
+		pending: make(map[string]bool),
+	}
+}
+
+func (l *LiveUpdates) Subscribe(bus events.Subscriber) error {
+	for _, topic := range liveTopics {
+		err := bus.Subscribe(topic, l.handle, events.SubscribeOptions{
+			Name:   "ui_live",
+			Policy: events.DropOldest,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *LiveUpdates) handle(_ context.Context, e events.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pending[e.Topic] = true
+	if l.timer == nil {
+		l.timer = time.AfterFunc(liveDebounce, l.flush)
+	}
+	return nil
+}
+
+func (l *LiveUpdates) flush() {
+	l.mu.Lock()
+	topics := make([]string, 0, len(l.pending))
+	for topic := range l.pending {
+		topics = append(topics, topic)
+	}
+	l.pending = make(map[string]bool)
+	l.timer = nil
+	l.mu.Unlock()
+
+	sort.Strings(topics)
+	data := []byte(strings.Join(topics, ","))
+	for _, view := range liveViews {
+		l.hub.Publish(view, data)
+	}
+}
+
+// Stream is the Server-Sent Events endpoint the pages connect to with the
+// htmx sse extension. Reconnecting clients resume from Last-Event-ID.
+func (l *LiveUpdates) Stream(c *gin.Context) {
+	l.hub.Stream(c.Writer, c.Request, liveHeartbeatInterval)
+}
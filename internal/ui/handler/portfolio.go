@@ -28,16 +28,18 @@ func NewPortfolioHandler(renderer *Renderer, repository *repo.Repository, priceC
 }
 
 type PortfolioPageData struct {
-	Title      string
-	PageTitle  string
-	ActivePage string
+	Title       string
+	PageTitle   string
+	ActivePage  string
+	PortfolioID int64
 }
 
 func (h *PortfolioHandler) Index(c *gin.Context) {
 	data := PortfolioPageData{
-		Title:      "Portfolio",
-		PageTitle:  "Portfolio",
-		ActivePage: "portfolio",
+		Title:       "Portfolio",
+		PageTitle:   "Portfolio",
+		ActivePage:  "portfolio",
+		PortfolioID: selectedPortfolioID(c),
 	}
 	h.renderer.HTML(c, http.StatusOK, "portfolio", data)
 }
@@ -54,9 +56,11 @@ type PortfolioSummaryData struct {
 }
 
 func (h *PortfolioHandler) Summary(c *gin.Context) {
-	holdings, currentValue := h.calculatePortfolio()
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+	portfolioID := selectedPortfolioID(c)
+
+	holdings, currentValue := h.calculatePortfolio(portfolioID)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	var totalInvested float64
 	for symbol, amount := range holdings {
@@ -95,6 +99,8 @@ type PortfolioChartData struct {
 }
 
 func (h *PortfolioHandler) Chart(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	rangeParam := c.DefaultQuery("range", "30d")
 	days := parseDays(rangeParam)
 
@@ -124,7 +130,7 @@ func (h *PortfolioHandler) Chart(c *gin.Context) {
 		labelStr := date.Format("Jan 2")
 
 		endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-		holdings := h.calculateHoldingsAtDate(endOfDay)
+		holdings := h.calculateHoldingsAtDate(portfolioID, endOfDay)
 
 		var dailyValue float64
 		for symbol, amount := range holdings {
@@ -195,11 +201,13 @@ type HoldingRow struct {
 }
 
 func (h *PortfolioHandler) Holdings(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	sortBy := c.DefaultQuery("sort", "value")
 
-	holdings, totalValue := h.calculatePortfolio()
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+	holdings, totalValue := h.calculatePortfolio(portfolioID)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	var rows []HoldingRow
 	for symbol, amount := range holdings {
@@ -286,11 +294,13 @@ type PerformanceRow struct {
 }
 
 func (h *PortfolioHandler) Performance(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	sortBy := c.DefaultQuery("sort", c.DefaultQuery("perf_sort", "value"))
 
-	holdings, _ := h.calculatePortfolio()
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+	holdings, _ := h.calculatePortfolio(portfolioID)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	var rows []PerformanceRow
 	var totalCost, totalValue, totalPnL float64
@@ -359,32 +369,14 @@ func (h *PortfolioHandler) Performance(c *gin.Context) {
 	c.HTML(http.StatusOK, "portfolio_performance.html", data)
 }
 
-func (h *PortfolioHandler) calculateHoldings() map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings
+func (h *PortfolioHandler) calculateHoldings(portfolioID int64) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, time.Time{})
 }
 
-func (h *PortfolioHandler) calculatePortfolio() (holdings map[string]float64, totalValue float64) {
-	holdings = h.calculateHoldings()
+func (h *PortfolioHandler) calculatePortfolio(portfolioID int64) (holdings map[string]float64, totalValue float64) {
+	holdings = h.calculateHoldings(portfolioID)
 
 	for symbol, amount := range holdings {
 		if amount <= 0 {
@@ -404,7 +396,7 @@ type costBasisEvent struct {
 	exchange  *models.Exchange
 }
 
-func (h *PortfolioHandler) calculateCostBasis(assets []models.Asset) map[string]float64 {
+func (h *PortfolioHandler) calculateCostBasis(portfolioID int64, assets []models.Asset) map[string]float64 {
 	costBasis := make(map[string]float64)
 	runningHoldings := make(map[string]float64)
 
@@ -417,7 +409,7 @@ func (h *PortfolioHandler) calculateCostBasis(assets []models.Asset) map[string]
 		})
 	}
 
-	exchanges, _ := h.repo.GetAllExchanges()
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
 	for i := range exchanges {
 		events = append(events, costBasisEvent{
 			timestamp: exchanges[i].Timestamp,
@@ -437,25 +429,25 @@ func (h *PortfolioHandler) calculateCostBasis(assets []models.Asset) map[string]
 			switch asset.TransactionType {
 			case "deposit":
 				price := h.getPriceAtTime(asset.Symbol, asset.Timestamp)
-				costBasis[asset.Symbol] += asset.Amount * price
-				runningHoldings[asset.Symbol] += asset.Amount
+				costBasis[asset.Symbol] += asset.Amount.InexactFloat64() * price
+				runningHoldings[asset.Symbol] += asset.Amount.InexactFloat64()
 			case "withdraw":
 				if runningHoldings[asset.Symbol] > 0 {
 					avgCost := costBasis[asset.Symbol] / runningHoldings[asset.Symbol]
-					costBasis[asset.Symbol] -= asset.Amount * avgCost
+					costBasis[asset.Symbol] -= asset.Amount.InexactFloat64() * avgCost
 				}
-				runningHoldings[asset.Symbol] -= asset.Amount
+				runningHoldings[asset.Symbol] -= asset.Amount.InexactFloat64()
 			}
 		case "exchange":
 			ex := event.exchange
 			if runningHoldings[ex.FromSymbol] > 0 {
 				avgCost := costBasis[ex.FromSymbol] / runningHoldings[ex.FromSymbol]
-				transferredCost := ex.FromAmount * avgCost
+				transferredCost := ex.FromAmount.InexactFloat64() * avgCost
 				costBasis[ex.FromSymbol] -= transferredCost
 				costBasis[ex.ToSymbol] += transferredCost
 			}
-			runningHoldings[ex.FromSymbol] -= ex.FromAmount
-			runningHoldings[ex.ToSymbol] += ex.ToAmount
+			runningHoldings[ex.FromSymbol] -= ex.FromAmount.InexactFloat64()
+			runningHoldings[ex.ToSymbol] += ex.ToAmount.InexactFloat64()
 		}
 	}
 
@@ -465,38 +457,16 @@ func (h *PortfolioHandler) calculateCostBasis(assets []models.Asset) map[string]
 func (h *PortfolioHandler) getPriceAtTime(symbol string, timestamp time.Time) float64 {
 	priceRecord, err := h.repo.GetPriceAtTime(symbol, "USD", timestamp)
 	if err == nil && priceRecord != nil {
-		return priceRecord.Price
+		return priceRecord.Price.InexactFloat64()
 	}
 	price, _ := h.priceCache.Get(symbol)
 	return price
 }
 
-func (h *PortfolioHandler) calculateHoldingsAtDate(targetDate time.Time) map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-	for _, asset := range assets {
-		if asset.Timestamp.After(targetDate) {
-			continue
-		}
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-	for _, ex := range exchanges {
-		if ex.Timestamp.After(targetDate) {
-			continue
-		}
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings
+func (h *PortfolioHandler) calculateHoldingsAtDate(portfolioID int64, targetDate time.Time) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, targetDate)
 }
 
 func formatPercentNoSign(value float64) string {
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const portfolioCookie = "portfolio_id"
+
+type PortfoliosHandler struct {
+	repo *repo.Repository
+}
+
+func NewPortfoliosHandler(repository *repo.Repository) *PortfoliosHandler {
+	return &PortfoliosHandler{
+		repo: repository,
+	}
+}
+
+type PortfolioSwitcherData struct {
+	Portfolios []PortfolioOption
+	SelectedID int64
+}
+
+type PortfolioOption struct {
+	ID   int64
+	Name string
+}
+
+func (h *PortfoliosHandler) Switcher(c *gin.Context) {
+	portfolios, _ := h.repo.ListPortfolios()
+
+	data := PortfolioSwitcherData{
+		SelectedID: selectedPortfolioID(c),
+	}
+	for _, p := range portfolios {
+		data.Portfolios = append(data.Portfolios, PortfolioOption{
+			ID:   p.ID,
+			Name: p.Name,
+		})
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "portfolio_switcher.html", data)
+}
+
+func (h *PortfoliosHandler) Select(c *gin.Context) {
+	id, err := strconv.ParseInt(c.PostForm("portfolio_id"), 10, 64)
+	if err != nil || id < 0 {
+		id = 0
+	}
+	if id > 0 {
+		if _, err := h.repo.GetPortfolioByID(id); err != nil {
+			c.Header("HX-Trigger", `{"show-toast": {"message": "Portfolio not found", "type": "error"}}`)
+			c.Status(http.StatusOK)
+			return
+		}
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(portfolioCookie, strconv.FormatInt(id, 10), 365*24*60*60, "/", "", false, true)
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusOK)
+}
+
+// selectedPortfolioID returns the portfolio chosen in the sidebar switcher.
+// Zero selects the consolidated view across all portfolios.
+func selectedPortfolioID(c *gin.Context) int64 {
+	value, err := c.Cookie(portfolioCookie)
+	if err != nil {
+		return 0
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
@@ -3,7 +3,9 @@ package handler
 import (
 	"net/http"
 	"sort"
+	"time"
 
+	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
 	"hodlbook/pkg/types/cache"
 
@@ -14,13 +16,15 @@ type PricesHandler struct {
 	renderer   *Renderer
 	repo       *repo.Repository
 	priceCache cache.Cache[string, float64]
+	staleAfter time.Duration
 }
 
-func NewPricesHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64]) *PricesHandler {
+func NewPricesHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64], staleAfter time.Duration) *PricesHandler {
 	return &PricesHandler{
 		renderer:   renderer,
 		repo:       repository,
 		priceCache: priceCache,
+		staleAfter: staleAfter,
 	}
 }
 
@@ -52,23 +56,48 @@ type PriceRow struct {
 	Holdings string
 	Value    string
 	ValueRaw float64
+	// Stale is set when the price could not be refreshed recently; AsOf is
+	// when it was last fetched or entered, empty if never.
+	Stale  bool
+	Manual bool
+	AsOf   string
 }
 
 func (h *PricesHandler) Table(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	symbols := h.getAllSymbols()
-	holdings := h.calculateHoldings()
+	holdings := h.calculateHoldings(portfolioID)
 
 	symbolNames := make(map[string]string)
-	assets, _ := h.repo.GetAllAssets()
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
 	for _, asset := range assets {
 		if _, exists := symbolNames[asset.Symbol]; !exists {
 			symbolNames[asset.Symbol] = asset.Name
 		}
 	}
 
+	lastKnown := make(map[string]models.LastKnownPrice)
+	stored, _ := h.repo.GetLastKnownPrices()
+	for _, p := range stored {
+		lastKnown[p.Symbol] = p
+	}
+	now := time.Now()
+
 	var rows []PriceRow
 	for _, symbol := range symbols {
 		price, _ := h.priceCache.Get(symbol)
+		stale := price <= 0
+		var manual bool
+		var asOf string
+		if p, ok := lastKnown[symbol]; ok {
+			if price <= 0 {
+				price = p.Price.InexactFloat64()
+			}
+			stale = p.IsStale(now, h.staleAfter)
+			manual = p.Source == models.PriceSourceManual
+			asOf = p.FetchedAt.Local().Format("2006-01-02 15:04")
+		}
 		amount := holdings[symbol]
 		value := amount * price
 
@@ -85,6 +114,9 @@ func (h *PricesHandler) Table(c *gin.Context) {
 			Holdings: formatAmount(amount),
 			Value:    formatCurrency(value, "USD"),
 			ValueRaw: value,
+			Stale:    stale,
+			Manual:   manual,
+			AsOf:     asOf,
 		})
 	}
 
@@ -101,28 +133,43 @@ func (h *PricesHandler) Table(c *gin.Context) {
 	c.HTML(http.StatusOK, "prices_table.html", data)
 }
 
-func (h *PricesHandler) calculateHoldings() map[string]float64 {
-	holdings := make(map[string]float64)
+type ManualPriceView struct {
+	ID        int64
+	Symbol    string
+	Price     string
+	Timestamp string
+	Expires   string
+}
 
-	assets, _ := h.repo.GetAllAssets()
+// ManualPrices lists the prices entered by hand, newest first.
+func (h *PricesHandler) ManualPrices(c *gin.Context) {
+	stored, _ := h.repo.ListManualPrices("")
 
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
+	views := make([]ManualPriceView, 0, len(stored))
+	for _, p := range stored {
+		expires := "Never"
+		if p.ExpiresAt != nil {
+			expires = p.ExpiresAt.Local().Format("2006-01-02 15:04")
 		}
+		views = append(views, ManualPriceView{
+			ID:        p.ID,
+			Symbol:    p.Symbol,
+			Price:     formatPrice(p.Price.InexactFloat64()),
+			Timestamp: p.Timestamp.Local().Format("2006-01-02 15:04"),
+			Expires:   expires,
+		})
 	}
 
-	exchanges, _ := h.repo.GetAllExchanges()
-
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "prices_manual.html", gin.H{
+		"Prices": views,
+	})
+}
 
-	return holdings
+func (h *PricesHandler) calculateHoldings(portfolioID int64) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, time.Time{})
 }
 
 func (h *PricesHandler) getAllSymbols() []string {
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecurringHandler struct {
+	renderer *Renderer
+	repo     *repo.Repository
+}
+
+func NewRecurringHandler(renderer *Renderer, repository *repo.Repository) *RecurringHandler {
+	return &RecurringHandler{
+		renderer: renderer,
+		repo:     repository,
+	}
+}
+
+type RecurringPageData struct {
+	Title       string
+	PageTitle   string
+	ActivePage  string
+	PortfolioID int64
+}
+
+func (h *RecurringHandler) Index(c *gin.Context) {
+	data := RecurringPageData{
+		Title:       "Recurring",
+		PageTitle:   "Recurring Transactions",
+		ActivePage:  "recurring",
+		PortfolioID: selectedPortfolioID(c),
+	}
+	h.renderer.HTML(c, http.StatusOK, "recurring", data)
+}
+
+type RecurringView struct {
+	ID          int64
+	Name        string
+	Description string
+	Cadence     string
+	Confirm     bool
+	Enabled     bool
+	Finished    bool
+	NextRun     string
+	EndsAt      string
+	LastError   string
+}
+
+// Schedules lists the recurring transactions of the selected portfolio.
+func (h *RecurringHandler) Schedules(c *gin.Context) {
+	list, _ := h.repo.ListRecurring(selectedPortfolioID(c))
+
+	views := make([]RecurringView, 0, len(list))
+	for _, r := range list {
+		view := RecurringView{
+			ID:          r.ID,
+			Name:        r.Name,
+			Description: recurringDescription(r.Type, r.Amount.String(), r.AmountUnit, r.FromSymbol, r.Symbol),
+			Cadence:     r.Cadence,
+			Confirm:     r.RequireConfirmation,
+			Enabled:     r.Enabled,
+			Finished:    r.Finished(),
+			NextRun:     r.NextRunAt.Local().Format("2006-01-02 15:04"),
+			LastError:   r.LastError,
+		}
+		if r.EndAt != nil {
+			view.EndsAt = r.EndAt.Local().Format("2006-01-02")
+		}
+		views = append(views, view)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "recurring_schedules.html", gin.H{
+		"Schedules": views,
+	})
+}
+
+type RecurringRunView struct {
+	ID          int64
+	Name        string
+	ScheduledAt string
+	Description string
+	Price       string
+	Status      string
+}
+
+// Runs lists the pending runs of the selected portfolio followed by the
+// most recent resolved ones.
+func (h *RecurringHandler) Runs(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+	runs, _ := h.repo.ListRecurringRuns(0, "")
+
+	var pending, recent []RecurringRunView
+	for _, run := range runs {
+		if portfolioID > 0 && run.PortfolioID != portfolioID {
+			continue
+		}
+		view := RecurringRunView{
+			ID:          run.ID,
+			Name:        run.Name,
+			ScheduledAt: run.ScheduledAt.Local().Format("2006-01-02 15:04"),
+			Description: recurringDescription(run.Type, run.Amount.String(), models.RecurringAmountCoin, run.FromSymbol, run.Symbol),
+			Status:      run.Status,
+		}
+		if run.Type == "exchange" {
+			view.Description = run.FromAmount.String() + " " + view.Description
+		}
+		if run.Price.IsPositive() {
+			view.Price = formatPrice(run.Price.InexactFloat64())
+		}
+		if run.Status == models.RecurringRunPending {
+			pending = append(pending, view)
+		} else if len(recent) < 20 {
+			recent = append(recent, view)
+		}
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "recurring_runs.html", gin.H{
+		"Pending": pending,
+		"Recent":  recent,
+	})
+}
+
+// recurringDescription reads as "deposit 0.01 BTC", "withdraw $50 of ETH"
+// or "exchange USDT for 0.01 BTC".
+func recurringDescription(kind, amount, unit, from, symbol string) string {
+	quantity := amount + " " + symbol
+	if unit == models.RecurringAmountFiat {
+		quantity = "$" + amount + " of " + symbol
+	}
+	if kind == "exchange" {
+		return from + " for " + quantity
+	}
+	return kind + " " + quantity
+}
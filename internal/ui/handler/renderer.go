@@ -4,25 +4,31 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"path/filepath"
+	"io/fs"
+	"net/http"
 	"strconv"
 	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 )
 
+// Renderer renders full pages from a templates tree laid out as layouts/,
+// pages/ and partials/. Parsed pages are cached unless reload is set.
 type Renderer struct {
-	templatesDir string
-	templates    map[string]*template.Template
-	mu           sync.RWMutex
-	funcs        template.FuncMap
+	fsys      fs.FS
+	reload    bool
+	templates map[string]*template.Template
+	mu        sync.RWMutex
+	funcs     template.FuncMap
 }
 
-func NewRenderer(templatesDir string) *Renderer {
+func NewRenderer(fsys fs.FS, reload bool) *Renderer {
 	return &Renderer{
-		templatesDir: templatesDir,
-		templates:    make(map[string]*template.Template),
-		funcs:        defaultFuncs(),
+		fsys:      fsys,
+		reload:    reload,
+		templates: make(map[string]*template.Template),
+		funcs:     defaultFuncs(),
 	}
 }
 
@@ -176,6 +182,10 @@ func formatPercent(value float64) string {
 }
 
 func (r *Renderer) loadTemplate(name string) (*template.Template, error) {
+	if r.reload {
+		return r.parseTemplate(name)
+	}
+
 	r.mu.RLock()
 	if tmpl, ok := r.templates[name]; ok {
 		r.mu.RUnlock()
@@ -190,16 +200,7 @@ func (r *Renderer) loadTemplate(name string) (*template.Template, error) {
 		return tmpl, nil
 	}
 
-	layoutPath := filepath.Join(r.templatesDir, "layouts", "base.html")
-	pagePath := filepath.Join(r.templatesDir, "pages", name+".html")
-	componentsGlob := filepath.Join(r.templatesDir, "partials", "components", "*.html")
-
-	tmpl, err := template.New("").Funcs(r.funcs).ParseGlob(componentsGlob)
-	if err != nil {
-		tmpl = template.New("").Funcs(r.funcs)
-	}
-
-	tmpl, err = tmpl.ParseFiles(layoutPath, pagePath)
+	tmpl, err := r.parseTemplate(name)
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +209,15 @@ func (r *Renderer) loadTemplate(name string) (*template.Template, error) {
 	return tmpl, nil
 }
 
+func (r *Renderer) parseTemplate(name string) (*template.Template, error) {
+	tmpl, err := template.New("").Funcs(r.funcs).ParseFS(r.fsys, "partials/components/*.html")
+	if err != nil {
+		tmpl = template.New("").Funcs(r.funcs)
+	}
+
+	return tmpl.ParseFS(r.fsys, "layouts/base.html", "pages/"+name+".html")
+}
+
 func (r *Renderer) Render(w io.Writer, name string, data any) error {
 	tmpl, err := r.loadTemplate(name)
 	if err != nil {
@@ -217,12 +227,11 @@ func (r *Renderer) Render(w io.Writer, name string, data any) error {
 }
 
 func (r *Renderer) RenderPartial(w io.Writer, name string, data any) error {
-	partialPath := filepath.Join(r.templatesDir, "partials", name+".html")
-	tmpl, err := template.New("").Funcs(r.funcs).ParseFiles(partialPath)
+	tmpl, err := template.New("").Funcs(r.funcs).ParseFS(r.fsys, "partials/"+name+".html")
 	if err != nil {
 		return err
 	}
-	return tmpl.ExecuteTemplate(w, filepath.Base(partialPath), data)
+	return tmpl.ExecuteTemplate(w, name+".html", data)
 }
 
 func (r *Renderer) HTML(c *gin.Context, code int, name string, data any) {
@@ -240,3 +249,54 @@ func (r *Renderer) Partial(c *gin.Context, code int, name string, data any) {
 		c.String(500, "Template error: %v", err)
 	}
 }
+
+// partialRender lets gin's c.HTML render the partials from an fs.FS. With
+// reload set the partials are parsed again on every render.
+type partialRender struct {
+	fsys   fs.FS
+	funcs  template.FuncMap
+	reload bool
+	tmpl   *template.Template
+}
+
+func newPartialRender(fsys fs.FS, funcs template.FuncMap, reload bool) (*partialRender, error) {
+	p := &partialRender{fsys: fsys, funcs: funcs, reload: reload}
+	tmpl, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.tmpl = tmpl
+	return p, nil
+}
+
+func (p *partialRender) parse() (*template.Template, error) {
+	return template.New("").Funcs(p.funcs).ParseFS(p.fsys, "partials/*.html")
+}
+
+func (p *partialRender) Instance(name string, data any) render.Render {
+	tmpl := p.tmpl
+	if p.reload {
+		var err error
+		if tmpl, err = p.parse(); err != nil {
+			return templateError{err: err}
+		}
+	}
+	return render.HTML{Template: tmpl, Name: name, Data: data}
+}
+
+// templateError answers with a 500 when the partials no longer parse, so a
+// typo while editing templates in dev mode does not take down the request.
+type templateError struct {
+	err error
+}
+
+func (e templateError) Render(w http.ResponseWriter) error {
+	e.WriteContentType(w)
+	w.WriteHeader(http.StatusInternalServerError)
+	_, err := fmt.Fprintf(w, "Template error: %v", e.err)
+	return err
+}
+
+func (e templateError) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialRender_ReloadParseError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fsys := fstest.MapFS{"partials/row.html": {Data: []byte(`<td>{{.}}</td>`)}}
+	partials, err := newPartialRender(fsys, defaultFuncs(), true)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.HTMLRender = partials
+	router.GET("/row", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "row.html", "BTC")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/row", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<td>BTC</td>", w.Body.String())
+
+	fsys["partials/row.html"] = &fstest.MapFile{Data: []byte(`<td>{{.}</td>`)}
+	w = httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/row", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Template error")
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/models"
+	"hodlbook/internal/repo"
+	"hodlbook/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsHandler struct {
+	renderer *Renderer
+	repo     *repo.Repository
+}
+
+func NewSettingsHandler(renderer *Renderer, repository *repo.Repository) *SettingsHandler {
+	return &SettingsHandler{
+		renderer: renderer,
+		repo:     repository,
+	}
+}
+
+type SettingsPageData struct {
+	Title        string
+	PageTitle    string
+	ActivePage   string
+	Scopes       []string
+	ChannelTypes []string
+	SignedIn     bool
+}
+
+type APIKeyView struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Scopes     []string
+	LastUsedAt string
+	ExpiresAt  string
+	Status     string
+}
+
+type APIKeysData struct {
+	Keys   []APIKeyView
+	NewKey string
+}
+
+func (h *SettingsHandler) Index(c *gin.Context) {
+	data := SettingsPageData{
+		Title:        "Settings",
+		PageTitle:    "Settings",
+		ActivePage:   "settings",
+		Scopes:       models.APIKeyScopes,
+		ChannelTypes: models.NotificationChannelTypes,
+	}
+	if cookie, err := c.Cookie(models.APIKeyCookie); err == nil && cookie != "" {
+		data.SignedIn = true
+	}
+	h.renderer.HTML(c, http.StatusOK, "settings", data)
+}
+
+func (h *SettingsHandler) APIKeys(c *gin.Context) {
+	h.renderAPIKeys(c, "")
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `form:"name" binding:"required"`
+	Scopes        []string `form:"scopes"`
+	ExpiresInDays int      `form:"expires_in_days"`
+}
+
+func (h *SettingsHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBind(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Name is required", "type": "error"}}`)
+		h.renderAPIKeys(c, "")
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	_, raw, err := h.repo.CreateAPIKey(strings.TrimSpace(req.Name), req.Scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, repo.ErrAPIKeyNoScopes) {
+			c.Header("HX-Trigger", `{"show-toast": {"message": "Select at least one scope", "type": "error"}}`)
+		} else {
+			c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to create API key", "type": "error"}}`)
+		}
+		h.renderAPIKeys(c, "")
+		return
+	}
+
+	c.Header("HX-Trigger", `{"show-toast": {"message": "API key created", "type": "success"}}`)
+	h.renderAPIKeys(c, raw)
+}
+
+func (h *SettingsHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Invalid API key ID", "type": "error"}}`)
+		h.renderAPIKeys(c, "")
+		return
+	}
+
+	if err := h.repo.RevokeAPIKey(id); err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to revoke API key", "type": "error"}}`)
+		h.renderAPIKeys(c, "")
+		return
+	}
+
+	c.Header("HX-Trigger", `{"show-toast": {"message": "API key revoked", "type": "success"}}`)
+	h.renderAPIKeys(c, "")
+}
+
+func (h *SettingsHandler) renderAPIKeys(c *gin.Context, newKey string) {
+	keys, _ := h.repo.ListAPIKeys()
+	now := time.Now()
+
+	data := APIKeysData{NewKey: newKey}
+	for i := range keys {
+		key := &keys[i]
+		view := APIKeyView{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.ScopeList(),
+			LastUsedAt: "Never",
+			ExpiresAt:  "Never",
+			Status:     "active",
+		}
+		if key.LastUsedAt != nil {
+			view.LastUsedAt = key.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		if key.ExpiresAt != nil {
+			view.ExpiresAt = key.ExpiresAt.Format("2006-01-02")
+		}
+		switch {
+		case key.RevokedAt != nil:
+			view.Status = "revoked"
+		case !key.IsActive(now):
+			view.Status = "expired"
+		}
+		data.Keys = append(data.Keys, view)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "settings_api_keys.html", data)
+}
+
+type CreateNotificationChannelRequest struct {
+	Name     string `form:"name"`
+	Type     string `form:"type"`
+	URL      string `form:"url"`
+	Topic    string `form:"topic"`
+	ChatID   string `form:"chat_id"`
+	SMTPHost string `form:"smtp_host"`
+	SMTPPort int    `form:"smtp_port"`
+	Username string `form:"username"`
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Secret   string `form:"secret"`
+}
+
+func (h *SettingsHandler) NotificationChannels(c *gin.Context) {
+	h.renderNotificationChannels(c)
+}
+
+func (h *SettingsHandler) CreateNotificationChannel(c *gin.Context) {
+	var req CreateNotificationChannelRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Invalid channel", "type": "error"}}`)
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	channel := models.NotificationChannel{
+		Name:     strings.TrimSpace(req.Name),
+		Type:     req.Type,
+		Enabled:  true,
+		URL:      strings.TrimSpace(req.URL),
+		Topic:    strings.TrimSpace(req.Topic),
+		ChatID:   strings.TrimSpace(req.ChatID),
+		SMTPHost: strings.TrimSpace(req.SMTPHost),
+		SMTPPort: req.SMTPPort,
+		Username: req.Username,
+		From:     strings.TrimSpace(req.From),
+		To:       req.To,
+		Secret:   req.Secret,
+	}
+	if err := channel.Validate(); err != nil {
+		h.toastError(c, err.Error())
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	if err := h.repo.CreateNotificationChannel(&channel); err != nil {
+		h.toastError(c, "Failed to create channel")
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	c.Header("HX-Trigger", `{"show-toast": {"message": "Channel created", "type": "success"}}`)
+	h.renderNotificationChannels(c)
+}
+
+func (h *SettingsHandler) TestNotificationChannel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.toastError(c, "Invalid channel ID")
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	channel, err := h.repo.GetNotificationChannelByID(id)
+	if err != nil {
+		h.toastError(c, "Channel not found")
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	if err := service.SendTestNotification(c.Request.Context(), channel); err != nil {
+		h.toastError(c, "Test failed: "+err.Error())
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	c.Header("HX-Trigger", `{"show-toast": {"message": "Test notification sent", "type": "success"}}`)
+	h.renderNotificationChannels(c)
+}
+
+func (h *SettingsHandler) DeleteNotificationChannel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.toastError(c, "Invalid channel ID")
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	if err := h.repo.DeleteNotificationChannel(id); err != nil {
+		h.toastError(c, "Failed to delete channel")
+		h.renderNotificationChannels(c)
+		return
+	}
+
+	c.Header("HX-Trigger", `{"show-toast": {"message": "Channel deleted", "type": "success"}}`)
+	h.renderNotificationChannels(c)
+}
+
+func (h *SettingsHandler) toastError(c *gin.Context, message string) {
+	payload, _ := json.Marshal(map[string]any{
+		"show-toast": map[string]string{"message": message, "type": "error"},
+	})
+	c.Header("HX-Trigger", string(payload))
+}
+
+func (h *SettingsHandler) renderNotificationChannels(c *gin.Context) {
+	channels, _ := h.repo.ListNotificationChannels()
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.HTML(http.StatusOK, "settings_notifications.html", gin.H{"Channels": channels})
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SimulationsHandler struct {
+	renderer *Renderer
+	repo     *repo.Repository
+}
+
+func NewSimulationsHandler(renderer *Renderer, repository *repo.Repository) *SimulationsHandler {
+	return &SimulationsHandler{
+		renderer: renderer,
+		repo:     repository,
+	}
+}
+
+type SimulationsPageData struct {
+	Title       string
+	PageTitle   string
+	ActivePage  string
+	PortfolioID int64
+	Symbols     []string
+}
+
+// Index renders the simulator, offering the symbols that have a price
+// history as targets.
+func (h *SimulationsHandler) Index(c *gin.Context) {
+	symbols, _ := h.repo.GetHistoricSymbols()
+	sort.Strings(symbols)
+
+	data := SimulationsPageData{
+		Title:       "Simulations",
+		PageTitle:   "Strategy Simulations",
+		ActivePage:  "simulations",
+		PortfolioID: selectedPortfolioID(c),
+		Symbols:     symbols,
+	}
+	h.renderer.HTML(c, http.StatusOK, "simulations", data)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/internal/repo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tablePageSize = 20
+
+// tableQuery holds the filter, sort and paging parameters shared by the
+// assets and exchanges tables.
+type tableQuery struct {
+	Page      int
+	SortBy    string
+	SortDir   string
+	Search    string
+	After     string
+	Before    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+func parseTableQuery(c *gin.Context) tableQuery {
+	q := tableQuery{
+		SortBy:  c.DefaultQuery("sort", "date"),
+		SortDir: c.DefaultQuery("dir", repo.SortDesc),
+		Search:  strings.TrimSpace(c.Query("q")),
+		After:   c.Query("after"),
+		Before:  c.Query("before"),
+	}
+
+	q.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if q.Page < 1 || (q.After == "" && q.Before == "") {
+		q.Page = 1
+	}
+
+	if t, err := time.Parse("2006-01-02", c.Query("from")); err == nil {
+		q.StartDate = &t
+	}
+	if t, err := time.Parse("2006-01-02", c.Query("to")); err == nil {
+		endOfDay := t.Add(24*time.Hour - time.Second)
+		q.EndDate = &endOfDay
+	}
+	return q
+}
+
+func totalPages(total int64) int {
+	pages := int((total + tablePageSize - 1) / tablePageSize)
+	if pages < 1 {
+		return 1
+	}
+	return pages
+}
+
+// querySymbols collects a repeated or comma-separated symbol parameter.
+func querySymbols(c *gin.Context, key string) []string {
+	var symbols []string
+	for _, value := range c.QueryArray(key) {
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+	}
+	return symbols
+}
+
+// tableError renders a list failure, reporting a stale cursor or unknown
+// sort column as a bad request.
+func tableError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repo.ErrInvalidSort) || errors.Is(err, repo.ErrInvalidCursor) {
+		c.String(http.StatusBadRequest, "Invalid sort or page")
+		return
+	}
+	c.String(http.StatusInternalServerError, message)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -10,11 +11,12 @@ import (
 
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
-	pricesIntegration "hodlbook/pkg/integrations/prices"
 	"hodlbook/pkg/types/cache"
+	"hodlbook/pkg/types/events"
 	"hodlbook/pkg/types/prices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type AssetsPageHandler struct {
@@ -22,14 +24,16 @@ type AssetsPageHandler struct {
 	repo         *repo.Repository
 	priceCache   cache.Cache[string, float64]
 	priceFetcher prices.PriceFetcher
+	events       events.Publisher
 }
 
-func NewAssetsPageHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64], priceFetcher prices.PriceFetcher) *AssetsPageHandler {
+func NewAssetsPageHandler(renderer *Renderer, repository *repo.Repository, priceCache cache.Cache[string, float64], priceFetcher prices.PriceFetcher, publisher events.Publisher) *AssetsPageHandler {
 	return &AssetsPageHandler{
 		renderer:     renderer,
 		repo:         repository,
 		priceCache:   priceCache,
 		priceFetcher: priceFetcher,
+		events:       publisher,
 	}
 }
 
@@ -59,6 +63,8 @@ type AssetsTableData struct {
 	TotalPages int
 	HasPrev    bool
 	HasNext    bool
+	PrevCursor string
+	NextCursor string
 	SortBy     string
 	SortDir    string
 }
@@ -70,7 +76,7 @@ type AssetRow struct {
 	TransactionType string
 	TypeClass       string
 	Amount          string
-	AmountRaw       float64
+	AmountRaw       string
 	Date            string
 	Timestamp       string
 	Notes           string
@@ -85,63 +91,26 @@ type AssetRow struct {
 }
 
 func (h *AssetsPageHandler) Table(c *gin.Context) {
-	symbol := c.Query("symbol")
-	txType := c.Query("type")
-	fromStr := c.Query("from")
-	toStr := c.Query("to")
-	pageStr := c.DefaultQuery("page", "1")
-	sortBy := c.DefaultQuery("sort", "date")
-	sortDir := c.DefaultQuery("dir", "desc")
-
-	page, _ := strconv.Atoi(pageStr)
-	if page < 1 {
-		page = 1
-	}
-	limit := 20
-
-	assets, _ := h.repo.GetAllAssets()
-
-	var filtered []models.Asset
-	for _, asset := range assets {
-		if symbol != "" && asset.Symbol != symbol {
-			continue
-		}
-		if txType != "" && asset.TransactionType != txType {
-			continue
-		}
-		if fromStr != "" {
-			from, err := time.Parse("2006-01-02", fromStr)
-			if err == nil && asset.Timestamp.Before(from) {
-				continue
-			}
-		}
-		if toStr != "" {
-			to, err := time.Parse("2006-01-02", toStr)
-			if err == nil && asset.Timestamp.After(to.Add(24*time.Hour)) {
-				continue
-			}
-		}
-		filtered = append(filtered, asset)
-	}
-
-	sortAssets(filtered, sortBy, sortDir)
-
-	total := len(filtered)
-	totalPages := (total + limit - 1) / limit
-	if totalPages < 1 {
-		totalPages = 1
-	}
-
-	start := (page - 1) * limit
-	end := start + limit
-	if start > total {
-		start = total
-	}
-	if end > total {
-		end = total
+	q := parseTableQuery(c)
+
+	result, err := h.repo.ListAssets(repo.AssetFilter{
+		PortfolioID:     selectedPortfolioID(c),
+		Symbols:         querySymbols(c, "symbol"),
+		TransactionType: c.Query("type"),
+		Search:          q.Search,
+		StartDate:       q.StartDate,
+		EndDate:         q.EndDate,
+		SortBy:          q.SortBy,
+		SortDir:         q.SortDir,
+		After:           q.After,
+		Before:          q.Before,
+		Limit:           tablePageSize,
+	})
+	if err != nil {
+		tableError(c, err, "Failed to load assets")
+		return
 	}
-
-	paginated := filtered[start:end]
+	paginated := result.Assets
 
 	queries := make([]repo.AssetPriceQuery, len(paginated))
 	for i, asset := range paginated {
@@ -163,7 +132,7 @@ func (h *AssetsPageHandler) Table(c *gin.Context) {
 	}
 	// Fallback to priceFetcher for any missing symbols
 	if h.priceFetcher != nil && len(currentPrices) == 0 {
-		allPrices, err := h.priceFetcher.FetchAll()
+		allPrices, err := h.priceFetcher.FetchAll(c.Request.Context())
 		if err == nil {
 			for _, p := range allPrices {
 				currentPrices[p.Asset.Symbol] = p.Value
@@ -181,13 +150,14 @@ func (h *AssetsPageHandler) Table(c *gin.Context) {
 			typeClass = "negative"
 		}
 
+		amount := asset.Amount.InexactFloat64()
 		var usdValue string
 		var historicPrice float64
 		hasUSDValue := false
 		if priceMap, ok := historicPrices[asset.Symbol]; ok {
 			if price, ok := priceMap[asset.Timestamp.Unix()]; ok {
 				historicPrice = price
-				usdValue = formatPrice(asset.Amount * price)
+				usdValue = formatPrice(amount * price)
 				hasUSDValue = true
 			}
 		}
@@ -195,12 +165,12 @@ func (h *AssetsPageHandler) Table(c *gin.Context) {
 		var currentValue, gainLossUSD, gainLossPercent, gainLossClass string
 		hasCurrentValue := false
 		if currentPrice, ok := currentPrices[asset.Symbol]; ok {
-			currentValue = formatPrice(asset.Amount * currentPrice)
+			currentValue = formatPrice(amount * currentPrice)
 			hasCurrentValue = true
 
 			if hasUSDValue && historicPrice > 0 {
-				historicValue := asset.Amount * historicPrice
-				currentVal := asset.Amount * currentPrice
+				historicValue := amount * historicPrice
+				currentVal := amount * currentPrice
 				diff := currentVal - historicValue
 				pct := (diff / historicValue) * 100
 
@@ -227,8 +197,8 @@ func (h *AssetsPageHandler) Table(c *gin.Context) {
 			Name:            asset.Name,
 			TransactionType: asset.TransactionType,
 			TypeClass:       typeClass,
-			Amount:          formatAmount(asset.Amount),
-			AmountRaw:       asset.Amount,
+			Amount:          formatAmount(asset.Amount.InexactFloat64()),
+			AmountRaw:       asset.Amount.String(),
 			Date:            asset.Timestamp.Format("2006-01-02 15:04"),
 			Timestamp:       asset.Timestamp.Format("2006-01-02T15:04"),
 			Notes:           asset.Notes,
@@ -246,48 +216,28 @@ func (h *AssetsPageHandler) Table(c *gin.Context) {
 	data := AssetsTableData{
 		Assets:     rows,
 		Empty:      len(rows) == 0,
-		Page:       page,
-		TotalPages: totalPages,
-		HasPrev:    page > 1,
-		HasNext:    page < totalPages,
-		SortBy:     sortBy,
-		SortDir:    sortDir,
+		Page:       q.Page,
+		TotalPages: totalPages(result.Total),
+		HasPrev:    result.PrevCursor != "",
+		HasNext:    result.NextCursor != "",
+		PrevCursor: result.PrevCursor,
+		NextCursor: result.NextCursor,
+		SortBy:     q.SortBy,
+		SortDir:    q.SortDir,
 	}
 
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	c.HTML(http.StatusOK, "assets_table.html", data)
 }
 
-func sortAssets(assets []models.Asset, sortBy, sortDir string) {
-	sort.Slice(assets, func(i, j int) bool {
-		var less bool
-		switch sortBy {
-		case "date":
-			less = assets[i].Timestamp.Before(assets[j].Timestamp)
-		case "type":
-			less = assets[i].TransactionType < assets[j].TransactionType
-		case "symbol":
-			less = assets[i].Symbol < assets[j].Symbol
-		case "amount":
-			less = assets[i].Amount < assets[j].Amount
-		default:
-			less = assets[i].Timestamp.Before(assets[j].Timestamp)
-		}
-		if sortDir == "desc" {
-			return !less
-		}
-		return less
-	})
-}
-
 type CreateAssetRequest struct {
-	Symbol          string  `form:"symbol" binding:"required"`
-	Name            string  `form:"name"`
-	TransactionType string  `form:"type" binding:"required"`
-	Amount          float64 `form:"amount" binding:"required"`
-	Timestamp       string  `form:"timestamp" binding:"required"`
-	Notes           string  `form:"notes"`
-	PriceSource     string  `form:"price_source"`
+	Symbol          string `form:"symbol" binding:"required"`
+	Name            string `form:"name"`
+	TransactionType string `form:"type" binding:"required"`
+	Amount          string `form:"amount" binding:"required"`
+	Timestamp       string `form:"timestamp" binding:"required"`
+	Notes           string `form:"notes"`
+	PriceSource     string `form:"price_source"`
 }
 
 func (h *AssetsPageHandler) Create(c *gin.Context) {
@@ -311,6 +261,13 @@ func (h *AssetsPageHandler) Create(c *gin.Context) {
 		return
 	}
 
+	amount, err := parseAmount(req.Amount, true)
+	if err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Amount must be a positive number", "type": "error"}}`)
+		h.Table(c)
+		return
+	}
+
 	timestamp, err := time.Parse("2006-01-02T15:04", req.Timestamp)
 	if err != nil {
 		timestamp = time.Now()
@@ -322,10 +279,11 @@ func (h *AssetsPageHandler) Create(c *gin.Context) {
 	}
 
 	asset := &models.Asset{
+		PortfolioID:     selectedPortfolioID(c),
 		Symbol:          req.Symbol,
 		Name:            req.Name,
 		TransactionType: req.TransactionType,
-		Amount:          req.Amount,
+		Amount:          amount,
 		Timestamp:       timestamp,
 		Notes:           req.Notes,
 		PriceSource:     priceSource,
@@ -337,7 +295,8 @@ func (h *AssetsPageHandler) Create(c *gin.Context) {
 		return
 	}
 
-	h.ensurePriceAtTimestamp(asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
+	h.ensurePriceAtTimestamp(c.Request.Context(), asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
+	publish(c, h.events, events.TopicAssetCreated, *asset)
 
 	h.Table(c)
 }
@@ -378,6 +337,13 @@ func (h *AssetsPageHandler) Update(c *gin.Context) {
 		return
 	}
 
+	amount, err := parseAmount(req.Amount, true)
+	if err != nil {
+		c.Header("HX-Trigger", `{"show-toast": {"message": "Amount must be a positive number", "type": "error"}}`)
+		h.Table(c)
+		return
+	}
+
 	timestamp, err := time.Parse("2006-01-02T15:04", req.Timestamp)
 	if err != nil {
 		timestamp = time.Now()
@@ -386,7 +352,7 @@ func (h *AssetsPageHandler) Update(c *gin.Context) {
 	asset.Symbol = req.Symbol
 	asset.Name = req.Name
 	asset.TransactionType = req.TransactionType
-	asset.Amount = req.Amount
+	asset.Amount = amount
 	asset.Timestamp = timestamp
 	asset.Notes = req.Notes
 	if req.PriceSource != "" {
@@ -401,33 +367,34 @@ func (h *AssetsPageHandler) Update(c *gin.Context) {
 		return
 	}
 
-	h.ensurePriceAtTimestamp(asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
+	h.ensurePriceAtTimestamp(c.Request.Context(), asset.Symbol, asset.Name, asset.Timestamp, asset.PriceSource)
+	publish(c, h.events, events.TopicAssetUpdated, *asset)
 
 	h.Table(c)
 }
 
-func (h *AssetsPageHandler) ensurePriceAtTimestamp(symbol, name string, timestamp time.Time, priceSource *string) {
+func (h *AssetsPageHandler) ensurePriceAtTimestamp(ctx context.Context, symbol, name string, timestamp time.Time, priceSource *string) {
 	if h.priceFetcher == nil {
 		return
 	}
 
 	var priceValue float64
 
-	if priceSource != nil && *priceSource != "" {
+	sourceFetcher, ok := h.priceFetcher.(prices.SourceFetcher)
+	if ok && priceSource != nil && *priceSource != "" {
 		price := &prices.Price{
 			Asset: prices.Asset{
 				Symbol: symbol,
 				Name:   name,
 			},
 		}
-		fetcher := pricesIntegration.NewPriceService()
-		if err := fetcher.FetchBySource(*priceSource, price); err == nil && price.Value > 0 {
+		if err := sourceFetcher.FetchBySource(ctx, *priceSource, price); err == nil && price.Value > 0 {
 			priceValue = price.Value
 		}
 	}
 
 	if priceValue == 0 {
-		allPrices, err := h.priceFetcher.FetchAll()
+		allPrices, err := h.priceFetcher.FetchAll(ctx)
 		if err != nil {
 			return
 		}
@@ -446,7 +413,7 @@ func (h *AssetsPageHandler) ensurePriceAtTimestamp(symbol, name string, timestam
 	h.repo.CreatePrice(&models.Price{
 		Symbol:    symbol,
 		Currency:  "USD",
-		Price:     priceValue,
+		Price:     decimal.NewFromFloat(priceValue),
 		Timestamp: timestamp,
 	})
 }
@@ -460,7 +427,7 @@ func (h *AssetsPageHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.DeleteAsset(id); err != nil {
+	if err := h.deleteAsset(c, id); err != nil {
 		c.Header("HX-Trigger", `{"show-toast": {"message": "Failed to delete asset entry", "type": "error"}}`)
 		h.Table(c)
 		return
@@ -481,7 +448,7 @@ func (h *AssetsPageHandler) BulkDelete(c *gin.Context) {
 
 	deleted := 0
 	for _, id := range req.IDs {
-		if err := h.repo.DeleteAsset(id); err == nil {
+		if err := h.deleteAsset(c, id); err == nil {
 			deleted++
 		}
 	}
@@ -490,6 +457,18 @@ func (h *AssetsPageHandler) BulkDelete(c *gin.Context) {
 	h.Table(c)
 }
 
+func (h *AssetsPageHandler) deleteAsset(c *gin.Context, id int64) error {
+	asset, err := h.repo.GetAssetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := h.repo.DeleteAsset(id); err != nil {
+		return err
+	}
+	publish(c, h.events, events.TopicAssetDeleted, *asset)
+	return nil
+}
+
 func (h *AssetsPageHandler) GetSymbols(c *gin.Context) {
 	symbols, err := h.repo.GetUniqueSymbols()
 	if err != nil {
@@ -500,7 +479,9 @@ func (h *AssetsPageHandler) GetSymbols(c *gin.Context) {
 }
 
 func (h *AssetsPageHandler) GetAssets(c *gin.Context) {
-	assets, err := h.repo.GetAllAssets()
+	portfolioID := selectedPortfolioID(c)
+
+	assets, err := h.repo.GetAssetsByPortfolio(portfolioID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assets"})
 		return
@@ -515,7 +496,7 @@ type SupportedCrypto struct {
 }
 
 func (h *AssetsPageHandler) GetSupportedCryptos(c *gin.Context) {
-	priceList, err := h.priceFetcher.FetchAll()
+	priceList, err := h.priceFetcher.FetchAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supported cryptos"})
 		return
@@ -568,10 +549,12 @@ type AssetsHoldingItem struct {
 }
 
 func (h *AssetsPageHandler) Holdings(c *gin.Context) {
+	portfolioID := selectedPortfolioID(c)
+
 	sortBy := c.DefaultQuery("sort", "value")
 	sortDir := c.DefaultQuery("dir", "desc")
 
-	items := h.buildHoldingsItems()
+	items := h.buildHoldingsItems(portfolioID)
 	sortAssetsHoldingsItems(items, sortBy, sortDir)
 
 	data := AssetsHoldingsData{
@@ -587,10 +570,10 @@ func (h *AssetsPageHandler) Holdings(c *gin.Context) {
 	c.HTML(http.StatusOK, "holdings_table.html", data)
 }
 
-func (h *AssetsPageHandler) buildHoldingsItems() []AssetsHoldingItem {
-	holdings := h.calculateHoldings()
-	assets, _ := h.repo.GetAllAssets()
-	costBasis := h.calculateCostBasis(assets)
+func (h *AssetsPageHandler) buildHoldingsItems(portfolioID int64) []AssetsHoldingItem {
+	holdings := h.calculateHoldings(portfolioID)
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	costBasis := h.calculateCostBasis(portfolioID, assets)
 
 	var items []AssetsHoldingItem
 	for symbol, amount := range holdings {
@@ -622,26 +605,10 @@ func (h *AssetsPageHandler) buildHoldingsItems() []AssetsHoldingItem {
 	return items
 }
 
-func (h *AssetsPageHandler) calculateHoldings() map[string]float64 {
-	holdings := make(map[string]float64)
-
-	assets, _ := h.repo.GetAllAssets()
-	for _, asset := range assets {
-		switch asset.TransactionType {
-		case "deposit":
-			holdings[asset.Symbol] += asset.Amount
-		case "withdraw":
-			holdings[asset.Symbol] -= asset.Amount
-		}
-	}
-
-	exchanges, _ := h.repo.GetAllExchanges()
-	for _, ex := range exchanges {
-		holdings[ex.FromSymbol] -= ex.FromAmount
-		holdings[ex.ToSymbol] += ex.ToAmount
-	}
-
-	return holdings
+func (h *AssetsPageHandler) calculateHoldings(portfolioID int64) map[string]float64 {
+	assets, _ := h.repo.GetAssetsByPortfolio(portfolioID)
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
+	return netHoldings(assets, exchanges, time.Time{})
 }
 
 type assetsCostBasisEvent struct {
@@ -651,7 +618,7 @@ type assetsCostBasisEvent struct {
 	exchange  *models.Exchange
 }
 
-func (h *AssetsPageHandler) calculateCostBasis(assets []models.Asset) map[string]float64 {
+func (h *AssetsPageHandler) calculateCostBasis(portfolioID int64, assets []models.Asset) map[string]float64 {
 	costBasis := make(map[string]float64)
 	runningHoldings := make(map[string]float64)
 
@@ -664,7 +631,7 @@ func (h *AssetsPageHandler) calculateCostBasis(assets []models.Asset) map[string
 		})
 	}
 
-	exchanges, _ := h.repo.GetAllExchanges()
+	exchanges, _ := h.repo.GetExchangesByPortfolio(portfolioID)
 	for i := range exchanges {
 		events = append(events, assetsCostBasisEvent{
 			timestamp: exchanges[i].Timestamp,
@@ -684,25 +651,25 @@ func (h *AssetsPageHandler) calculateCostBasis(assets []models.Asset) map[string
 			switch asset.TransactionType {
 			case "deposit":
 				price := h.getPriceAtTime(asset.Symbol, asset.Timestamp)
-				costBasis[asset.Symbol] += asset.Amount * price
-				runningHoldings[asset.Symbol] += asset.Amount
+				costBasis[asset.Symbol] += asset.Amount.InexactFloat64() * price
+				runningHoldings[asset.Symbol] += asset.Amount.InexactFloat64()
 			case "withdraw":
 				if runningHoldings[asset.Symbol] > 0 {
 					avgCost := costBasis[asset.Symbol] / runningHoldings[asset.Symbol]
-					costBasis[asset.Symbol] -= asset.Amount * avgCost
+					costBasis[asset.Symbol] -= asset.Amount.InexactFloat64() * avgCost
 				}
-				runningHoldings[asset.Symbol] -= asset.Amount
+				runningHoldings[asset.Symbol] -= asset.Amount.InexactFloat64()
 			}
 		case "exchange":
 			ex := event.exchange
 			if runningHoldings[ex.FromSymbol] > 0 {
 				avgCost := costBasis[ex.FromSymbol] / runningHoldings[ex.FromSymbol]
-				transferredCost := ex.FromAmount * avgCost
+				transferredCost := ex.FromAmount.InexactFloat64() * avgCost
 				costBasis[ex.FromSymbol] -= transferredCost
 				costBasis[ex.ToSymbol] += transferredCost
 			}
-			runningHoldings[ex.FromSymbol] -= ex.FromAmount
-			runningHoldings[ex.ToSymbol] += ex.ToAmount
+			runningHoldings[ex.FromSymbol] -= ex.FromAmount.InexactFloat64()
+			runningHoldings[ex.ToSymbol] += ex.ToAmount.InexactFloat64()
 		}
 	}
 
@@ -712,7 +679,7 @@ func (h *AssetsPageHandler) calculateCostBasis(assets []models.Asset) map[string
 func (h *AssetsPageHandler) getPriceAtTime(symbol string, timestamp time.Time) float64 {
 	priceRecord, err := h.repo.GetPriceAtTime(symbol, "USD", timestamp)
 	if err == nil && priceRecord != nil {
-		return priceRecord.Price
+		return priceRecord.Price.InexactFloat64()
 	}
 	price, _ := h.priceCache.Get(symbol)
 	return price
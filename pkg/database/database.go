@@ -5,11 +5,20 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"time"
 
 	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Dialect names as reported by Dialect.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
 // Database holds the GORM database instance
 type Database struct {
 	conn *gorm.DB
@@ -64,7 +73,7 @@ func WithPath(path string) Option {
 		os.Remove(testFile)
 
 		// Open database
-		conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+		conn, err := open(sqlite.Open(path))
 		if err != nil {
 			return fmt.Errorf("failed to connect to database: %w (path: %s, dir exists: true)", err, path)
 		}
@@ -75,6 +84,83 @@ func WithPath(path string) Option {
 	}
 }
 
+// WithPostgres connects to PostgreSQL. The DSN may be a URL or a keyword/value
+// string as accepted by pgx.
+func WithPostgres(dsn string) Option {
+	return func(db *Database) error {
+		if dsn == "" {
+			return fmt.Errorf("postgres DSN cannot be empty")
+		}
+
+		conn, err := open(postgres.Open(dsn))
+		if err != nil {
+			return fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+
+		db.conn = conn
+		log.Printf("Database connected: postgres")
+		return nil
+	}
+}
+
+func open(dialector gorm.Dialector) (*gorm.DB, error) {
+	conn, err := gorm.Open(dialector, &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := registerUTCTimestamps(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// registerUTCTimestamps stores every time.Time field in UTC. SQLite keeps
+// timestamps as text, so values written with different offsets would
+// otherwise compare and sort as strings rather than as instants.
+func registerUTCTimestamps(conn *gorm.DB) error {
+	toUTC := func(tx *gorm.DB) {
+		if tx.Statement.Schema == nil || !tx.Statement.ReflectValue.IsValid() {
+			return
+		}
+		rv := reflect.Indirect(tx.Statement.ReflectValue)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				utcFields(tx, reflect.Indirect(rv.Index(i)))
+			}
+		case reflect.Struct:
+			utcFields(tx, rv)
+		}
+	}
+
+	if err := conn.Callback().Create().Before("gorm:create").Register("hodlbook:utc_timestamps", toUTC); err != nil {
+		return err
+	}
+	return conn.Callback().Update().Before("gorm:update").Register("hodlbook:utc_timestamps", toUTC)
+}
+
+func utcFields(tx *gorm.DB, rv reflect.Value) {
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	ctx := tx.Statement.Context
+	for _, field := range tx.Statement.Schema.Fields {
+		value, zero := field.ValueOf(ctx, rv)
+		if zero {
+			continue
+		}
+		switch t := value.(type) {
+		case time.Time:
+			field.Set(ctx, rv, t.UTC())
+		case *time.Time:
+			utc := t.UTC()
+			field.Set(ctx, rv, &utc)
+		}
+	}
+}
+
 // Get returns the underlying GORM database instance
 func (d *Database) Get() *gorm.DB {
 	if d.conn == nil {
@@ -83,6 +169,14 @@ func (d *Database) Get() *gorm.DB {
 	return d.conn
 }
 
+// Dialect reports which database engine is in use.
+func (d *Database) Dialect() string {
+	if d.conn == nil {
+		return ""
+	}
+	return d.conn.Dialector.Name()
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	if d.conn == nil {
@@ -94,3 +188,24 @@ func (d *Database) Close() error {
 	}
 	return sqlDB.Close()
 }
+
+// Backup writes a consistent copy of the database to path. The target must
+// not exist yet.
+func (d *Database) Backup(path string) error {
+	if d.conn == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if d.Dialect() != DialectSQLite {
+		return fmt.Errorf("backup is only supported for sqlite, use the %s tools instead", d.Dialect())
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup target %s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := d.conn.Exec("VACUUM INTO ?", path).Error; err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -19,3 +21,51 @@ func TestDatabase_DefaultPath(t *testing.T) {
 	require.NotNil(t, db.Get())
 	require.NoError(t, db.Close())
 }
+
+func TestDatabase_Backup(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(WithPath(filepath.Join(dir, "hodlbook.db")))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Get().Exec("CREATE TABLE notes (body TEXT)").Error)
+	require.NoError(t, db.Get().Exec("INSERT INTO notes VALUES ('hodl')").Error)
+
+	target := filepath.Join(dir, "backups", "copy.db")
+	require.NoError(t, db.Backup(target))
+	require.Error(t, db.Backup(target), "refuses to overwrite")
+
+	restored, err := New(WithPath(target))
+	require.NoError(t, err)
+	defer restored.Close()
+
+	var body string
+	require.NoError(t, restored.Get().Raw("SELECT body FROM notes").Scan(&body).Error)
+	require.Equal(t, "hodl", body)
+}
+
+func TestDatabase_StoresTimestampsInUTC(t *testing.T) {
+	db, err := New(WithPath(filepath.Join(t.TempDir(), "hodlbook.db")))
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, DialectSQLite, db.Dialect())
+
+	type event struct {
+		ID   int64
+		At   time.Time
+		Seen *time.Time
+	}
+	require.NoError(t, db.Get().AutoMigrate(&event{}))
+
+	berlin := time.FixedZone("CET", 3600)
+	at := time.Date(2024, 1, 1, 0, 30, 0, 0, berlin)
+	events := []event{{At: at, Seen: &at}, {At: at.Add(-time.Hour).UTC()}}
+	require.NoError(t, db.Get().Create(&events).Error)
+
+	var stored []event
+	require.NoError(t, db.Get().Where("at < ?", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).Order("at").Find(&stored).Error)
+	require.Len(t, stored, 2, "both instants are before midnight UTC")
+	require.Equal(t, time.UTC, stored[1].At.Location())
+	require.True(t, at.Equal(stored[1].At))
+	require.True(t, at.Equal(*stored[1].Seen))
+}
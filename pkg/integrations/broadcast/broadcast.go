@@ -0,0 +1,219 @@
+package broadcast
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"hodlbook/pkg/integrations/metrics"
+)
+
+var evictions = metrics.NewCounterVec(
+	"hodlbook_broadcast_evicted_subscribers_total",
+	"Subscribers disconnected because they fell behind.",
+)
+
+type Message struct {
+	ID    uint64
+	Event string
+	Data  []byte
+}
+
+// WriteTo writes the message in Server-Sent Events framing.
+func (m Message) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\nevent: %s\n", m.ID, m.Event)
+	for _, line := range strings.Split(string(m.Data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Subscription receives messages on C until it is closed or evicted. C is
+// closed when the subscriber falls behind by more than the buffer size; the
+// client is expected to reconnect and resume from the last ID it saw.
+type Subscription struct {
+	C <-chan Message
+
+	ch  chan Message
+	hub *Hub
+}
+
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// Hub fans every published message out to all subscribers. It keeps the
+// latest message per event as a snapshot for new subscribers and a bounded
+// history so reconnecting clients can resume from a Last-Event-ID.
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subs        map[*Subscription]struct{}
+	latest      map[string]Message
+	history     []Message
+	bufferSize  int
+	historySize int
+	noSnapshots bool
+	closed      bool
+}
+
+type Option func(*Hub)
+
+// WithBufferSize sets how many messages a subscriber may fall behind before
+// it is evicted.
+func WithBufferSize(n int) Option {
+	return func(h *Hub) {
+		h.bufferSize = n
+	}
+}
+
+// WithHistorySize sets how many messages are kept for Last-Event-ID resume.
+func WithHistorySize(n int) Option {
+	return func(h *Hub) {
+		h.historySize = n
+	}
+}
+
+// WithoutSnapshots stops new subscribers from receiving the latest message of
+// every event. Useful when messages are change notifications rather than
+// state. Resuming subscribers still replay what they missed.
+func WithoutSnapshots() Option {
+	return func(h *Hub) {
+		h.noSnapshots = true
+	}
+}
+
+func New(opts ...Option) *Hub {
+	h := &Hub{
+		subs:        make(map[*Subscription]struct{}),
+		latest:      make(map[string]Message),
+		bufferSize:  16,
+		historySize: 64,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.bufferSize < 1 {
+		h.bufferSize = 1
+	}
+	return h
+}
+
+func (h *Hub) Publish(event string, data []byte) Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	msg := Message{ID: h.nextID, Event: event, Data: data}
+	if h.closed {
+		return msg
+	}
+
+	h.latest[event] = msg
+	if h.historySize > 0 {
+		h.history = append(h.history, msg)
+		if len(h.history) > h.historySize {
+			h.history = h.history[len(h.history)-h.historySize:]
+		}
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.ch <- msg:
+		default:
+			h.remove(sub)
+			evictions.Inc()
+		}
+	}
+
+	return msg
+}
+
+// Subscribe registers a subscriber. When lastEventID is still covered by the
+// history the missed messages are replayed; otherwise the subscriber starts
+// with the latest message of every event. IDs restart from one with the
+// process, so an ID the hub has not issued before its latest message is
+// taken to come from an earlier run and treated as unknown.
+func (h *Hub) Subscribe(lastEventID uint64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	backlog := h.backlog(lastEventID)
+
+	size := h.bufferSize
+	if len(backlog) > size {
+		size = len(backlog)
+	}
+	ch := make(chan Message, size)
+	for _, msg := range backlog {
+		ch <- msg
+	}
+
+	sub := &Subscription{C: ch, ch: ch, hub: h}
+	if h.closed {
+		close(ch)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) backlog(lastEventID uint64) []Message {
+	known := lastEventID > 0 && lastEventID < h.nextID
+	if known && len(h.history) > 0 && h.history[0].ID <= lastEventID+1 {
+		var missed []Message
+		for _, msg := range h.history {
+			if msg.ID > lastEventID {
+				missed = append(missed, msg)
+			}
+		}
+		return missed
+	}
+	if lastEventID == 0 && h.noSnapshots {
+		return nil
+	}
+
+	snapshot := make([]Message, 0, len(h.latest))
+	for _, msg := range h.latest {
+		snapshot = append(snapshot, msg)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].ID < snapshot[j].ID })
+	return snapshot
+}
+
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Close disconnects every subscriber. Later subscriptions are closed
+// immediately and publishes are ignored.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,171 @@
+package broadcast
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) Message {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.C:
+		require.True(t, ok, "subscription closed")
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+		return Message{}
+	}
+}
+
+func TestHub_FanOut(t *testing.T) {
+	h := New()
+	a := h.Subscribe(0)
+	b := h.Subscribe(0)
+	assert.Equal(t, 2, h.Subscribers())
+
+	h.Publish("prices", []byte(`{"BTC":1}`))
+
+	assert.Equal(t, `{"BTC":1}`, string(receive(t, a).Data))
+	assert.Equal(t, `{"BTC":1}`, string(receive(t, b).Data))
+
+	a.Close()
+	a.Close()
+	assert.Equal(t, 1, h.Subscribers())
+}
+
+func TestHub_SnapshotOnConnect(t *testing.T) {
+	h := New()
+	h.Publish("prices", []byte("1"))
+	h.Publish("status", []byte("ok"))
+	h.Publish("prices", []byte("2"))
+
+	sub := h.Subscribe(0)
+	first := receive(t, sub)
+	second := receive(t, sub)
+	assert.Equal(t, "status", first.Event)
+	assert.Equal(t, "2", string(second.Data))
+}
+
+func TestHub_WithoutSnapshots(t *testing.T) {
+	h := New(WithoutSnapshots())
+	h.Publish("refresh", []byte("1"))
+
+	sub := h.Subscribe(0)
+	assert.Empty(t, sub.C)
+
+	h.Publish("refresh", []byte("2"))
+	assert.Equal(t, "2", string(receive(t, sub).Data))
+
+	missed := h.Subscribe(1)
+	assert.Equal(t, uint64(2), receive(t, missed).ID)
+}
+
+func TestMessage_WriteTo(t *testing.T) {
+	var b strings.Builder
+	Message{ID: 7, Event: "prices", Data: []byte("a\nb")}.WriteTo(&b)
+	assert.Equal(t, "id: 7\nevent: prices\ndata: a\ndata: b\n\n", b.String())
+}
+
+func TestHub_ResumeFromLastEventID(t *testing.T) {
+	h := New(WithHistorySize(3))
+	for i := 1; i <= 5; i++ {
+		h.Publish("prices", []byte(fmt.Sprint(i)))
+	}
+
+	sub := h.Subscribe(3)
+	assert.Equal(t, uint64(4), receive(t, sub).ID)
+	assert.Equal(t, uint64(5), receive(t, sub).ID)
+
+	stale := h.Subscribe(1)
+	msg := receive(t, stale)
+	assert.Equal(t, uint64(5), msg.ID, "falls back to the snapshot when history no longer covers the ID")
+}
+
+func TestHub_ResumeAfterRestart(t *testing.T) {
+	// The previous run got to ID 40; this one has only published two.
+	h := New(WithoutSnapshots())
+	h.Publish("prices", []byte("1"))
+	h.Publish("status", []byte("ok"))
+
+	sub := h.Subscribe(40)
+	assert.Equal(t, uint64(1), receive(t, sub).ID, "an ID from an earlier run falls back to the snapshot")
+	assert.Equal(t, uint64(2), receive(t, sub).ID)
+
+	same := h.Subscribe(2)
+	assert.Len(t, same.C, 2, "an ID equal to the newest may also come from an earlier run")
+}
+
+func TestHub_EvictsSlowConsumer(t *testing.T) {
+	h := New(WithBufferSize(2))
+	slow := h.Subscribe(0)
+	fast := h.Subscribe(0)
+
+	for i := 0; i < 3; i++ {
+		h.Publish("prices", []byte(fmt.Sprint(i)))
+		receive(t, fast)
+	}
+
+	var drained int
+	for range slow.C {
+		drained++
+	}
+	assert.Equal(t, 2, drained)
+	assert.Equal(t, 1, h.Subscribers())
+
+	h.Publish("prices", []byte("after"))
+	assert.Equal(t, "after", string(receive(t, fast).Data))
+}
+
+func TestHub_ConcurrentSubscribers(t *testing.T) {
+	h := New(WithBufferSize(100))
+	const subscribers, messages = 20, 50
+
+	var ready, done sync.WaitGroup
+	counts := make([]int, subscribers)
+	for i := 0; i < subscribers; i++ {
+		ready.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			sub := h.Subscribe(0)
+			ready.Done()
+			for msg := range sub.C {
+				counts[i]++
+				if string(msg.Data) == "last" {
+					sub.Close()
+				}
+			}
+		}(i)
+	}
+	ready.Wait()
+
+	for i := 0; i < messages-1; i++ {
+		h.Publish("prices", []byte(fmt.Sprint(i)))
+	}
+	h.Publish("prices", []byte("last"))
+	done.Wait()
+
+	for i, count := range counts {
+		assert.Equal(t, messages, count, "subscriber %d", i)
+	}
+	assert.Equal(t, 0, h.Subscribers())
+}
+
+func TestHub_Close(t *testing.T) {
+	h := New()
+	sub := h.Subscribe(0)
+	h.Close()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	late := h.Subscribe(0)
+	_, ok = <-late.C
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package broadcast
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hodlbook/pkg/integrations/metrics"
+)
+
+var streamClients = metrics.NewGaugeVec(
+	"hodlbook_sse_subscribers",
+	"Connected Server-Sent Events clients.",
+)
+
+// Stream serves the hub to one client as Server-Sent Events until the client
+// goes away or is evicted. A reconnecting client resumes from the
+// Last-Event-ID header or the last_event_id query parameter. A comment is
+// written every heartbeat so proxies do not close an idle stream.
+func (h *Hub) Stream(w http.ResponseWriter, r *http.Request, heartbeat time.Duration) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	sub := h.Subscribe(LastEventID(r))
+	streamClients.Inc()
+	defer func() {
+		sub.Close()
+		streamClients.Dec()
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if _, err := msg.WriteTo(w); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flush(w)
+	}
+}
+
+// LastEventID returns the ID a reconnecting client saw last, zero for a new
+// client.
+func LastEventID(r *http.Request) uint64 {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.ParseUint(raw, 10, 64)
+	return id
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
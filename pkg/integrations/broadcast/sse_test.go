@@ -0,0 +1,49 @@
+package broadcast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_StreamResumesAndHeartbeats(t *testing.T) {
+	h := New()
+	h.Publish("price", []byte("1"))
+	h.Publish("price", []byte("2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "1")
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.Stream(w, req, 10*time.Millisecond)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return h.Subscribers() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, body, "id: 2\nevent: price\ndata: 2\n\n")
+	assert.NotContains(t, body, "data: 1\n", "the client already saw event 1")
+	assert.Contains(t, body, ": heartbeat\n\n")
+	assert.Zero(t, h.Subscribers())
+}
+
+func TestLastEventID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/stream?last_event_id=7", nil)
+	assert.Equal(t, uint64(7), LastEventID(req))
+
+	req.Header.Set("Last-Event-ID", "9")
+	assert.Equal(t, uint64(9), LastEventID(req), "the header wins")
+
+	assert.Zero(t, LastEventID(httptest.NewRequest(http.MethodGet, "/stream", nil)))
+}
@@ -0,0 +1,291 @@
+package eventbus
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"hodlbook/pkg/integrations/metrics"
+	"hodlbook/pkg/types/events"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidBusConfig = errors.New("invalid event bus config")
+	ErrBusClosed        = errors.New("event bus is closed")
+	ErrInvalidTopic     = errors.New("invalid topic")
+	ErrUnexpectedType   = errors.New("unexpected payload type")
+)
+
+var _ events.Bus = (*Bus)(nil)
+
+const defaultQueueSize = 64
+
+var (
+	published = metrics.NewCounterVec(
+		"hodlbook_events_published_total",
+		"Events published on the in-process bus.",
+		"topic",
+	)
+	dropped = metrics.NewCounterVec(
+		"hodlbook_events_dropped_total",
+		"Events dropped because a subscriber queue was full.",
+		"topic", "subscriber",
+	)
+)
+
+type subscription struct {
+	name    string
+	pattern string
+	handler events.Handler
+	policy  events.Policy
+	queue   chan events.Event
+}
+
+func (s *subscription) matches(topic string) bool {
+	switch {
+	case s.pattern == "*":
+		return true
+	case strings.HasSuffix(s.pattern, ".*"):
+		return strings.HasPrefix(topic, strings.TrimSuffix(s.pattern, "*"))
+	default:
+		return s.pattern == topic
+	}
+}
+
+// Bus is an in-process publish/subscribe bus. Each subscriber has its own
+// bounded queue and goroutine so a slow handler cannot stall the others.
+type Bus struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+
+	mu     sync.RWMutex
+	subs   []*subscription
+	closed bool
+	wg     sync.WaitGroup
+
+	// closing is closed by Close to release publishers blocked on a full
+	// queue; publishing counts those still enqueueing so queues are only
+	// closed once nobody can send on them.
+	closing    chan struct{}
+	publishing sync.WaitGroup
+}
+
+type Option func(*Bus)
+
+func WithContext(ctx context.Context) Option {
+	return func(b *Bus) {
+		b.ctx = ctx
+	}
+}
+
+func WithLogger(l *slog.Logger) Option {
+	return func(b *Bus) {
+		b.logger = l
+	}
+}
+
+func (b *Bus) IsValid() error {
+	switch {
+	case b.ctx == nil:
+		return errors.Wrap(ErrInvalidBusConfig, "ctx cannot be nil")
+	case b.logger == nil:
+		return errors.Wrap(ErrInvalidBusConfig, "logger cannot be nil")
+	default:
+		return nil
+	}
+}
+
+func New(opts ...Option) (*Bus, error) {
+	b := &Bus{closing: make(chan struct{})}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if err := b.IsValid(); err != nil {
+		return nil, err
+	}
+
+	// Handlers get a context that outlives the parent so Close can drain
+	// queued events; it is cancelled when Close gives up waiting.
+	b.ctx, b.cancel = context.WithCancel(context.WithoutCancel(b.ctx))
+	return b, nil
+}
+
+func (b *Bus) Subscribe(topic string, handler events.Handler, opts events.SubscribeOptions) error {
+	if topic == "" {
+		return errors.Wrap(ErrInvalidTopic, "topic cannot be empty")
+	}
+	if handler == nil {
+		return errors.Wrap(ErrInvalidBusConfig, "handler cannot be nil")
+	}
+
+	size := opts.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	name := opts.Name
+	if name == "" {
+		name = topic
+	}
+
+	sub := &subscription{
+		name:    name,
+		pattern: topic,
+		handler: handler,
+		policy:  opts.Policy,
+		queue:   make(chan events.Event, size),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	b.subs = append(b.subs, sub)
+
+	b.wg.Add(1)
+	go b.run(sub)
+	return nil
+}
+
+func (b *Bus) Publish(ctx context.Context, topic string, payload any) error {
+	if topic == "" || strings.Contains(topic, "*") {
+		return errors.Wrap(ErrInvalidTopic, topic)
+	}
+
+	event := events.Event{
+		Topic:       topic,
+		Payload:     payload,
+		PublishedAt: time.Now(),
+	}
+
+	// Matching subscriptions are copied so the lock is not held while a
+	// Block queue waits for room, which would stall Close and Subscribe.
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	var subs []*subscription
+	for _, sub := range b.subs {
+		if sub.matches(topic) {
+			subs = append(subs, sub)
+		}
+	}
+	b.publishing.Add(1)
+	b.mu.RUnlock()
+	defer b.publishing.Done()
+
+	published.Inc(topic)
+	var errs []error
+	for _, sub := range subs {
+		if err := b.enqueue(ctx, sub, event); err != nil {
+			errs = append(errs, errors.Wrapf(err, "subscriber %s", sub.name))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (b *Bus) enqueue(ctx context.Context, sub *subscription, event events.Event) error {
+	select {
+	case sub.queue <- event:
+		return nil
+	default:
+	}
+
+	switch sub.policy {
+	case events.Block:
+		select {
+		case sub.queue <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closing:
+			return ErrBusClosed
+		}
+	case events.DropOldest:
+		select {
+		case <-sub.queue:
+		default:
+		}
+		select {
+		case sub.queue <- event:
+		default:
+		}
+	}
+
+	dropped.Inc(event.Topic, sub.name)
+	b.logger.Warn("event queue full, dropping event", "topic", event.Topic, "subscriber", sub.name)
+	return nil
+}
+
+func (b *Bus) run(sub *subscription) {
+	defer b.wg.Done()
+	for event := range sub.queue {
+		b.handle(sub, event)
+	}
+}
+
+func (b *Bus) handle(sub *subscription, event events.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event handler panicked", "topic", event.Topic, "subscriber", sub.name, "panic", r)
+		}
+	}()
+
+	if err := sub.handler(b.ctx, event); err != nil {
+		b.logger.Error("event handler error", "topic", event.Topic, "subscriber", sub.name, "error", err)
+	}
+}
+
+// Close stops accepting events and waits for queued events to be handled
+// until ctx is done.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.closing)
+	b.mu.Unlock()
+
+	b.publishing.Wait()
+	for _, sub := range b.subs {
+		close(sub.queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// Handle adapts a typed handler to events.Handler.
+func Handle[T any](fn func(ctx context.Context, payload T) error) events.Handler {
+	return func(ctx context.Context, event events.Event) error {
+		payload, ok := event.Payload.(T)
+		if !ok {
+			return errors.Wrap(ErrUnexpectedType, fmt.Sprintf("%s: got %T", event.Topic, event.Payload))
+		}
+		return fn(ctx, payload)
+	}
+}
@@ -0,0 +1,250 @@
+package eventbus
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/types/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestBus(t *testing.T) *Bus {
+	t.Helper()
+	b, err := New(WithContext(t.Context()), WithLogger(discardLogger))
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close(context.Background()) })
+	return b
+}
+
+func collect(t *testing.T, b *Bus, topic string, opts events.SubscribeOptions) <-chan events.Event {
+	t.Helper()
+	ch := make(chan events.Event, 100)
+	require.NoError(t, b.Subscribe(topic, func(_ context.Context, e events.Event) error {
+		ch <- e
+		return nil
+	}, opts))
+	return ch
+}
+
+func next(t *testing.T, ch <-chan events.Event) events.Event {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return events.Event{}
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(WithLogger(discardLogger))
+	assert.ErrorIs(t, err, ErrInvalidBusConfig)
+
+	_, err = New(WithContext(t.Context()))
+	assert.ErrorIs(t, err, ErrInvalidBusConfig)
+}
+
+func TestBus_MultipleSubscribersAndWildcards(t *testing.T) {
+	b := newTestBus(t)
+	exact := collect(t, b, events.TopicAssetCreated, events.SubscribeOptions{})
+	prefix := collect(t, b, "asset.*", events.SubscribeOptions{})
+	all := collect(t, b, "*", events.SubscribeOptions{})
+
+	require.NoError(t, b.Publish(t.Context(), events.TopicAssetCreated, "a"))
+	require.NoError(t, b.Publish(t.Context(), events.TopicAssetDeleted, "b"))
+	require.NoError(t, b.Publish(t.Context(), events.TopicExchangeCreated, "c"))
+
+	assert.Equal(t, "a", next(t, exact).Payload)
+	assert.Equal(t, "a", next(t, prefix).Payload)
+	assert.Equal(t, "b", next(t, prefix).Payload)
+	assert.Equal(t, "a", next(t, all).Payload)
+	assert.Equal(t, "b", next(t, all).Payload)
+	assert.Equal(t, events.TopicExchangeCreated, next(t, all).Topic)
+
+	select {
+	case e := <-exact:
+		t.Fatalf("unexpected event %v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBus_PublishRejectsWildcardTopic(t *testing.T) {
+	b := newTestBus(t)
+	assert.ErrorIs(t, b.Publish(t.Context(), "asset.*", nil), ErrInvalidTopic)
+}
+
+func TestBus_TypedHandler(t *testing.T) {
+	b := newTestBus(t)
+
+	got := make(chan events.PricesUpdated, 1)
+	require.NoError(t, b.Subscribe(events.TopicPricesUpdated, Handle(func(_ context.Context, p events.PricesUpdated) error {
+		got <- p
+		return nil
+	}), events.SubscribeOptions{}))
+
+	require.NoError(t, b.Publish(t.Context(), events.TopicPricesUpdated, events.PricesUpdated{"BTC": 1}))
+	assert.Equal(t, 1.0, (<-got)["BTC"])
+
+	err := Handle(func(context.Context, events.PricesUpdated) error { return nil })(t.Context(), events.Event{Payload: "nope"})
+	assert.ErrorIs(t, err, ErrUnexpectedType)
+}
+
+// blockingHandler holds the subscriber until release is closed so queue
+// policies can be observed deterministically.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}, seen *[]any, mu *sync.Mutex) events.Handler {
+	var once sync.Once
+	return func(_ context.Context, e events.Event) error {
+		once.Do(func() { close(started) })
+		<-release
+		mu.Lock()
+		*seen = append(*seen, e.Payload)
+		mu.Unlock()
+		return nil
+	}
+}
+
+func TestBus_DropPolicies(t *testing.T) {
+	tests := []struct {
+		policy events.Policy
+		want   []any
+	}{
+		{events.DropNewest, []any{0, 1, 2}},
+		{events.DropOldest, []any{0, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		b := newTestBus(t)
+		started, release := make(chan struct{}), make(chan struct{})
+		var seen []any
+		var mu sync.Mutex
+		require.NoError(t, b.Subscribe("test", blockingHandler(started, release, &seen, &mu), events.SubscribeOptions{QueueSize: 2, Policy: tt.policy}))
+
+		require.NoError(t, b.Publish(t.Context(), "test", 0))
+		<-started
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, b.Publish(t.Context(), "test", i))
+		}
+		close(release)
+		require.NoError(t, b.Close(context.Background()))
+
+		assert.Equal(t, tt.want, seen, "policy %d", tt.policy)
+	}
+}
+
+func TestBus_BlockPolicyAppliesBackpressure(t *testing.T) {
+	b := newTestBus(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	var seen []any
+	var mu sync.Mutex
+	require.NoError(t, b.Subscribe("test", blockingHandler(started, release, &seen, &mu), events.SubscribeOptions{QueueSize: 1, Policy: events.Block}))
+
+	require.NoError(t, b.Publish(t.Context(), "test", 0))
+	<-started
+	require.NoError(t, b.Publish(t.Context(), "test", 1))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Publish(ctx, "test", 2), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, []any{0, 1}, seen)
+}
+
+func TestBus_BlockedSubscriberDoesNotStopDelivery(t *testing.T) {
+	b := newTestBus(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	var seen []any
+	var mu sync.Mutex
+	require.NoError(t, b.Subscribe("test", blockingHandler(started, release, &seen, &mu), events.SubscribeOptions{Name: "slow", QueueSize: 1, Policy: events.Block}))
+	other := collect(t, b, "test", events.SubscribeOptions{})
+
+	require.NoError(t, b.Publish(t.Context(), "test", 0))
+	<-started
+	require.NoError(t, b.Publish(t.Context(), "test", 1))
+	next(t, other)
+	next(t, other)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	err := b.Publish(ctx, "test", 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "subscriber slow")
+	assert.Equal(t, 2, next(t, other).Payload, "later subscribers still get the event")
+
+	close(release)
+}
+
+func TestBus_CloseReleasesBlockedPublisher(t *testing.T) {
+	b := newTestBus(t)
+	blocked := make(chan error, 1)
+	require.NoError(t, b.Subscribe("test", func(ctx context.Context, e events.Event) error {
+		if e.Payload == 0 {
+			// The handler is the only consumer, so the second publish waits
+			// for room in its own full queue.
+			assert.NoError(t, b.Publish(ctx, "test", 1))
+			blocked <- b.Publish(ctx, "test", 2)
+		}
+		return nil
+	}, events.SubscribeOptions{QueueSize: 1, Policy: events.Block}))
+
+	require.NoError(t, b.Publish(t.Context(), "test", 0))
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close deadlocked behind a blocked publisher")
+	}
+	assert.ErrorIs(t, <-blocked, ErrBusClosed)
+}
+
+func TestBus_CloseDrainsAndRejects(t *testing.T) {
+	b := newTestBus(t)
+	var mu sync.Mutex
+	var handled int
+	require.NoError(t, b.Subscribe("test", func(context.Context, events.Event) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		return nil
+	}, events.SubscribeOptions{}))
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, b.Publish(t.Context(), "test", i))
+	}
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, 10, handled)
+
+	assert.ErrorIs(t, b.Publish(t.Context(), "test", 0), ErrBusClosed)
+	assert.ErrorIs(t, b.Subscribe("test", func(context.Context, events.Event) error { return nil }, events.SubscribeOptions{}), ErrBusClosed)
+}
+
+func TestBus_HandlerPanicDoesNotStopSubscriber(t *testing.T) {
+	b := newTestBus(t)
+	got := make(chan any, 2)
+	require.NoError(t, b.Subscribe("test", func(_ context.Context, e events.Event) error {
+		if e.Payload == "boom" {
+			panic("boom")
+		}
+		got <- e.Payload
+		return nil
+	}, events.SubscribeOptions{}))
+
+	require.NoError(t, b.Publish(t.Context(), "test", "boom"))
+	require.NoError(t, b.Publish(t.Context(), "test", "ok"))
+	assert.Equal(t, "ok", <-got)
+}
@@ -0,0 +1,209 @@
+// Package httpclient provides the HTTP client shared by the requests to one
+// upstream API. Requests wait for a rate limiter, and throttled or failed
+// requests are retried with backoff, honouring Retry-After.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultRetries      = 2
+	defaultBackoff      = 500 * time.Millisecond
+	defaultMaxRetryWait = 10 * time.Second
+)
+
+// Transport limits and retries requests to a single upstream. Every request
+// holding a context aborts as soon as that context is done, including while
+// it waits for the limiter or between retries.
+type Transport struct {
+	base         http.RoundTripper
+	limiter      *rate.Limiter
+	retries      int
+	backoff      time.Duration
+	maxRetryWait time.Duration
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+type Option func(*Transport)
+
+// WithRateLimit allows perSecond requests on average with bursts of burst.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(t *Transport) {
+		t.limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+	}
+}
+
+// WithRetries sets how many times a throttled or failed request is retried.
+func WithRetries(n int) Option {
+	return func(t *Transport) {
+		t.retries = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry; it doubles after each.
+func WithBackoff(d time.Duration) Option {
+	return func(t *Transport) {
+		t.backoff = d
+	}
+}
+
+// WithMaxRetryWait bounds how long a Retry-After is honoured. Responses asking
+// for a longer wait are returned to the caller instead of retried.
+func WithMaxRetryWait(d time.Duration) Option {
+	return func(t *Transport) {
+		t.maxRetryWait = d
+	}
+}
+
+func WithBaseTransport(rt http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.base = rt
+	}
+}
+
+func NewTransport(opts ...Option) *Transport {
+	t := &Transport{
+		base:         http.DefaultTransport,
+		limiter:      rate.NewLimiter(rate.Inf, 0),
+		retries:      defaultRetries,
+		backoff:      defaultBackoff,
+		maxRetryWait: defaultMaxRetryWait,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// New returns a client using a new Transport. The timeout bounds a request
+// including its retries.
+func New(opts ...Option) *http.Client {
+	return &http.Client{
+		Timeout:   defaultTimeout,
+		Transport: NewTransport(opts...),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// Requests with a body can only be resent when it can be recreated.
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return nil, err
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if !replayable || attempt >= t.retries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := t.retryDelay(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait blocks until the upstream's Retry-After has passed and the limiter
+// admits the request.
+func (t *Transport) wait(ctx context.Context) error {
+	t.mu.Lock()
+	blocked := time.Until(t.blockedUntil)
+	t.mu.Unlock()
+	if blocked > 0 {
+		if err := sleep(ctx, blocked); err != nil {
+			return err
+		}
+	}
+	return t.limiter.Wait(ctx)
+}
+
+// retryDelay decides whether a response or error is worth retrying and how
+// long to wait first. A Retry-After also holds back every other request to
+// the upstream until it has passed.
+func (t *Transport) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := t.backoff << attempt
+	if err != nil {
+		return backoff, true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if wait > t.maxRetryWait {
+				return 0, false
+			}
+			t.mu.Lock()
+			if until := time.Now().Add(wait); until.After(t.blockedUntil) {
+				t.blockedUntil = until
+			}
+			t.mu.Unlock()
+			return 0, true
+		}
+		return backoff, true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,193 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidManagerConfig = errors.New("invalid lifecycle manager config")
+	ErrStopTimeout          = errors.New("component did not stop in time")
+)
+
+const defaultStopTimeout = 10 * time.Second
+
+// Component is a part of the application with a start and stop step. Either
+// may be nil.
+type Component struct {
+	Name  string
+	Start func() error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were added and stops them in
+// reverse, so a component can rely on everything added before it.
+type Manager struct {
+	logger      *slog.Logger
+	stopTimeout time.Duration
+
+	mu         sync.Mutex
+	components []Component
+	started    int
+}
+
+type Option func(*Manager)
+
+func WithLogger(l *slog.Logger) Option {
+	return func(m *Manager) {
+		m.logger = l
+	}
+}
+
+// WithStopTimeout bounds how long each component may take to stop.
+func WithStopTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.stopTimeout = d
+	}
+}
+
+func (m *Manager) IsValid() error {
+	switch {
+	case m.logger == nil:
+		return fmt.Errorf("%w: logger cannot be nil", ErrInvalidManagerConfig)
+	case m.stopTimeout <= 0:
+		return fmt.Errorf("%w: stop timeout must be positive", ErrInvalidManagerConfig)
+	default:
+		return nil
+	}
+}
+
+func New(opts ...Option) (*Manager, error) {
+	m := &Manager{
+		stopTimeout: defaultStopTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if err := m.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Add registers a component. Components added after Start run on the next
+// call to Start.
+func (m *Manager) Add(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, c)
+}
+
+// Start starts every component not started yet. If one fails, the ones
+// already started are stopped again.
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.started < len(m.components) {
+		c := m.components[m.started]
+		if c.Start != nil {
+			if err := c.Start(); err != nil {
+				if stopErr := m.stopLocked(context.Background()); stopErr != nil {
+					m.logger.Error("failed to roll back startup", "error", stopErr)
+				}
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+		}
+		m.started++
+		m.logger.Debug("component started", "component", c.Name)
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Each one gets the
+// configured stop timeout, cut short if ctx ends first; a component that
+// overruns is abandoned and the next one is stopped.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopLocked(ctx)
+}
+
+func (m *Manager) stopLocked(ctx context.Context) error {
+	var errs []error
+	for m.started > 0 {
+		m.started--
+		c := m.components[m.started]
+		if c.Stop == nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := m.stopOne(ctx, c); err != nil {
+			m.logger.Error("component failed to stop", "component", c.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+			continue
+		}
+		m.logger.Debug("component stopped", "component", c.Name, "took", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stopOne(ctx context.Context, c Component) error {
+	stopCtx, cancel := context.WithTimeout(ctx, m.stopTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Stop(stopCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCtx.Done():
+		return ErrStopTimeout
+	}
+}
+
+// StopFunc adapts a blocking Stop method that takes no context.
+func StopFunc(stop func()) func(context.Context) error {
+	return func(context.Context) error {
+		stop()
+		return nil
+	}
+}
+
+// CloseFunc adapts a Close method that takes no context.
+func CloseFunc(closeFn func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return closeFn()
+	}
+}
+
+// HTTPServer listens on srv.Addr when started and shuts srv down gracefully,
+// letting in-flight requests finish, when stopped. Errors from serving after
+// a successful start are sent to errs if it is not nil.
+func HTTPServer(name string, srv *http.Server, errs chan<- error) Component {
+	return Component{
+		Name: name,
+		Start: func() error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) && errs != nil {
+					errs <- err
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	}
+}
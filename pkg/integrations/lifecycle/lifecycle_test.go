@@ -0,0 +1,175 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/integrations/eventbus"
+	"hodlbook/pkg/integrations/scheduler"
+	"hodlbook/pkg/types/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestManager(t *testing.T, opts ...Option) *Manager {
+	m, err := New(append([]Option{WithLogger(discardLogger)}, opts...)...)
+	require.NoError(t, err)
+	return m
+}
+
+func recorder(order *[]string, name string) Component {
+	return Component{
+		Name: name,
+		Start: func() error {
+			*order = append(*order, "start "+name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			*order = append(*order, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestManager_InvalidConfig(t *testing.T) {
+	_, err := New()
+	assert.ErrorIs(t, err, ErrInvalidManagerConfig)
+
+	_, err = New(WithLogger(discardLogger), WithStopTimeout(0))
+	assert.ErrorIs(t, err, ErrInvalidManagerConfig)
+}
+
+func TestManager_StartsInOrderStopsInReverse(t *testing.T) {
+	var order []string
+	m := newTestManager(t)
+	m.Add(recorder(&order, "db"))
+	m.Add(recorder(&order, "bus"))
+	m.Add(recorder(&order, "http"))
+
+	require.NoError(t, m.Start())
+	require.NoError(t, m.Stop(context.Background()))
+	require.NoError(t, m.Stop(context.Background()), "second stop is a no-op")
+
+	assert.Equal(t, []string{
+		"start db", "start bus", "start http",
+		"stop http", "stop bus", "stop db",
+	}, order)
+}
+
+func TestManager_StartFailureRollsBack(t *testing.T) {
+	var order []string
+	m := newTestManager(t)
+	m.Add(recorder(&order, "db"))
+	m.Add(Component{Name: "broken", Start: func() error { return errors.New("boom") }})
+	m.Add(recorder(&order, "http"))
+
+	err := m.Start()
+	assert.ErrorContains(t, err, "failed to start broken: boom")
+	assert.Equal(t, []string{"start db", "stop db"}, order)
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	var order []string
+	release := make(chan struct{})
+	defer close(release)
+
+	m := newTestManager(t, WithStopTimeout(20*time.Millisecond))
+	m.Add(recorder(&order, "db"))
+	m.Add(Component{Name: "stuck", Stop: StopFunc(func() { <-release })})
+
+	require.NoError(t, m.Start())
+	err := m.Stop(context.Background())
+	assert.ErrorIs(t, err, ErrStopTimeout)
+	assert.ErrorContains(t, err, "stuck")
+	assert.Equal(t, []string{"start db", "stop db"}, order, "later components still stop")
+}
+
+func TestManager_NoGoroutineLeaks(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := newTestManager(t)
+
+	bus, err := eventbus.New(eventbus.WithContext(ctx), eventbus.WithLogger(discardLogger))
+	require.NoError(t, err)
+	handled := make(chan struct{}, 1)
+	require.NoError(t, bus.Subscribe("test", func(context.Context, events.Event) error {
+		time.Sleep(20 * time.Millisecond)
+		handled <- struct{}{}
+		return nil
+	}, events.SubscribeOptions{}))
+	m.Add(Component{Name: "event bus", Stop: bus.Close})
+
+	sched, err := scheduler.New(
+		scheduler.WithContext(ctx),
+		scheduler.WithLogger(discardLogger),
+		scheduler.WithInterval(time.Millisecond),
+		scheduler.WithHandler(func(context.Context) error { return nil }),
+	)
+	require.NoError(t, err)
+	m.Add(Component{Name: "scheduler", Start: sched.Start, Stop: StopFunc(sched.Stop)})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	inFlight := make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(inFlight)
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+	m.Add(HTTPServer("http", srv, nil))
+
+	require.NoError(t, m.Start())
+
+	require.NoError(t, bus.Publish(ctx, "test", nil))
+
+	transport := &http.Transport{}
+	client := &http.Client{Transport: transport}
+	resp := make(chan int, 1)
+	go func() {
+		r, err := client.Get("http://" + addr)
+		if err != nil {
+			resp <- 0
+			return
+		}
+		r.Body.Close()
+		resp <- r.StatusCode
+	}()
+
+	<-inFlight
+	require.NoError(t, m.Stop(context.Background()))
+
+	assert.Equal(t, http.StatusNoContent, <-resp, "in-flight request is drained")
+	select {
+	case <-handled:
+	default:
+		t.Fatal("queued event was not handled before stop returned")
+	}
+
+	transport.CloseIdleConnections()
+
+	// assert.Eventually polls from its own goroutines, so count inline.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "goroutines leaked after stop")
+}
@@ -0,0 +1,326 @@
+// Package metrics is a small Prometheus-compatible metrics registry that
+// renders the text exposition format without pulling in client_golang.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var Default = NewRegistry()
+
+type collector interface {
+	name() string
+	metricType() string
+	write(w *strings.Builder)
+}
+
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// register returns the existing collector when one with the same name was
+// already registered, so package-level metrics survive repeated construction.
+// Registering a name again as a different metric type is a programming error
+// and panics.
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.collectors[c.name()]; ok {
+		if existing.metricType() != c.metricType() {
+			panic(fmt.Sprintf("metrics: %s is already registered as a %s, not a %s", c.name(), existing.metricType(), c.metricType()))
+		}
+		return existing
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, typeCounter, labels)}
+	return r.register(c).(*CounterVec)
+}
+
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec(name, help, typeGauge, labels)}
+	return r.register(g).(*GaugeVec)
+}
+
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{vec: newVec(name, help, typeHistogram, labels), buckets: sorted}
+	return r.register(h).(*HistogramVec)
+}
+
+// Update runs fn under the registry lock, so a scrape sees either none or all
+// of the changes it makes. fn must not register new metrics.
+func (r *Registry) Update(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
+
+// WriteText renders every registered metric in the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		r.collectors[name].write(&sb)
+	}
+	r.mu.RUnlock()
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.CounterVec(name, help, labels...)
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.GaugeVec(name, help, labels...)
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.HistogramVec(name, help, buckets, labels...)
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+type vec struct {
+	mu     sync.Mutex
+	fqName string
+	help   string
+	typ    string
+	labels []string
+	series map[string]*series
+}
+
+func newVec(name, help, typ string, labels []string) vec {
+	return vec{
+		fqName: name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+}
+
+func (v *vec) name() string {
+	return v.fqName
+}
+
+func (v *vec) metricType() string {
+	return v.typ
+}
+
+// get must be called with v.mu held.
+func (v *vec) get(labelValues []string) *series {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: values}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *vec) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.series = make(map[string]*series)
+}
+
+func (v *vec) sortedSeries() []*series {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*series, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, v.series[k])
+	}
+	return out
+}
+
+func (v *vec) writeHeader(sb *strings.Builder) {
+	sb.WriteString("# HELP " + v.fqName + " " + escapeHelp(v.help) + "\n")
+	sb.WriteString("# TYPE " + v.fqName + " " + v.typ + "\n")
+}
+
+func (v *vec) writeSimple(sb *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.writeHeader(sb)
+	for _, s := range v.sortedSeries() {
+		sb.WriteString(v.fqName + formatLabels(v.labels, s.labelValues, "", "") + " " + formatFloat(s.value) + "\n")
+	}
+}
+
+type CounterVec struct {
+	vec
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter. Negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues).value += delta
+}
+
+func (c *CounterVec) write(sb *strings.Builder) {
+	c.writeSimple(sb)
+}
+
+type GaugeVec struct {
+	vec
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value = value
+}
+
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value += delta
+}
+
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *GaugeVec) write(sb *strings.Builder) {
+	g.writeSimple(sb)
+}
+
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(sb *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(sb)
+	for _, s := range h.sortedSeries() {
+		for i, upper := range h.buckets {
+			var n uint64
+			if s.counts != nil {
+				n = s.counts[i]
+			}
+			sb.WriteString(h.fqName + "_bucket" + formatLabels(h.labels, s.labelValues, "le", formatFloat(upper)) + " " + strconv.FormatUint(n, 10) + "\n")
+		}
+		sb.WriteString(h.fqName + "_bucket" + formatLabels(h.labels, s.labelValues, "le", "+Inf") + " " + strconv.FormatUint(s.count, 10) + "\n")
+		sb.WriteString(h.fqName + "_sum" + formatLabels(h.labels, s.labelValues, "", "") + " " + formatFloat(s.sum) + "\n")
+		sb.WriteString(h.fqName + "_count" + formatLabels(h.labels, s.labelValues, "", "") + " " + strconv.FormatUint(s.count, 10) + "\n")
+	}
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, r *Registry) string {
+	var sb strings.Builder
+	require.NoError(t, r.WriteText(&sb))
+	return sb.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.CounterVec("test_requests_total", "Requests.", "method")
+
+	c.Inc("GET")
+	c.Add(2, "GET")
+	c.Inc("POST")
+	c.Add(-5, "POST")
+
+	out := render(t, r)
+	assert.Contains(t, out, "# HELP test_requests_total Requests.\n")
+	assert.Contains(t, out, "# TYPE test_requests_total counter\n")
+	assert.Contains(t, out, `test_requests_total{method="GET"} 3`+"\n")
+	assert.Contains(t, out, `test_requests_total{method="POST"} 1`+"\n")
+}
+
+func TestGaugeVec(t *testing.T) {
+	r := NewRegistry()
+	g := r.GaugeVec("test_subscribers", "Subscribers.")
+
+	g.Inc()
+	g.Inc()
+	g.Dec()
+
+	assert.Contains(t, render(t, r), "test_subscribers 1\n")
+
+	g.Reset()
+	assert.NotContains(t, render(t, r), "test_subscribers 1\n")
+
+	g.Set(4.5)
+	assert.Contains(t, render(t, r), "test_subscribers 4.5\n")
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.HistogramVec("test_duration_seconds", "Duration.", []float64{1, 0.1}, "op")
+
+	h.Observe(0.05, "fetch")
+	h.Observe(0.5, "fetch")
+	h.Observe(5, "fetch")
+
+	out := render(t, r)
+	assert.Contains(t, out, "# TYPE test_duration_seconds histogram\n")
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="fetch",le="0.1"} 1`+"\n")
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="fetch",le="1"} 2`+"\n")
+	assert.Contains(t, out, `test_duration_seconds_bucket{op="fetch",le="+Inf"} 3`+"\n")
+	assert.Contains(t, out, `test_duration_seconds_sum{op="fetch"} 5.55`+"\n")
+	assert.Contains(t, out, `test_duration_seconds_count{op="fetch"} 3`+"\n")
+}
+
+func TestRegistry_ReturnsExisting(t *testing.T) {
+	r := NewRegistry()
+	a := r.CounterVec("test_total", "Test.")
+	b := r.CounterVec("test_total", "Test.")
+	assert.Same(t, a, b)
+
+	assert.PanicsWithValue(t, "metrics: test_total is already registered as a counter, not a gauge", func() {
+		r.GaugeVec("test_total", "Test.")
+	})
+}
+
+func TestRegistry_Update(t *testing.T) {
+	r := NewRegistry()
+	g := r.GaugeVec("test_value", "Value.", "name")
+	g.Set(1, "old")
+
+	r.Update(func() {
+		g.Reset()
+		g.Set(2, "new")
+	})
+
+	out := render(t, r)
+	assert.NotContains(t, out, `name="old"`)
+	assert.Contains(t, out, `test_value{name="new"} 2`+"\n")
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	g := r.GaugeVec("test_value", "Value.", "name")
+	g.Set(1, "a \"quoted\"\\name\n")
+
+	assert.Contains(t, render(t, r), `test_value{name="a \"quoted\"\\name\n"} 1`)
+}
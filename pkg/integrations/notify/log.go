@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var _ notify.Notifier = (*LogNotifier)(nil)
+
+// LogNotifier writes notifications to the application log. It is always
+// available so alerts are visible even without external channels.
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Name() string {
+	return "log"
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg notify.Notification) error {
+	n.logger.Info("alert triggered", "title", msg.Title, "message", msg.Message)
+	return nil
+}
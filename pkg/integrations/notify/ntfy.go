@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var (
+	_ notify.Notifier = (*NtfyNotifier)(nil)
+	_ notify.Notifier = (*GotifyNotifier)(nil)
+)
+
+// NtfyNotifier publishes to an ntfy topic. Token is optional and sent as a
+// bearer token for protected topics.
+type NtfyNotifier struct {
+	Channel string
+	BaseURL string
+	Topic   string
+	Token   string
+	Client  *http.Client
+}
+
+func NewNtfyNotifier(baseURL, topic, token string) *NtfyNotifier {
+	if baseURL == "" {
+		baseURL = "https://ntfy.sh"
+	}
+	return &NtfyNotifier{
+		Channel: "ntfy",
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Topic:   topic,
+		Token:   token,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *NtfyNotifier) Name() string {
+	return n.Channel
+}
+
+func (n *NtfyNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	endpoint := fmt.Sprintf("%s/%s", n.BaseURL, n.Topic)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(msg.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	return send(n.Client, req)
+}
+
+// GotifyNotifier sends messages to a Gotify server using an application token.
+type GotifyNotifier struct {
+	Channel  string
+	BaseURL  string
+	Token    string
+	Priority int
+	Client   *http.Client
+}
+
+func NewGotifyNotifier(baseURL, token string) *GotifyNotifier {
+	return &GotifyNotifier{
+		Channel:  "gotify",
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Token:    token,
+		Priority: 5,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *GotifyNotifier) Name() string {
+	return n.Channel
+}
+
+func (n *GotifyNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	body, err := json.Marshal(map[string]any{
+		"title":    msg.Title,
+		"message":  msg.Message,
+		"priority": n.Priority,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal gotify payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.BaseURL+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.Token)
+
+	return send(n.Client, req)
+}
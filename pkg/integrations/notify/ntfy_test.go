@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNtfyNotifier_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "/hodlbook", r.URL.Path)
+		assert.Equal(t, "BTC high", r.Header.Get("Title"))
+		assert.Equal(t, "Bearer tk_123", r.Header.Get("Authorization"))
+		assert.Equal(t, "BTC is at $100000", string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewNtfyNotifier(server.URL+"/", "hodlbook", "tk_123")
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+}
+
+func TestGotifyNotifier_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/message", r.URL.Path)
+		assert.Equal(t, "app-token", r.Header.Get("X-Gotify-Key"))
+
+		var payload struct {
+			Title    string `json:"title"`
+			Message  string `json:"message"`
+			Priority int    `json:"priority"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "BTC high", payload.Title)
+		assert.Equal(t, 5, payload.Priority)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewGotifyNotifier(server.URL, "app-token")
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var _ notify.Notifier = (*RetryNotifier)(nil)
+
+// RetryNotifier retries a failed delivery with exponential backoff. Client
+// errors (4xx other than 429) are returned immediately since they will not
+// succeed on retry.
+type RetryNotifier struct {
+	notify.Notifier
+	Attempts int
+	Backoff  time.Duration
+}
+
+func NewRetryNotifier(n notify.Notifier, attempts int, backoff time.Duration) *RetryNotifier {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryNotifier{
+		Notifier: n,
+		Attempts: attempts,
+		Backoff:  backoff,
+	}
+}
+
+func (n *RetryNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	delay := n.Backoff
+
+	var err error
+	for attempt := 1; attempt <= n.Attempts; attempt++ {
+		if err = n.Notifier.Notify(ctx, msg); err == nil || !retryable(err) || attempt == n.Attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == 429 || statusErr.StatusCode >= 500
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryNotifier_RetriesServerErrors(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewRetryNotifier(NewWebhookNotifier(server.URL, ""), 3, time.Millisecond)
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "webhook", n.Name())
+}
+
+func TestRetryNotifier_StopsOnClientError(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	n := NewRetryNotifier(NewWebhookNotifier(server.URL, ""), 3, time.Millisecond)
+	require.Error(t, n.Notify(context.Background(), testNotification))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryNotifier_RespectsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	n := NewRetryNotifier(NewWebhookNotifier(server.URL, ""), 5, time.Second)
+	err := n.Notify(ctx, testNotification)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
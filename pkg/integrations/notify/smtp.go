@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var _ notify.Notifier = (*SMTPNotifier)(nil)
+
+// SMTPNotifier emails notifications. Authentication is only attempted when
+// Username is set; net/smtp refuses plain auth over unencrypted connections
+// to anything but localhost.
+type SMTPNotifier struct {
+	Channel  string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func NewSMTPNotifier(host string, port int, username, password, from string, to []string) *SMTPNotifier {
+	if port == 0 {
+		port = 587
+	}
+	return &SMTPNotifier{
+		Channel:  "smtp",
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+		To:       to,
+	}
+}
+
+func (n *SMTPNotifier) Name() string {
+	return n.Channel
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	if len(n.To) == 0 {
+		return fmt.Errorf("no recipients configured")
+	}
+	from, to, err := n.addresses()
+	if err != nil {
+		return err
+	}
+
+	body := n.message(from, to, msg)
+
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	// The deadline bounds a stalled server; cancelling ctx without one
+	// expires the connection at once, so nothing is left blocked on it.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := n.send(conn, from, to, body); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send runs one SMTP session over conn the way smtp.SendMail does: STARTTLS
+// when offered, then authentication when Username is set.
+func (n *SMTPNotifier) send(conn net.Conn, from *mail.Address, to []*mail.Address, body []byte) error {
+	c, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// addresses parses From and To as RFC 5322 addresses. Anything else, such as
+// a value smuggling extra headers in with CR/LF, is refused before it reaches
+// the message headers or the SMTP envelope.
+func (n *SMTPNotifier) addresses() (*mail.Address, []*mail.Address, error) {
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid from address %q: %w", n.From, err)
+	}
+	to := make([]*mail.Address, 0, len(n.To))
+	for _, raw := range n.To {
+		addr, err := mail.ParseAddress(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid recipient %q: %w", raw, err)
+		}
+		to = append(to, addr)
+	}
+	return from, to, nil
+}
+
+func (n *SMTPNotifier) message(from *mail.Address, to []*mail.Address, msg notify.Notification) []byte {
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("From: " + from.String() + "\r\n")
+	sb.WriteString("To: " + strings.Join(recipients, ", ") + "\r\n")
+	sb.WriteString("Subject: " + sanitizeHeader(msg.Title) + "\r\n")
+	sb.WriteString("Date: " + timestamp.Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Message, "\r\n", "\n"), "\n", "\r\n"))
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
+
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
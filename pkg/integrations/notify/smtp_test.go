@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single message and returns its envelope and data.
+func fakeSMTPServer(t *testing.T) (string, int, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var transcript strings.Builder
+		write("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				write("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				transcript.WriteString(strings.TrimSpace(line) + "\n")
+				write("250 OK")
+			case cmd == "DATA":
+				write("354 Go ahead")
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					transcript.WriteString(dataLine)
+				}
+				write("250 OK")
+			case cmd == "QUIT":
+				write("221 Bye")
+				received <- transcript.String()
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return host, port, received
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+
+	n := NewSMTPNotifier(host, port, "", "", "hodlbook@example.com", []string{"me@example.com"})
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<hodlbook@example.com>")
+	assert.Contains(t, transcript, "RCPT TO:<me@example.com>")
+	assert.Contains(t, transcript, "Subject: BTC high")
+	assert.Contains(t, transcript, "BTC is at $100000")
+}
+
+func TestSMTPNotifier_NormalizesLineEndings(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+
+	msg := testNotification
+	msg.Message = "first\r\nsecond\nthird"
+	n := NewSMTPNotifier(host, port, "", "", "hodlbook@example.com", []string{"me@example.com"})
+	require.NoError(t, n.Notify(context.Background(), msg))
+
+	transcript := <-received
+	assert.Contains(t, transcript, "first\r\nsecond\r\nthird\r\n")
+	assert.NotContains(t, transcript, "\r\r\n")
+}
+
+func TestSMTPNotifier_StalledServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	// The server accepts but never greets, then reports when the client
+	// hangs up.
+	closed := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1))
+		close(closed)
+	}()
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	n := NewSMTPNotifier(host, port, "", "", "hodlbook@example.com", []string{"me@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, n.Notify(ctx, testNotification), context.DeadlineExceeded)
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection to the stalled server was left open")
+	}
+}
+
+func TestSMTPNotifier_RejectsHeaderInjection(t *testing.T) {
+	n := NewSMTPNotifier("localhost", 25, "", "", "hodlbook@example.com\r\nBcc: evil@example.com", []string{"me@example.com"})
+	assert.ErrorContains(t, n.Notify(context.Background(), testNotification), "invalid from address")
+
+	n = NewSMTPNotifier("localhost", 25, "", "", "hodlbook@example.com", []string{"me@example.com\nBcc: evil@example.com"})
+	assert.ErrorContains(t, n.Notify(context.Background(), testNotification), "invalid recipient")
+}
+
+func TestSMTPNotifier_NoRecipients(t *testing.T) {
+	n := NewSMTPNotifier("localhost", 25, "", "", "hodlbook@example.com", nil)
+	require.Error(t, n.Notify(context.Background(), testNotification))
+}
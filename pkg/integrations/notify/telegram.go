@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var _ notify.Notifier = (*TelegramNotifier)(nil)
+
+// TelegramNotifier sends messages through the Telegram Bot API or any server
+// implementing its sendMessage method.
+type TelegramNotifier struct {
+	Channel string
+	BaseURL string
+	Token   string
+	ChatID  string
+	Client  *http.Client
+}
+
+func NewTelegramNotifier(token, chatID string) *TelegramNotifier {
+	return &TelegramNotifier{
+		Channel: "telegram",
+		BaseURL: "https://api.telegram.org",
+		Token:   token,
+		ChatID:  chatID,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *TelegramNotifier) Name() string {
+	return n.Channel
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	text := msg.Message
+	if msg.Title != "" {
+		text = msg.Title + "\n" + msg.Message
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"chat_id": n.ChatID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram payload: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(n.BaseURL, "/"), n.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !result.OK {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%w: %s", &StatusError{StatusCode: resp.StatusCode}, result.Description)
+		}
+		return fmt.Errorf("telegram error: %s", result.Description)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramNotifier_Notify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+
+		var payload map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "42", payload["chat_id"])
+		assert.Equal(t, "BTC high\nBTC is at $100000", payload["text"])
+
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	n := NewTelegramNotifier("123:abc", "42")
+	n.BaseURL = server.URL
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+}
+
+func TestTelegramNotifier_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok": false, "description": "Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	n := NewTelegramNotifier("123:abc", "42")
+	n.BaseURL = server.URL
+	err := n.Notify(context.Background(), testNotification)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chat not found")
+	assert.False(t, retryable(err))
+}
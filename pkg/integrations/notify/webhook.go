@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+)
+
+var _ notify.Notifier = (*WebhookNotifier)(nil)
+
+const SignatureHeader = "X-HodlBook-Signature"
+
+// WebhookNotifier POSTs notifications as JSON. When Secret is set the body is
+// signed with HMAC-SHA256 and sent in SignatureHeader as "sha256=<hex>".
+type WebhookNotifier struct {
+	Channel string
+	URL     string
+	Secret  string
+	Client  *http.Client
+}
+
+type webhookPayload struct {
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		Channel: "webhook",
+		URL:     url,
+		Secret:  secret,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return n.Channel
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg notify.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		Title:     msg.Title,
+		Message:   msg.Message,
+		Timestamp: msg.Timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	return send(n.Client, req)
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// StatusError is returned when a channel responds with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/types/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNotification = notify.Notification{
+	Title:     "BTC high",
+	Message:   "BTC is at $100000",
+	Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestWebhookNotifier_SignsPayload(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, "s3cret")
+	require.NoError(t, n.Notify(context.Background(), testNotification))
+
+	var payload webhookPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "BTC high", payload.Title)
+	assert.Equal(t, "BTC is at $100000", payload.Message)
+	assert.Equal(t, Sign("s3cret", body), signature)
+}
+
+func TestWebhookNotifier_Unsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL, "").Notify(context.Background(), testNotification)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
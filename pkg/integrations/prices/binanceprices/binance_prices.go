@@ -1,13 +1,14 @@
 package binanceprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/pairs"
 	"hodlbook/pkg/types/prices"
 )
@@ -16,6 +17,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to Binance are limited
+// together. Binance weighs requests; ten a second stays well inside its limit.
+var client = httpclient.New(httpclient.WithRateLimit(10, 20))
+
 type PriceFetcher struct {
 	BaseURL string
 	Client  *http.Client
@@ -24,15 +29,20 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://api.binance.com/api/v3",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 	}
 }
 
-func (b *PriceFetcher) Fetch(price *prices.Price) error {
+func (b *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
 	pair := price.Asset.Symbol + "USD" // Normalize to USD
 	endpoint := fmt.Sprintf("%s/ticker/price?symbol=%s", b.BaseURL, pair)
 
-	resp, err := b.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := b.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch price: %w", err)
 	}
@@ -63,10 +73,15 @@ func (b *PriceFetcher) Fetch(price *prices.Price) error {
 	return nil
 }
 
-func (b *PriceFetcher) FetchMany(prices ...*prices.Price) error {
+func (b *PriceFetcher) FetchMany(ctx context.Context, prices ...*prices.Price) error {
 	endpoint := fmt.Sprintf("%s/ticker/price", b.BaseURL)
 
-	resp, err := b.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := b.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch prices: %w", err)
 	}
@@ -99,10 +114,15 @@ func (b *PriceFetcher) FetchMany(prices ...*prices.Price) error {
 	return nil
 }
 
-func (b *PriceFetcher) FetchAll() ([]prices.Price, error) {
+func (b *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
 	endpoint := fmt.Sprintf("%s/ticker/price", b.BaseURL)
 
-	resp, err := b.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := b.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch prices: %w", err)
 	}
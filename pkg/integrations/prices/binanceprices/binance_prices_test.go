@@ -1,6 +1,7 @@
 package binanceprices
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -37,7 +38,7 @@ func TestPriceFetcher_Fetch(t *testing.T) {
 	}
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -72,7 +73,7 @@ func TestPriceFetcher_FetchMany(t *testing.T) {
 		{Asset: prices.Asset{Name: "Ethereum", Symbol: "ETH"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -109,7 +110,7 @@ func TestPriceFetcher_FetchMany_StablecoinsReturnOne(t *testing.T) {
 		{Asset: prices.Asset{Name: "USD Coin", Symbol: "USDC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	for _, pair := range testPrices {
@@ -131,7 +132,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
@@ -156,7 +157,7 @@ func TestPriceFetcher_FetchAll(t *testing.T) {
 		fetcher.BaseURL = server.URL
 	}
 
-	allPrices, err := fetcher.FetchAll()
+	allPrices, err := fetcher.FetchAll(context.Background())
 	require.NoError(t, err)
 
 	if isIntegration() {
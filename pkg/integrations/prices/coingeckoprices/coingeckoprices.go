@@ -1,12 +1,13 @@
 package coingeckoprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/types/prices"
 )
 
@@ -14,6 +15,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to CoinGecko are limited
+// together. The CoinGecko free tier allows about 30 requests a minute.
+var client = httpclient.New(httpclient.WithRateLimit(0.5, 30))
+
 type PriceFetcher struct {
 	BaseURL string
 	Client  *http.Client
@@ -22,17 +27,17 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://api.coingecko.com/api/v3",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 	}
 }
 
 // all asset prices are shown in USD cents
-func (c *PriceFetcher) Fetch(price *prices.Price) error {
-	return c.FetchMany(price)
+func (c *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
+	return c.FetchMany(ctx, price)
 }
 
 // all asset prices are shown in USD cents
-func (c *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (c *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
 	ids := make([]string, 0)
 	cryptoPairs := make([]*prices.Price, 0)
 
@@ -55,7 +60,12 @@ func (c *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 		strings.Join(ids, ","),
 	)
 
-	resp, err := c.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch prices: %w", err)
 	}
@@ -86,17 +96,17 @@ const (
 	defaultPerPage = 250
 )
 
-func (c *PriceFetcher) FetchAll() ([]prices.Price, error) {
-	return c.FetchAllPages(defaultPages)
+func (c *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
+	return c.FetchAllPages(ctx, defaultPages)
 }
 
-func (c *PriceFetcher) FetchAllPages(pages int) ([]prices.Price, error) {
+func (c *PriceFetcher) FetchAllPages(ctx context.Context, pages int) ([]prices.Price, error) {
 	pricesList := make([]prices.Price, 0, pages*defaultPerPage)
 
 	for page := 1; page <= pages; page++ {
-		pagePrices, err := c.fetchPage(page)
+		pagePrices, err := c.fetchPage(ctx, page)
 		if err != nil {
-			if page == 1 {
+			if page == 1 || ctx.Err() != nil {
 				return nil, err
 			}
 			break
@@ -110,11 +120,16 @@ func (c *PriceFetcher) FetchAllPages(pages int) ([]prices.Price, error) {
 	return pricesList, nil
 }
 
-func (c *PriceFetcher) fetchPage(page int) ([]prices.Price, error) {
+func (c *PriceFetcher) fetchPage(ctx context.Context, page int) ([]prices.Price, error) {
 	endpoint := fmt.Sprintf("%s/coins/markets?vs_currency=usd&per_page=%d&page=%d",
 		c.BaseURL, defaultPerPage, page)
 
-	resp, err := c.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch prices: %w", err)
 	}
@@ -1,6 +1,7 @@
 package coingeckoprices
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -34,7 +35,7 @@ func TestPriceFetcher_Fetch(t *testing.T) {
 	}
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	if isIntegration && err != nil && strings.Contains(err.Error(), "429") {
 		t.Skip("skipping due to rate limiting (429)")
 	}
@@ -69,7 +70,7 @@ func TestPriceFetcher_FetchMany(t *testing.T) {
 		{Asset: prices.Asset{Name: "Ethereum", Symbol: "ETH"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	if isIntegration && err != nil && strings.Contains(err.Error(), "429") {
 		t.Skip("skipping due to rate limiting (429)")
 	}
@@ -96,7 +97,7 @@ func TestPriceFetcher_FetchMany_StablecoinsReturnOne(t *testing.T) {
 		{Asset: prices.Asset{Name: "USD Coin", Symbol: "USDC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	for _, pair := range testPrices {
@@ -118,7 +119,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
@@ -168,7 +169,7 @@ func TestPriceFetcher_FetchAll(t *testing.T) {
 		fetcher.BaseURL = server.URL
 	}
 
-	allPrices, err := fetcher.FetchAll()
+	allPrices, err := fetcher.FetchAll(context.Background())
 	if isIntegration && err != nil && strings.Contains(err.Error(), "429") {
 		t.Skip("skipping due to rate limiting (429)")
 	}
@@ -219,7 +220,7 @@ func TestPriceFetcher_FetchAllPages_StopsOnEmptyPage(t *testing.T) {
 	fetcher := NewPriceFetcher()
 	fetcher.BaseURL = server.URL
 
-	allPrices, err := fetcher.FetchAllPages(5)
+	allPrices, err := fetcher.FetchAllPages(context.Background(), 5)
 	require.NoError(t, err)
 	assert.Len(t, allPrices, 1)
 	assert.Equal(t, 2, pageRequests)
@@ -234,7 +235,7 @@ func TestPriceFetcher_FetchAllPages_FirstPageError(t *testing.T) {
 	fetcher := NewPriceFetcher()
 	fetcher.BaseURL = server.URL
 
-	_, err := fetcher.FetchAllPages(3)
+	_, err := fetcher.FetchAllPages(context.Background(), 3)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
 }
@@ -261,7 +262,7 @@ func TestPriceFetcher_FetchAllPages_LaterPageError(t *testing.T) {
 	fetcher := NewPriceFetcher()
 	fetcher.BaseURL = server.URL
 
-	allPrices, err := fetcher.FetchAllPages(3)
+	allPrices, err := fetcher.FetchAllPages(context.Background(), 3)
 	require.NoError(t, err)
 	assert.Len(t, allPrices, 1)
 }
@@ -287,7 +288,7 @@ func TestPriceFetcher_FetchAllPages_SkipsNullPrices(t *testing.T) {
 	fetcher := NewPriceFetcher()
 	fetcher.BaseURL = server.URL
 
-	allPrices, err := fetcher.FetchAllPages(2)
+	allPrices, err := fetcher.FetchAllPages(context.Background(), 2)
 	require.NoError(t, err)
 	assert.Len(t, allPrices, 2)
 	assert.Equal(t, "bitcoin", allPrices[0].Asset.Name)
@@ -323,7 +324,7 @@ func TestPriceFetcher_FetchAllPages_StopsOnAllNullPage(t *testing.T) {
 	fetcher := NewPriceFetcher()
 	fetcher.BaseURL = server.URL
 
-	allPrices, err := fetcher.FetchAllPages(5)
+	allPrices, err := fetcher.FetchAllPages(context.Background(), 5)
 	require.NoError(t, err)
 	assert.Len(t, allPrices, 1)
 	assert.Equal(t, "bitcoin", allPrices[0].Asset.Name)
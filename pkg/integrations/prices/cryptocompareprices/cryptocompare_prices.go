@@ -1,12 +1,13 @@
 package cryptocompareprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/types/prices"
 )
 
@@ -14,6 +15,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to CryptoCompare are limited
+// together. CryptoCompare allows a few requests a second without a key.
+var client = httpclient.New(httpclient.WithRateLimit(5, 10))
+
 type PriceFetcher struct {
 	BaseURL string
 	Client  *http.Client
@@ -23,7 +28,7 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://min-api.cryptocompare.com/data",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 	}
 }
 
@@ -39,7 +44,7 @@ func (c *PriceFetcher) addAuth(req *http.Request) {
 	}
 }
 
-func (c *PriceFetcher) Fetch(price *prices.Price) error {
+func (c *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
 	symbol := strings.ToUpper(price.Asset.Symbol)
 	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
 		price.Value = 1.0
@@ -48,7 +53,7 @@ func (c *PriceFetcher) Fetch(price *prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/price?fsym=%s&tsyms=USD", c.BaseURL, symbol)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -77,7 +82,7 @@ func (c *PriceFetcher) Fetch(price *prices.Price) error {
 	return fmt.Errorf("price not found for %s", symbol)
 }
 
-func (c *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (c *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
 	symbols := make([]string, 0, len(pairs))
 	symbolToPair := make(map[string]*prices.Price)
 
@@ -97,7 +102,7 @@ func (c *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/pricemulti?fsyms=%s&tsyms=USD", c.BaseURL, strings.Join(symbols, ","))
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -129,10 +134,10 @@ func (c *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 	return nil
 }
 
-func (c *PriceFetcher) FetchAll() ([]prices.Price, error) {
+func (c *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
 	endpoint := fmt.Sprintf("%s/top/mktcapfull?limit=100&tsym=USD", c.BaseURL)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -1,6 +1,7 @@
 package cryptocompareprices
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -31,7 +32,7 @@ func TestPriceFetcher_Fetch(t *testing.T) {
 	}
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -63,7 +64,7 @@ func TestPriceFetcher_FetchMany(t *testing.T) {
 		{Asset: prices.Asset{Name: "Ethereum", Symbol: "ETH"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -87,7 +88,7 @@ func TestPriceFetcher_FetchMany_StablecoinsReturnOne(t *testing.T) {
 		{Asset: prices.Asset{Name: "USD Coin", Symbol: "USDC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	for _, pair := range testPrices {
@@ -109,7 +110,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
@@ -153,7 +154,7 @@ func TestPriceFetcher_FetchAll(t *testing.T) {
 		fetcher.BaseURL = server.URL
 	}
 
-	allPrices, err := fetcher.FetchAll()
+	allPrices, err := fetcher.FetchAll(context.Background())
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -188,7 +189,7 @@ func TestPriceFetcher_WithAPIKey(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	require.NoError(t, err)
 
 	assert.Equal(t, "Apikey test-api-key", receivedAuth)
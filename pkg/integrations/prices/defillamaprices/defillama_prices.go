@@ -1,12 +1,13 @@
 package defillamaprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/types/prices"
 )
 
@@ -14,6 +15,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to DefiLlama are limited
+// together. DefiLlama has no published limit; stay polite.
+var client = httpclient.New(httpclient.WithRateLimit(5, 10))
+
 type PriceFetcher struct {
 	BaseURL  string
 	Client   *http.Client
@@ -24,7 +29,7 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://coins.llama.fi",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 		Network: "ethereum",
 	}
 }
@@ -36,7 +41,7 @@ func NewPriceFetcherForToken(network, contract string) *PriceFetcher {
 	return f
 }
 
-func (d *PriceFetcher) Fetch(price *prices.Price) error {
+func (d *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
 	symbol := strings.ToUpper(price.Asset.Symbol)
 	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
 		price.Value = 1.0
@@ -54,7 +59,12 @@ func (d *PriceFetcher) Fetch(price *prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/prices/current/%s", d.BaseURL, identifier)
 
-	resp, err := d.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := d.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch price: %w", err)
 	}
@@ -94,7 +104,7 @@ func isContractAddress(s string) bool {
 	return strings.HasPrefix(strings.ToLower(s), "0x") && len(s) == 42
 }
 
-func (d *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (d *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
 	ids := make([]string, 0, len(pairs))
 	idToPair := make(map[string]*prices.Price)
 
@@ -115,7 +125,12 @@ func (d *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/prices/current/%s", d.BaseURL, strings.Join(ids, ","))
 
-	resp, err := d.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := d.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch prices: %w", err)
 	}
@@ -145,6 +160,6 @@ func (d *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 	return nil
 }
 
-func (d *PriceFetcher) FetchAll() ([]prices.Price, error) {
+func (d *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
 	return nil, fmt.Errorf("FetchAll not supported: DefiLlama requires specific coin identifiers")
 }
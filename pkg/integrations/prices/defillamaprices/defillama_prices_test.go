@@ -1,6 +1,7 @@
 package defillamaprices
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -39,7 +40,7 @@ func TestPriceFetcher_Fetch(t *testing.T) {
 	}
 
 	price := &prices.Price{Asset: prices.Asset{Name: "bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -79,7 +80,7 @@ func TestPriceFetcher_FetchMany(t *testing.T) {
 		{Asset: prices.Asset{Name: "ethereum", Symbol: "ETH"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -103,7 +104,7 @@ func TestPriceFetcher_FetchMany_StablecoinsReturnOne(t *testing.T) {
 		{Asset: prices.Asset{Name: "USD Coin", Symbol: "USDC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	for _, pair := range testPrices {
@@ -125,7 +126,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "bitcoin", Symbol: "BTC"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
@@ -133,7 +134,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 
 func TestPriceFetcher_FetchAll_NotSupported(t *testing.T) {
 	fetcher := NewPriceFetcher()
-	_, err := fetcher.FetchAll()
+	_, err := fetcher.FetchAll(context.Background())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not supported")
 }
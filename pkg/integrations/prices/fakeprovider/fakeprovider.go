@@ -0,0 +1,311 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hodlbook/pkg/types/prices"
+)
+
+// Server is an HTTP server answering like the Kraken, Binance and CoinGecko
+// price APIs, for testing the price service against every provider at once.
+// Each provider is served under its own path; point a fetcher's BaseURL at
+// KrakenURL, BinanceURL or CoinGeckoURL. Prices and failures can be changed
+// while it runs.
+type Server struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	assets   map[string]asset
+	failures map[string]int
+	delays   map[string]time.Duration
+	requests map[string]int
+}
+
+type asset struct {
+	name  string
+	price float64
+}
+
+// New starts a server without prices. Close it when done.
+func New() *Server {
+	s := &Server{
+		assets:   make(map[string]asset),
+		failures: make(map[string]int),
+		delays:   make(map[string]time.Duration),
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /kraken/0/public/Ticker", s.provider(prices.SourceKraken, s.krakenTicker))
+	mux.HandleFunc("GET /binance/api/v3/ticker/price", s.provider(prices.SourceBinance, s.binanceTicker))
+	mux.HandleFunc("GET /coingecko/api/v3/simple/price", s.provider(prices.SourceCoinGecko, s.coingeckoSimplePrice))
+	mux.HandleFunc("GET /coingecko/api/v3/coins/markets", s.provider(prices.SourceCoinGecko, s.coingeckoMarkets))
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) KrakenURL() string {
+	return s.server.URL + "/kraken/0/public"
+}
+
+func (s *Server) BinanceURL() string {
+	return s.server.URL + "/binance/api/v3"
+}
+
+func (s *Server) CoinGeckoURL() string {
+	return s.server.URL + "/coingecko/api/v3"
+}
+
+// URL returns the base URL of a provider by its prices.Source name, or an
+// empty string for one the server does not mimic.
+func (s *Server) URL(provider string) string {
+	switch provider {
+	case prices.SourceKraken:
+		return s.KrakenURL()
+	case prices.SourceBinance:
+		return s.BinanceURL()
+	case prices.SourceCoinGecko:
+		return s.CoinGeckoURL()
+	}
+	return ""
+}
+
+// SetPrice makes every provider quote symbol at price USD. The name is the
+// CoinGecko id; it defaults to the lowercased symbol.
+func (s *Server) SetPrice(symbol, name string, price float64) {
+	symbol = strings.ToUpper(symbol)
+	if name == "" {
+		name = strings.ToLower(symbol)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assets[symbol] = asset{name: name, price: price}
+}
+
+// RemovePrice stops every provider from quoting symbol.
+func (s *Server) RemovePrice(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.assets, strings.ToUpper(symbol))
+}
+
+// Fail makes a provider answer every request with status. A status of zero
+// or 200 makes it answer normally again. The fetchers retry 429 and most 5xx
+// answers with backoff, so other statuses keep tests fast.
+func (s *Server) Fail(provider string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 || status == http.StatusOK {
+		delete(s.failures, provider)
+		return
+	}
+	s.failures[provider] = status
+}
+
+// Delay holds a provider's answers back by d, or until the request is
+// cancelled.
+func (s *Server) Delay(provider string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[provider] = d
+}
+
+// Requests returns how many requests a provider has received.
+func (s *Server) Requests(provider string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[provider]
+}
+
+func (s *Server) provider(name string, handle func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[name]++
+		status := s.failures[name]
+		delay := s.delays[name]
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		handle(w, r)
+	}
+}
+
+// snapshot returns the assets sorted by symbol.
+func (s *Server) snapshot() ([]string, map[string]asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assets := make(map[string]asset, len(s.assets))
+	symbols := make([]string, 0, len(s.assets))
+	for symbol, a := range s.assets {
+		assets[symbol] = a
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+	return symbols, assets
+}
+
+func (s *Server) krakenTicker(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		Ask   []string `json:"a"`
+		Bid   []string `json:"b"`
+		Close []string `json:"c"`
+	}
+	symbols, assets := s.snapshot()
+
+	requested := symbols
+	if pair := r.URL.Query().Get("pair"); pair != "" {
+		requested = nil
+		for _, p := range strings.Split(pair, ",") {
+			symbol := fromKrakenPair(p)
+			if _, ok := assets[symbol]; !ok {
+				writeJSON(w, map[string]any{"error": []string{"EQuery:Unknown asset pair"}, "result": map[string]entry{}})
+				return
+			}
+			requested = append(requested, symbol)
+		}
+	}
+
+	result := make(map[string]entry, len(requested))
+	for _, symbol := range requested {
+		price := formatPrice(assets[symbol].price)
+		result[toKrakenPair(symbol)] = entry{
+			Ask:   []string{price, "1", "1.000"},
+			Bid:   []string{price, "1", "1.000"},
+			Close: []string{price, "0.01"},
+		}
+	}
+	writeJSON(w, map[string]any{"error": []string{}, "result": result})
+}
+
+func (s *Server) binanceTicker(w http.ResponseWriter, r *http.Request) {
+	type ticker struct {
+		Symbol string `json:"symbol"`
+		Price  string `json:"price"`
+	}
+	symbols, assets := s.snapshot()
+
+	if pair := r.URL.Query().Get("symbol"); pair != "" {
+		pair = strings.ToUpper(pair)
+		symbol := strings.TrimSuffix(strings.TrimSuffix(pair, "USDT"), "USD")
+		a, ok := assets[symbol]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"code": -1121, "msg": "Invalid symbol."})
+			return
+		}
+		writeJSON(w, ticker{Symbol: pair, Price: formatPrice(a.price)})
+		return
+	}
+
+	tickers := make([]ticker, 0, len(symbols))
+	for _, symbol := range symbols {
+		tickers = append(tickers, ticker{Symbol: symbol + "USDT", Price: formatPrice(assets[symbol].price)})
+	}
+	writeJSON(w, tickers)
+}
+
+func (s *Server) coingeckoSimplePrice(w http.ResponseWriter, r *http.Request) {
+	_, assets := s.snapshot()
+	byID := make(map[string]float64, len(assets))
+	for _, a := range assets {
+		byID[a.name] = a.price
+	}
+
+	result := make(map[string]map[string]float64)
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if price, ok := byID[strings.ToLower(id)]; ok {
+			result[id] = map[string]float64{"usd": price}
+		}
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) coingeckoMarkets(w http.ResponseWriter, r *http.Request) {
+	type market struct {
+		ID           string  `json:"id"`
+		Symbol       string  `json:"symbol"`
+		Name         string  `json:"name"`
+		CurrentPrice float64 `json:"current_price"`
+	}
+	symbols, assets := s.snapshot()
+
+	perPage := queryInt(r, "per_page", 100)
+	page := queryInt(r, "page", 1)
+	start := min((page-1)*perPage, len(symbols))
+	end := min(start+perPage, len(symbols))
+
+	markets := make([]market, 0, end-start)
+	for _, symbol := range symbols[start:end] {
+		a := assets[symbol]
+		markets = append(markets, market{
+			ID:           a.name,
+			Symbol:       strings.ToLower(symbol),
+			Name:         a.name,
+			CurrentPrice: a.price,
+		})
+	}
+	writeJSON(w, markets)
+}
+
+func queryInt(r *http.Request, key string, fallback int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// toKrakenPair and fromKrakenPair follow Kraken's naming, where BTC is XBT
+// and the oldest pairs carry X and Z prefixes.
+func toKrakenPair(symbol string) string {
+	switch symbol {
+	case "BTC":
+		return "XXBTZUSD"
+	case "ETH":
+		return "XETHZUSD"
+	}
+	return symbol + "USD"
+}
+
+func fromKrakenPair(pair string) string {
+	pair = strings.ToUpper(pair)
+	switch pair {
+	case "XXBTZUSD", "XBTUSD":
+		return "BTC"
+	case "XETHZUSD", "ETHUSD":
+		return "ETH"
+	}
+	return strings.TrimSuffix(pair, "USD")
+}
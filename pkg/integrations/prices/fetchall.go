@@ -0,0 +1,213 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hodlbook/pkg/types/prices"
+)
+
+const defaultProviderTimeout = 20 * time.Second
+
+// primaryProvider is a provider FetchAll merges. Providers are listed in
+// priority order: the first with a price for a symbol wins.
+type primaryProvider struct {
+	name    string
+	fetcher prices.PriceFetcher
+	// names marks the provider whose asset names replace the others'.
+	names bool
+}
+
+// ProviderError is the failure of one provider.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e ProviderError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Provider, e.Err)
+}
+
+func (e ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderErrors lists the providers that failed, in priority order.
+type ProviderErrors []ProviderError
+
+func (e ProviderErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, err := range e {
+		parts[i] = err.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e ProviderErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// FetchResult holds the prices merged from every provider that answered and
+// the errors of those that did not.
+type FetchResult struct {
+	Prices []prices.Price
+	Errors ProviderErrors
+}
+
+// Err returns the provider errors, or nil when every provider answered.
+func (r FetchResult) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors
+}
+
+// flight is a FetchAll in progress that concurrent callers share. It is
+// cancelled once every caller waiting on it has given up.
+type flight struct {
+	done    chan struct{}
+	result  FetchResult
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// FetchAll returns the merged prices of all primary providers. Prices from
+// the providers that answered are returned even when others failed; an
+// error is returned only when none of them did.
+func (p *PriceService) FetchAll(ctx context.Context) ([]prices.Price, error) {
+	result, err := p.FetchAllResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Prices) == 0 && len(result.Errors) > 0 {
+		return nil, result.Errors
+	}
+	return result.Prices, nil
+}
+
+// FetchAllResult is FetchAll reporting which providers failed. Providers are
+// asked concurrently, each bounded by the provider timeout, and callers
+// arriving while a fetch is in progress wait for it instead of starting
+// another. The error is set only when ctx is done first.
+func (p *PriceService) FetchAllResult(ctx context.Context) (FetchResult, error) {
+	if p.offline {
+		return FetchResult{}, ErrOffline
+	}
+	if cached, ok := p.cache.Get(); ok {
+		return FetchResult{Prices: cached}, nil
+	}
+
+	f := p.joinFlight(ctx)
+	select {
+	case <-f.done:
+		return f.result, nil
+	case <-ctx.Done():
+		p.leaveFlight(f)
+		return FetchResult{}, ctx.Err()
+	}
+}
+
+func (p *PriceService) joinFlight(ctx context.Context) *flight {
+	p.flightMu.Lock()
+	defer p.flightMu.Unlock()
+
+	// A flight every caller has left is being cancelled; start afresh.
+	if p.flight == nil || p.flight.waiters == 0 {
+		// The fetch outlives the caller that started it, as long as
+		// someone is still waiting for it.
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f := &flight{done: make(chan struct{}), cancel: cancel}
+		p.flight = f
+		go p.fly(flightCtx, f)
+	}
+	p.flight.waiters++
+	return p.flight
+}
+
+func (p *PriceService) leaveFlight(f *flight) {
+	p.flightMu.Lock()
+	defer p.flightMu.Unlock()
+
+	f.waiters--
+	if f.waiters == 0 {
+		f.cancel()
+	}
+}
+
+func (p *PriceService) fly(ctx context.Context, f *flight) {
+	defer f.cancel()
+
+	result := p.fetchAllProviders(ctx)
+	if ctx.Err() == nil && len(result.Prices) > 0 {
+		p.cache.Set(result.Prices)
+	}
+
+	p.flightMu.Lock()
+	if p.flight == f {
+		p.flight = nil
+	}
+	p.flightMu.Unlock()
+
+	f.result = result
+	close(f.done)
+}
+
+func (p *PriceService) fetchAllProviders(ctx context.Context) FetchResult {
+	fetched := make([][]prices.Price, len(p.primary))
+	errs := make([]error, len(p.primary))
+
+	var wg sync.WaitGroup
+	for i, provider := range p.primary {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			providerCtx, cancel := context.WithTimeout(ctx, p.providerTimeout)
+			defer cancel()
+			fetched[i], errs[i] = fetchAllFrom(providerCtx, provider.name, provider.fetcher)
+		}()
+	}
+	wg.Wait()
+
+	var result FetchResult
+	merged := make(map[string]prices.Price)
+	names := make(map[string]string)
+	for i, provider := range p.primary {
+		if errs[i] != nil {
+			result.Errors = append(result.Errors, ProviderError{Provider: provider.name, Err: errs[i]})
+			continue
+		}
+		for _, price := range fetched[i] {
+			if price.Value == 0 {
+				continue
+			}
+			symbol := price.Asset.Symbol
+			if _, ok := merged[symbol]; !ok {
+				price.Source = provider.name
+				merged[symbol] = price
+			}
+			if provider.names && price.Asset.Name != "" {
+				names[symbol] = price.Asset.Name
+			}
+		}
+	}
+
+	result.Prices = make([]prices.Price, 0, len(merged))
+	for symbol, price := range merged {
+		if name, ok := names[symbol]; ok {
+			price.Asset.Name = name
+		}
+		result.Prices = append(result.Prices, price)
+	}
+	slices.SortFunc(result.Prices, func(a, b prices.Price) int {
+		return strings.Compare(a.Asset.Symbol, b.Asset.Symbol)
+	})
+	return result
+}
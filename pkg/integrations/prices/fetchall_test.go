@@ -0,0 +1,189 @@
+package prices
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/types/prices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubFetcher struct {
+	prices []prices.Price
+	err    error
+	delay  time.Duration
+	calls  atomic.Int32
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, price *prices.Price) error {
+	return errors.New("not implemented")
+}
+
+func (f *stubFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
+	return errors.New("not implemented")
+}
+
+func (f *stubFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
+	f.calls.Add(1)
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return f.prices, f.err
+}
+
+func price(symbol, name string, value float64) prices.Price {
+	return prices.Price{Asset: prices.Asset{Symbol: symbol, Name: name}, Value: value}
+}
+
+func from(source string, p prices.Price) prices.Price {
+	p.Source = source
+	return p
+}
+
+func newStubService(kraken, binance, coingecko *stubFetcher) *PriceService {
+	p := NewPriceService()
+	p.primary = []primaryProvider{
+		{name: prices.SourceKraken, fetcher: kraken},
+		{name: prices.SourceBinance, fetcher: binance},
+		{name: prices.SourceCoinGecko, fetcher: coingecko, names: true},
+	}
+	return p
+}
+
+func TestFetchAll_MergesByPriority(t *testing.T) {
+	kraken := &stubFetcher{prices: []prices.Price{price("BTC", "BTC", 100), price("ETH", "ETH", 0)}, delay: 20 * time.Millisecond}
+	binance := &stubFetcher{prices: []prices.Price{price("BTC", "BTC", 101), price("ETH", "ETH", 10)}}
+	coingecko := &stubFetcher{prices: []prices.Price{price("BTC", "Bitcoin", 102), price("SOL", "Solana", 5)}}
+
+	result, err := newStubService(kraken, binance, coingecko).FetchAllResult(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, result.Err())
+
+	assert.Equal(t, []prices.Price{
+		from(prices.SourceKraken, price("BTC", "Bitcoin", 100)),
+		from(prices.SourceBinance, price("ETH", "ETH", 10)),
+		from(prices.SourceCoinGecko, price("SOL", "Solana", 5)),
+	}, result.Prices)
+}
+
+func TestFetchAll_ReturnsPartialResults(t *testing.T) {
+	kraken := &stubFetcher{err: errors.New("down")}
+	binance := &stubFetcher{prices: []prices.Price{price("BTC", "BTC", 101)}}
+	coingecko := &stubFetcher{delay: time.Second}
+
+	p := newStubService(kraken, binance, coingecko)
+	p.providerTimeout = 20 * time.Millisecond
+
+	result, err := p.FetchAllResult(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []prices.Price{from(prices.SourceBinance, price("BTC", "BTC", 101))}, result.Prices)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, prices.SourceKraken, result.Errors[0].Provider)
+	assert.Equal(t, prices.SourceCoinGecko, result.Errors[1].Provider)
+	assert.ErrorIs(t, result.Err(), context.DeadlineExceeded)
+
+	p.cache.ttl = 0
+	all, err := p.FetchAll(context.Background())
+	require.NoError(t, err, "FetchAll succeeds while any provider answers")
+	assert.Len(t, all, 1)
+}
+
+func TestFetchAll_FailsWhenEveryProviderFails(t *testing.T) {
+	failing := func() *stubFetcher { return &stubFetcher{err: errors.New("down")} }
+
+	_, err := newStubService(failing(), failing(), failing()).FetchAll(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "kraken: down; binance: down; coingecko: down", err.Error())
+}
+
+func TestFetchAll_CoalescesConcurrentCallers(t *testing.T) {
+	stub := func() *stubFetcher {
+		return &stubFetcher{prices: []prices.Price{price("BTC", "Bitcoin", 100)}, delay: 50 * time.Millisecond}
+	}
+	kraken, binance, coingecko := stub(), stub(), stub()
+	p := newStubService(kraken, binance, coingecko)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			all, err := p.FetchAll(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, all, 1)
+		}()
+	}
+	wg.Wait()
+
+	for _, f := range []*stubFetcher{kraken, binance, coingecko} {
+		assert.EqualValues(t, 1, f.calls.Load())
+	}
+}
+
+func TestFetchAll_CancelledCallerLeavesOthersWaiting(t *testing.T) {
+	stub := func() *stubFetcher {
+		return &stubFetcher{prices: []prices.Price{price("BTC", "Bitcoin", 100)}, delay: 50 * time.Millisecond}
+	}
+	p := newStubService(stub(), stub(), stub())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := p.FetchAll(ctx)
+		cancelled <- err
+	}()
+
+	waiting := make(chan []prices.Price, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		all, _ := p.FetchAll(context.Background())
+		waiting <- all
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	assert.Len(t, <-waiting, 1, "the shared fetch continues for the remaining caller")
+}
+
+func TestFetchAll_LastCallerLeavingCancelsFetch(t *testing.T) {
+	stub := func() *stubFetcher { return &stubFetcher{delay: time.Minute} }
+	kraken, binance, coingecko := stub(), stub(), stub()
+	p := newStubService(kraken, binance, coingecko)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := p.FetchAll(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	p.flightMu.Lock()
+	f := p.flight
+	p.flightMu.Unlock()
+	if f != nil {
+		select {
+		case <-f.done:
+		case <-time.After(time.Second):
+			t.Fatal("abandoned fetch was not cancelled")
+		}
+	}
+}
+
+func TestPriceService_OfflineSkipsProviders(t *testing.T) {
+	kraken := &stubFetcher{prices: []prices.Price{price("BTC", "Bitcoin", 100)}}
+	p := newStubService(kraken, &stubFetcher{}, &stubFetcher{})
+	WithOffline(true)(p)
+
+	_, err := p.FetchAll(context.Background())
+	assert.ErrorIs(t, err, ErrOffline)
+	assert.ErrorIs(t, p.FetchMany(context.Background(), &prices.Price{Asset: prices.Asset{Symbol: "BTC"}}), ErrOffline)
+	assert.ErrorIs(t, p.FetchBySource(context.Background(), prices.SourceKraken, &prices.Price{}), ErrOffline)
+	assert.Zero(t, kraken.calls.Load())
+}
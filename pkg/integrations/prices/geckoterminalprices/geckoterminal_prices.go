@@ -1,14 +1,15 @@
 package geckoterminalprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/types/prices"
 )
 
@@ -16,6 +17,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to GeckoTerminal are limited
+// together. GeckoTerminal allows 30 requests a minute.
+var client = httpclient.New(httpclient.WithRateLimit(0.5, 10))
+
 type PriceFetcher struct {
 	BaseURL string
 	Client  *http.Client
@@ -25,7 +30,7 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://api.geckoterminal.com/api/v2",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 		Network: "eth",
 	}
 }
@@ -36,7 +41,7 @@ func NewPriceFetcherForNetwork(network string) *PriceFetcher {
 	return f
 }
 
-func (g *PriceFetcher) Fetch(price *prices.Price) error {
+func (g *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
 	symbol := strings.ToUpper(price.Asset.Symbol)
 	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
 		price.Value = 1.0
@@ -44,17 +49,17 @@ func (g *PriceFetcher) Fetch(price *prices.Price) error {
 	}
 
 	if isPoolAddress(price.Asset.Name) || isPoolAddress(price.Asset.Symbol) {
-		return g.fetchByPoolAddress(price)
+		return g.fetchByPoolAddress(ctx, price)
 	}
 
-	return g.fetchBySearch(price)
+	return g.fetchBySearch(ctx, price)
 }
 
 func isPoolAddress(s string) bool {
 	return strings.HasPrefix(strings.ToLower(s), "0x") && len(s) == 42
 }
 
-func (g *PriceFetcher) fetchByPoolAddress(price *prices.Price) error {
+func (g *PriceFetcher) fetchByPoolAddress(ctx context.Context, price *prices.Price) error {
 	poolAddress := price.Asset.Name
 	if isPoolAddress(price.Asset.Symbol) {
 		poolAddress = price.Asset.Symbol
@@ -62,7 +67,12 @@ func (g *PriceFetcher) fetchByPoolAddress(price *prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/networks/%s/pools/%s", g.BaseURL, g.Network, strings.ToLower(poolAddress))
 
-	resp, err := g.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch pool: %w", err)
 	}
@@ -103,7 +113,7 @@ func (g *PriceFetcher) fetchByPoolAddress(price *prices.Price) error {
 	return nil
 }
 
-func (g *PriceFetcher) fetchBySearch(price *prices.Price) error {
+func (g *PriceFetcher) fetchBySearch(ctx context.Context, price *prices.Price) error {
 	symbol := strings.ToUpper(price.Asset.Symbol)
 
 	query := strings.ToLower(price.Asset.Symbol)
@@ -114,7 +124,12 @@ func (g *PriceFetcher) fetchBySearch(price *prices.Price) error {
 	endpoint := fmt.Sprintf("%s/search/pools?query=%s&network=%s&page=1",
 		g.BaseURL, url.QueryEscape(query), g.Network)
 
-	resp, err := g.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch price: %w", err)
 	}
@@ -158,24 +173,32 @@ func (g *PriceFetcher) fetchBySearch(price *prices.Price) error {
 	return nil
 }
 
-func (g *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (g *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
 	for _, pair := range pairs {
 		symbol := strings.ToUpper(pair.Asset.Symbol)
 		if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
 			pair.Value = 1.0
 			continue
 		}
-		if err := g.Fetch(pair); err != nil {
+		if err := g.Fetch(ctx, pair); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			continue
 		}
 	}
 	return nil
 }
 
-func (g *PriceFetcher) FetchAll() ([]prices.Price, error) {
+func (g *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
 	endpoint := fmt.Sprintf("%s/networks/%s/trending_pools?page=1", g.BaseURL, g.Network)
 
-	resp, err := g.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch prices: %w", err)
 	}
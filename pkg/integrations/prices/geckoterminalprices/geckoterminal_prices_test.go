@@ -1,6 +1,7 @@
 package geckoterminalprices
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -40,7 +41,7 @@ func TestPriceFetcher_Fetch(t *testing.T) {
 	}
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Wrapped Ether", Symbol: "WETH"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -86,7 +87,7 @@ func TestPriceFetcher_FetchMany(t *testing.T) {
 		{Asset: prices.Asset{Name: "Wrapped Bitcoin", Symbol: "WBTC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -110,7 +111,7 @@ func TestPriceFetcher_FetchMany_StablecoinsReturnOne(t *testing.T) {
 		{Asset: prices.Asset{Name: "USD Coin", Symbol: "USDC"}},
 	}
 
-	err := fetcher.FetchMany(testPrices...)
+	err := fetcher.FetchMany(context.Background(), testPrices...)
 	require.NoError(t, err)
 
 	for _, pair := range testPrices {
@@ -132,7 +133,7 @@ func TestPriceFetcher_Fetch_HTTPError(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Wrapped Ether", Symbol: "WETH"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "429")
@@ -156,7 +157,7 @@ func TestPriceFetcher_Fetch_NoPoolsFound(t *testing.T) {
 	fetcher.BaseURL = server.URL
 
 	price := &prices.Price{Asset: prices.Asset{Name: "Unknown", Symbol: "UNKNOWN"}}
-	err := fetcher.Fetch(price)
+	err := fetcher.Fetch(context.Background(), price)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no pools found")
@@ -204,7 +205,7 @@ func TestPriceFetcher_FetchAll(t *testing.T) {
 		fetcher.BaseURL = server.URL
 	}
 
-	allPrices, err := fetcher.FetchAll()
+	allPrices, err := fetcher.FetchAll(context.Background())
 	require.NoError(t, err)
 
 	if isIntegration() {
@@ -1,13 +1,14 @@
 package krakenprices
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
+	"hodlbook/pkg/integrations/httpclient"
 	"hodlbook/pkg/types/prices"
 )
 
@@ -15,6 +16,10 @@ var (
 	_ prices.PriceFetcher = (*PriceFetcher)(nil)
 )
 
+// client is shared by every fetcher so requests to Kraken are limited
+// together. Kraken allows roughly one public request per second.
+var client = httpclient.New(httpclient.WithRateLimit(1, 5))
+
 type PriceFetcher struct {
 	BaseURL string
 	Client  *http.Client
@@ -23,7 +28,7 @@ type PriceFetcher struct {
 func NewPriceFetcher() *PriceFetcher {
 	return &PriceFetcher{
 		BaseURL: "https://api.kraken.com/0/public",
-		Client:  &http.Client{Timeout: 10 * time.Second},
+		Client:  client,
 	}
 }
 
@@ -38,7 +43,7 @@ type tickerResultEntry struct {
 	Close []string `json:"c"` // [price, lot_volume] - last trade closed
 }
 
-func (k *PriceFetcher) Fetch(price *prices.Price) error {
+func (k *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
 	symbol := strings.ToUpper(price.Asset.Symbol)
 	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
 		price.Value = 1.0
@@ -48,7 +53,12 @@ func (k *PriceFetcher) Fetch(price *prices.Price) error {
 	pair := toKrakenPair(symbol)
 	endpoint := fmt.Sprintf("%s/Ticker?pair=%s", k.BaseURL, pair)
 
-	resp, err := k.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := k.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch price: %w", err)
 	}
@@ -81,7 +91,7 @@ func (k *PriceFetcher) Fetch(price *prices.Price) error {
 	return fmt.Errorf("no price found for %s", symbol)
 }
 
-func (k *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
+func (k *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
 	krakenPairs := make([]string, 0, len(pairs))
 	pairMap := make(map[string]*prices.Price)
 
@@ -102,7 +112,12 @@ func (k *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 
 	endpoint := fmt.Sprintf("%s/Ticker?pair=%s", k.BaseURL, strings.Join(krakenPairs, ","))
 
-	resp, err := k.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := k.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch prices: %w", err)
 	}
@@ -140,10 +155,15 @@ func (k *PriceFetcher) FetchMany(pairs ...*prices.Price) error {
 	return nil
 }
 
-func (k *PriceFetcher) FetchAll() ([]prices.Price, error) {
+func (k *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
 	endpoint := fmt.Sprintf("%s/Ticker", k.BaseURL)
 
-	resp, err := k.Client.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := k.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch prices: %w", err)
 	}
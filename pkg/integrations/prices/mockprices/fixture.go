@@ -0,0 +1,108 @@
+package mockprices
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Fixture lists the assets a PriceFetcher prices, for example:
+//
+//	{"assets": [
+//	  {"symbol": "BTC", "name": "bitcoin", "price": 60000},
+//	  {"symbol": "ETH", "history": [
+//	    {"time": "2024-01-01T00:00:00Z", "price": 2300},
+//	    {"time": "2024-02-01T00:00:00Z", "price": 2500}
+//	  ]}
+//	]}
+type Fixture struct {
+	Assets []FixtureAsset `json:"assets"`
+}
+
+// FixtureAsset walks around Price, or replays History when it is set: the
+// price at a time is that of the newest point at or before it, the first
+// point's before the history starts.
+type FixtureAsset struct {
+	Symbol  string         `json:"symbol"`
+	Name    string         `json:"name,omitempty"`
+	Price   float64        `json:"price,omitempty"`
+	History []FixturePoint `json:"history,omitempty"`
+}
+
+type FixturePoint struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+// LoadFixture reads a JSON fixture file.
+func LoadFixture(path string) (*Fixture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture: %w", err)
+	}
+	defer file.Close()
+
+	return ParseFixture(file)
+}
+
+// ParseFixture decodes and checks a JSON fixture.
+func ParseFixture(r io.Reader) (*Fixture, error) {
+	var fixture Fixture
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture: %w", err)
+	}
+	if err := fixture.IsValid(); err != nil {
+		return nil, err
+	}
+	for i := range fixture.Assets {
+		slices.SortFunc(fixture.Assets[i].History, func(a, b FixturePoint) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+	return &fixture, nil
+}
+
+func (f *Fixture) IsValid() error {
+	if len(f.Assets) == 0 {
+		return fmt.Errorf("fixture has no assets")
+	}
+
+	seen := make(map[string]bool, len(f.Assets))
+	for _, asset := range f.Assets {
+		symbol := strings.ToUpper(strings.TrimSpace(asset.Symbol))
+		switch {
+		case symbol == "":
+			return fmt.Errorf("fixture asset without a symbol")
+		case seen[symbol]:
+			return fmt.Errorf("fixture lists %s twice", symbol)
+		case len(asset.History) == 0 && asset.Price <= 0:
+			return fmt.Errorf("fixture asset %s needs a positive price or a history", symbol)
+		}
+		for _, point := range asset.History {
+			if point.Price <= 0 {
+				return fmt.Errorf("fixture asset %s has a non-positive price at %s", symbol, point.Time.Format(time.RFC3339))
+			}
+		}
+		seen[symbol] = true
+	}
+	return nil
+}
+
+func (a FixtureAsset) priceAt(at time.Time) float64 {
+	i, found := slices.BinarySearchFunc(a.History, at, func(p FixturePoint, t time.Time) int {
+		return p.Time.Compare(t)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		i = 0
+	}
+	return a.History[i].Price
+}
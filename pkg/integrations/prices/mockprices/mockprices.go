@@ -0,0 +1,234 @@
+package mockprices
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"hodlbook/pkg/types/prices"
+)
+
+var (
+	_ prices.PriceFetcher      = (*PriceFetcher)(nil)
+	_ prices.HistoricalFetcher = (*PriceFetcher)(nil)
+)
+
+const (
+	defaultVolatility = 0.02
+	// octaves is the number of time scales the walk is built from, the
+	// shortest an hour and each twice as long as the one before.
+	octaves = 11
+)
+
+// defaultAssets are priced when no fixture is loaded. Names are CoinGecko
+// ids, as the live providers report them.
+var defaultAssets = []FixtureAsset{
+	{Symbol: "BTC", Name: "bitcoin", Price: 60000},
+	{Symbol: "ETH", Name: "ethereum", Price: 3000},
+	{Symbol: "SOL", Name: "solana", Price: 150},
+	{Symbol: "BNB", Name: "binancecoin", Price: 600},
+	{Symbol: "XRP", Name: "ripple", Price: 0.6},
+	{Symbol: "ADA", Name: "cardano", Price: 0.45},
+	{Symbol: "DOGE", Name: "dogecoin", Price: 0.15},
+	{Symbol: "DOT", Name: "polkadot", Price: 7},
+	{Symbol: "LINK", Name: "chainlink", Price: 15},
+	{Symbol: "AVAX", Name: "avalanche-2", Price: 35},
+}
+
+// PriceFetcher serves made-up prices, for running without network access
+// and for tests. Each asset follows a deterministic random walk around its
+// base price: the same seed and time always give the same price, so
+// historical prices are as easy to ask for as current ones.
+//
+// Without a fixture every symbol has a price, the unknown ones a base price
+// derived from the symbol. With a fixture only its assets do.
+type PriceFetcher struct {
+	seed       uint64
+	volatility float64
+	now        func() time.Time
+	assets     map[string]FixtureAsset
+	// strict rejects symbols missing from assets.
+	strict bool
+}
+
+type Option func(*PriceFetcher)
+
+// WithSeed changes every walk. Fetchers with the same seed agree.
+func WithSeed(seed uint64) Option {
+	return func(f *PriceFetcher) {
+		f.seed = seed
+	}
+}
+
+// WithVolatility scales how far prices move in a day, as a fraction of the
+// price. Zero keeps every asset at its base price.
+func WithVolatility(v float64) Option {
+	return func(f *PriceFetcher) {
+		f.volatility = v
+	}
+}
+
+// WithClock sets the time current prices are taken at.
+func WithClock(now func() time.Time) Option {
+	return func(f *PriceFetcher) {
+		f.now = now
+	}
+}
+
+// WithFixture prices only the fixture's assets, replacing the defaults.
+func WithFixture(fixture *Fixture) Option {
+	return func(f *PriceFetcher) {
+		f.assets = make(map[string]FixtureAsset, len(fixture.Assets))
+		for _, asset := range fixture.Assets {
+			f.assets[strings.ToUpper(strings.TrimSpace(asset.Symbol))] = asset
+		}
+		f.strict = true
+	}
+}
+
+func NewPriceFetcher(opts ...Option) *PriceFetcher {
+	f := &PriceFetcher{
+		volatility: defaultVolatility,
+		now:        time.Now,
+		assets:     make(map[string]FixtureAsset, len(defaultAssets)),
+	}
+	for _, asset := range defaultAssets {
+		f.assets[asset.Symbol] = asset
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *PriceFetcher) Fetch(ctx context.Context, price *prices.Price) error {
+	return f.FetchAt(ctx, price, f.now())
+}
+
+// FetchAt sets the price the asset had at the given time.
+func (f *PriceFetcher) FetchAt(ctx context.Context, price *prices.Price, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	value, ok := f.priceAt(price.Asset.Symbol, at)
+	if !ok {
+		return fmt.Errorf("no price found for %s", strings.ToUpper(price.Asset.Symbol))
+	}
+	price.Value = value
+	return nil
+}
+
+func (f *PriceFetcher) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	at := f.now()
+	for _, pair := range pairs {
+		if value, ok := f.priceAt(pair.Asset.Symbol, at); ok {
+			pair.Value = value
+		}
+	}
+	return nil
+}
+
+// FetchAll returns the price of every default or fixture asset, sorted by
+// symbol.
+func (f *PriceFetcher) FetchAll(ctx context.Context) ([]prices.Price, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	at := f.now()
+	pricesList := make([]prices.Price, 0, len(f.assets))
+	for symbol, asset := range f.assets {
+		value, _ := f.priceAt(symbol, at)
+		name := asset.Name
+		if name == "" {
+			name = symbol
+		}
+		pricesList = append(pricesList, prices.Price{
+			Asset: prices.Asset{Name: name, Symbol: symbol},
+			Value: value,
+		})
+	}
+	slices.SortFunc(pricesList, func(a, b prices.Price) int {
+		return strings.Compare(a.Asset.Symbol, b.Asset.Symbol)
+	})
+	return pricesList, nil
+}
+
+func (f *PriceFetcher) priceAt(symbol string, at time.Time) (float64, bool) {
+	symbol = strings.ToUpper(symbol)
+	if symbol == "USD" || symbol == "USDT" || symbol == "USDC" {
+		return 1.0, true
+	}
+
+	asset, ok := f.assets[symbol]
+	if !ok {
+		if f.strict {
+			return 0, false
+		}
+		asset = FixtureAsset{Symbol: symbol, Price: f.basePrice(symbol)}
+	}
+	if len(asset.History) > 0 {
+		return asset.priceAt(at), true
+	}
+	return asset.Price * math.Exp(f.walk(symbol, at)), true
+}
+
+// basePrice picks a price between 0.1 and 1000 for a symbol without one.
+func (f *PriceFetcher) basePrice(symbol string) float64 {
+	u := float64(f.hash(symbol, 0, -1)>>11) / (1 << 53)
+	return math.Round(math.Pow(10, u*4-1)*100) / 100
+}
+
+// walk returns the log-price offset of symbol at a time. It sums smoothed
+// noise over several time scales, larger ones weighing more, which moves
+// like a random walk but needs no earlier steps to compute any instant.
+func (f *PriceFetcher) walk(symbol string, at time.Time) float64 {
+	if f.volatility == 0 {
+		return 0
+	}
+
+	hours := float64(at.Unix()) / 3600
+	var offset float64
+	for o := 0; o < octaves; o++ {
+		period := math.Exp2(float64(o))
+		pos := hours / period
+		knot := math.Floor(pos)
+		t := pos - knot
+		t = t * t * (3 - 2*t)
+
+		a := f.noise(symbol, o, int64(knot))
+		b := f.noise(symbol, o, int64(knot)+1)
+		offset += math.Sqrt(period/24) * (a + (b-a)*t)
+	}
+	return f.volatility * offset
+}
+
+// noise returns a value in [-1, 1) fixed by the seed, symbol, octave and
+// knot.
+func (f *PriceFetcher) noise(symbol string, octave int, knot int64) float64 {
+	return float64(f.hash(symbol, octave, knot)>>11)/(1<<52) - 1
+}
+
+func (f *PriceFetcher) hash(symbol string, octave int, knot int64) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	x := h.Sum64() ^ f.seed
+	x = splitmix(x ^ uint64(octave)<<56)
+	return splitmix(x ^ uint64(knot))
+}
+
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
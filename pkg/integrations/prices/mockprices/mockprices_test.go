@@ -0,0 +1,125 @@
+package mockprices
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/types/prices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var at = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func fixedClock() time.Time {
+	return at
+}
+
+func TestPriceFetcher_IsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	a := NewPriceFetcher(WithSeed(7), WithClock(fixedClock))
+	b := NewPriceFetcher(WithSeed(7), WithClock(fixedClock))
+	other := NewPriceFetcher(WithSeed(8), WithClock(fixedClock))
+
+	priceA := &prices.Price{Asset: prices.Asset{Symbol: "BTC"}}
+	priceB := &prices.Price{Asset: prices.Asset{Symbol: "btc"}}
+	priceOther := &prices.Price{Asset: prices.Asset{Symbol: "BTC"}}
+	require.NoError(t, a.Fetch(ctx, priceA))
+	require.NoError(t, b.Fetch(ctx, priceB))
+	require.NoError(t, other.Fetch(ctx, priceOther))
+
+	assert.Equal(t, priceA.Value, priceB.Value)
+	assert.NotEqual(t, priceA.Value, priceOther.Value)
+	assert.InEpsilon(t, 60000, priceA.Value, 0.5, "the walk stays near the base price")
+}
+
+func TestPriceFetcher_WalksOverTime(t *testing.T) {
+	ctx := context.Background()
+	f := NewPriceFetcher(WithClock(fixedClock))
+
+	current := &prices.Price{Asset: prices.Asset{Symbol: "ETH"}}
+	require.NoError(t, f.Fetch(ctx, current))
+
+	historic := &prices.Price{Asset: prices.Asset{Symbol: "ETH"}}
+	require.NoError(t, f.FetchAt(ctx, historic, at))
+	assert.Equal(t, current.Value, historic.Value, "Fetch is FetchAt now")
+
+	nearby := &prices.Price{Asset: prices.Asset{Symbol: "ETH"}}
+	require.NoError(t, f.FetchAt(ctx, nearby, at.Add(time.Minute)))
+	assert.NotEqual(t, current.Value, nearby.Value)
+	assert.InEpsilon(t, current.Value, nearby.Value, 0.01, "prices move smoothly")
+
+	month := &prices.Price{Asset: prices.Asset{Symbol: "ETH"}}
+	require.NoError(t, f.FetchAt(ctx, month, at.AddDate(0, -1, 0)))
+	assert.NotEqual(t, current.Value, month.Value)
+}
+
+func TestPriceFetcher_ZeroVolatilityKeepsBasePrice(t *testing.T) {
+	f := NewPriceFetcher(WithVolatility(0))
+
+	pairs := []*prices.Price{
+		{Asset: prices.Asset{Symbol: "SOL"}},
+		{Asset: prices.Asset{Symbol: "USDT"}},
+		{Asset: prices.Asset{Symbol: "UNLISTED"}},
+	}
+	require.NoError(t, f.FetchMany(context.Background(), pairs...))
+
+	assert.Equal(t, 150.0, pairs[0].Value)
+	assert.Equal(t, 1.0, pairs[1].Value)
+	assert.Greater(t, pairs[2].Value, 0.0, "symbols without a fixture get a derived price")
+}
+
+func TestPriceFetcher_FixtureReplaysHistory(t *testing.T) {
+	fixture, err := ParseFixture(strings.NewReader(`{"assets": [
+		{"symbol": "btc", "name": "bitcoin", "price": 50000},
+		{"symbol": "ETH", "history": [
+			{"time": "2024-02-01T00:00:00Z", "price": 2500},
+			{"time": "2024-01-01T00:00:00Z", "price": 2300}
+		]}
+	]}`))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	f := NewPriceFetcher(WithFixture(fixture), WithVolatility(0), WithClock(fixedClock))
+
+	all, err := f.FetchAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []prices.Price{
+		{Asset: prices.Asset{Name: "bitcoin", Symbol: "BTC"}, Value: 50000},
+		{Asset: prices.Asset{Name: "ETH", Symbol: "ETH"}, Value: 2500},
+	}, all)
+
+	for _, tc := range []struct {
+		at   time.Time
+		want float64
+	}{
+		{time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), 2300},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 2300},
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 2300},
+		{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 2500},
+	} {
+		price := &prices.Price{Asset: prices.Asset{Symbol: "ETH"}}
+		require.NoError(t, f.FetchAt(ctx, price, tc.at))
+		assert.Equal(t, tc.want, price.Value, tc.at)
+	}
+
+	err = f.Fetch(ctx, &prices.Price{Asset: prices.Asset{Symbol: "SOL"}})
+	assert.Error(t, err, "a fixture prices only its own assets")
+}
+
+func TestParseFixture_Rejects(t *testing.T) {
+	for name, body := range map[string]string{
+		"no assets":      `{"assets": []}`,
+		"no symbol":      `{"assets": [{"price": 1}]}`,
+		"duplicate":      `{"assets": [{"symbol": "BTC", "price": 1}, {"symbol": "btc", "price": 2}]}`,
+		"no price":       `{"assets": [{"symbol": "BTC"}]}`,
+		"negative point": `{"assets": [{"symbol": "BTC", "history": [{"time": "2024-01-01T00:00:00Z", "price": -1}]}]}`,
+		"unknown field":  `{"assets": [{"symbol": "BTC", "price": 1, "volume": 2}]}`,
+	} {
+		_, err := ParseFixture(strings.NewReader(body))
+		assert.Error(t, err, name)
+	}
+}
@@ -1,31 +1,77 @@
 package prices
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	"hodlbook/pkg/integrations/metrics"
 	"hodlbook/pkg/integrations/prices/binanceprices"
 	"hodlbook/pkg/integrations/prices/coingeckoprices"
 	"hodlbook/pkg/integrations/prices/cryptocompareprices"
 	"hodlbook/pkg/integrations/prices/defillamaprices"
 	"hodlbook/pkg/integrations/prices/geckoterminalprices"
 	"hodlbook/pkg/integrations/prices/krakenprices"
+	"hodlbook/pkg/integrations/prices/mockprices"
 	"hodlbook/pkg/types/prices"
 )
 
 var (
-	_ prices.PriceFetcher = (*PriceService)(nil)
+	_ prices.PriceFetcher  = (*PriceService)(nil)
+	_ prices.SourceFetcher = (*PriceService)(nil)
 )
 
+// ErrOffline is returned by every fetch while the providers are disabled.
+var ErrOffline = errors.New("price providers are disabled in offline mode")
+
+var (
+	providerFetchDuration = metrics.NewHistogramVec(
+		"hodlbook_price_provider_fetch_duration_seconds",
+		"Latency of price provider requests.",
+		nil, "provider", "operation",
+	)
+	providerFetchErrors = metrics.NewCounterVec(
+		"hodlbook_price_provider_fetch_errors_total",
+		"Failed price provider requests.",
+		"provider", "operation",
+	)
+)
+
+func recordFetch(provider, operation string, start time.Time, err error) {
+	providerFetchDuration.Observe(time.Since(start).Seconds(), provider, operation)
+	if err != nil {
+		providerFetchErrors.Inc(provider, operation)
+	}
+}
+
+func fetchFrom(ctx context.Context, provider string, fetcher prices.PriceFetcher, price *prices.Price) error {
+	start := time.Now()
+	err := fetcher.Fetch(ctx, price)
+	recordFetch(provider, "fetch", start, err)
+	return err
+}
+
+func fetchAllFrom(ctx context.Context, provider string, fetcher prices.PriceFetcher) ([]prices.Price, error) {
+	start := time.Now()
+	result, err := fetcher.FetchAll(ctx)
+	recordFetch(provider, "fetch_all", start, err)
+	return result, err
+}
+
 type cachedPrice struct {
 	value     float64
+	source    string
 	timestamp time.Time
 }
 
-// simple in-memory cache with 1m TTL
+const defaultCacheTTL = time.Minute
+
+// simple in-memory cache, entries expire after ttl
 type cache struct {
+	ttl       time.Duration
 	mu        sync.RWMutex
 	timestamp time.Time
 	prices    []prices.Price
@@ -35,7 +81,7 @@ type cache struct {
 func (c *cache) Get() ([]prices.Price, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	if time.Since(c.timestamp) > time.Minute {
+	if time.Since(c.timestamp) > c.ttl {
 		return nil, false
 	}
 	return c.prices, true
@@ -50,160 +96,176 @@ func (c *cache) Set(prices []prices.Price) {
 		if c.bySymbol == nil {
 			c.bySymbol = make(map[string]cachedPrice)
 		}
-		c.bySymbol[p.Asset.Symbol] = cachedPrice{value: p.Value, timestamp: time.Now()}
+		c.bySymbol[p.Asset.Symbol] = cachedPrice{value: p.Value, source: p.Source, timestamp: time.Now()}
 	}
 }
 
-func (c *cache) GetBySymbol(symbol string) (float64, bool) {
+func (c *cache) GetBySymbol(symbol string) (cachedPrice, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	p, ok := c.bySymbol[symbol]
-	if !ok || time.Since(p.timestamp) > time.Minute {
-		return 0, false
+	if !ok || time.Since(p.timestamp) > c.ttl {
+		return cachedPrice{}, false
 	}
-	return p.value, true
+	return p, true
 }
 
-func (c *cache) SetBySymbol(symbol string, value float64) {
+func (c *cache) SetBySymbol(symbol string, value float64, source string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.bySymbol == nil {
 		c.bySymbol = make(map[string]cachedPrice)
 	}
-	c.bySymbol[symbol] = cachedPrice{value: value, timestamp: time.Now()}
+	c.bySymbol[symbol] = cachedPrice{value: value, source: source, timestamp: time.Now()}
 }
 
+// PriceService merges prices from the major exchanges and falls back to the
+// other providers by name. Its fetchers are created once and share one
+// rate-limited client per provider.
 type PriceService struct {
-	kraken    prices.PriceFetcher
-	binance   prices.PriceFetcher
-	coingecko prices.PriceFetcher
-	cache     cache
+	primary         []primaryProvider
+	fetchers        map[string]prices.PriceFetcher
+	providerTimeout time.Duration
+	cache           cache
+	offline         bool
+
+	flightMu sync.Mutex
+	flight   *flight
 }
 
-func NewPriceService() *PriceService {
-	return &PriceService{
-		kraken:    krakenprices.NewPriceFetcher(),
-		binance:   binanceprices.NewPriceFetcher(),
-		coingecko: coingeckoprices.NewPriceFetcher(),
+type Option func(*PriceService)
+
+// WithCacheTTL sets how long fetched prices are reused before the providers
+// are asked again.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(p *PriceService) {
+		p.cache.ttl = ttl
 	}
 }
 
-func (p *PriceService) Fetch(price *prices.Price) error {
-	if cached, ok := p.cache.GetBySymbol(price.Asset.Symbol); ok {
-		price.Value = cached
-		return nil
+// WithProviderTimeout bounds how long FetchAll waits for each provider.
+func WithProviderTimeout(d time.Duration) Option {
+	return func(p *PriceService) {
+		p.providerTimeout = d
 	}
+}
 
-	err := p.kraken.Fetch(price)
-	if err == nil {
-		p.cache.SetBySymbol(price.Asset.Symbol, price.Value)
-		return nil
+// WithOffline disables every provider, for deployments without network
+// access. Prices then come only from those entered by hand.
+func WithOffline(offline bool) Option {
+	return func(p *PriceService) {
+		p.offline = offline
 	}
-	krakenErr := fmt.Errorf("kraken error: %w", err)
+}
 
-	err = p.binance.Fetch(price)
-	if err == nil {
-		p.cache.SetBySymbol(price.Asset.Symbol, price.Value)
-		return nil
+// WithMockPrices replaces every provider with a mock one, so prices are
+// made up locally instead of fetched.
+func WithMockPrices(fetcher *mockprices.PriceFetcher) Option {
+	return func(p *PriceService) {
+		p.primary = []primaryProvider{
+			{name: prices.SourceMock, fetcher: fetcher, names: true},
+		}
+		p.fetchers = map[string]prices.PriceFetcher{
+			prices.SourceMock: fetcher,
+		}
 	}
-	binanceErr := fmt.Errorf("binance error: %w", err)
+}
 
-	err = p.coingecko.Fetch(price)
-	if err == nil {
-		p.cache.SetBySymbol(price.Asset.Symbol, price.Value)
-		return nil
+// WithBaseURL points a provider at another server, such as a fake one in
+// tests. Unknown providers are ignored.
+func WithBaseURL(source, baseURL string) Option {
+	return func(p *PriceService) {
+		switch fetcher := p.fetchers[source].(type) {
+		case *krakenprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		case *binanceprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		case *coingeckoprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		case *cryptocompareprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		case *defillamaprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		case *geckoterminalprices.PriceFetcher:
+			fetcher.BaseURL = baseURL
+		}
 	}
-	coingeckoErr := fmt.Errorf("coingecko error: %w", err)
-
-	return fmt.Errorf("%w; %w; %w", krakenErr, binanceErr, coingeckoErr)
 }
 
-func (p *PriceService) FetchMany(pairs ...*prices.Price) error {
-	allPrices, err := p.FetchAll()
-	if err != nil {
-		return err
+func NewPriceService(opts ...Option) *PriceService {
+	kraken := krakenprices.NewPriceFetcher()
+	binance := binanceprices.NewPriceFetcher()
+	coingecko := coingeckoprices.NewPriceFetcher()
+
+	p := &PriceService{
+		primary: []primaryProvider{
+			{name: prices.SourceKraken, fetcher: kraken},
+			{name: prices.SourceBinance, fetcher: binance},
+			{name: prices.SourceCoinGecko, fetcher: coingecko, names: true},
+		},
+		providerTimeout: defaultProviderTimeout,
+		cache:           cache{ttl: defaultCacheTTL},
 	}
-
-	priceMap := make(map[string]float64, len(allPrices))
-	for _, price := range allPrices {
-		priceMap[price.Asset.Symbol] = price.Value
+	p.fetchers = map[string]prices.PriceFetcher{
+		prices.SourceKraken:        kraken,
+		prices.SourceBinance:       binance,
+		prices.SourceCoinGecko:     coingecko,
+		prices.SourceCryptoCompare: cryptocompareprices.NewPriceFetcher(),
+		prices.SourceDefiLlama:     defillamaprices.NewPriceFetcher(),
+		prices.SourceGeckoTerminal: geckoterminalprices.NewPriceFetcher(),
 	}
-
-	for _, pair := range pairs {
-		if value, ok := priceMap[pair.Asset.Symbol]; ok {
-			pair.Value = value
-		}
+	for _, opt := range opts {
+		opt(p)
 	}
-
-	return nil
+	return p
 }
 
-func (p *PriceService) FetchAll() ([]prices.Price, error) {
-	if cached, ok := p.cache.Get(); ok {
-		return cached, nil
+// Fetch asks the primary providers in priority order until one has the price.
+func (p *PriceService) Fetch(ctx context.Context, price *prices.Price) error {
+	if p.offline {
+		return ErrOffline
 	}
-
-	merged := make(map[string]prices.Price)
-
-	krakenPrices, krakenErr := p.kraken.FetchAll()
-	if krakenErr == nil {
-		for _, price := range krakenPrices {
-			if price.Value == 0 {
-				continue
-			}
-			symbol := price.Asset.Symbol
-			merged[symbol] = price
-		}
+	if cached, ok := p.cache.GetBySymbol(price.Asset.Symbol); ok {
+		price.Value = cached.value
+		price.Source = cached.source
+		return nil
 	}
 
-	binPrices, binErr := p.binance.FetchAll()
-	if binErr == nil {
-		for _, price := range binPrices {
-			if price.Value == 0 {
-				continue
-			}
-			symbol := price.Asset.Symbol
-			if _, ok := merged[symbol]; !ok {
-				merged[symbol] = price
-			}
+	var errs ProviderErrors
+	for _, provider := range p.primary {
+		err := fetchFrom(ctx, provider.name, provider.fetcher, price)
+		if err == nil {
+			price.Source = provider.name
+			p.cache.SetBySymbol(price.Asset.Symbol, price.Value, provider.name)
+			return nil
 		}
-	}
-
-	cgPrices, cgErr := p.coingecko.FetchAll()
-	if cgErr == nil {
-		for _, price := range cgPrices {
-			if price.Value == 0 {
-				continue
-			}
-			symbol := price.Asset.Symbol
-			if existing, ok := merged[symbol]; ok {
-				existing.Asset.Name = price.Asset.Name
-				merged[symbol] = existing
-			} else {
-				merged[symbol] = price
-			}
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+		errs = append(errs, ProviderError{Provider: provider.name, Err: err})
 	}
+	return errs
+}
 
-	if krakenErr != nil && binErr != nil && cgErr != nil {
-		return nil, fmt.Errorf("kraken: %w; binance: %w; coingecko: %w", krakenErr, binErr, cgErr)
+func (p *PriceService) FetchMany(ctx context.Context, pairs ...*prices.Price) error {
+	allPrices, err := p.FetchAll(ctx)
+	if err != nil {
+		return err
 	}
 
-	pricesList := make([]prices.Price, 0, len(merged))
-	for _, price := range merged {
-		pricesList = append(pricesList, price)
+	priceMap := make(map[string]prices.Price, len(allPrices))
+	for _, price := range allPrices {
+		priceMap[price.Asset.Symbol] = price
 	}
 
-	p.cache.Set(pricesList)
-	return pricesList, nil
-}
+	for _, pair := range pairs {
+		if price, ok := priceMap[pair.Asset.Symbol]; ok {
+			pair.Value = price.Value
+			pair.Source = price.Source
+		}
+	}
 
-var deepSearchProviders = map[string]func() prices.PriceFetcher{
-	prices.SourceKraken:        func() prices.PriceFetcher { return krakenprices.NewPriceFetcher() },
-	prices.SourceBinance:       func() prices.PriceFetcher { return binanceprices.NewPriceFetcher() },
-	prices.SourceCoinGecko:     func() prices.PriceFetcher { return coingeckoprices.NewPriceFetcher() },
-	prices.SourceDefiLlama:     func() prices.PriceFetcher { return defillamaprices.NewPriceFetcher() },
-	prices.SourceGeckoTerminal: func() prices.PriceFetcher { return geckoterminalprices.NewPriceFetcher() },
+	return nil
 }
 
 func AvailableDeepSearchProviders() []string {
@@ -225,7 +287,10 @@ type DeepSearchResult struct {
 	Network     string `json:"network,omitempty"`
 }
 
-func (p *PriceService) DeepSearch(query string, name string, network string, providers []string) ([]DeepSearchResult, error) {
+func (p *PriceService) DeepSearch(ctx context.Context, query string, name string, network string, providers []string) ([]DeepSearchResult, error) {
+	if p.offline {
+		return nil, ErrOffline
+	}
 	if len(providers) == 0 {
 		providers = AvailableDeepSearchProviders()
 	}
@@ -245,27 +310,21 @@ func (p *PriceService) DeepSearch(query string, name string, network string, pro
 	var results []DeepSearchResult
 
 	for _, providerName := range providers {
-		var fetcher prices.PriceFetcher
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
+		fetcher, ok := p.fetchers[providerName]
+		if !ok || providerName == prices.SourceCryptoCompare {
+			continue
+		}
 		if network != "" && isPoolAddress(query) {
 			switch providerName {
 			case prices.SourceGeckoTerminal:
 				fetcher = geckoterminalprices.NewPriceFetcherForNetwork(network)
 			case prices.SourceDefiLlama:
 				fetcher = defillamaprices.NewPriceFetcherForToken(network, query)
-			default:
-				factory, ok := deepSearchProviders[providerName]
-				if !ok {
-					continue
-				}
-				fetcher = factory()
-			}
-		} else {
-			factory, ok := deepSearchProviders[providerName]
-			if !ok {
-				continue
 			}
-			fetcher = factory()
 		}
 
 		price := &prices.Price{
@@ -275,7 +334,7 @@ func (p *PriceService) DeepSearch(query string, name string, network string, pro
 			},
 		}
 
-		if err := fetcher.Fetch(price); err != nil {
+		if err := fetchFrom(ctx, providerName, fetcher, price); err != nil {
 			continue
 		}
 
@@ -300,21 +359,17 @@ func isPoolAddress(s string) bool {
 	return strings.HasPrefix(strings.ToLower(s), "0x") && len(s) == 42
 }
 
-func (p *PriceService) FetchBySource(source string, price *prices.Price) error {
-	switch source {
-	case prices.SourceCryptoCompare:
-		return cryptocompareprices.NewPriceFetcher().Fetch(price)
-	case prices.SourceDefiLlama:
-		return defillamaprices.NewPriceFetcher().Fetch(price)
-	case prices.SourceGeckoTerminal:
-		return geckoterminalprices.NewPriceFetcher().Fetch(price)
-	case prices.SourceCoinGecko:
-		return p.coingecko.Fetch(price)
-	case prices.SourceBinance:
-		return p.binance.Fetch(price)
-	case prices.SourceKraken:
-		return p.kraken.Fetch(price)
-	default:
-		return p.Fetch(price)
+func (p *PriceService) FetchBySource(ctx context.Context, source string, price *prices.Price) error {
+	if p.offline {
+		return ErrOffline
 	}
+	fetcher, ok := p.fetchers[source]
+	if !ok {
+		return p.Fetch(ctx, price)
+	}
+	if err := fetchFrom(ctx, source, fetcher, price); err != nil {
+		return err
+	}
+	price.Source = source
+	return nil
 }
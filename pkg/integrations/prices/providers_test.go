@@ -0,0 +1,93 @@
+package prices_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"hodlbook/pkg/integrations/prices"
+	"hodlbook/pkg/integrations/prices/fakeprovider"
+	"hodlbook/pkg/integrations/prices/mockprices"
+	types "hodlbook/pkg/types/prices"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeService(t *testing.T, opts ...prices.Option) (*prices.PriceService, *fakeprovider.Server) {
+	t.Helper()
+	server := fakeprovider.New()
+	t.Cleanup(server.Close)
+
+	opts = append([]prices.Option{
+		prices.WithBaseURL(types.SourceKraken, server.KrakenURL()),
+		prices.WithBaseURL(types.SourceBinance, server.BinanceURL()),
+		prices.WithBaseURL(types.SourceCoinGecko, server.CoinGeckoURL()),
+	}, opts...)
+	return prices.NewPriceService(opts...), server
+}
+
+func TestPriceService_FetchFallsBackToNextProvider(t *testing.T) {
+	svc, server := newFakeService(t)
+	server.SetPrice("BTC", "bitcoin", 64000)
+	server.Fail(types.SourceKraken, http.StatusForbidden)
+
+	price := &types.Price{Asset: types.Asset{Name: "bitcoin", Symbol: "BTC"}}
+	require.NoError(t, svc.Fetch(context.Background(), price))
+
+	assert.Equal(t, 64000.0, price.Value)
+	assert.Equal(t, types.SourceBinance, price.Source)
+	assert.Equal(t, 1, server.Requests(types.SourceKraken))
+}
+
+func TestPriceService_FetchReportsEveryProvider(t *testing.T) {
+	svc, server := newFakeService(t)
+	for _, provider := range []string{types.SourceKraken, types.SourceBinance, types.SourceCoinGecko} {
+		server.Fail(provider, http.StatusUnauthorized)
+	}
+
+	err := svc.Fetch(context.Background(), &types.Price{Asset: types.Asset{Name: "bitcoin", Symbol: "BTC"}})
+
+	var errs prices.ProviderErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 3)
+}
+
+func TestPriceService_FetchAllSkipsSlowProvider(t *testing.T) {
+	svc, server := newFakeService(t, prices.WithProviderTimeout(200*time.Millisecond))
+	server.SetPrice("BTC", "bitcoin", 64000)
+	server.SetPrice("ETH", "ethereum", 3100)
+	server.Delay(types.SourceKraken, time.Second)
+	server.Fail(types.SourceBinance, http.StatusUnavailableForLegalReasons)
+
+	result, err := svc.FetchAllResult(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, result.Prices, 2)
+	for _, price := range result.Prices {
+		assert.Equal(t, types.SourceCoinGecko, price.Source)
+	}
+	assert.Equal(t, "bitcoin", result.Prices[0].Asset.Name)
+	assert.Equal(t, 64000.0, result.Prices[0].Value)
+
+	failed := make([]string, 0, len(result.Errors))
+	for _, providerErr := range result.Errors {
+		failed = append(failed, providerErr.Provider)
+	}
+	assert.Equal(t, []string{types.SourceKraken, types.SourceBinance}, failed)
+}
+
+func TestPriceService_MockPrices(t *testing.T) {
+	mock := mockprices.NewPriceFetcher(mockprices.WithVolatility(0))
+	svc := prices.NewPriceService(prices.WithMockPrices(mock))
+
+	all, err := svc.FetchAll(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	price := &types.Price{Asset: types.Asset{Symbol: "BTC"}}
+	require.NoError(t, svc.Fetch(context.Background(), price))
+	assert.Equal(t, 60000.0, price.Value)
+	assert.Equal(t, types.SourceMock, price.Source)
+}
@@ -3,8 +3,11 @@ package scheduler
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
+	"hodlbook/pkg/integrations/metrics"
+
 	"github.com/pkg/errors"
 )
 
@@ -12,18 +15,45 @@ var (
 	ErrInvalidSchedulerConfig = errors.New("invalid scheduler config")
 )
 
+var (
+	tickDuration = metrics.NewHistogramVec(
+		"hodlbook_scheduler_tick_duration_seconds",
+		"Duration of scheduler handler runs.",
+		[]float64{.01, .1, .5, 1, 5, 10, 30, 60, 300},
+		"scheduler",
+	)
+	tickErrors = metrics.NewCounterVec(
+		"hodlbook_scheduler_tick_errors_total",
+		"Scheduler handler runs that returned an error.",
+		"scheduler",
+	)
+)
+
 type Scheduler struct {
+	name         string
 	interval     time.Duration
 	ctx          context.Context
 	logger       *slog.Logger
-	handler      func() error
-	ticker       *time.Ticker
+	handler      func(ctx context.Context) error
 	targetHour   int           // hour of day to run (0-23), -1 to disable
 	initialDelay time.Duration // delay before first tick, 0 to disable
+
+	runCtx   context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 type Option func(*Scheduler)
 
+// WithName labels the scheduler's metrics.
+func WithName(name string) Option {
+	return func(s *Scheduler) {
+		s.name = name
+	}
+}
+
 func WithInterval(d time.Duration) Option {
 	return func(s *Scheduler) {
 		s.interval = d
@@ -42,7 +72,9 @@ func WithLogger(l *slog.Logger) Option {
 	}
 }
 
-func WithHandler(h func() error) Option {
+// WithHandler sets the function run on each tick. Its context is cancelled
+// when the scheduler is stopped, aborting a run in progress.
+func WithHandler(h func(ctx context.Context) error) Option {
 	return func(s *Scheduler) {
 		s.handler = h
 	}
@@ -77,7 +109,9 @@ func (s *Scheduler) IsValid() error {
 
 func New(opts ...Option) (*Scheduler, error) {
 	s := &Scheduler{
+		name:       "default",
 		targetHour: -1,
+		stop:       make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -92,7 +126,10 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
+	s.runCtx, s.cancel = context.WithCancel(s.ctx)
+	s.done = make(chan struct{})
 	go func() {
+		defer close(s.done)
 		if s.targetHour >= 0 {
 			s.runAtTargetHour()
 		} else {
@@ -117,9 +154,11 @@ func (s *Scheduler) runAtTargetHour() {
 		select {
 		case <-time.After(delay):
 			s.logger.Info("scheduler firing at target hour")
-			if err := s.handler(); err != nil {
+			if err := s.run(); err != nil {
 				s.logger.Error("scheduler handler error", "error", err)
 			}
+		case <-s.stop:
+			return
 		case <-s.ctx.Done():
 			return
 		}
@@ -127,17 +166,19 @@ func (s *Scheduler) runAtTargetHour() {
 }
 
 func (s *Scheduler) runAtInterval() {
-	s.ticker = time.NewTicker(s.interval)
-	defer s.ticker.Stop()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
 
 	if s.initialDelay > 0 {
 		s.logger.Info("scheduler started with initial delay", "delay", s.initialDelay, "interval", s.interval)
 		select {
 		case <-time.After(s.initialDelay):
 			s.logger.Info("scheduler initial tick firing")
-			if err := s.handler(); err != nil {
+			if err := s.run(); err != nil {
 				s.logger.Error("scheduler handler error", "interval", s.interval, "error", err)
 			}
+		case <-s.stop:
+			return
 		case <-s.ctx.Done():
 			return
 		}
@@ -145,18 +186,36 @@ func (s *Scheduler) runAtInterval() {
 
 	for {
 		select {
-		case <-s.ticker.C:
-			if err := s.handler(); err != nil {
+		case <-ticker.C:
+			if err := s.run(); err != nil {
 				s.logger.Error("scheduler handler error", "interval", s.interval, "error", err)
 			}
+		case <-s.stop:
+			return
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
+func (s *Scheduler) run() error {
+	start := time.Now()
+	err := s.handler(s.runCtx)
+	tickDuration.Observe(time.Since(start).Seconds(), s.name)
+	if err != nil {
+		tickErrors.Inc(s.name)
+	}
+	return err
+}
+
+// Stop ends the schedule, cancels a handler run in progress and waits for it
+// to return.
 func (s *Scheduler) Stop() {
-	if s.ticker != nil {
-		s.ticker.Stop()
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.done != nil {
+		<-s.done
 	}
 }
@@ -22,7 +22,7 @@ func TestScheduler_Start(t *testing.T) {
 		WithContext(ctx),
 		WithLogger(discardLogger),
 		WithInterval(50*time.Millisecond),
-		WithHandler(func() error {
+		WithHandler(func(context.Context) error {
 			count.Add(1)
 			return nil
 		}),
@@ -42,7 +42,7 @@ func TestScheduler_TicksMultipleTimes(t *testing.T) {
 		WithContext(ctx),
 		WithLogger(discardLogger),
 		WithInterval(10*time.Millisecond),
-		WithHandler(func() error {
+		WithHandler(func(context.Context) error {
 			count.Add(1)
 			return nil
 		}),
@@ -64,7 +64,7 @@ func TestScheduler_StopsOnContextCancel(t *testing.T) {
 		WithContext(ctx),
 		WithLogger(discardLogger),
 		WithInterval(10*time.Millisecond),
-		WithHandler(func() error {
+		WithHandler(func(context.Context) error {
 			count.Add(1)
 			return nil
 		}),
@@ -87,10 +87,10 @@ func TestScheduler_InvalidConfig(t *testing.T) {
 		name string
 		opts []Option
 	}{
-		{"no context", []Option{WithLogger(discardLogger), WithInterval(time.Second), WithHandler(func() error { return nil })}},
-		{"no interval", []Option{WithLogger(discardLogger), WithContext(context.Background()), WithHandler(func() error { return nil })}},
+		{"no context", []Option{WithLogger(discardLogger), WithInterval(time.Second), WithHandler(func(context.Context) error { return nil })}},
+		{"no interval", []Option{WithLogger(discardLogger), WithContext(context.Background()), WithHandler(func(context.Context) error { return nil })}},
 		{"no handler", []Option{WithLogger(discardLogger), WithContext(context.Background()), WithInterval(time.Second)}},
-		{"no logger", []Option{WithContext(context.Background()), WithInterval(time.Second), WithHandler(func() error { return nil })}},
+		{"no logger", []Option{WithContext(context.Background()), WithInterval(time.Second), WithHandler(func(context.Context) error { return nil })}},
 	}
 
 	for _, tt := range tests {
@@ -100,3 +100,71 @@ func TestScheduler_InvalidConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestScheduler_StopWaitsForHandler(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+
+	s, err := New(
+		WithContext(t.Context()),
+		WithLogger(discardLogger),
+		WithInterval(time.Millisecond),
+		WithHandler(func(context.Context) error {
+			select {
+			case started <- struct{}{}:
+				<-release
+				finished.Store(true)
+			default:
+			}
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start())
+
+	<-started
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while the handler was still running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-stopped
+	assert.True(t, finished.Load())
+
+	s.Stop()
+}
+
+func TestScheduler_StopCancelsHandler(t *testing.T) {
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+
+	s, err := New(
+		WithContext(t.Context()),
+		WithLogger(discardLogger),
+		WithInterval(time.Millisecond),
+		WithHandler(func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+				<-ctx.Done()
+				cancelled.Store(true)
+			default:
+			}
+			return ctx.Err()
+		}),
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start())
+
+	<-started
+	s.Stop()
+	assert.True(t, cancelled.Load())
+}
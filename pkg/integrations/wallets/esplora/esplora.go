@@ -0,0 +1,148 @@
+package esplora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"hodlbook/pkg/integrations/httpclient"
+	"hodlbook/pkg/integrations/wallets/hdkey"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	_ wallets.BalanceFetcher = (*BalanceFetcher)(nil)
+)
+
+const (
+	// DefaultGapLimit is how many unused addresses in a row end the scan of
+	// an extended key, as BIP44 recommends.
+	DefaultGapLimit = 20
+	// maxAddresses bounds the scan of each chain of an extended key.
+	maxAddresses = 10000
+)
+
+// client is shared by every fetcher so requests to the Esplora server are
+// limited together.
+var client = httpclient.New(httpclient.WithRateLimit(5, 10))
+
+// BalanceFetcher reads confirmed BTC balances from an Esplora API, such as
+// blockstream.info or a self-hosted electrs.
+type BalanceFetcher struct {
+	BaseURL  string
+	Client   *http.Client
+	GapLimit int
+}
+
+func NewBalanceFetcher() *BalanceFetcher {
+	return &BalanceFetcher{
+		BaseURL:  "https://blockstream.info/api",
+		Client:   client,
+		GapLimit: DefaultGapLimit,
+	}
+}
+
+type addressResponse struct {
+	ChainStats struct {
+		FundedTxoSum int64 `json:"funded_txo_sum"`
+		SpentTxoSum  int64 `json:"spent_txo_sum"`
+		TxCount      int   `json:"tx_count"`
+	} `json:"chain_stats"`
+}
+
+// FetchBalances returns the BTC balance of an address, or of every address
+// of an extended public key: its receive and change chains are scanned
+// until GapLimit unused addresses in a row. Tokens are ignored.
+func (e *BalanceFetcher) FetchBalances(ctx context.Context, address string, _ []wallets.Token) ([]wallets.Balance, error) {
+	var sats int64
+	if hdkey.IsExtendedKey(address) {
+		key, err := hdkey.ParseExtendedKey(address)
+		if err != nil {
+			return nil, err
+		}
+		for _, branch := range []uint32{0, 1} {
+			branchSats, err := e.scan(ctx, key, branch)
+			if err != nil {
+				return nil, err
+			}
+			sats += branchSats
+		}
+	} else {
+		stats, err := e.fetchAddress(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		sats = stats.ChainStats.FundedTxoSum - stats.ChainStats.SpentTxoSum
+	}
+
+	return []wallets.Balance{{Symbol: "BTC", Amount: decimal.New(sats, -8)}}, nil
+}
+
+func (e *BalanceFetcher) scan(ctx context.Context, key *hdkey.ExtendedKey, branch uint32) (int64, error) {
+	chain, err := key.Child(branch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to derive chain %d: %w", branch, err)
+	}
+
+	gapLimit := e.GapLimit
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	var sats int64
+	unused := 0
+	for index := uint32(0); unused < gapLimit && index < maxAddresses; index++ {
+		child, err := chain.Child(index)
+		if errors.Is(err, hdkey.ErrUnusableChild) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		stats, err := e.fetchAddress(ctx, child.Address())
+		if err != nil {
+			return 0, err
+		}
+		if stats.ChainStats.TxCount == 0 {
+			unused++
+			continue
+		}
+		unused = 0
+		sats += stats.ChainStats.FundedTxoSum - stats.ChainStats.SpentTxoSum
+	}
+	return sats, nil
+}
+
+func (e *BalanceFetcher) fetchAddress(ctx context.Context, address string) (*addressResponse, error) {
+	endpoint := fmt.Sprintf("%s/address/%s", e.BaseURL, url.PathEscape(address))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch address: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("invalid bitcoin address: %s", address)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result addressResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
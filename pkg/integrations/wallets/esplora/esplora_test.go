@@ -0,0 +1,73 @@
+package esplora
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, funded map[string]int64, spent map[string]int64) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requested []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := strings.TrimPrefix(r.URL.Path, "/address/")
+		mu.Lock()
+		requested = append(requested, address)
+		mu.Unlock()
+
+		var resp addressResponse
+		if sats, ok := funded[address]; ok {
+			resp.ChainStats.FundedTxoSum = sats
+			resp.ChainStats.SpentTxoSum = spent[address]
+			resp.ChainStats.TxCount = 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+func TestBalanceFetcher_Address(t *testing.T) {
+	address := "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+	server, _ := newServer(t, map[string]int64{address: 150_000_000}, map[string]int64{address: 25_000_000})
+
+	fetcher := NewBalanceFetcher()
+	fetcher.BaseURL = server.URL
+
+	balances, err := fetcher.FetchBalances(context.Background(), address, nil)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, "BTC", balances[0].Symbol)
+	assert.Equal(t, "1.25", balances[0].Amount.String())
+}
+
+func TestBalanceFetcher_ExtendedKeyScansUntilGap(t *testing.T) {
+	// The first two receive addresses and the first change address of the
+	// BIP84 test vector account.
+	server, requested := newServer(t, map[string]int64{
+		"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu": 100_000,
+		"bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g": 20_000,
+		"bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el": 3_000,
+	}, nil)
+
+	fetcher := NewBalanceFetcher()
+	fetcher.BaseURL = server.URL
+	fetcher.GapLimit = 2
+
+	balances, err := fetcher.FetchBalances(context.Background(),
+		"zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs", nil)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, "0.00123", balances[0].Amount.String())
+	assert.Len(t, *requested, 7, "two used and two unused receive addresses, one used and two unused change addresses")
+}
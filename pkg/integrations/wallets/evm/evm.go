@@ -0,0 +1,166 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"hodlbook/pkg/integrations/httpclient"
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	_ wallets.BalanceFetcher = (*BalanceFetcher)(nil)
+)
+
+// ERC-20 function selectors.
+const (
+	selectorBalanceOf = "0x70a08231"
+	selectorDecimals  = "0x313ce567"
+)
+
+// nativeDecimals is the precision of the gas coin on every EVM chain.
+const nativeDecimals = 18
+
+// client is shared by every fetcher so requests to public RPC endpoints are
+// limited together.
+var client = httpclient.New(httpclient.WithRateLimit(5, 10))
+
+// BalanceFetcher reads native coin and ERC-20 token balances over an EVM
+// chain's JSON-RPC API, from a public endpoint or a local node.
+type BalanceFetcher struct {
+	BaseURL      string
+	NativeSymbol string
+	Client       *http.Client
+
+	nextID atomic.Int64
+}
+
+func NewBalanceFetcher(rpcURL, nativeSymbol string) *BalanceFetcher {
+	return &BalanceFetcher{
+		BaseURL:      rpcURL,
+		NativeSymbol: nativeSymbol,
+		Client:       client,
+	}
+}
+
+// IsAddress reports whether s is a 0x-prefixed 20 byte hex address.
+func IsAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(strings.ToLower(s), "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result string    `json:"result"`
+	Error  *rpcError `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type callParams struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+// FetchBalances returns the native coin balance of address followed by the
+// balance of each token, scaled by the decimals the token contract reports.
+func (e *BalanceFetcher) FetchBalances(ctx context.Context, address string, tokens []wallets.Token) ([]wallets.Balance, error) {
+	if !IsAddress(address) {
+		return nil, fmt.Errorf("invalid address: %s", address)
+	}
+	address = strings.ToLower(address)
+
+	wei, err := e.callUint(ctx, "eth_getBalance", address, "latest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s balance: %w", e.NativeSymbol, err)
+	}
+	balances := []wallets.Balance{{Symbol: e.NativeSymbol, Amount: decimal.NewFromBigInt(wei, -nativeDecimals)}}
+
+	for _, token := range tokens {
+		if !IsAddress(token.Contract) {
+			return nil, fmt.Errorf("invalid contract address for %s: %s", token.Symbol, token.Contract)
+		}
+		contract := strings.ToLower(token.Contract)
+
+		decimals, err := e.callUint(ctx, "eth_call", callParams{To: contract, Data: selectorDecimals}, "latest")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s decimals: %w", token.Symbol, err)
+		}
+		data := selectorBalanceOf + strings.Repeat("0", 24) + address[2:]
+		amount, err := e.callUint(ctx, "eth_call", callParams{To: contract, Data: data}, "latest")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s balance: %w", token.Symbol, err)
+		}
+
+		balances = append(balances, wallets.Balance{
+			Symbol: strings.ToUpper(token.Symbol),
+			Amount: decimal.NewFromBigInt(amount, -int32(decimals.Int64())),
+		})
+	}
+	return balances, nil
+}
+
+// callUint makes a JSON-RPC call whose result is a hex encoded unsigned
+// integer.
+func (e *BalanceFetcher) callUint(ctx context.Context, method string, params ...any) (*big.Int, error) {
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: e.nextID.Add(1), Method: method, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.BaseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("rpc error %d: %s", result.Error.Code, result.Error.Message)
+	}
+
+	hexValue := strings.TrimPrefix(result.Result, "0x")
+	if hexValue == "" {
+		// Calls to an address without code return "0x".
+		return nil, fmt.Errorf("empty result from %s", method)
+	}
+	value, ok := new(big.Int).SetString(hexValue, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid result from %s: %s", method, result.Result)
+	}
+	return value, nil
+}
@@ -0,0 +1,71 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hodlbook/pkg/types/wallets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	holder = "0x00000000219ab540356cBB839Cbe05303d7705Fa"
+	usdc   = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+func TestBalanceFetcher_FetchBalances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		result := "0x"
+		switch req.Method {
+		case "eth_getBalance":
+			result = "0x1bc16d674ec80000" // 2 ETH
+		case "eth_call":
+			var call callParams
+			require.NoError(t, json.Unmarshal(req.Params[0], &call))
+			assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", call.To)
+			switch call.Data {
+			case selectorDecimals:
+				result = "0x0000000000000000000000000000000000000000000000000000000000000006"
+			case selectorBalanceOf + "00000000000000000000000000000000219ab540356cbb839cbe05303d7705fa":
+				result = "0x0000000000000000000000000000000000000000000000000000000005f5e100" // 100 USDC
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	fetcher := NewBalanceFetcher(server.URL, "ETH")
+	balances, err := fetcher.FetchBalances(context.Background(), holder, []wallets.Token{{Symbol: "usdc", Contract: usdc}})
+	require.NoError(t, err)
+
+	require.Len(t, balances, 2)
+	assert.Equal(t, "ETH", balances[0].Symbol)
+	assert.Equal(t, "2", balances[0].Amount.String())
+	assert.Equal(t, "USDC", balances[1].Symbol)
+	assert.Equal(t, "100", balances[1].Amount.String())
+}
+
+func TestBalanceFetcher_RPCError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "error": map[string]any{"code": -32000, "message": "header not found"}})
+	}))
+	defer server.Close()
+
+	_, err := NewBalanceFetcher(server.URL, "ETH").FetchBalances(context.Background(), holder, nil)
+	assert.ErrorContains(t, err, "header not found")
+
+	_, err = NewBalanceFetcher(server.URL, "ETH").FetchBalances(context.Background(), "0x1234", nil)
+	assert.ErrorContains(t, err, "invalid address")
+}
@@ -0,0 +1,104 @@
+package hdkey
+
+import (
+	"errors"
+	"math/big"
+)
+
+// The secp256k1 curve y² = x³ + 7 over the prime field p, with base point G
+// of order n. Only the public-key operations BIP32 needs are implemented;
+// nothing here handles secrets, so constant time does not matter.
+var (
+	curveP, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	curveN, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	curveGx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	curveGy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+)
+
+// point is an affine curve point; a nil x is the point at infinity.
+type point struct {
+	x, y *big.Int
+}
+
+func (p point) infinity() bool {
+	return p.x == nil
+}
+
+// compressed encodes the point in SEC1 compressed form.
+func (p point) compressed() []byte {
+	out := make([]byte, 33)
+	out[0] = 0x02 + byte(p.y.Bit(0))
+	p.x.FillBytes(out[1:])
+	return out
+}
+
+// decompress parses a SEC1 compressed point and checks it is on the curve.
+func decompress(b []byte) (point, error) {
+	if len(b) != 33 || (b[0] != 0x02 && b[0] != 0x03) {
+		return point{}, errors.New("public key is not compressed")
+	}
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(curveP) >= 0 {
+		return point{}, errors.New("public key is not on the curve")
+	}
+
+	// y = sqrt(x³ + 7), which is (x³ + 7)^((p+1)/4) as p ≡ 3 mod 4.
+	rhs := new(big.Int).Exp(x, big.NewInt(3), curveP)
+	rhs.Add(rhs, big.NewInt(7)).Mod(rhs, curveP)
+	exp := new(big.Int).Add(curveP, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(rhs, exp, curveP)
+	if new(big.Int).Exp(y, big.NewInt(2), curveP).Cmp(rhs) != 0 {
+		return point{}, errors.New("public key is not on the curve")
+	}
+	if y.Bit(0) != uint(b[0]-0x02) {
+		y.Sub(curveP, y)
+	}
+	return point{x: x, y: y}, nil
+}
+
+func add(a, b point) point {
+	switch {
+	case a.infinity():
+		return b
+	case b.infinity():
+		return a
+	}
+
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return point{}
+		}
+		// Doubling: (3x²) / (2y).
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den, curveP))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, curveP)
+		slope = num.Mul(num, den.ModInverse(den, curveP))
+	}
+	slope.Mod(slope, curveP)
+
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, curveP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, curveP)
+	return point{x: x, y: y}
+}
+
+// scalarBaseMult returns k·G by double-and-add.
+func scalarBaseMult(k *big.Int) point {
+	var result point
+	addend := point{x: curveGx, y: curveGy}
+	for i := 0; i < k.BitLen(); i++ {
+		if k.Bit(i) == 1 {
+			result = add(result, addend)
+		}
+		addend = add(addend, addend)
+	}
+	return result
+}
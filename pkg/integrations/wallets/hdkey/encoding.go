@@ -0,0 +1,130 @@
+package hdkey
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func encodeBase58Check(payload []byte) string {
+	data := append(bytes.Clone(payload), doubleSHA256(payload)[:4]...)
+
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func decodeBase58Check(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, errInvalidBase58
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(digit)))
+	}
+
+	data := n.Bytes()
+	for _, r := range s {
+		if r != rune(base58Alphabet[0]) {
+			break
+		}
+		data = append([]byte{0}, data...)
+	}
+	if len(data) < 4 {
+		return nil, errInvalidBase58
+	}
+
+	payload, checksum := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(doubleSHA256(payload)[:4], checksum) {
+		return nil, errInvalidChecksum
+	}
+	return payload, nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// encodeSegWit encodes a witness program as a BIP173 bech32 address.
+func encodeSegWit(hrp string, version byte, program []byte) string {
+	data := append([]byte{version}, convertBits(program, 8, 5)...)
+	checksum := bech32Checksum(hrp, data)
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range append(data, checksum...) {
+		sb.WriteByte(bech32Charset[d])
+	}
+	return sb.String()
+}
+
+func convertBits(data []byte, from, to uint) []byte {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+	var out []byte
+	for _, b := range data {
+		acc = acc<<from | uint(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc<<(to-bits)&maxv))
+	}
+	return out
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := make([]byte, 0, len(hrp)*2+1+len(data)+6)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	values = append(values, data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+
+	mod := bech32Polymod(values) ^ 1
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(mod >> (5 * (5 - i)) & 31)
+	}
+	return checksum
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if top>>i&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
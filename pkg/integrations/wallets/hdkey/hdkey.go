@@ -0,0 +1,198 @@
+// Package hdkey derives Bitcoin addresses from BIP32 extended public keys
+// (xpub, ypub, zpub and their testnet forms), so a watch-only wallet can be
+// scanned without ever handling a private key.
+package hdkey
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+var (
+	ErrInvalidKey      = errors.New("invalid extended public key")
+	ErrPrivateKey      = errors.New("extended private keys are not accepted")
+	ErrHardenedChild   = errors.New("hardened children cannot be derived from a public key")
+	ErrUnusableChild   = errors.New("child key is unusable, skip to the next index")
+	errInvalidBase58   = errors.New("invalid base58 string")
+	errInvalidChecksum = errors.New("checksum mismatch")
+)
+
+// HardenedOffset is the first hardened child index.
+const HardenedOffset uint32 = 1 << 31
+
+type script int
+
+const (
+	p2pkh script = iota
+	p2shP2WPKH
+	p2wpkh
+)
+
+type network struct {
+	pubKeyHash byte
+	scriptHash byte
+	hrp        string
+}
+
+var (
+	mainnet = network{pubKeyHash: 0x00, scriptHash: 0x05, hrp: "bc"}
+	testnet = network{pubKeyHash: 0x6f, scriptHash: 0xc4, hrp: "tb"}
+)
+
+// versions maps the serialization version of each supported key type to
+// the network and address type its children are used with.
+var versions = map[uint32]struct {
+	net    network
+	script script
+}{
+	0x0488b21e: {mainnet, p2pkh},      // xpub
+	0x049d7cb2: {mainnet, p2shP2WPKH}, // ypub
+	0x04b24746: {mainnet, p2wpkh},     // zpub
+	0x043587cf: {testnet, p2pkh},      // tpub
+	0x044a5262: {testnet, p2shP2WPKH}, // upub
+	0x045f1cf6: {testnet, p2wpkh},     // vpub
+}
+
+// privateVersions are the serialization versions of extended private keys,
+// recognized only to reject them with a clear error.
+var privateVersions = map[uint32]bool{
+	0x0488ade4: true, 0x049d7878: true, 0x04b2430c: true,
+	0x04358394: true, 0x044a4e28: true, 0x045f18bc: true,
+}
+
+// ExtendedKey is a BIP32 extended public key.
+type ExtendedKey struct {
+	version   uint32
+	depth     byte
+	chainCode []byte
+	key       point
+}
+
+// IsExtendedKey reports whether s looks like an extended key rather than an
+// address.
+func IsExtendedKey(s string) bool {
+	if len(s) < 4 {
+		return false
+	}
+	switch s[:4] {
+	case "xpub", "ypub", "zpub", "tpub", "upub", "vpub",
+		"xprv", "yprv", "zprv", "tprv", "uprv", "vprv":
+		return true
+	}
+	return false
+}
+
+// ParseExtendedKey decodes a base58 extended public key.
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	payload, err := decodeBase58Check(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(payload) != 78 {
+		return nil, fmt.Errorf("%w: wrong length", ErrInvalidKey)
+	}
+
+	version := binary.BigEndian.Uint32(payload[:4])
+	if privateVersions[version] || payload[45] == 0 {
+		return nil, ErrPrivateKey
+	}
+	if _, ok := versions[version]; !ok {
+		return nil, fmt.Errorf("%w: unknown version %08x", ErrInvalidKey, version)
+	}
+
+	key, err := decompress(payload[45:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return &ExtendedKey{
+		version:   version,
+		depth:     payload[4],
+		chainCode: bytes.Clone(payload[13:45]),
+		key:       key,
+	}, nil
+}
+
+// Child derives the non-hardened child at index. ErrUnusableChild is
+// returned for the rare index BIP32 says to skip.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if index >= HardenedOffset {
+		return nil, ErrHardenedChild
+	}
+
+	data := make([]byte, 0, 37)
+	data = append(data, k.key.compressed()...)
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curveN) >= 0 {
+		return nil, ErrUnusableChild
+	}
+	child := add(scalarBaseMult(tweak), k.key)
+	if child.infinity() {
+		return nil, ErrUnusableChild
+	}
+
+	return &ExtendedKey{
+		version:   k.version,
+		depth:     k.depth + 1,
+		chainCode: sum[32:],
+		key:       child,
+	}, nil
+}
+
+// Derive follows a path of non-hardened indexes, such as 0 then 5 for the
+// sixth receive address of an account key.
+func (k *ExtendedKey) Derive(path ...uint32) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Address encodes the key as an address of the type its version implies:
+// legacy for xpub, nested SegWit for ypub and native SegWit for zpub.
+func (k *ExtendedKey) Address() string {
+	v := versions[k.version]
+	pubKeyHash := hash160(k.key.compressed())
+
+	switch v.script {
+	case p2shP2WPKH:
+		redeem := append([]byte{0x00, 0x14}, pubKeyHash...)
+		return encodeBase58Check(append([]byte{v.net.scriptHash}, hash160(redeem)...))
+	case p2wpkh:
+		return encodeSegWit(v.net.hrp, 0, pubKeyHash)
+	default:
+		return encodeBase58Check(append([]byte{v.net.pubKeyHash}, pubKeyHash...))
+	}
+}
+
+func hash160(b []byte) []byte {
+	sha := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
@@ -0,0 +1,92 @@
+package hdkey
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Account keys of the "abandon ... about" mnemonic and their first receive,
+// second receive and first change addresses, from the BIP44, BIP49 and
+// BIP84 test vectors, one for every supported version.
+var accountVectors = []struct {
+	name      string
+	key       string
+	addresses [3]string
+}{
+	{"xpub", "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj",
+		[3]string{"1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", "1Ak8PffB2meyfYnbXZR9EGfLfFZVpzJvQP", "1J3J6EvPrv8q6AC3VCjWV45Uf3nssNMRtH"}},
+	{"ypub", "ypub6Ww3ibxVfGzLrAH1PNcjyAWenMTbbAosGNB6VvmSEgytSER9azLDWCxoJwW7Ke7icmizBMXrzBx9979FfaHxHcrArf3zbeJJJUZPf663zsP",
+		[3]string{"37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf", "3LtMnn87fqUeHBUG414p9CWwnoV6E2pNKS", "34K56kSjgUCUSD8GTtuF7c9Zzwokbs6uZ7"}},
+	{"zpub", bip84AccountKey,
+		[3]string{"bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"}},
+	{"tpub", "tpubDC5FSnBiZDMmhiuCmWAYsLwgLYrrT9rAqvTySfuCCrgsWz8wxMXUS9Tb9iVMvcRbvFcAHGkMD5Kx8koh4GquNGNTfohfk7pgjhaPCdXpoba",
+		[3]string{"mkpZhYtJu2r87Js3pDiWJDmPte2NRZ8bJV", "mzpbWabUQm1w8ijuJnAof5eiSTep27deVH", "mi8nhzZgGZQthq6DQHbru9crMDerUdTKva"}},
+	{"upub", "upub5EFU65HtV5TeiSHmZZm7FUffBGy8UKeqp7vw43jYbvZPpoVsgU93oac7Wk3u6moKegAEWtGNF8DehrnHtv21XXEMYRUocHqguyjknFHYfgY",
+		[3]string{"2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2", "2N55m54k8vr95ggehfUcNkdbUuQvaqG2GxK", "2MvdUi5o3f2tnEFh9yGvta6FzptTZtkPJC8"}},
+	{"vpub", "vpub5Y6cjg78GGuNLsaPhmYsiw4gYX3HoQiRBiSwDaBXKUafCt9bNwWQiitDk5VZ5BVxYnQdwoTyXSs2JHRPAgjAvtbBrf8ZhDYe2jWAqvZVnsc",
+		[3]string{"tb1q6rz28mcfaxtmd6v789l9rrlrusdprr9pqcpvkl", "tb1qd7spv5q28348xl4myc8zmh983w5jx32cjhkn97", "tb1q9u62588spffmq4dzjxsr5l297znf3z6j5p2688"}},
+}
+
+const bip84AccountKey = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+
+func TestExtendedKey_DerivesAccountAddresses(t *testing.T) {
+	covered := make(map[uint32]bool)
+	for _, tc := range accountVectors {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ParseExtendedKey(tc.key)
+			require.NoError(t, err)
+			covered[key.version] = true
+
+			for i, path := range [][]uint32{{0, 0}, {0, 1}, {1, 0}} {
+				child, err := key.Derive(path...)
+				require.NoError(t, err)
+				assert.Equal(t, tc.addresses[i], child.Address(), path)
+			}
+		})
+	}
+	for version := range versions {
+		assert.True(t, covered[version], "no vector for version %08x", version)
+	}
+}
+
+func TestExtendedKey_BIP32PublicDerivation(t *testing.T) {
+	// Test vector 1 of BIP32: m/0H/1/2H/2 and its child 1000000000.
+	parent, err := ParseExtendedKey("xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV")
+	require.NoError(t, err)
+	want, err := ParseExtendedKey("xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy")
+	require.NoError(t, err)
+
+	child, err := parent.Child(1000000000)
+	require.NoError(t, err)
+	assert.Equal(t, want.depth, child.depth)
+	assert.Equal(t, want.chainCode, child.chainCode)
+	assert.Equal(t, want.key.compressed(), child.key.compressed())
+	assert.Equal(t, "1LZiqrop2HGR4qrH1ULZPyBpU6AUP49Uam", child.Address())
+
+	_, err = parent.Child(HardenedOffset)
+	assert.ErrorIs(t, err, ErrHardenedChild)
+}
+
+func TestParseExtendedKey_Rejects(t *testing.T) {
+	_, err := ParseExtendedKey(bip84AccountKey[:len(bip84AccountKey)-1] + "t")
+	assert.ErrorIs(t, err, ErrInvalidKey, "a changed character breaks the checksum")
+
+	_, err = ParseExtendedKey("bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	payload, err := decodeBase58Check(bip84AccountKey)
+	require.NoError(t, err)
+	binary.BigEndian.PutUint32(payload, 0x04b2430c)
+	payload[45] = 0
+	_, err = ParseExtendedKey(encodeBase58Check(payload))
+	assert.ErrorIs(t, err, ErrPrivateKey, "a zprv")
+}
+
+func TestIsExtendedKey(t *testing.T) {
+	assert.True(t, IsExtendedKey(bip84AccountKey))
+	assert.False(t, IsExtendedKey("bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"))
+	assert.False(t, IsExtendedKey("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"))
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	TopicPricesUpdated   = "prices.updated"
+	TopicAssetCreated    = "asset.created"
+	TopicAssetUpdated    = "asset.updated"
+	TopicAssetDeleted    = "asset.deleted"
+	TopicExchangeCreated = "exchange.created"
+	TopicExchangeUpdated = "exchange.updated"
+	TopicExchangeDeleted = "exchange.deleted"
+	TopicImportCompleted = "import.completed"
+)
+
+// Event is delivered to subscribers. Payload types per topic:
+//
+//	prices.updated   PricesUpdated
+//	asset.*          models.Asset
+//	exchange.*       models.Exchange
+//	import.completed ImportCompleted
+type Event struct {
+	Topic       string
+	Payload     any
+	PublishedAt time.Time
+}
+
+type PricesUpdated map[string]float64
+
+type ImportCompleted struct {
+	ImportID    int64
+	PortfolioID int64
+	Imported    int
+	Failed      int
+	Status      string
+}
+
+type Handler func(ctx context.Context, event Event) error
+
+// Policy decides what happens when a subscriber's queue is full.
+type Policy int
+
+const (
+	// DropNewest discards the event being published.
+	DropNewest Policy = iota
+	// DropOldest discards the oldest queued event to make room.
+	DropOldest
+	// Block makes Publish wait for room or for its context to end.
+	Block
+)
+
+type SubscribeOptions struct {
+	// Name identifies the subscriber in logs and metrics.
+	Name      string
+	QueueSize int
+	Policy    Policy
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload any) error
+}
+
+// Subscriber registers handlers. Topics may end in ".*" to match every topic
+// with that prefix, or be "*" to match all topics.
+type Subscriber interface {
+	Subscribe(topic string, handler Handler, opts SubscribeOptions) error
+}
+
+type Bus interface {
+	Publisher
+	Subscriber
+}
@@ -0,0 +1,17 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+type Notification struct {
+	Title     string
+	Message   string
+	Timestamp time.Time
+}
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
@@ -1,5 +1,10 @@
 package prices
 
+import (
+	"context"
+	"time"
+)
+
 const (
 	SourceCoinGecko     = "coingecko"
 	SourceBinance       = "binance"
@@ -7,6 +12,7 @@ const (
 	SourceCryptoCompare = "cryptocompare"
 	SourceDefiLlama     = "defillama"
 	SourceGeckoTerminal = "geckoterminal"
+	SourceMock          = "mock"
 )
 
 type Asset struct {
@@ -22,10 +28,23 @@ type Price struct {
 	Network     string
 }
 
+// PriceFetcher fetches prices from an upstream provider. Calls abort once
+// ctx is done.
 type PriceFetcher interface {
-	Fetch(price *Price) error
-	FetchMany(pairs ...*Price) error
-	FetchAll() ([]Price, error)
+	Fetch(ctx context.Context, price *Price) error
+	FetchMany(ctx context.Context, pairs ...*Price) error
+	FetchAll(ctx context.Context) ([]Price, error)
+}
+
+// SourceFetcher fetches a price from one named provider, such as those found
+// by a deep search.
+type SourceFetcher interface {
+	FetchBySource(ctx context.Context, source string, price *Price) error
+}
+
+// HistoricalFetcher fetches the price an asset had at a point in time.
+type HistoricalFetcher interface {
+	FetchAt(ctx context.Context, price *Price, at time.Time) error
 }
 
 var (
@@ -1,15 +1,25 @@
 package repo
 
 import (
+	"time"
+
 	"hodlbook/internal/models"
 	"hodlbook/internal/repo"
 )
 
 type Repository interface {
+	// Portfolios
+	ListPortfolios() ([]models.Portfolio, error)
+	GetPortfolioByID(id int64) (*models.Portfolio, error)
+	CreatePortfolio(portfolio *models.Portfolio) error
+	UpdatePortfolio(portfolio *models.Portfolio) error
+	DeletePortfolio(id int64) error
+
 	// Assets
 	ListAssets(filter repo.AssetFilter) (*repo.AssetListResult, error)
 	GetAssetByID(id int64) (*models.Asset, error)
 	GetAllAssets() ([]models.Asset, error)
+	GetAssetsByPortfolio(portfolioID int64) ([]models.Asset, error)
 	GetAssetsBySymbol(symbol string) ([]models.Asset, error)
 	CreateAsset(asset *models.Asset) error
 	UpdateAsset(asset *models.Asset) error
@@ -20,6 +30,7 @@ type Repository interface {
 	ListExchanges(filter repo.ExchangeFilter) (*repo.ExchangeListResult, error)
 	GetExchangeByID(id int64) (*models.Exchange, error)
 	GetAllExchanges() ([]models.Exchange, error)
+	GetExchangesByPortfolio(portfolioID int64) ([]models.Exchange, error)
 	CreateExchange(exchange *models.Exchange) error
 	UpdateExchange(exchange *models.Exchange) error
 	DeleteExchange(id int64) error
@@ -27,14 +38,81 @@ type Repository interface {
 	// Price history
 	SelectAllBySymbol(symbol string) ([]models.AssetHistoricValue, error)
 	Insert(value *models.AssetHistoricValue) error
+	ReplaceHistoricValues(symbol string, values []models.AssetHistoricValue) error
 
 	// Prices
 	CreatePrice(price *models.Price) error
+	GetPriceAtTime(symbol, currency string, timestamp time.Time) (*models.Price, error)
+	GetLastKnownPrices() ([]models.LastKnownPrice, error)
+	CreateManualPrice(price *models.Price) (*models.LastKnownPrice, error)
+	ListManualPrices(symbol string) ([]models.Price, error)
+	DeleteManualPrice(id int64) (string, *models.LastKnownPrice, error)
 
 	// Import logs
 	CreateImportLog(log *models.ImportLog) error
 	GetImportLogByID(id int64) (*models.ImportLog, error)
 	ListImportLogs() ([]models.ImportLog, error)
+	ListImportLogsByPortfolio(portfolioID int64) ([]models.ImportLog, error)
 	UpdateImportLog(log *models.ImportLog) error
 	DeleteImportLog(id int64) error
+
+	// API keys
+	CreateAPIKey(name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error)
+	GetAPIKeyByID(id int64) (*models.APIKey, error)
+	ListAPIKeys() ([]models.APIKey, error)
+	AuthenticateAPIKey(raw string) (*models.APIKey, error)
+	RevokeAPIKey(id int64) error
+	DeleteAPIKey(id int64) error
+
+	// Alerts
+	CreateAlertRule(rule *models.AlertRule) error
+	GetAlertRuleByID(id int64) (*models.AlertRule, error)
+	ListAlertRules() ([]models.AlertRule, error)
+	UpdateAlertRule(rule *models.AlertRule) error
+	DeleteAlertRule(id int64) error
+	ListAlertEvents(limit int) ([]models.AlertEvent, error)
+
+	// Notification channels
+	CreateNotificationChannel(channel *models.NotificationChannel) error
+	GetNotificationChannelByID(id int64) (*models.NotificationChannel, error)
+	ListNotificationChannels() ([]models.NotificationChannel, error)
+	UpdateNotificationChannel(channel *models.NotificationChannel) error
+	DeleteNotificationChannel(id int64) error
+
+	// Wallets
+	CreateWallet(wallet *models.Wallet) error
+	GetWalletByID(id int64) (*models.Wallet, error)
+	ListWallets() ([]models.Wallet, error)
+	UpdateWallet(wallet *models.Wallet) error
+	DeleteWallet(id int64) error
+	ListWalletBalances(walletID int64) ([]models.WalletBalance, error)
+	ListWalletDiscrepancies(status string) ([]models.WalletDiscrepancy, error)
+	AcceptWalletDiscrepancy(id int64, at time.Time) (*models.Asset, *models.WalletDiscrepancy, error)
+	DismissWalletDiscrepancy(id int64, at time.Time) (*models.WalletDiscrepancy, error)
+
+	// Reconciliations
+	CreateReconciliation(reconciliation *models.Reconciliation) error
+	GetReconciliationByID(id int64) (*models.Reconciliation, error)
+	ListReconciliations(portfolioID int64) ([]models.Reconciliation, error)
+	DeleteReconciliation(id int64) error
+	AdjustReconciliationLine(reconciliationID, lineID int64) (*models.Asset, error)
+
+	// Recurring transactions
+	CreateRecurring(recurring *models.RecurringTransaction) error
+	GetRecurringByID(id int64) (*models.RecurringTransaction, error)
+	ListRecurring(portfolioID int64) ([]models.RecurringTransaction, error)
+	ListDueRecurring(now time.Time) ([]models.RecurringTransaction, error)
+	UpdateRecurring(recurring *models.RecurringTransaction) error
+	DeleteRecurring(id int64) error
+	SaveRecurringRun(recurring *models.RecurringTransaction, run *models.RecurringRun) error
+	SetRecurringError(id int64, message string) error
+	ListRecurringRuns(recurringID int64, status string) ([]models.RecurringRun, error)
+	ConfirmRecurringRun(id int64, at time.Time) (*models.RecurringRun, error)
+	SkipRecurringRun(id int64, at time.Time) (*models.RecurringRun, error)
+
+	// Allocation targets
+	ListAllocationTargets(portfolioID int64) ([]models.AllocationTarget, error)
+	ReplaceAllocationTargets(portfolioID int64, targets []models.AllocationTarget) error
+	ListAssetCategories() ([]models.AssetCategory, error)
+	ReplaceAssetCategories(categories []models.AssetCategory) error
 }
@@ -0,0 +1,52 @@
+package wallets
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ChainBitcoin   = "bitcoin"
+	ChainEthereum  = "ethereum"
+	ChainPolygon   = "polygon"
+	ChainArbitrum  = "arbitrum"
+	ChainOptimism  = "optimism"
+	ChainBase      = "base"
+	ChainBSC       = "bsc"
+	ChainAvalanche = "avalanche"
+)
+
+// NativeSymbols maps each supported EVM chain to the symbol of the coin its
+// gas is paid in.
+var NativeSymbols = map[string]string{
+	ChainEthereum:  "ETH",
+	ChainPolygon:   "POL",
+	ChainArbitrum:  "ETH",
+	ChainOptimism:  "ETH",
+	ChainBase:      "ETH",
+	ChainBSC:       "BNB",
+	ChainAvalanche: "AVAX",
+}
+
+func IsEVMChain(chain string) bool {
+	_, ok := NativeSymbols[chain]
+	return ok
+}
+
+// Token is an ERC-20 token watched on an EVM chain.
+type Token struct {
+	Symbol   string
+	Contract string
+}
+
+type Balance struct {
+	Symbol string
+	Amount decimal.Decimal
+}
+
+// BalanceFetcher reads the balances held by a watch-only address, or by
+// every address of an extended public key. Calls abort once ctx is done.
+type BalanceFetcher interface {
+	FetchBalances(ctx context.Context, address string, tokens []Token) ([]Balance, error)
+}
@@ -1,46 +1,9 @@
 package utils
 
 import (
-	"bufio"
-	"log"
 	"os"
-	"strings"
 )
 
-func LoadEnv() {
-	file, err := os.Open(".env")
-	if err != nil {
-
-		return
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		if os.Getenv(key) == "" {
-			os.Setenv(key, value)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading .env file: %v", err)
-	}
-}
-
 func GetEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {